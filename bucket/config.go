@@ -13,14 +13,82 @@ import (
 )
 
 type MaandConf struct {
-	UseSUDO            bool   `toml:"use_sudo"`
-	SSHUser            string `toml:"ssh_user"`
-	SSHKeyFile         string `toml:"ssh_key"`
-	SSHPort            int    `toml:"ssh_port"`
-	CertsTTL           int    `toml:"certs_ttl"`
-	CertsRenewalBuffer int    `toml:"certs_renewal_buffer"`
-	JobConfigSelector  string `toml:"job_config_selector,omitempty"`
-	LogFormat          string `toml:"log_format,omitempty"`
+	UseSUDO            bool           `toml:"use_sudo"`
+	SSHUser            string         `toml:"ssh_user"`
+	SSHKeyFile         string         `toml:"ssh_key"`
+	SSHPort            int            `toml:"ssh_port"`
+	CertsTTL           int            `toml:"certs_ttl"`
+	CertsRenewalBuffer int            `toml:"certs_renewal_buffer"`
+	JobConfigSelector  string         `toml:"job_config_selector,omitempty"`
+	WorkspaceOverlay   string         `toml:"workspace_overlay,omitempty"`
+	LogFormat          string         `toml:"log_format,omitempty"`
+	Overcommit         OvercommitConf `toml:"overcommit,omitempty"`
+}
+
+// OvercommitRatios scale a worker's memory, cpu and disk from workers.json into the
+// capacity build places jobs on and validates reservations against. 0 means not set.
+type OvercommitRatios struct {
+	Memory float64 `toml:"memory,omitempty"`
+	CPU    float64 `toml:"cpu,omitempty"`
+	Disk   float64 `toml:"disk,omitempty"`
+}
+
+// OvercommitConf is the [overcommit] table of maand.conf: bucket-wide ratios, overridden
+// per worker label by [overcommit.labels.<label>].
+type OvercommitConf struct {
+	OvercommitRatios
+	Labels map[string]OvercommitRatios `toml:"labels,omitempty"`
+}
+
+// ForLabels returns the ratios for a worker with labels. A ratio set by any of the labels
+// replaces the bucket-wide one (the largest wins when several labels set it); unset ratios
+// are 1.
+func (c OvercommitConf) ForLabels(labels []string) OvercommitRatios {
+	ratios := c.OvercommitRatios
+	var override OvercommitRatios
+	for _, label := range labels {
+		byLabel := c.Labels[label]
+		override.Memory = max(override.Memory, byLabel.Memory)
+		override.CPU = max(override.CPU, byLabel.CPU)
+		override.Disk = max(override.Disk, byLabel.Disk)
+	}
+	pick := func(bucketWide, byLabel float64) float64 {
+		if byLabel > 0 {
+			return byLabel
+		}
+		if bucketWide > 0 {
+			return bucketWide
+		}
+		return 1
+	}
+	return OvercommitRatios{
+		Memory: pick(ratios.Memory, override.Memory),
+		CPU:    pick(ratios.CPU, override.CPU),
+		Disk:   pick(ratios.Disk, override.Disk),
+	}
+}
+
+func (c OvercommitConf) validate() error {
+	check := func(scope string, ratios OvercommitRatios) error {
+		switch {
+		case ratios.Memory < 0:
+			return fmt.Errorf("%w: %s.memory can't be less than 0", ErrInvalidMaandConf, scope)
+		case ratios.CPU < 0:
+			return fmt.Errorf("%w: %s.cpu can't be less than 0", ErrInvalidMaandConf, scope)
+		case ratios.Disk < 0:
+			return fmt.Errorf("%w: %s.disk can't be less than 0", ErrInvalidMaandConf, scope)
+		}
+		return nil
+	}
+	if err := check("overcommit", c.OvercommitRatios); err != nil {
+		return err
+	}
+	for label, ratios := range c.Labels {
+		if err := check("overcommit.labels."+label, ratios); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // SSHPort returns the SSH port from maand.conf (default 22).
@@ -49,6 +117,10 @@ func GetMaandConf() (MaandConf, error) {
 			return MaandConf{}, fmt.Errorf("%w: %w", ErrInvalidMaandConf, err)
 		}
 
+		if err := maandConf.Overcommit.validate(); err != nil {
+			return MaandConf{}, err
+		}
+
 		if maandConf.CertsTTL == 0 {
 			maandConf.CertsTTL = 60
 		}
@@ -0,0 +1,50 @@
+// Copyright 2025 Kiruba Sankar Swaminathan. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package bucket
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOvercommitConfForLabels(t *testing.T) {
+	conf := OvercommitConf{
+		OvercommitRatios: OvercommitRatios{CPU: 1.5},
+		Labels: map[string]OvercommitRatios{
+			"batch": {CPU: 2},
+			"gpu":   {CPU: 3, Memory: 0.9},
+		},
+	}
+
+	assert.Equal(t, OvercommitRatios{Memory: 1, CPU: 1.5, Disk: 1}, conf.ForLabels([]string{"web"}))
+	assert.Equal(t, OvercommitRatios{Memory: 1, CPU: 2, Disk: 1}, conf.ForLabels([]string{"batch"}))
+	assert.Equal(t, OvercommitRatios{Memory: 0.9, CPU: 3, Disk: 1}, conf.ForLabels([]string{"batch", "gpu"}))
+	assert.Equal(t, OvercommitRatios{Memory: 1, CPU: 1, Disk: 1}, OvercommitConf{}.ForLabels(nil))
+}
+
+func TestGetMaandConfOvercommit(t *testing.T) {
+	orig := Location
+	Location = t.TempDir()
+	t.Cleanup(func() { Location = orig })
+
+	write := func(content string) {
+		require.NoError(t, os.WriteFile(path.Join(Location, "maand.conf"), []byte(content), 0o644))
+	}
+
+	write("[overcommit]\ncpu = 1.5\n\n[overcommit.labels.batch]\ncpu = 2.0\n")
+	conf, err := GetMaandConf()
+	require.NoError(t, err)
+	assert.Equal(t, 1.5, conf.Overcommit.CPU)
+	assert.Equal(t, 2.0, conf.Overcommit.Labels["batch"].CPU)
+
+	write("[overcommit.labels.batch]\nmemory = -1\n")
+	_, err = GetMaandConf()
+	assert.ErrorIs(t, err, ErrInvalidMaandConf)
+	assert.ErrorContains(t, err, "overcommit.labels.batch.memory")
+}
@@ -32,12 +32,15 @@ var (
 	ErrInvalidJob                       = errors.New("invalid job")
 	ErrInsufficientResource             = errors.New("insufficient resource")
 	ErrInsufficientAllocations          = errors.New("missing allocations")
+	ErrPlacementConflict                = errors.New("placement rule violated")
 	ErrHealthCheckFailed                = errors.New("health check failed")
 	ErrUnsupportedResourceConfiguration = errors.New("unsupported resource configuration")
 	ErrRunCommand                       = errors.New("run command failed")
 	ErrJobCommandFailed                 = errors.New("job command failed")
 	ErrWorkerPrerequisites              = errors.New("worker prerequisites not met")
 	ErrHostPrerequisites                = errors.New("host prerequisites not met")
+	ErrBucketLocked                     = errors.New("bucket is locked")
+	ErrCommandInterrupted               = errors.New("command interrupted")
 )
 
 // Deprecated: use ErrInvalidWorkerJSON.
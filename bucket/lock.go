@@ -0,0 +1,213 @@
+// Copyright 2025 Kiruba Sankar Swaminathan. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package bucket
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"path"
+	"syscall"
+	"time"
+)
+
+// LockInfo describes the process holding the bucket operation lock.
+type LockInfo struct {
+	Holder    string    `json:"holder"`
+	PID       int       `json:"pid"`
+	Host      string    `json:"host"`
+	Command   string    `json:"command"`
+	StartedAt time.Time `json:"started_at"`
+}
+
+// Stale reports whether the holder ran on this host and its process no longer exists.
+// Locks held from another host are never considered stale.
+func (l LockInfo) Stale() bool {
+	host, err := os.Hostname()
+	if err != nil || host != l.Host {
+		return false
+	}
+	return !processAlive(l.PID)
+}
+
+func (l LockInfo) String() string {
+	return fmt.Sprintf("%s (pid %d on %s, %q since %s)",
+		l.Holder, l.PID, l.Host, l.Command, l.StartedAt.Format(time.RFC3339))
+}
+
+// LockPath returns the bucket operation lock file next to maand.db.
+func LockPath() string {
+	return path.Join(Location, "data", "maand.lock")
+}
+
+// AcquireLock takes the bucket operation lock for command. A stale lock is replaced; a live
+// one fails with ErrBucketLocked naming the holder. When two callers find the same stale
+// lock, only one replaces it. Call the returned release when done.
+func AcquireLock(command string) (func() error, error) {
+	info := currentLockInfo(command)
+	encoded, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return nil, UnexpectedError(err)
+	}
+	if err := os.MkdirAll(path.Dir(LockPath()), 0o755); err != nil {
+		return nil, UnexpectedError(err)
+	}
+
+	// The lock is written to a temporary file and linked into place, so it appears whole
+	// and only when no lock exists.
+	pending, err := os.CreateTemp(path.Dir(LockPath()), "maand.lock.*")
+	if err != nil {
+		return nil, UnexpectedError(err)
+	}
+	defer func() {
+		_ = os.Remove(pending.Name())
+	}()
+	_, writeErr := pending.Write(append(encoded, '\n'))
+	if closeErr := pending.Close(); writeErr != nil || closeErr != nil {
+		return nil, UnexpectedError(errors.Join(writeErr, closeErr))
+	}
+
+	for attempt := 0; ; attempt++ {
+		err := os.Link(pending.Name(), LockPath())
+		if err == nil {
+			return func() error { return releaseLock(info.PID) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, UnexpectedError(err)
+		}
+
+		raw, holder, readErr := readLockFile()
+		if readErr != nil {
+			return nil, readErr
+		}
+		if holder == nil {
+			continue
+		}
+		if attempt > 0 || !holder.Stale() {
+			return nil, fmt.Errorf("%w by %s; run maand lock break if that process is gone", ErrBucketLocked, holder)
+		}
+		if staleLockHook != nil {
+			staleLockHook()
+		}
+		if err := removeLockIf(func(current []byte) bool { return bytes.Equal(current, raw) }); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// staleLockHook, when set by tests, runs after AcquireLock finds a stale lock and before it
+// removes it.
+var staleLockHook func()
+
+// removeLockIf removes the lock file when match accepts its current content. Every
+// removal of the lock file goes through here, under an flock on its directory, so the
+// content matched is the content removed: a stale lock that another process already
+// replaced with a live one is left alone.
+func removeLockIf(match func(current []byte) bool) error {
+	dir, err := os.Open(path.Dir(LockPath()))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return UnexpectedError(err)
+	}
+	defer func() {
+		_ = dir.Close()
+	}()
+	if err := syscall.Flock(int(dir.Fd()), syscall.LOCK_EX); err != nil {
+		return UnexpectedError(err)
+	}
+	defer func() {
+		_ = syscall.Flock(int(dir.Fd()), syscall.LOCK_UN)
+	}()
+
+	current, err := os.ReadFile(LockPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return UnexpectedError(err)
+	}
+	if !match(current) {
+		return nil
+	}
+	if err := os.Remove(LockPath()); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return UnexpectedError(err)
+	}
+	return nil
+}
+
+// ReadLock returns the current lock holder, or nil when the bucket is not locked.
+func ReadLock() (*LockInfo, error) {
+	_, info, err := readLockFile()
+	return info, err
+}
+
+// readLockFile returns the lock file as stored and its holder, or nil when the bucket is
+// not locked.
+func readLockFile() ([]byte, *LockInfo, error) {
+	raw, err := os.ReadFile(LockPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, UnexpectedError(err)
+	}
+	var info LockInfo
+	if err := json.Unmarshal(raw, &info); err != nil {
+		return nil, nil, UnexpectedError(fmt.Errorf("%s: %w", LockPath(), err))
+	}
+	return raw, &info, nil
+}
+
+// BreakLock removes the lock regardless of its holder and returns who held it (nil when
+// the bucket was not locked or the lock file was unreadable).
+func BreakLock() (*LockInfo, error) {
+	info, _ := ReadLock()
+	if err := removeLockIf(func([]byte) bool { return true }); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// releaseLock removes the lock only while pid still holds it, so a broken and re-taken lock
+// is left alone.
+func releaseLock(pid int) error {
+	return removeLockIf(func(current []byte) bool {
+		var info LockInfo
+		return json.Unmarshal(current, &info) == nil && info.PID == pid
+	})
+}
+
+func currentLockInfo(command string) LockInfo {
+	info := LockInfo{
+		Holder:    "unknown",
+		PID:       os.Getpid(),
+		Command:   command,
+		StartedAt: time.Now().UTC().Truncate(time.Second),
+	}
+	if current, err := user.Current(); err == nil {
+		info.Holder = current.Username
+	}
+	if host, err := os.Hostname(); err == nil {
+		info.Host = host
+	}
+	return info
+}
+
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	err = process.Signal(syscall.Signal(0))
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
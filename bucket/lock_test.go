@@ -0,0 +1,154 @@
+// Copyright 2025 Kiruba Sankar Swaminathan. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package bucket
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func useLockBucket(t *testing.T) {
+	t.Helper()
+	origLocation := Location
+	Location = t.TempDir()
+	UpdatePath()
+	t.Cleanup(func() {
+		Location = origLocation
+		UpdatePath()
+	})
+}
+
+func writeLockInfo(t *testing.T, info LockInfo) {
+	t.Helper()
+	raw, err := json.Marshal(info)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(LockPath(), raw, 0o644))
+}
+
+func TestAcquireLock_exclusiveUntilReleased(t *testing.T) {
+	useLockBucket(t)
+
+	release, err := AcquireLock("maand deploy")
+	require.NoError(t, err)
+	info, err := ReadLock()
+	require.NoError(t, err)
+	require.NotNil(t, info)
+	assert.Equal(t, os.Getpid(), info.PID)
+	assert.Equal(t, "maand deploy", info.Command)
+	assert.False(t, info.Stale())
+
+	_, err = AcquireLock("maand build")
+	require.ErrorIs(t, err, ErrBucketLocked)
+	assert.Contains(t, err.Error(), "maand deploy")
+
+	require.NoError(t, release())
+	info, err = ReadLock()
+	require.NoError(t, err)
+	assert.Nil(t, info)
+
+	release, err = AcquireLock("maand build")
+	require.NoError(t, err)
+	require.NoError(t, release())
+}
+
+func TestAcquireLock_replacesStaleLock(t *testing.T) {
+	useLockBucket(t)
+	host, err := os.Hostname()
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(path.Dir(LockPath()), 0o755))
+
+	// pid beyond the default pid_max, so no process holds it
+	writeLockInfo(t, LockInfo{Holder: "ops", PID: 1 << 30, Host: host, Command: "maand gc", StartedAt: time.Now()})
+	release, err := AcquireLock("maand deploy")
+	require.NoError(t, err)
+	info, err := ReadLock()
+	require.NoError(t, err)
+	assert.Equal(t, "maand deploy", info.Command)
+	require.NoError(t, release())
+}
+
+func TestAcquireLock_staleLockFoundTwiceHasOneWinner(t *testing.T) {
+	useLockBucket(t)
+	host, err := os.Hostname()
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(path.Dir(LockPath()), 0o755))
+	writeLockInfo(t, LockInfo{Holder: "ops", PID: 1 << 30, Host: host, Command: "maand gc", StartedAt: time.Now()})
+
+	// both callers read the same stale lock before either removes it
+	var found sync.WaitGroup
+	found.Add(2)
+	staleLockHook = func() {
+		found.Done()
+		found.Wait()
+	}
+	t.Cleanup(func() { staleLockHook = nil })
+
+	commands := []string{"maand deploy", "maand build"}
+	errs := make([]error, len(commands))
+	var done sync.WaitGroup
+	for i, command := range commands {
+		done.Add(1)
+		go func() {
+			defer done.Done()
+			_, errs[i] = AcquireLock(command)
+		}()
+	}
+	done.Wait()
+
+	var winners []string
+	for i, err := range errs {
+		if err == nil {
+			winners = append(winners, commands[i])
+			continue
+		}
+		assert.ErrorIs(t, err, ErrBucketLocked)
+	}
+	require.Len(t, winners, 1, "exactly one caller replaces the stale lock")
+	info, err := ReadLock()
+	require.NoError(t, err)
+	require.NotNil(t, info)
+	assert.Equal(t, winners[0], info.Command)
+}
+
+func TestAcquireLock_otherHostIsNeverStale(t *testing.T) {
+	useLockBucket(t)
+	require.NoError(t, os.MkdirAll(path.Dir(LockPath()), 0o755))
+
+	writeLockInfo(t, LockInfo{Holder: "ops", PID: 1 << 30, Host: "elsewhere.invalid", Command: "maand gc", StartedAt: time.Now()})
+	_, err := AcquireLock("maand deploy")
+	require.ErrorIs(t, err, ErrBucketLocked)
+
+	broken, err := BreakLock()
+	require.NoError(t, err)
+	require.NotNil(t, broken)
+	assert.Equal(t, "elsewhere.invalid", broken.Host)
+
+	release, err := AcquireLock("maand deploy")
+	require.NoError(t, err)
+	require.NoError(t, release())
+}
+
+func TestReleaseLock_leavesRetakenLockAlone(t *testing.T) {
+	useLockBucket(t)
+
+	release, err := AcquireLock("maand deploy")
+	require.NoError(t, err)
+	_, err = BreakLock()
+	require.NoError(t, err)
+	writeLockInfo(t, LockInfo{Holder: "ops", PID: os.Getpid() + 1, Host: "elsewhere.invalid", Command: "maand build", StartedAt: time.Now()})
+
+	require.NoError(t, release())
+	info, err := ReadLock()
+	require.NoError(t, err)
+	require.NotNil(t, info)
+	assert.Equal(t, "maand build", info.Command)
+}
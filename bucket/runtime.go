@@ -22,6 +22,10 @@ import (
 type Runtime struct {
 	logMu sync.Mutex
 	run   RunContext
+
+	procMu      sync.Mutex
+	running     map[*exec.Cmd]struct{}
+	interrupted bool
 }
 
 // SetupRuntime prepares host execution for a bucket session.
@@ -39,6 +43,45 @@ func (r *Runtime) Stop() error {
 	return nil
 }
 
+// Interrupt kills the commands RunCommand is waiting on and makes later RunCommand calls
+// fail with ErrCommandInterrupted.
+func (r *Runtime) Interrupt() {
+	r.procMu.Lock()
+	defer r.procMu.Unlock()
+	r.interrupted = true
+	for cmd := range r.running {
+		_ = cmd.Process.Kill()
+	}
+}
+
+// Interrupted reports whether Interrupt was called.
+func (r *Runtime) Interrupted() bool {
+	r.procMu.Lock()
+	defer r.procMu.Unlock()
+	return r.interrupted
+}
+
+// track registers a started command so Interrupt can kill it; it kills cmd right away when
+// the runtime is already interrupted.
+func (r *Runtime) track(cmd *exec.Cmd) {
+	r.procMu.Lock()
+	defer r.procMu.Unlock()
+	if r.interrupted {
+		_ = cmd.Process.Kill()
+		return
+	}
+	if r.running == nil {
+		r.running = make(map[*exec.Cmd]struct{})
+	}
+	r.running[cmd] = struct{}{}
+}
+
+func (r *Runtime) untrack(cmd *exec.Cmd) {
+	r.procMu.Lock()
+	defer r.procMu.Unlock()
+	delete(r.running, cmd)
+}
+
 // LogEvent writes a structured event line to bucket logs.
 func (r *Runtime) LogEvent(workerIP, event string, extra map[string]string) error {
 	line := formatEventLine(r.run, workerIP, event, extra)
@@ -70,6 +113,9 @@ func (r *Runtime) Exec(workerIP string, cmdCtx CommandContext, commandLines []st
 // RunCommand starts cmd, streams stdout/stderr to logs, and returns on completion.
 func (r *Runtime) RunCommand(workerIP string, cmdCtx CommandContext, cmd *exec.Cmd) error {
 	cmdCtx = cmdCtx.withDefaults(workerIP, cmd, nil)
+	if r.Interrupted() {
+		return ErrCommandInterrupted
+	}
 
 	if cmd.Dir == "" {
 		bucketRoot, err := filepath.Abs(Location)
@@ -96,6 +142,8 @@ func (r *Runtime) RunCommand(workerIP string, cmdCtx CommandContext, cmd *exec.C
 	if err := cmd.Start(); err != nil {
 		return UnexpectedError(err)
 	}
+	r.track(cmd)
+	defer r.untrack(cmd)
 
 	var (
 		wg        sync.WaitGroup
@@ -151,6 +199,9 @@ func (r *Runtime) RunCommand(workerIP string, cmdCtx CommandContext, cmd *exec.C
 	}
 
 	if waitErr != nil {
+		if r.Interrupted() {
+			return fmt.Errorf("%w: %v", ErrCommandInterrupted, commandFailedError(waitErr))
+		}
 		return commandFailedError(waitErr)
 	}
 	return nil
@@ -10,6 +10,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -82,3 +83,36 @@ func TestLogEvent(t *testing.T) {
 	require.Contains(t, string(data), "event=deploy_skip")
 	require.Contains(t, string(data), "job=api")
 }
+
+func TestInterruptKillsRunningCommand(t *testing.T) {
+	root := t.TempDir()
+	origLocation := Location
+	Location = root
+	UpdatePath()
+	t.Cleanup(func() {
+		Location = origLocation
+		UpdatePath()
+	})
+
+	rt, err := SetupRuntime("bucket-1", NewRunContext("deploy", 1))
+	require.NoError(t, err)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- rt.RunCommand("10.0.0.1", CommandContext{Job: "api"}, exec.Command("sleep", "30"))
+	}()
+	require.Eventually(t, func() bool {
+		rt.procMu.Lock()
+		defer rt.procMu.Unlock()
+		return len(rt.running) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	rt.Interrupt()
+	select {
+	case err := <-done:
+		require.ErrorIs(t, err, ErrCommandInterrupted)
+	case <-time.After(5 * time.Second):
+		t.Fatal("command was not killed")
+	}
+	require.ErrorIs(t, rt.Exec("", CommandContext{}, []string{"true"}, nil), ErrCommandInterrupted)
+}
@@ -7,6 +7,8 @@ package build
 import (
 	"database/sql"
 	"errors"
+	"slices"
+	"strings"
 
 	"maand/bucket"
 	"maand/data"
@@ -14,58 +16,62 @@ import (
 	"maand/workspace"
 )
 
-const matchingJobsByLabelsQuery = `
-			SELECT DISTINCT j.name
-            FROM job j
-            JOIN job_selectors js ON js.job_id = j.job_id
-            JOIN worker_labels al ON al.label = js.selector
-            WHERE (
-                SELECT COUNT(DISTINCT js_sub.selector)
-                FROM job_selectors js_sub
-                WHERE js_sub.job_id = j.job_id
-            ) = (
-                SELECT COUNT(DISTINCT wl_sub.label)
-                FROM worker_labels wl_sub
-                JOIN worker a ON wl_sub.worker_id = a.worker_id
-                WHERE wl_sub.label IN (
-                    SELECT jl_sub.selector
-                    FROM job_selectors jl_sub
-                    WHERE jl_sub.job_id = j.job_id
-                ) AND a.worker_ip = ?
-			) 
-		`
-
 func BuildAllocations(tx *sql.Tx, jobWorkspace *workspace.DefaultWorkspace) error {
+	return buildAllocations(tx, jobWorkspace, func(string) bool { return false })
+}
+
+// RebalanceAllocations is BuildAllocations with the counted jobs named in jobs (every
+// counted job when jobs is empty) placed afresh instead of kept on their current workers.
+// Jobs without count run on every matching worker and are not affected.
+func RebalanceAllocations(tx *sql.Tx, jobWorkspace *workspace.DefaultWorkspace, jobs []string) error {
+	if len(jobs) > 0 {
+		known, err := data.GetJobs(tx)
+		if err != nil {
+			return err
+		}
+		if unknown := utils.Difference(jobs, known); len(unknown) > 0 {
+			return bucket.NotFoundError("jobs " + strings.Join(unknown, ", "))
+		}
+	}
+	return buildAllocations(tx, jobWorkspace, func(job string) bool {
+		return len(jobs) == 0 || slices.Contains(jobs, job)
+	})
+}
+
+func buildAllocations(tx *sql.Tx, jobWorkspace *workspace.DefaultWorkspace, rebalance func(job string) bool) error {
 	disabledBefore, err := loadDisabledAllocations(tx)
 	if err != nil {
 		return err
 	}
 
+	disableConfig, err := jobWorkspace.GetDisabled()
+	if err != nil {
+		return err
+	}
+
 	workerIPs, err := data.GetWorkers(tx, nil)
 	if err != nil {
 		return err
 	}
 
-	for _, workerIP := range workerIPs {
-		rows, err := tx.Query(matchingJobsByLabelsQuery, workerIP)
-		if err != nil {
-			return bucket.DatabaseError(err)
+	placements, err := planAllocations(tx, workerIPs, disableConfig, rebalance)
+	if err != nil {
+		return err
+	}
+	placedJobsByWorker := make(map[string][]string, len(workerIPs))
+	for jobName, workers := range placements {
+		for _, workerIP := range workers {
+			placedJobsByWorker[workerIP] = append(placedJobsByWorker[workerIP], jobName)
 		}
+	}
 
-		var matchedJobNames []string
-		for rows.Next() {
-			var jobName string
-			err = rows.Scan(&jobName)
-			if err != nil {
-				_ = rows.Close()
-				return bucket.DatabaseError(err)
-			}
-
-			matchedJobNames = append(matchedJobNames, jobName)
+	for _, workerIP := range workerIPs {
+		placedJobNames := placedJobsByWorker[workerIP]
+		slices.Sort(placedJobNames)
 
+		for _, jobName := range placedJobNames {
 			allocationID, err := data.GetAllocationID(tx, workerIP, jobName)
 			if err != nil && !errors.Is(err, sql.ErrNoRows) {
-				_ = rows.Close()
 				return err
 			}
 
@@ -75,7 +81,6 @@ func BuildAllocations(tx *sql.Tx, jobWorkspace *workspace.DefaultWorkspace) erro
 
 			targetVersion, err := data.TargetJobVersion(tx, jobName)
 			if err != nil {
-				_ = rows.Close()
 				return err
 			}
 
@@ -84,23 +89,16 @@ func BuildAllocations(tx *sql.Tx, jobWorkspace *workspace.DefaultWorkspace) erro
 			) VALUES (?, ?, ?, ?, ?, ?, ?)`
 			_, err = tx.Exec(upsertAllocationQuery, allocationID, jobName, workerIP, 0, 0, 0, targetVersion)
 			if err != nil {
-				_ = rows.Close()
 				return bucket.DatabaseError(err)
 			}
 		}
-		if err := rows.Close(); err != nil {
-			return bucket.DatabaseError(err)
-		}
-		if err := data.RowsErr(rows); err != nil {
-			return err
-		}
 
 		persistedJobNames, err := data.GetAllocatedJobs(tx, workerIP)
 		if err != nil {
 			return err
 		}
 
-		jobNamesToRemove := utils.Difference(persistedJobNames, matchedJobNames)
+		jobNamesToRemove := utils.Difference(persistedJobNames, placedJobNames)
 		for _, jobName := range jobNamesToRemove {
 			_, err := tx.Exec("UPDATE allocations SET removed = 1 WHERE job = ? AND worker_ip = ?", jobName, workerIP)
 			if err != nil {
@@ -114,11 +112,6 @@ func BuildAllocations(tx *sql.Tx, jobWorkspace *workspace.DefaultWorkspace) erro
 		return bucket.DatabaseError(err)
 	}
 
-	disableConfig, err := jobWorkspace.GetDisabled()
-	if err != nil {
-		return err
-	}
-
 	for _, workerIP := range disableConfig.Workers {
 		_, err := tx.Exec("UPDATE allocations SET disabled = 1 WHERE worker_ip = ?", workerIP)
 		if err != nil {
@@ -145,6 +138,102 @@ func BuildAllocations(tx *sql.Tx, jobWorkspace *workspace.DefaultWorkspace) erro
 	return markReenabledAllocations(tx, disabledBefore)
 }
 
+// planAllocations reads the catalog and returns the workers each job is placed on (see
+// placeAllocations). Counted jobs for which rebalance reports true are placed afresh.
+func planAllocations(
+	tx *sql.Tx,
+	workerIPs []string,
+	disableConfig workspace.DisabledAllocations,
+	rebalance func(job string) bool,
+) (map[string][]string, error) {
+	reservations, err := data.ListJobReservations(tx)
+	if err != nil {
+		return nil, err
+	}
+	candidates, err := matchingWorkers(tx, workerIPs, reservations)
+	if err != nil {
+		return nil, err
+	}
+	workers, err := data.ListWorkerCapacity(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	jobs := make([]jobPlacement, 0, len(reservations))
+	for _, reservation := range reservations {
+		current, err := data.GetNonRemovedAllocations(tx, reservation.Job)
+		if err != nil {
+			return nil, err
+		}
+		placement, err := data.GetJobPlacement(tx, reservation.Job)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, jobPlacement{
+			JobReservation: reservation,
+			candidates:     candidates[reservation.Job],
+			current:        current,
+			excluded:       disabledWorkers(disableConfig, reservation.Job),
+			rules:          placement.Rules(),
+			rebalance:      reservation.Count > 0 && rebalance(reservation.Job),
+		})
+	}
+	return placeAllocations(workers, jobs), nil
+}
+
+// disabledWorkers returns the workers disabled.json disables job on. A job disabled as a
+// whole returns none: every allocation is disabled, so there is nowhere better to move to.
+func disabledWorkers(disableConfig workspace.DisabledAllocations, jobName string) map[string]bool {
+	excluded := make(map[string]bool)
+	if spec, ok := disableConfig.Jobs[jobName]; ok {
+		if len(spec.Allocations) == 0 {
+			return excluded
+		}
+		for _, workerIP := range spec.Allocations {
+			excluded[workerIP] = true
+		}
+	}
+	for _, workerIP := range disableConfig.Workers {
+		excluded[workerIP] = true
+	}
+	return excluded
+}
+
+// matchingWorkers returns, for each job, the workers whose labels and tags match the job's
+// selector, in workerIPs order.
+func matchingWorkers(tx *sql.Tx, workerIPs []string, jobs []data.JobReservation) (map[string][]string, error) {
+	selectors := make(map[string]workspace.Selector, len(jobs))
+	for _, job := range jobs {
+		selector, err := data.GetJobSelector(tx, job.Job)
+		if err != nil {
+			return nil, err
+		}
+		selectors[job.Job] = selector
+	}
+
+	candidates := make(map[string][]string)
+	for _, workerIP := range workerIPs {
+		workerID, err := data.GetWorkerID(tx, workerIP)
+		if err != nil {
+			return nil, err
+		}
+		labels, err := data.GetWorkerLabels(tx, workerID)
+		if err != nil {
+			return nil, err
+		}
+		tags, err := data.GetWorkerTags(tx, workerID)
+		if err != nil {
+			return nil, err
+		}
+		for _, job := range jobs {
+			if selector := selectors[job.Job]; selector != nil && selector.Match(labels, tags) {
+				candidates[job.Job] = append(candidates[job.Job], workerIP)
+			}
+		}
+	}
+	return candidates, nil
+}
+
 func loadDisabledAllocations(tx *sql.Tx) (map[string]struct{}, error) {
 	rows, err := tx.Query(`SELECT worker_ip, job FROM allocations WHERE disabled = 1 AND removed = 0`)
 	if err != nil {
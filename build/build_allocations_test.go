@@ -266,6 +266,156 @@ func TestBuildAllocations_createsNewAllocation(t *testing.T) {
 	assert.Equal(t, "2.0.0", newVersion)
 }
 
+func TestBuildAllocations_countPlacesStableSubset(t *testing.T) {
+	root := t.TempDir()
+	orig := bucket.Location
+	bucket.Location = root
+	bucket.UpdatePath()
+	t.Cleanup(func() {
+		bucket.Location = orig
+		bucket.UpdatePath()
+	})
+
+	db := openBuildAllocationsTestDB(t)
+	defer func() { _ = db.Close() }()
+	_, err := db.Exec(`
+		INSERT INTO worker (worker_id, worker_ip, available_memory_mb, available_cpu_mhz, position)
+		VALUES ('w1', '10.0.0.1', '1024', '2000', 0),
+		       ('w2', '10.0.0.2', '1024', '2000', 1),
+		       ('w3', '10.0.0.3', '1024', '2000', 2);
+		INSERT INTO worker_labels (worker_id, label) VALUES ('w1', 'web'), ('w2', 'web'), ('w3', 'web');
+		INSERT INTO worker_tags (worker_id, key, value) VALUES ('w1', 'zone', 'a'), ('w2', 'zone', 'a'), ('w3', 'zone', 'b');
+		INSERT INTO job (
+			job_id, name, version,
+			min_memory_mb, max_memory_mb, current_memory_mb,
+			min_cpu_mhz, max_cpu_mhz, current_cpu_mhz,
+			max_concurrent_upgrades, health_check, allocation_count
+		) VALUES ('job-web', 'web', '1.0.0', '0', '0', '0', '0', '0', '0', 1, '', 2);
+		INSERT INTO job_selectors (job_id, selector) VALUES ('job-web', 'web');
+	`)
+	require.NoError(t, err)
+
+	build := func() []string {
+		tx, err := db.Begin()
+		require.NoError(t, err)
+		require.NoError(t, BuildAllocations(tx, workspace.Default()))
+		workers, err := data.GetNonRemovedAllocations(tx, "web")
+		require.NoError(t, err)
+		require.NoError(t, tx.Commit())
+		return workers
+	}
+
+	assert.Equal(t, []string{"10.0.0.1", "10.0.0.3"}, build())
+	assert.Equal(t, []string{"10.0.0.1", "10.0.0.3"}, build())
+
+	_, err = db.Exec(`DELETE FROM worker WHERE worker_ip = '10.0.0.3'`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"10.0.0.1", "10.0.0.2"}, build())
+}
+
+func TestRebalanceAllocations_movesOntoAddedWorker(t *testing.T) {
+	root := t.TempDir()
+	orig := bucket.Location
+	bucket.Location = root
+	bucket.UpdatePath()
+	t.Cleanup(func() {
+		bucket.Location = orig
+		bucket.UpdatePath()
+	})
+
+	db := openBuildAllocationsTestDB(t)
+	defer func() { _ = db.Close() }()
+	_, err := db.Exec(`
+		INSERT INTO worker (worker_id, worker_ip, available_memory_mb, available_cpu_mhz, position)
+		VALUES ('w1', '10.0.0.1', '1024', '2000', 0),
+		       ('w2', '10.0.0.2', '1024', '2000', 1),
+		       ('w3', '10.0.0.3', '1024', '2000', 2);
+		INSERT INTO worker_labels (worker_id, label) VALUES ('w1', 'web'), ('w2', 'web'), ('w3', 'web');
+		INSERT INTO worker_tags (worker_id, key, value) VALUES ('w1', 'zone', 'a'), ('w2', 'zone', 'a'), ('w3', 'zone', 'b');
+		INSERT INTO job (
+			job_id, name, version,
+			min_memory_mb, max_memory_mb, current_memory_mb,
+			min_cpu_mhz, max_cpu_mhz, current_cpu_mhz,
+			max_concurrent_upgrades, health_check, allocation_count
+		) VALUES ('job-web', 'web', '1.0.0', '0', '0', '0', '0', '0', '0', 1, '', 2);
+		INSERT INTO job_selectors (job_id, selector) VALUES ('job-web', 'web');
+		INSERT INTO allocations (alloc_id, worker_ip, job, disabled, removed, deployment_seq, new_version)
+		VALUES ('web-1', '10.0.0.1', 'web', 0, 0, 0, '1.0.0'),
+		       ('web-2', '10.0.0.2', 'web', 0, 0, 0, '1.0.0');
+	`)
+	require.NoError(t, err)
+
+	build := func(allocate func(tx *sql.Tx) error) []string {
+		tx, err := db.Begin()
+		require.NoError(t, err)
+		require.NoError(t, allocate(tx))
+		workers, err := data.GetNonRemovedAllocations(tx, "web")
+		require.NoError(t, err)
+		require.NoError(t, tx.Commit())
+		return workers
+	}
+
+	assert.Equal(t, []string{"10.0.0.1", "10.0.0.2"}, build(func(tx *sql.Tx) error {
+		return BuildAllocations(tx, workspace.Default())
+	}))
+	assert.Equal(t, []string{"10.0.0.1", "10.0.0.3"}, build(func(tx *sql.Tx) error {
+		return RebalanceAllocations(tx, workspace.Default(), []string{"web"})
+	}))
+
+	tx, err := db.Begin()
+	require.NoError(t, err)
+	defer func() { _ = tx.Rollback() }()
+	err = RebalanceAllocations(tx, workspace.Default(), []string{"web", "missing"})
+	assert.ErrorIs(t, err, bucket.ErrNotFound)
+	assert.ErrorContains(t, err, "missing")
+}
+
+func TestBuildAllocations_affinityPlacesWithReferencedJob(t *testing.T) {
+	root := t.TempDir()
+	orig := bucket.Location
+	bucket.Location = root
+	bucket.UpdatePath()
+	t.Cleanup(func() {
+		bucket.Location = orig
+		bucket.UpdatePath()
+	})
+
+	db := openBuildAllocationsTestDB(t)
+	defer func() { _ = db.Close() }()
+	_, err := db.Exec(`
+		INSERT INTO worker (worker_id, worker_ip, available_memory_mb, available_cpu_mhz, position)
+		VALUES ('w1', '10.0.0.1', '1024', '2000', 0),
+		       ('w2', '10.0.0.2', '1024', '2000', 1);
+		INSERT INTO worker_labels (worker_id, label) VALUES ('w1', 'worker'), ('w2', 'worker');
+		INSERT INTO job (
+			job_id, name, version,
+			min_memory_mb, max_memory_mb, current_memory_mb,
+			min_cpu_mhz, max_cpu_mhz, current_cpu_mhz,
+			max_concurrent_upgrades, health_check, allocation_count, placement
+		) VALUES ('job-api', 'api', '1.0.0', '0', '0', '0', '0', '0', '0', 1, '', 1, ''),
+		         ('job-agent', 'agent', '1.0.0', '0', '0', '0', '0', '0', '0', 1, '', 0, '{"affinity":[{"job":"api"}]}'),
+		         ('job-batch', 'batch', '1.0.0', '0', '0', '0', '0', '0', '0', 1, '', 0, '{"anti_affinity":[{"job":"api"}]}');
+		INSERT INTO job_selectors (job_id, selector) VALUES ('job-api', 'worker'), ('job-agent', 'worker'), ('job-batch', 'worker');
+	`)
+	require.NoError(t, err)
+
+	tx, err := db.Begin()
+	require.NoError(t, err)
+	defer func() { _ = tx.Rollback() }()
+	require.NoError(t, BuildAllocations(tx, workspace.Default()))
+	require.NoError(t, ValidatePlacementRules(tx))
+
+	for job, want := range map[string][]string{
+		"api":   {"10.0.0.1"},
+		"agent": {"10.0.0.1"},
+		"batch": {"10.0.0.2"},
+	} {
+		workers, err := data.GetNonRemovedAllocations(tx, job)
+		require.NoError(t, err)
+		assert.Equal(t, want, workers, job)
+	}
+}
+
 func TestLoadDisabledAllocations(t *testing.T) {
 	db := openBuildAllocationsTestDB(t)
 	defer func() { _ = db.Close() }()
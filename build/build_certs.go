@@ -40,6 +40,12 @@ type jobCertSpec struct {
 }
 
 func BuildCerts(tx *sql.Tx) error {
+	return buildCerts(tx, bucket.TempLocation)
+}
+
+// buildCerts generates the job certs that need it under tempDir/workers/<ip>/jobs/<job>/certs
+// and syncs them to KV.
+func buildCerts(tx *sql.Tx, tempDir string) error {
 	caHash, err := utils.CalculateFileMD5(path.Join(bucket.SecretLocation, "ca.crt"))
 	if err != nil {
 		return err
@@ -60,7 +66,7 @@ func BuildCerts(tx *sql.Tx) error {
 	}
 
 	for _, jobName := range jobs {
-		if err := buildJobCerts(tx, jobName, caChanged); err != nil {
+		if err := buildJobCerts(tx, tempDir, jobName, caChanged); err != nil {
 			return err
 		}
 	}
@@ -68,7 +74,7 @@ func BuildCerts(tx *sql.Tx) error {
 	return data.PromoteHash(tx, "build", "ca")
 }
 
-func buildJobCerts(tx *sql.Tx, jobName string, caChanged bool) error {
+func buildJobCerts(tx *sql.Tx, tempDir, jobName string, caChanged bool) error {
 	jobDir := path.Join("jobs", jobName)
 
 	jobCertConfigChanged, err := data.HashChanged(tx, "build_certs", jobName)
@@ -131,6 +137,7 @@ func buildJobCerts(tx *sql.Tx, jobName string, caChanged bool) error {
 
 		if spec.one {
 			certPEM, keyPEM, err := buildSharedCertPEM(
+				tempDir,
 				jobName,
 				jobDir,
 				spec,
@@ -172,6 +179,7 @@ func buildJobCerts(tx *sql.Tx, jobName string, caChanged bool) error {
 			}
 
 			certPEM, keyPEM, err := buildWorkerCertPEM(
+				tempDir,
 				jobName,
 				jobDir,
 				workerIP,
@@ -314,7 +322,7 @@ func certPEMExpiringSoon(certPEM []byte, renewalBufferDays int) (bool, error) {
 }
 
 func buildSharedCertPEM(
-	jobName, jobDir string,
+	tempDir, jobName, jobDir string,
 	spec jobCertSpec,
 	workerIPs []string,
 	regenerate bool,
@@ -322,7 +330,7 @@ func buildSharedCertPEM(
 ) ([]byte, []byte, error) {
 	if regenerate {
 		firstWorker := workerIPs[0]
-		certPath := workerCertDir(firstWorker, jobDir)
+		certPath := workerCertDir(tempDir, firstWorker, jobDir)
 		if err := os.MkdirAll(certPath, 0o755); err != nil {
 			return nil, nil, fmt.Errorf("%w: %w", bucket.ErrUnexpectedError, err)
 		}
@@ -334,7 +342,7 @@ func buildSharedCertPEM(
 			return nil, nil, err
 		}
 		for _, workerIP := range workerIPs {
-			targetPath := workerCertDir(workerIP, jobDir)
+			targetPath := workerCertDir(tempDir, workerIP, jobDir)
 			if err := os.MkdirAll(targetPath, 0o755); err != nil {
 				return nil, nil, fmt.Errorf("%w: %w", bucket.ErrUnexpectedError, err)
 			}
@@ -359,12 +367,12 @@ func buildSharedCertPEM(
 }
 
 func buildWorkerCertPEM(
-	jobName, jobDir, workerIP string,
+	tempDir, jobName, jobDir, workerIP string,
 	spec jobCertSpec,
 	regenerate bool,
 	ttlDays int,
 ) ([]byte, []byte, error) {
-	certPath := workerCertDir(workerIP, jobDir)
+	certPath := workerCertDir(tempDir, workerIP, jobDir)
 	if err := os.MkdirAll(certPath, 0o755); err != nil {
 		return nil, nil, fmt.Errorf("%w: %w", bucket.ErrUnexpectedError, err)
 	}
@@ -403,8 +411,8 @@ func addCertVariables(
 	return nil
 }
 
-func workerCertDir(workerIP, jobDir string) string {
-	return path.Join(bucket.TempLocation, "workers", workerIP, jobDir, "certs")
+func workerCertDir(tempDir, workerIP, jobDir string) string {
+	return path.Join(tempDir, "workers", workerIP, jobDir, "certs")
 }
 
 func readCertFiles(certPath, certName string) ([]byte, []byte, error) {
@@ -125,7 +125,7 @@ func TestBuildSharedCertPEM_reusesStoredKV(t *testing.T) {
 	require.NoError(t, tx.Commit())
 
 	spec := jobCertSpec{name: "tls", pkcs8: true, one: true, subject: pkix.Name{CommonName: "api"}}
-	gotCert, gotKey, err := buildSharedCertPEM("api", "jobs/api", spec, []string{"10.0.0.1", "10.0.0.2"}, false, 365)
+	gotCert, gotKey, err := buildSharedCertPEM(bucket.TempLocation, "api", "jobs/api", spec, []string{"10.0.0.1", "10.0.0.2"}, false, 365)
 	require.NoError(t, err)
 	assert.Contains(t, string(gotCert), "BEGIN CERTIFICATE")
 	assert.Contains(t, string(gotKey), "BEGIN PRIVATE KEY")
@@ -246,12 +246,12 @@ func TestBuildWorkerCertPEM_regenerates(t *testing.T) {
 	setupBuildCertSecrets(t)
 
 	spec := jobCertSpec{name: "tls", pkcs8: true, one: false, subject: pkix.Name{CommonName: "api"}}
-	certPEM, keyPEM, err := buildWorkerCertPEM("api", "jobs/api", "10.0.0.1", spec, true, 365)
+	certPEM, keyPEM, err := buildWorkerCertPEM(bucket.TempLocation, "api", "jobs/api", "10.0.0.1", spec, true, 365)
 	require.NoError(t, err)
 	assert.Contains(t, string(certPEM), "BEGIN CERTIFICATE")
 	assert.Contains(t, string(keyPEM), "BEGIN PRIVATE KEY")
 
-	certPath := workerCertDir("10.0.0.1", "jobs/api")
+	certPath := workerCertDir(bucket.TempLocation, "10.0.0.1", "jobs/api")
 	assert.FileExists(t, path.Join(certPath, "tls.crt"))
 	assert.FileExists(t, path.Join(certPath, "tls.key"))
 }
@@ -331,13 +331,13 @@ func TestBuildSharedCertPEM_regeneratesAllWorkers(t *testing.T) {
 	setupBuildCertSecrets(t)
 
 	spec := jobCertSpec{name: "tls", pkcs8: true, one: true, subject: pkix.Name{CommonName: "api"}}
-	certPEM, keyPEM, err := buildSharedCertPEM("api", "jobs/api", spec, []string{"10.0.0.1", "10.0.0.2"}, true, 365)
+	certPEM, keyPEM, err := buildSharedCertPEM(bucket.TempLocation, "api", "jobs/api", spec, []string{"10.0.0.1", "10.0.0.2"}, true, 365)
 	require.NoError(t, err)
 	assert.Contains(t, string(certPEM), "BEGIN CERTIFICATE")
 	assert.Contains(t, string(keyPEM), "BEGIN PRIVATE KEY")
 
 	for _, workerIP := range []string{"10.0.0.1", "10.0.0.2"} {
-		certPath := workerCertDir(workerIP, "jobs/api")
+		certPath := workerCertDir(bucket.TempLocation, workerIP, "jobs/api")
 		assert.FileExists(t, path.Join(certPath, "tls.crt"))
 		assert.FileExists(t, path.Join(certPath, "tls.key"))
 	}
@@ -357,7 +357,7 @@ func TestBuildWorkerCertPEM_reusesStoredKV(t *testing.T) {
 	require.NoError(t, tx.Commit())
 
 	spec := jobCertSpec{name: "tls", pkcs8: true, one: false, subject: pkix.Name{CommonName: "api"}}
-	certPEM, keyPEM, err := buildWorkerCertPEM("api", "jobs/api", "10.0.0.1", spec, false, 365)
+	certPEM, keyPEM, err := buildWorkerCertPEM(bucket.TempLocation, "api", "jobs/api", "10.0.0.1", spec, false, 365)
 	require.NoError(t, err)
 	assert.Equal(t, "STORED-CERT", string(certPEM))
 	assert.Equal(t, "STORED-KEY", string(keyPEM))
@@ -35,6 +35,12 @@ func BuildJobs(tx *sql.Tx, jobWorkspace *workspace.DefaultWorkspace) ([]string,
 	for _, jobName := range workspaceJobNames {
 		jobID := workspace.GetHashUUID(jobName)
 
+		// Buckets from before job_files_promoted have no rollback snapshot until the next
+		// deploy; take it from the files being replaced when they are what was promoted.
+		if err := data.SeedPromotedJobFiles(tx, jobName); err != nil {
+			return nil, err
+		}
+
 		purgeChildTableQueries := []string{
 			"DELETE FROM job_selectors WHERE job_id = ?",
 			"DELETE FROM job_commands WHERE job_id = ?",
@@ -85,6 +91,21 @@ func BuildJobs(tx *sql.Tx, jobWorkspace *workspace.DefaultWorkspace) ([]string,
 			return nil, fmt.Errorf("%w: job %s minMemoryMb > maxMemoryMb", bucket.ErrInvalidManifest, jobName)
 		}
 
+		minDiskMB, err := utils.ParseDiskMB(manifest.MinDisk())
+		if err != nil {
+			return nil, fmt.Errorf("%w: job %s %w", bucket.ErrInvalidManifest, jobName, err)
+		}
+		maxDiskMB, err := utils.ParseDiskMB(manifest.MaxDisk())
+		if err != nil {
+			return nil, fmt.Errorf("%w: job %s %w", bucket.ErrInvalidManifest, jobName, err)
+		}
+		if minDiskMB != 0 && maxDiskMB == 0 {
+			maxDiskMB = minDiskMB
+		}
+		if minDiskMB > maxDiskMB {
+			return nil, fmt.Errorf("%w: job %s minDiskMb > maxDiskMb", bucket.ErrInvalidManifest, jobName)
+		}
+
 		bucketJobsConfigFile, jobConfig, err := loadJobBucketConfig(jobName)
 		if err != nil {
 			return nil, err
@@ -130,6 +151,26 @@ func BuildJobs(tx *sql.Tx, jobWorkspace *workspace.DefaultWorkspace) ([]string,
 			}
 		}
 
+		requestedDiskMB := maxDiskMB
+		if _, ok := jobConfig["disk"]; ok {
+			requestedDiskMB, err = utils.ParseDiskMB(jobConfig["disk"])
+			if err != nil {
+				return nil, err
+			}
+
+			if minDiskMB == 0 && maxDiskMB == 0 {
+				minDiskMB = requestedDiskMB
+				maxDiskMB = requestedDiskMB
+			}
+
+			if requestedDiskMB > maxDiskMB {
+				return nil, fmt.Errorf("%w: %s, job %s max_disk_mb %.2f mb, requested %.2f mb", bucket.ErrUnsupportedResourceConfiguration, bucketJobsConfigFile, jobName, maxDiskMB, requestedDiskMB)
+			}
+			if requestedDiskMB < minDiskMB {
+				return nil, fmt.Errorf("%w: %s, job %s min_disk_mb %.2f mb, requested %.2f mb", bucket.ErrUnsupportedResourceConfiguration, bucketJobsConfigFile, jobName, minDiskMB, requestedDiskMB)
+			}
+		}
+
 		memorySource := "manifest"
 		if _, ok := jobConfig["memory"]; ok {
 			memorySource = bucketJobsConfigFile
@@ -140,6 +181,11 @@ func BuildJobs(tx *sql.Tx, jobWorkspace *workspace.DefaultWorkspace) ([]string,
 			cpuSource = bucketJobsConfigFile
 		}
 
+		diskSource := "manifest"
+		if _, ok := jobConfig["disk"]; ok {
+			diskSource = bucketJobsConfigFile
+		}
+
 		if err := workspace.ValidateHealthCheck(jobName, manifest); err != nil {
 			return nil, err
 		}
@@ -149,9 +195,29 @@ func BuildJobs(tx *sql.Tx, jobWorkspace *workspace.DefaultWorkspace) ([]string,
 		if err := workspace.ValidateRestartGlobs(jobName, manifest); err != nil {
 			return nil, err
 		}
+		if err := workspace.ValidateRollout(jobName, manifest); err != nil {
+			return nil, err
+		}
+		if err := workspace.ValidatePlacement(jobName, manifest, workspaceJobNames); err != nil {
+			return nil, err
+		}
+		selector, err := workspace.PlacementSelector(jobName, manifest)
+		if err != nil {
+			return nil, err
+		}
+		selectorExpr := ""
+		if selector != nil {
+			selectorExpr = selector.String()
+		}
 		if manifest.MinAllocationsCount < 0 {
 			return nil, fmt.Errorf("%w: job %s min_allocations_count must be >= 0", bucket.ErrInvalidManifest, jobName)
 		}
+		if manifest.Count < 0 {
+			return nil, fmt.Errorf("%w: job %s count must be >= 0", bucket.ErrInvalidManifest, jobName)
+		}
+		if manifest.Count > 0 && manifest.MinAllocationsCount > manifest.Count {
+			return nil, fmt.Errorf("%w: job %s min_allocations_count %d exceeds count %d", bucket.ErrInvalidManifest, jobName, manifest.MinAllocationsCount, manifest.Count)
+		}
 		if err := workspace.ValidatePrometheusServerFiles(jobName); err != nil {
 			return nil, err
 		}
@@ -164,6 +230,32 @@ func BuildJobs(tx *sql.Tx, jobWorkspace *workspace.DefaultWorkspace) ([]string,
 			healthCheckJSON = string(encoded)
 		}
 
+		rollout, err := workspace.NormalizeRollout(manifest.Rollout)
+		if err != nil {
+			return nil, fmt.Errorf("%w: job %s %w", bucket.ErrInvalidManifest, jobName, err)
+		}
+		rolloutJSON := ""
+		if rollout != nil {
+			encoded, err := json.Marshal(rollout)
+			if err != nil {
+				return nil, fmt.Errorf("%w: job %s rollout: %w", bucket.ErrInvalidManifest, jobName, err)
+			}
+			rolloutJSON = string(encoded)
+		}
+
+		placement, err := workspace.NormalizePlacement(manifest.Placement)
+		if err != nil {
+			return nil, fmt.Errorf("%w: job %s %w", bucket.ErrInvalidManifest, jobName, err)
+		}
+		placementJSON := ""
+		if placement != nil {
+			encoded, err := json.Marshal(placement)
+			if err != nil {
+				return nil, fmt.Errorf("%w: job %s placement: %w", bucket.ErrInvalidManifest, jobName, err)
+			}
+			placementJSON = string(encoded)
+		}
+
 		version := workspace.GetVersion(manifest)
 		restartPolicy, err := workspace.NormalizeRestartPolicy(manifest.RestartPolicy)
 		if err != nil {
@@ -174,8 +266,8 @@ func BuildJobs(tx *sql.Tx, jobWorkspace *workspace.DefaultWorkspace) ([]string,
 			return nil, fmt.Errorf("%w: job %s %w", bucket.ErrInvalidManifest, jobName, err)
 		}
 		upsertJobQuery := `
-			INSERT OR REPLACE INTO job (job_id, name, version, min_memory_mb, max_memory_mb, current_memory_mb, current_memory_source, min_cpu_mhz, max_cpu_mhz, current_cpu_mhz, current_cpu_source, max_concurrent_upgrades, max_concurrent_starts, restart_policy, restart_globs, health_check)
-			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			INSERT OR REPLACE INTO job (job_id, name, version, min_memory_mb, max_memory_mb, current_memory_mb, current_memory_source, min_cpu_mhz, max_cpu_mhz, current_cpu_mhz, current_cpu_source, max_concurrent_upgrades, max_concurrent_starts, restart_policy, restart_globs, health_check, rollback_on_failure, rollout, rollout_by, allocation_count, placement, selector, min_disk_mb, max_disk_mb, current_disk_mb, current_disk_source)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		`
 		_, err = tx.Exec(
 			upsertJobQuery, jobID, jobName, version,
@@ -192,15 +284,27 @@ func BuildJobs(tx *sql.Tx, jobWorkspace *workspace.DefaultWorkspace) ([]string,
 			restartPolicy,
 			restartGlobsJSON,
 			healthCheckJSON,
+			manifest.RollbackOnFailure,
+			rolloutJSON,
+			workspace.NormalizeRolloutBy(manifest.RolloutBy),
+			manifest.AllocationCount(),
+			placementJSON,
+			selectorExpr,
+			fmt.Sprintf("%v", minDiskMB),
+			fmt.Sprintf("%v", maxDiskMB),
+			fmt.Sprintf("%v", requestedDiskMB),
+			diskSource,
 		)
 		if err != nil {
 			return nil, bucket.DatabaseError(err)
 		}
 
-		for _, selector := range workspace.PlacementSelectors(jobName, manifest) {
-			_, err := tx.Exec("INSERT INTO job_selectors (job_id, selector) VALUES (?, ?)", jobID, selector)
-			if err != nil {
-				return nil, bucket.DatabaseError(err)
+		if manifest.Selector == "" {
+			for _, label := range workspace.PlacementSelectors(jobName, manifest) {
+				_, err := tx.Exec("INSERT INTO job_selectors (job_id, selector) VALUES (?, ?)", jobID, label)
+				if err != nil {
+					return nil, bucket.DatabaseError(err)
+				}
 			}
 		}
 
@@ -277,7 +381,7 @@ func BuildJobs(tx *sql.Tx, jobWorkspace *workspace.DefaultWorkspace) ([]string,
 
 			content := []byte("")
 			if !d.IsDir() {
-				content, err = os.ReadFile(workspace.GetJobFilePath(path))
+				content, err = workspace.ReadJobFile(path)
 				if err != nil {
 					return err
 				}
@@ -336,6 +440,7 @@ func BuildJobs(tx *sql.Tx, jobWorkspace *workspace.DefaultWorkspace) ([]string,
 			"DELETE FROM job_selectors WHERE job_id = ?",
 			"DELETE FROM job_commands WHERE job_id = ?",
 			"DELETE FROM job_files WHERE job_id = ?",
+			"DELETE FROM job_files_promoted WHERE job_id = ?",
 			"DELETE FROM job_certs WHERE job_id = ?",
 		} {
 			if _, err := tx.Exec(stmt, jobID); err != nil {
@@ -346,6 +451,10 @@ func BuildJobs(tx *sql.Tx, jobWorkspace *workspace.DefaultWorkspace) ([]string,
 		if err != nil {
 			return nil, bucket.DatabaseError(err)
 		}
+		_, err = tx.Exec("DELETE FROM rollout_state WHERE job = ?", jobName)
+		if err != nil {
+			return nil, bucket.DatabaseError(err)
+		}
 		_, err = tx.Exec("DELETE FROM hash WHERE namespace = 'build_certs' AND key = ?", jobName)
 		if err != nil {
 			return nil, bucket.DatabaseError(err)
@@ -93,6 +93,82 @@ func TestBuildJobs_storesPlacementSelectors(t *testing.T) {
 	assert.ElementsMatch(t, []string{"web"}, selectors)
 }
 
+func TestBuildAllocations_placesBySelectorExpression(t *testing.T) {
+	root := t.TempDir()
+	orig := bucket.Location
+	bucket.Location = root
+	bucket.UpdatePath()
+	t.Cleanup(func() {
+		bucket.Location = orig
+		bucket.UpdatePath()
+	})
+
+	require.NoError(t, os.MkdirAll(bucket.WorkspaceLocation, 0o755))
+	workersJSON := `[
+		{"host":"10.0.0.1","labels":["db"],"tags":{"zone":"a"},"memory":"1024","cpu":"2000","position":0},
+		{"host":"10.0.0.2","labels":["db"],"tags":{"zone":"c"},"memory":"1024","cpu":"2000","position":1},
+		{"host":"10.0.0.3","labels":["db","decommissioning"],"tags":{"zone":"b"},"memory":"1024","cpu":"2000","position":2},
+		{"host":"10.0.0.4","labels":["db"],"tags":{"zone":"b"},"memory":"1024","cpu":"2000","position":3}
+	]`
+	require.NoError(t, os.WriteFile(path.Join(bucket.WorkspaceLocation, "workers.json"), []byte(workersJSON), 0o644))
+
+	jobPath := path.Join(bucket.WorkspaceLocation, "jobs", "postgres")
+	require.NoError(t, os.MkdirAll(jobPath, 0o755))
+	manifest := `{"selector":"label:db && tag:zone in (b,a) && !label:decommissioning"}`
+	require.NoError(t, os.WriteFile(path.Join(jobPath, "manifest.json"), []byte(manifest), 0o644))
+	require.NoError(t, os.WriteFile(path.Join(jobPath, "Makefile"), []byte(""), 0o644))
+
+	db := openBuildAllocationsTestDB(t)
+	defer func() { _ = db.Close() }()
+
+	tx, err := db.Begin()
+	require.NoError(t, err)
+	_, err = BuildWorkers(tx, workspace.Default())
+	require.NoError(t, err)
+	_, err = BuildJobs(tx, workspace.Default())
+	require.NoError(t, err)
+	require.NoError(t, BuildAllocations(tx, workspace.Default()))
+	require.NoError(t, tx.Commit())
+
+	var selectors string
+	require.NoError(t, db.QueryRow(`SELECT selectors FROM cat_jobs WHERE name = 'postgres'`).Scan(&selectors))
+	assert.Equal(t, "label:db && tag:zone in (a, b) && !label:decommissioning", selectors)
+
+	tx, err = db.Begin()
+	require.NoError(t, err)
+	workers, err := data.GetNonRemovedAllocations(tx, "postgres")
+	require.NoError(t, err)
+	require.NoError(t, tx.Rollback())
+	assert.ElementsMatch(t, []string{"10.0.0.1", "10.0.0.4"}, workers)
+}
+
+func TestBuildJobs_rejectsInvalidSelectorExpression(t *testing.T) {
+	root := t.TempDir()
+	orig := bucket.Location
+	bucket.Location = root
+	bucket.UpdatePath()
+	t.Cleanup(func() {
+		bucket.Location = orig
+		bucket.UpdatePath()
+	})
+
+	jobPath := path.Join(bucket.WorkspaceLocation, "jobs", "api")
+	require.NoError(t, os.MkdirAll(jobPath, 0o755))
+	require.NoError(t, os.WriteFile(path.Join(jobPath, "manifest.json"), []byte(`{"selector":"label:web && (tag:zone"}`), 0o644))
+	require.NoError(t, os.WriteFile(path.Join(jobPath, "Makefile"), []byte(""), 0o644))
+
+	db := openBuildAllocationsTestDB(t)
+	defer func() { _ = db.Close() }()
+
+	tx, err := db.Begin()
+	require.NoError(t, err)
+	_, err = BuildJobs(tx, workspace.Default())
+	require.NoError(t, tx.Rollback())
+	require.Error(t, err)
+	assert.ErrorIs(t, err, bucket.ErrInvalidManifest)
+	assert.ErrorContains(t, err, `job api selector "label:web && (tag:zone": expected ")"`)
+}
+
 func TestBuildAllocations_placesJobByNameSelectorOnly(t *testing.T) {
 	root := t.TempDir()
 	orig := bucket.Location
@@ -434,6 +510,79 @@ memory = "512 mb"
 	require.NoError(t, tx.Rollback())
 }
 
+func TestBuildJobs_reservesDiskFromBucketJobsConf(t *testing.T) {
+	root := t.TempDir()
+	orig := bucket.Location
+	bucket.Location = root
+	bucket.UpdatePath()
+	t.Cleanup(func() {
+		bucket.Location = orig
+		bucket.UpdatePath()
+	})
+
+	jobPath := path.Join(bucket.WorkspaceLocation, "jobs", "db")
+	require.NoError(t, os.MkdirAll(jobPath, 0o755))
+	require.NoError(t, os.WriteFile(path.Join(jobPath, "manifest.json"), []byte(`{
+		"selectors": ["db"],
+		"resources": {"disk": {"min": "5 gb", "max": "50 gb"}}
+	}`), 0o644))
+	require.NoError(t, os.WriteFile(path.Join(jobPath, "Makefile"), []byte(""), 0o644))
+	require.NoError(t, os.WriteFile(path.Join(bucket.WorkspaceLocation, "bucket.jobs.conf"), []byte(`[db]
+disk = "20 gb"
+`), 0o644))
+
+	db := openBuildAllocationsTestDB(t)
+	defer func() { _ = db.Close() }()
+
+	tx, err := db.Begin()
+	require.NoError(t, err)
+	defer func() { _ = tx.Rollback() }()
+	_, err = BuildJobs(tx, workspace.Default())
+	require.NoError(t, err)
+
+	var minDisk, maxDisk, currentDisk, source string
+	err = tx.QueryRow(`SELECT min_disk_mb, max_disk_mb, current_disk_mb, current_disk_source FROM job WHERE name = 'db'`).
+		Scan(&minDisk, &maxDisk, &currentDisk, &source)
+	require.NoError(t, err)
+	assert.Equal(t, "5120", minDisk)
+	assert.Equal(t, "51200", maxDisk)
+	assert.Equal(t, "20480", currentDisk)
+	assert.Equal(t, "bucket.jobs.conf", source)
+}
+
+func TestBuildJobs_rejectsDiskAboveManifestMax(t *testing.T) {
+	root := t.TempDir()
+	orig := bucket.Location
+	bucket.Location = root
+	bucket.UpdatePath()
+	t.Cleanup(func() {
+		bucket.Location = orig
+		bucket.UpdatePath()
+	})
+
+	jobPath := path.Join(bucket.WorkspaceLocation, "jobs", "db")
+	require.NoError(t, os.MkdirAll(jobPath, 0o755))
+	require.NoError(t, os.WriteFile(path.Join(jobPath, "manifest.json"), []byte(`{
+		"selectors": ["db"],
+		"resources": {"disk": {"min": "1 gb", "max": "10 gb"}}
+	}`), 0o644))
+	require.NoError(t, os.WriteFile(path.Join(jobPath, "Makefile"), []byte(""), 0o644))
+	require.NoError(t, os.WriteFile(path.Join(bucket.WorkspaceLocation, "bucket.jobs.conf"), []byte(`[db]
+disk = "20 gb"
+`), 0o644))
+
+	db := openBuildAllocationsTestDB(t)
+	defer func() { _ = db.Close() }()
+
+	tx, err := db.Begin()
+	require.NoError(t, err)
+	_, err = BuildJobs(tx, workspace.Default())
+	require.Error(t, err)
+	assert.ErrorIs(t, err, bucket.ErrUnsupportedResourceConfiguration)
+	assert.Contains(t, err.Error(), "max_disk_mb")
+	require.NoError(t, tx.Rollback())
+}
+
 func TestBuildJobs_rejectsInvalidCPU(t *testing.T) {
 	root := t.TempDir()
 	orig := bucket.Location
@@ -18,6 +18,8 @@ type portAllocator struct {
 	range_      bucket.PortRange
 	existing    data.JobPortAssignments
 	usedNumbers map[int]struct{}
+	// reserved are the reserved_ports of every worker; the pool skips them.
+	reserved map[int]struct{}
 }
 
 func newPortAllocator(existing data.JobPortAssignments, portRange bucket.PortRange) *portAllocator {
@@ -34,6 +36,15 @@ func newPortAllocator(existing data.JobPortAssignments, portRange bucket.PortRan
 		range_:      portRange,
 		existing:    existing,
 		usedNumbers: used,
+		reserved:    make(map[int]struct{}),
+	}
+}
+
+// reservePorts keeps ports out of the pool. Fixed manifest ports are checked against
+// reserved ports per worker by ValidatePortConflicts.
+func (a *portAllocator) reservePorts(ports []int) {
+	for _, port := range ports {
+		a.reserved[port] = struct{}{}
 	}
 }
 
@@ -70,10 +81,10 @@ func (a *portAllocator) assignProvisioned(jobName string, portName string) (int,
 	}
 
 	// Reuse the number already stored in job_ports when it lies in the bucket pool.
-	// Numbers outside port_min–port_max (e.g. a former fixed port like 9500) are
-	// released and reassigned from the pool when the manifest uses {}.
+	// Numbers outside port_min–port_max (e.g. a former fixed port like 9500) or reserved
+	// on a worker are released and reassigned from the pool when the manifest uses {}.
 	if prev, ok := a.existing[jobName][portName]; ok {
-		if a.range_.Contains(prev) {
+		if _, reserved := a.reserved[prev]; a.range_.Contains(prev) && !reserved {
 			a.usedNumbers[prev] = struct{}{}
 			return prev, nil
 		}
@@ -84,6 +95,9 @@ func (a *portAllocator) assignProvisioned(jobName string, portName string) (int,
 		if _, taken := a.usedNumbers[port]; taken {
 			continue
 		}
+		if _, reserved := a.reserved[port]; reserved {
+			continue
+		}
 		if err := a.claimPort(jobName, portName, port); err != nil {
 			return 0, err
 		}
@@ -190,8 +204,16 @@ func buildPortAllocator(tx *sql.Tx, workspaceJobNames []string, jobWorkspace *wo
 	if err != nil {
 		return nil, err
 	}
+	reserved, err := data.ListReservedPorts(tx)
+	if err != nil {
+		return nil, err
+	}
 
-	return newPortAllocator(existing, portRange), nil
+	allocator := newPortAllocator(existing, portRange)
+	for _, ports := range reserved {
+		allocator.reservePorts(ports)
+	}
+	return allocator, nil
 }
 
 func sortedJobNames(names []string) []string {
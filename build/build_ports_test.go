@@ -171,3 +171,25 @@ func TestPortAllocatorProvisionedReuseOutsideNarrowedPool(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, 30000, port)
 }
+
+func TestPortAllocatorSkipsReservedPorts(t *testing.T) {
+	existing := data.JobPortAssignments{
+		"api": {"http_port": 30001},
+	}
+	alloc := newPortAllocator(existing, bucket.PortRange{Min: 30000, Max: 30005})
+	alloc.reservePorts([]int{30000, 30001})
+
+	port, err := alloc.assignProvisioned("worker", "metrics_port")
+	require.NoError(t, err)
+	assert.Equal(t, 30002, port)
+
+	// A pool port that became reserved is reassigned.
+	port, err = alloc.assignProvisioned("api", "http_port")
+	require.NoError(t, err)
+	assert.Equal(t, 30003, port)
+
+	// Fixed ports are checked against reserved ports per worker, not here.
+	port, err = alloc.assignFixed("ssh", "ssh_port", 30000)
+	require.NoError(t, err)
+	assert.Equal(t, 30000, port)
+}
@@ -6,7 +6,9 @@ package build
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path"
 	"strconv"
@@ -119,6 +121,12 @@ func buildWorkerVariables(tx *sql.Tx, workerMeta map[string]workspace.Worker, re
 		}
 		variables["worker_memory_mb"] = availableMemoryMB
 
+		availableDiskMB, err := data.GetWorkerDisk(tx, workerIP)
+		if err != nil {
+			return err
+		}
+		variables["worker_disk_mb"] = availableDiskMB
+
 		allocatedJobNames, err := data.GetActiveAllocatedJobs(tx, workerIP)
 		if err != nil {
 			return err
@@ -385,6 +393,13 @@ func buildJobVariables(tx *sql.Tx, removedJobs []string, purgeJobCommandKV bool)
 		variables["min_cpu_mhz"] = minCPU
 		variables["max_cpu_mhz"] = maxCPU
 
+		minDisk, maxDisk, err := data.GetJobDiskLimits(tx, jobName)
+		if err != nil {
+			return err
+		}
+		variables["min_disk_mb"] = minDisk
+		variables["max_disk_mb"] = maxDisk
+
 		_, bucketJobSettings, err := loadJobBucketConfig(jobName)
 		if err != nil {
 			return err
@@ -408,6 +423,15 @@ func buildJobVariables(tx *sql.Tx, removedJobs []string, purgeJobCommandKV bool)
 			variables["max_cpu_mhz"] = variables["cpu"]
 		}
 
+		variables["disk"], err = data.GetJobDisk(tx, jobName)
+		if err != nil {
+			return err
+		}
+		if minDisk == "0" && maxDisk == "0" {
+			variables["min_disk_mb"] = variables["disk"]
+			variables["max_disk_mb"] = variables["disk"]
+		}
+
 		variables["workers"] = strings.Join(workersForVars, ",")
 		variables["workers_length"] = strconv.Itoa(len(workersForVars))
 		for idx, workerIP := range workersForVars {
@@ -472,15 +496,19 @@ func purgeBuildJobKVNamespaces(tx *sql.Tx, jobName string) error {
 	return nil
 }
 
-// mergeWorkspaceJobVars applies jobs/<job>/vars.toml into vars/job/<job> without deleting
-// other keys (user/script-owned config survives rebuild).
+// mergeWorkspaceJobVars applies jobs/<job>/vars.toml, with the workspace overlay's keys
+// merged in, into vars/job/<job> without deleting other keys (user/script-owned config
+// survives rebuild).
 func mergeWorkspaceJobVars(jobName string) error {
 	varsPath := path.Join(bucket.WorkspaceLocation, "jobs", jobName, "vars.toml")
-	data, err := os.ReadFile(varsPath)
+	data, err := workspace.ReadJobFile(path.Join(jobName, "vars.toml"))
 	if err != nil {
-		if os.IsNotExist(err) {
+		if errors.Is(err, fs.ErrNotExist) {
 			return nil
 		}
+		if errors.Is(err, bucket.ErrInvalidJobVars) {
+			return err
+		}
 		return fmt.Errorf("%w: read %s: %w", bucket.ErrUnexpectedError, varsPath, err)
 	}
 
@@ -524,8 +552,8 @@ func syncKeyValues(tx *sql.Tx, namespace string, keyValues map[string]string) er
 // worker tags => maand/worker/10.0.0.1/tags
 // custom job variables = vars/job/a
 // bucket.jobs.conf => vars/bucket/job/a
-// bucket.jobs.conf (memory, cpu) => maand/job/a
-// job resources (memory and cpu) => maand/job/a
+// bucket.jobs.conf (memory, cpu, disk) => maand/job/a
+// job resources (memory, cpu and disk) => maand/job/a
 // job ports (active allocations only) => maand/bucket
 // activejobs => maand/bucket
 // job meta (workers, version, resources) => maand/job/a
@@ -146,6 +146,7 @@ func TestBuildJobVariablesPurgesCommandKVWhenRequested(t *testing.T) {
 			job_id TEXT, name TEXT, version TEXT,
 			min_memory_mb TEXT, max_memory_mb TEXT, current_memory_mb TEXT,
 			min_cpu_mhz TEXT, max_cpu_mhz TEXT, current_cpu_mhz TEXT,
+			min_disk_mb TEXT DEFAULT '0', max_disk_mb TEXT DEFAULT '0', current_disk_mb TEXT DEFAULT '0',
 			max_concurrent_upgrades INT, health_check TEXT,
 			PRIMARY KEY(name)
 		);
@@ -196,6 +197,7 @@ func TestBuildJobVariablesPurgesRemovedJobKV(t *testing.T) {
 			job_id TEXT, name TEXT, version TEXT,
 			min_memory_mb TEXT, max_memory_mb TEXT, current_memory_mb TEXT,
 			min_cpu_mhz TEXT, max_cpu_mhz TEXT, current_cpu_mhz TEXT,
+			min_disk_mb TEXT DEFAULT '0', max_disk_mb TEXT DEFAULT '0', current_disk_mb TEXT DEFAULT '0',
 			max_concurrent_upgrades INT, health_check TEXT,
 			PRIMARY KEY(name)
 		);
@@ -456,12 +458,13 @@ func TestBuildJobVariablesSyncsActiveJobKV(t *testing.T) {
 			job_id TEXT, name TEXT, version TEXT,
 			min_memory_mb TEXT, max_memory_mb TEXT, current_memory_mb TEXT,
 			min_cpu_mhz TEXT, max_cpu_mhz TEXT, current_cpu_mhz TEXT,
+			min_disk_mb TEXT DEFAULT '0', max_disk_mb TEXT DEFAULT '0', current_disk_mb TEXT DEFAULT '0',
 			max_concurrent_upgrades INT, health_check TEXT,
 			PRIMARY KEY(name)
 		);
 		CREATE TABLE job_selectors (job_id TEXT, selector TEXT);
 		CREATE TABLE job_ports (job_id TEXT, name TEXT, port INT);
-		CREATE TABLE worker (worker_id TEXT, worker_ip TEXT PRIMARY KEY, available_memory_mb TEXT, available_cpu_mhz TEXT, position INT);
+		CREATE TABLE worker (worker_id TEXT, worker_ip TEXT PRIMARY KEY, available_memory_mb TEXT, available_cpu_mhz TEXT, available_disk_mb TEXT DEFAULT '0', position INT, memory_overcommit REAL DEFAULT 1, cpu_overcommit REAL DEFAULT 1, disk_overcommit REAL DEFAULT 1);
 		INSERT INTO worker (worker_id, worker_ip, available_memory_mb, available_cpu_mhz, position)
 		VALUES ('w1', '10.0.0.1', '1024', '2000', 0);
 		INSERT INTO job (
@@ -35,6 +35,11 @@ func BuildWorkers(tx *sql.Tx, jobWorkspace *workspace.DefaultWorkspace) ([]strin
 		return nil, fmt.Errorf("%w: duplicate worker ip found", bucket.ErrInvalidWorkerJSON)
 	}
 
+	maandConf, err := bucket.GetMaandConf()
+	if err != nil {
+		return nil, err
+	}
+
 	for _, worker := range workspaceWorkers {
 		row := tx.QueryRow("SELECT worker_id FROM worker WHERE worker_ip = ?", worker.Host)
 
@@ -65,8 +70,22 @@ func BuildWorkers(tx *sql.Tx, jobWorkspace *workspace.DefaultWorkspace) ([]strin
 			return nil, fmt.Errorf("%w: worker %s, cpu can't be less than 0", bucket.ErrInvalidWorkerJSON, worker.Host)
 		}
 
-		upsertWorkerQuery := "INSERT OR REPLACE INTO worker (worker_id, worker_ip, available_memory_mb, available_cpu_mhz, position) VALUES (?, ?, ?, ?, ?)"
-		_, err = tx.Exec(upsertWorkerQuery, workerID, worker.Host, fmt.Sprintf("%v", availableMemoryMB), fmt.Sprintf("%v", availableCPUMHz), worker.Position)
+		availableDiskMB, err := utils.ParseDiskMB(worker.Disk)
+		if err != nil {
+			return nil, fmt.Errorf("%w: worker %s %w", bucket.ErrInvalidWorkerJSON, worker.Host, err)
+		}
+		if availableDiskMB < 0 {
+			return nil, fmt.Errorf("%w: worker %s, disk can't be less than 0", bucket.ErrInvalidWorkerJSON, worker.Host)
+		}
+
+		overcommit := maandConf.Overcommit.ForLabels(worker.Labels)
+
+		upsertWorkerQuery := `INSERT OR REPLACE INTO worker (
+			worker_id, worker_ip, available_memory_mb, available_cpu_mhz, available_disk_mb, position,
+			memory_overcommit, cpu_overcommit, disk_overcommit
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+		_, err = tx.Exec(upsertWorkerQuery, workerID, worker.Host, fmt.Sprintf("%v", availableMemoryMB), fmt.Sprintf("%v", availableCPUMHz), fmt.Sprintf("%v", availableDiskMB), worker.Position,
+			overcommit.Memory, overcommit.CPU, overcommit.Disk)
 		if err != nil {
 			return nil, bucket.DatabaseError(err)
 		}
@@ -78,6 +97,10 @@ func BuildWorkers(tx *sql.Tx, jobWorkspace *workspace.DefaultWorkspace) ([]strin
 		if err := syncWorkerTags(tx, workerID, worker); err != nil {
 			return nil, err
 		}
+
+		if err := syncWorkerReservedPorts(tx, workerID, worker); err != nil {
+			return nil, err
+		}
 	}
 
 	databaseWorkerIPs, err := data.GetAllWorkers(tx)
@@ -98,6 +121,11 @@ func BuildWorkers(tx *sql.Tx, jobWorkspace *workspace.DefaultWorkspace) ([]strin
 			return nil, bucket.DatabaseError(err)
 		}
 
+		_, err = tx.Exec("DELETE FROM worker_reserved_ports WHERE worker_id IN (SELECT worker_id FROM worker WHERE worker_ip = ?)", workerIP)
+		if err != nil {
+			return nil, bucket.DatabaseError(err)
+		}
+
 		_, err = tx.Exec("DELETE FROM worker WHERE worker_ip = ?", workerIP)
 		if err != nil {
 			return nil, bucket.DatabaseError(err)
@@ -143,3 +171,18 @@ func syncWorkerTags(tx *sql.Tx, workerID string, worker workspace.Worker) error
 	}
 	return nil
 }
+
+func syncWorkerReservedPorts(tx *sql.Tx, workerID string, worker workspace.Worker) error {
+	_, err := tx.Exec("DELETE FROM worker_reserved_ports WHERE worker_id = ?", workerID)
+	if err != nil {
+		return bucket.DatabaseError(err)
+	}
+
+	for _, port := range worker.ReservedPorts {
+		_, err = tx.Exec("INSERT INTO worker_reserved_ports (worker_id, port) VALUES (?, ?)", workerID, port)
+		if err != nil {
+			return bucket.DatabaseError(err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,272 @@
+// Copyright 2025 Kiruba Sankar Swaminathan. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package build
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"maand/bucket"
+	"maand/data"
+	"maand/utils"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+)
+
+// Catalog changes reported by maand build --dry-run.
+const (
+	ChangeAdded       = "added"
+	ChangeChanged     = "changed"
+	ChangeRemoved     = "removed"
+	ChangeRegenerated = "regenerated"
+)
+
+// CatalogChange is one catalog entry a build would add, change or remove, or a cert it
+// would regenerate. Detail lists the changed fields as "field: old -> new"; KV values are
+// never shown.
+type CatalogChange struct {
+	Section string `json:"section"`
+	Key     string `json:"key"`
+	Change  string `json:"change"`
+	Detail  string `json:"detail"`
+}
+
+// catalogSection selects one part of the catalog for the diff. The first column is the
+// key; the other columns are compared. Redact hides their values in Detail.
+type catalogSection struct {
+	name   string
+	query  string
+	redact bool
+}
+
+var catalogSections = []catalogSection{
+	{name: "workers", query: `SELECT w.worker_ip, w.available_memory_mb AS memory_mb, w.available_cpu_mhz AS cpu_mhz,
+			w.available_disk_mb AS disk_mb, w.position, w.memory_overcommit, w.cpu_overcommit, w.disk_overcommit,
+			ifnull((SELECT group_concat(label) FROM
+				(SELECT label FROM worker_labels l WHERE l.worker_id = w.worker_id ORDER BY label)), '') AS labels,
+			ifnull((SELECT group_concat(key || '=' || value) FROM
+				(SELECT key, value FROM worker_tags t WHERE t.worker_id = w.worker_id ORDER BY key)), '') AS tags
+		FROM worker w`},
+	{name: "jobs", query: `SELECT j.name, j.version,
+			j.min_memory_mb, j.max_memory_mb, j.current_memory_mb,
+			j.min_cpu_mhz, j.max_cpu_mhz, j.current_cpu_mhz,
+			j.min_disk_mb, j.max_disk_mb, j.current_disk_mb,
+			j.max_concurrent_upgrades, j.max_concurrent_starts, j.restart_policy, j.restart_globs,
+			j.health_check, j.rollback_on_failure, j.rollout, j.rollout_by, j.allocation_count, j.placement,
+			(CASE WHEN j.selector != '' THEN j.selector ELSE ifnull((SELECT group_concat(selector) FROM
+				(SELECT selector FROM job_selectors s WHERE s.job_id = j.job_id ORDER BY selector)), '') END) AS selectors
+		FROM job j`},
+	{name: "allocations", query: `SELECT job || ' ' || worker_ip, disabled, removed, deployment_seq, new_version
+		FROM allocations`},
+	{name: "ports", query: `SELECT j.name || ' ' || p.name, p.port FROM job_ports p JOIN job j ON j.job_id = p.job_id`},
+	{name: "kv", redact: true, query: `SELECT k.namespace || ' ' || k.key, k.value FROM key_value k
+		WHERE k.deleted = 0 AND k.version = (SELECT max(version) FROM key_value
+			WHERE namespace = k.namespace AND key = k.key)`},
+}
+
+// catalogState holds each section's rows by key, with the compared columns.
+type catalogState map[string]map[string][]string
+
+// DryRun runs the build in a transaction it always rolls back and reports how the catalog
+// would change: workers, jobs, allocations, ports and KV keys added, changed or removed, and
+// the job certs that would be regenerated. post_build hooks are not run. Certs are generated
+// in a temporary directory of its own, removed afterwards, so bucket.TempLocation (staged
+// by a deploy that holds the bucket lock) is never touched.
+func DryRun(opts ...Options) ([]CatalogChange, error) {
+	var options Options
+	if len(opts) > 0 {
+		options = opts[0]
+	}
+
+	tempDir, err := os.MkdirTemp("", "maand-build-dry-run-")
+	if err != nil {
+		return nil, bucket.UnexpectedError(err)
+	}
+	defer func() {
+		_ = os.RemoveAll(tempDir)
+	}()
+
+	db, err := data.OpenDatabase(true)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, bucket.DatabaseError(err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	before, columns, err := readCatalogState(tx)
+	if err != nil {
+		return nil, err
+	}
+	if err := buildCatalog(tx, options, tempDir); err != nil {
+		return nil, err
+	}
+	after, _, err := readCatalogState(tx)
+	if err != nil {
+		return nil, err
+	}
+	return diffCatalog(before, after, columns), nil
+}
+
+func readCatalogState(tx *sql.Tx) (catalogState, map[string][]string, error) {
+	state := make(catalogState, len(catalogSections))
+	columns := make(map[string][]string, len(catalogSections))
+	for _, section := range catalogSections {
+		entries, names, err := readCatalogSection(tx, section)
+		if err != nil {
+			return nil, nil, err
+		}
+		state[section.name] = entries
+		columns[section.name] = names
+	}
+	return state, columns, nil
+}
+
+// readCatalogSection returns the section's rows by key and the names of the compared
+// columns.
+func readCatalogSection(tx *sql.Tx, section catalogSection) (map[string][]string, []string, error) {
+	rows, err := tx.Query(section.query)
+	if err != nil {
+		return nil, nil, bucket.DatabaseError(fmt.Errorf("%s: %w", section.name, err))
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	names, err := rows.Columns()
+	if err != nil {
+		return nil, nil, bucket.DatabaseError(err)
+	}
+	entries := make(map[string][]string)
+	for rows.Next() {
+		values := make([]sql.NullString, len(names))
+		dest := make([]any, len(names))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, nil, bucket.DatabaseError(err)
+		}
+		fields := make([]string, len(names)-1)
+		for i, value := range values[1:] {
+			fields[i] = value.String
+		}
+		entries[values[0].String] = fields
+	}
+	if err := data.RowsErr(rows); err != nil {
+		return nil, nil, err
+	}
+	return entries, names[1:], nil
+}
+
+// diffCatalog compares two catalog states section by section, keys sorted, and adds a
+// certs section for every job cert whose certificate KV entry was added or changed.
+func diffCatalog(before, after catalogState, columns map[string][]string) []CatalogChange {
+	var changes []CatalogChange
+	for _, section := range catalogSections {
+		old, current := before[section.name], after[section.name]
+		keys := make([]string, 0, len(old)+len(current))
+		for key := range old {
+			keys = append(keys, key)
+		}
+		for key := range current {
+			if _, ok := old[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		slices.Sort(keys)
+
+		for _, key := range keys {
+			oldFields, hadKey := old[key]
+			newFields, hasKey := current[key]
+			switch {
+			case !hadKey:
+				changes = append(changes, CatalogChange{Section: section.name, Key: key, Change: ChangeAdded})
+			case !hasKey:
+				changes = append(changes, CatalogChange{Section: section.name, Key: key, Change: ChangeRemoved})
+			default:
+				detail := fieldChanges(columns[section.name], oldFields, newFields, section.redact)
+				if detail != "" {
+					changes = append(changes, CatalogChange{Section: section.name, Key: key, Change: ChangeChanged, Detail: detail})
+				}
+			}
+		}
+	}
+
+	for _, change := range changes {
+		if change.Section != "kv" || (change.Change != ChangeAdded && change.Change != ChangeChanged) {
+			continue
+		}
+		if cert, ok := regeneratedCert(change.Key); ok {
+			changes = append(changes, CatalogChange{Section: "certs", Key: cert, Change: ChangeRegenerated})
+		}
+	}
+	return changes
+}
+
+func fieldChanges(columns, old, current []string, redact bool) string {
+	var details []string
+	for i, column := range columns {
+		if old[i] == current[i] {
+			continue
+		}
+		if redact {
+			details = append(details, column)
+			continue
+		}
+		details = append(details, fmt.Sprintf("%s: %s -> %s", column, old[i], current[i]))
+	}
+	return strings.Join(details, "; ")
+}
+
+// regeneratedCert maps the KV key of a job certificate, "maand/job/<job>/worker/<ip>
+// certs/<name>.crt", to "<job> <ip> <name>".
+func regeneratedCert(kvKey string) (string, bool) {
+	namespace, key, ok := strings.Cut(kvKey, " ")
+	if !ok || !strings.HasPrefix(key, "certs/") || !strings.HasSuffix(key, ".crt") {
+		return "", false
+	}
+	parts := strings.Split(namespace, "/")
+	if len(parts) != 5 || parts[0] != "maand" || parts[1] != "job" || parts[3] != "worker" {
+		return "", false
+	}
+	name := strings.TrimSuffix(strings.TrimPrefix(key, "certs/"), ".crt")
+	return strings.Join([]string{parts[2], parts[4], name}, " "), true
+}
+
+// PrintDryRun renders the changes of a build dry-run: a table and a summary, or the
+// records in format.
+func PrintDryRun(format utils.OutputFormat, changes []CatalogChange) error {
+	if format != utils.OutputTable {
+		return utils.PrintRecords(format, changes)
+	}
+
+	if len(changes) == 0 {
+		fmt.Println("build dry-run: no catalog changes")
+	} else {
+		t := utils.GetTable(table.Row{"section", "key", "change", "detail"})
+		counts := make(map[string]int)
+		for _, change := range changes {
+			t.AppendRow(table.Row{change.Section, change.Key, change.Change, change.Detail})
+			counts[change.Change]++
+		}
+		t.Render()
+		fmt.Printf("%d added, %d changed, %d removed, %d certs regenerated\n",
+			counts[ChangeAdded], counts[ChangeChanged], counts[ChangeRemoved], counts[ChangeRegenerated])
+	}
+	fmt.Println("post_build hooks were not run; maand.db is unchanged")
+	return nil
+}
@@ -0,0 +1,134 @@
+// Copyright 2025 Kiruba Sankar Swaminathan. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package build
+
+import (
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"maand/bucket"
+	"maand/data"
+)
+
+func changeFor(changes []CatalogChange, section, key string) (CatalogChange, bool) {
+	for _, change := range changes {
+		if change.Section == section && change.Key == key {
+			return change, true
+		}
+	}
+	return CatalogChange{}, false
+}
+
+func TestDryRun_reportsChangesWithoutWriting(t *testing.T) {
+	setupInitializedBuildBucket(t)
+	writeMinimalBuildWorkspace(t)
+
+	changes, err := DryRun()
+	require.NoError(t, err)
+
+	for _, key := range []struct{ section, key string }{
+		{"workers", "10.0.0.1"},
+		{"jobs", "api"},
+		{"allocations", "api 10.0.0.1"},
+		{"kv", "maand/job/api version"},
+	} {
+		change, ok := changeFor(changes, key.section, key.key)
+		require.True(t, ok, "%s %s", key.section, key.key)
+		assert.Equal(t, ChangeAdded, change.Change)
+	}
+
+	db, err := data.OpenDatabase(true)
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+	var workerCount int
+	require.NoError(t, db.QueryRow(`SELECT count(*) FROM worker`).Scan(&workerCount))
+	assert.Zero(t, workerCount)
+}
+
+func TestDryRun_afterBuildShowsOnlyWorkspaceEdits(t *testing.T) {
+	setupInitializedBuildBucket(t)
+	writeMinimalBuildWorkspace(t)
+	require.NoError(t, Execute())
+
+	changes, err := DryRun()
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+
+	manifestPath := path.Join(bucket.WorkspaceLocation, "jobs", "api", "manifest.json")
+	manifest, err := os.ReadFile(manifestPath)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(manifestPath, []byte(strings.Replace(string(manifest), "1.0.0", "1.1.0", 1)), 0o644))
+
+	changes, err = DryRun()
+	require.NoError(t, err)
+
+	job, ok := changeFor(changes, "jobs", "api")
+	require.True(t, ok)
+	assert.Equal(t, ChangeChanged, job.Change)
+	assert.Equal(t, "version: 1.0.0 -> 1.1.0", job.Detail)
+
+	kvChange, ok := changeFor(changes, "kv", "maand/job/api version")
+	require.True(t, ok)
+	assert.Equal(t, "value", kvChange.Detail)
+}
+
+func TestDiffCatalogReportsRegeneratedCerts(t *testing.T) {
+	columns := map[string][]string{"kv": {"value"}}
+	before := catalogState{"kv": {
+		"maand/job/api/worker/10.0.0.1 certs/server.crt": {"old"},
+		"maand/job/api/worker/10.0.0.1 certs/server.key": {"old"},
+		"maand/job/api name":                             {"api"},
+	}}
+	after := catalogState{"kv": {
+		"maand/job/api/worker/10.0.0.1 certs/server.crt": {"new"},
+		"maand/job/api/worker/10.0.0.1 certs/server.key": {"new"},
+	}}
+
+	changes := diffCatalog(before, after, columns)
+	assert.Equal(t, []CatalogChange{
+		{Section: "kv", Key: "maand/job/api name", Change: ChangeRemoved},
+		{Section: "kv", Key: "maand/job/api/worker/10.0.0.1 certs/server.crt", Change: ChangeChanged, Detail: "value"},
+		{Section: "kv", Key: "maand/job/api/worker/10.0.0.1 certs/server.key", Change: ChangeChanged, Detail: "value"},
+		{Section: "certs", Key: "api 10.0.0.1 server", Change: ChangeRegenerated},
+	}, changes)
+}
+
+func TestDryRun_leavesTempLocationAlone(t *testing.T) {
+	setupInitializedBuildBucket(t)
+	writeMinimalBuildWorkspace(t)
+	writeDryRunCertJob(t)
+
+	staged := path.Join(bucket.TempLocation, "workers", "10.0.0.1", "jobs", "api", "Makefile")
+	require.NoError(t, os.MkdirAll(path.Dir(staged), 0o755))
+	require.NoError(t, os.WriteFile(staged, []byte("staged by deploy"), 0o644))
+
+	changes, err := DryRun()
+	require.NoError(t, err)
+	_, ok := changeFor(changes, "certs", "api 10.0.0.1 tls")
+	require.True(t, ok)
+
+	content, err := os.ReadFile(staged)
+	require.NoError(t, err)
+	assert.Equal(t, "staged by deploy", string(content))
+	assert.NoDirExists(t, path.Join(bucket.TempLocation, "workers", "10.0.0.1", "jobs", "api", "certs"))
+}
+
+func writeDryRunCertJob(t *testing.T) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path.Join(bucket.WorkspaceLocation, "jobs", "api", "manifest.json"), []byte(`{
+		"version": "1.0.0",
+		"selectors": ["web"],
+		"resources": {
+			"memory": {"min": "128", "max": "256"},
+			"cpu": {"min": "100", "max": "200"}
+		},
+		"certs": {"tls": {"subject": {"common_name": "api.local"}}}
+	}`), 0o644))
+}
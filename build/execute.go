@@ -26,6 +26,10 @@ type Options struct {
 	// PurgeJobCommandKV marks vars/job/<job> and secrets/job/<job> deleted when a job
 	// has no active allocations (or is removed from the workspace).
 	PurgeJobCommandKV bool
+	// Rebalance places counted jobs afresh instead of keeping them on their current workers
+	// (maand rebalance). RebalanceJobs limits it to these jobs; empty means every counted job.
+	Rebalance     bool
+	RebalanceJobs []string
 }
 
 func runPostBuildHooks(tx *sql.Tx) error {
@@ -111,6 +115,41 @@ func Execute(opts ...Options) error {
 		_ = buildTx.Rollback()
 	}()
 
+	if err := buildCatalog(buildTx, options, bucket.TempLocation); err != nil {
+		return err
+	}
+
+	if err := buildTx.Commit(); err != nil {
+		return bucket.DatabaseError(err)
+	}
+
+	if _, err := db.Exec("VACUUM"); err != nil {
+		return bucket.DatabaseError(err)
+	}
+
+	postBuildTx, err := db.Begin()
+	if err != nil {
+		return bucket.DatabaseError(err)
+	}
+	defer func() {
+		_ = postBuildTx.Rollback()
+	}()
+
+	if err := runPostBuildHooks(postBuildTx); err != nil {
+		return err
+	}
+
+	if err := postBuildTx.Commit(); err != nil {
+		return bucket.DatabaseError(err)
+	}
+
+	return nil
+}
+
+// buildCatalog syncs the workspace into the catalog in buildTx: workers, jobs, allocations,
+// deployment sequence, variables, certs and the prometheus catalog, with KV persisted last.
+// Certs are generated under tempDir.
+func buildCatalog(buildTx *sql.Tx, options Options, tempDir string) error {
 	jobWorkspace := workspace.GetWorkspace()
 
 	if err := kv.Initialize(buildTx); err != nil {
@@ -135,7 +174,13 @@ func Execute(opts ...Options) error {
 		return err
 	}
 
-	if err := BuildAllocations(buildTx, jobWorkspace); err != nil {
+	allocate := BuildAllocations
+	if options.Rebalance {
+		allocate = func(tx *sql.Tx, ws *workspace.DefaultWorkspace) error {
+			return RebalanceAllocations(tx, ws, options.RebalanceJobs)
+		}
+	}
+	if err := allocate(buildTx, jobWorkspace); err != nil {
 		return err
 	}
 
@@ -143,6 +188,14 @@ func Execute(opts ...Options) error {
 		return err
 	}
 
+	if err := ValidatePlacementRules(buildTx); err != nil {
+		return err
+	}
+
+	if err := ValidatePortConflicts(buildTx); err != nil {
+		return err
+	}
+
 	if err := BuildDeploymentSequence(buildTx); err != nil {
 		return err
 	}
@@ -151,7 +204,7 @@ func Execute(opts ...Options) error {
 		return err
 	}
 
-	if err := BuildCerts(buildTx); err != nil {
+	if err := buildCerts(buildTx, tempDir); err != nil {
 		return err
 	}
 
@@ -163,7 +216,7 @@ func Execute(opts ...Options) error {
 		return err
 	}
 
-	if err := os.MkdirAll(bucket.TempLocation, 0o755); err != nil {
+	if err := os.MkdirAll(tempDir, 0o755); err != nil {
 		return bucket.UnexpectedError(err)
 	}
 
@@ -175,33 +228,5 @@ func Execute(opts ...Options) error {
 		return err
 	}
 
-	if err := kv.PersistToTransaction(buildTx, kv.GetStore()); err != nil {
-		return err
-	}
-
-	if err := buildTx.Commit(); err != nil {
-		return bucket.DatabaseError(err)
-	}
-
-	if _, err := db.Exec("VACUUM"); err != nil {
-		return bucket.DatabaseError(err)
-	}
-
-	postBuildTx, err := db.Begin()
-	if err != nil {
-		return bucket.DatabaseError(err)
-	}
-	defer func() {
-		_ = postBuildTx.Rollback()
-	}()
-
-	if err := runPostBuildHooks(postBuildTx); err != nil {
-		return err
-	}
-
-	if err := postBuildTx.Commit(); err != nil {
-		return bucket.DatabaseError(err)
-	}
-
-	return nil
+	return kv.PersistToTransaction(buildTx, kv.GetStore())
 }
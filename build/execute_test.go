@@ -9,6 +9,7 @@ import (
 	"path"
 	"testing"
 
+	"github.com/pelletier/go-toml/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -215,3 +216,39 @@ func TestExecute_notInitialized(t *testing.T) {
 	require.Error(t, err)
 	assert.ErrorIs(t, err, bucket.ErrNotInitialized)
 }
+
+func TestExecute_appliesWorkspaceOverlay(t *testing.T) {
+	setupInitializedBuildBucket(t)
+	writeMinimalBuildWorkspace(t)
+
+	conf, err := bucket.GetMaandConf()
+	require.NoError(t, err)
+	conf.WorkspaceOverlay = "prod"
+	confData, err := toml.Marshal(conf)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path.Join(bucket.Location, "maand.conf"), confData, 0o644))
+
+	overlayJobPath := path.Join(bucket.WorkspaceLocation, "overlays", "prod", "jobs", "api")
+	require.NoError(t, os.MkdirAll(overlayJobPath, 0o755))
+	require.NoError(t, os.WriteFile(path.Join(overlayJobPath, "manifest.patch.json"), []byte(`{"version": "2.0.0"}`), 0o644))
+	require.NoError(t, os.WriteFile(path.Join(overlayJobPath, "Makefile"), []byte("prod"), 0o644))
+
+	require.NoError(t, Execute())
+
+	db, err := data.OpenDatabase(true)
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	var version string
+	require.NoError(t, db.QueryRow(`SELECT version FROM job WHERE name = 'api'`).Scan(&version))
+	assert.Equal(t, "2.0.0", version)
+
+	var makefile string
+	require.NoError(t, db.QueryRow(`SELECT f.content FROM job_files f JOIN job j ON j.job_id = f.job_id
+		WHERE j.name = 'api' AND f.path = 'api/Makefile'`).Scan(&makefile))
+	assert.Equal(t, "prod", makefile)
+
+	var patchFiles int
+	require.NoError(t, db.QueryRow(`SELECT count(*) FROM job_files WHERE path LIKE '%manifest.patch.json'`).Scan(&patchFiles))
+	assert.Zero(t, patchFiles)
+}
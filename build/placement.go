@@ -0,0 +1,341 @@
+// Copyright 2025 Kiruba Sankar Swaminathan. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package build
+
+import (
+	"slices"
+
+	"maand/data"
+	"maand/workspace"
+)
+
+// jobPlacement is one job as the scheduler sees it.
+type jobPlacement struct {
+	data.JobReservation
+	// candidates are the workers whose labels and tags match the job's selector, in position order.
+	candidates []string
+	// current are the workers the job has a non-removed allocation on.
+	current []string
+	// excluded are workers whose allocation of the job is disabled in disabled.json; counted
+	// jobs move off them when another matching worker is free.
+	excluded map[string]bool
+	// rules are the job's affinity and anti-affinity rules.
+	rules []workspace.PlacementConstraint
+	// rebalance places a counted job afresh (maand rebalance): it keeps none of its current
+	// workers, so pick may move it to workers with more headroom.
+	rebalance bool
+}
+
+// kept returns the current workers the job may stay on: still matching and not disabled.
+func (j jobPlacement) kept() []string {
+	if j.rebalance {
+		return nil
+	}
+	var kept []string
+	for _, workerIP := range j.current {
+		if slices.Contains(j.candidates, workerIP) && !j.excluded[workerIP] {
+			kept = append(kept, workerIP)
+		}
+	}
+	return kept
+}
+
+type workerUsage struct {
+	memoryMB float64
+	cpuMHz   float64
+	diskMB   float64
+}
+
+// scheduler tracks what placed allocations reserve on each worker and where each job has
+// been placed so far.
+type scheduler struct {
+	workers map[string]data.WorkerCapacity
+	used    map[string]*workerUsage
+	placed  map[string][]string
+}
+
+func newScheduler(workers []data.WorkerCapacity) *scheduler {
+	s := &scheduler{
+		workers: make(map[string]data.WorkerCapacity, len(workers)),
+		used:    make(map[string]*workerUsage, len(workers)),
+		placed:  make(map[string][]string),
+	}
+	for _, worker := range workers {
+		s.workers[worker.WorkerIP] = worker
+		s.used[worker.WorkerIP] = &workerUsage{}
+	}
+	return s
+}
+
+func (s *scheduler) reserve(workerIP string, job data.JobReservation) {
+	usage, ok := s.used[workerIP]
+	if !ok {
+		return
+	}
+	usage.memoryMB += job.MemoryMB
+	usage.cpuMHz += job.CPUMHz
+	usage.diskMB += job.DiskMB
+}
+
+func (s *scheduler) release(workerIP string, job data.JobReservation) {
+	usage, ok := s.used[workerIP]
+	if !ok {
+		return
+	}
+	usage.memoryMB -= job.MemoryMB
+	usage.cpuMHz -= job.CPUMHz
+	usage.diskMB -= job.DiskMB
+}
+
+func (s *scheduler) remaining(workerIP string) (float64, float64, float64) {
+	worker := s.workers[workerIP]
+	usage := s.used[workerIP]
+	return worker.MemoryMB - usage.memoryMB, worker.CPUMHz - usage.cpuMHz, worker.DiskMB - usage.diskMB
+}
+
+func (s *scheduler) fits(workerIP string, job data.JobReservation) bool {
+	memoryMB, cpuMHz, diskMB := s.remaining(workerIP)
+	return (job.MemoryMB == 0 || memoryMB >= job.MemoryMB) &&
+		(job.CPUMHz == 0 || cpuMHz >= job.CPUMHz) &&
+		(job.DiskMB == 0 || diskMB >= job.DiskMB)
+}
+
+// allowed reports whether the hard rules of job permit workerIP. Rules naming a job that
+// is not placed yet (a rule cycle) are checked by ValidatePlacementRules instead.
+func (s *scheduler) allowed(job jobPlacement, workerIP string) bool {
+	for _, rule := range job.rules {
+		if !rule.Hard() {
+			continue
+		}
+		placed, ok := s.placed[rule.Job]
+		if !ok {
+			continue
+		}
+		if slices.Contains(placed, workerIP) == rule.Anti {
+			return false
+		}
+	}
+	return true
+}
+
+// preference scores workerIP by the soft rules of job: +1 per affinity job placed there,
+// -1 per anti-affinity job placed there.
+func (s *scheduler) preference(job jobPlacement, workerIP string) int {
+	score := 0
+	for _, rule := range job.rules {
+		if rule.Hard() || !slices.Contains(s.placed[rule.Job], workerIP) {
+			continue
+		}
+		if rule.Anti {
+			score--
+		} else {
+			score++
+		}
+	}
+	return score
+}
+
+// pick chooses up to n workers from options for job, one at a time, reserving each choice.
+// Workers with room for the job come first, then those its soft rules prefer, then those
+// in zones with the fewest of the job's allocations (placed plus picked so far), then the
+// most remaining memory, cpu and disk, then the lowest position.
+func (s *scheduler) pick(job jobPlacement, options []string, n int, placed []string) []string {
+	zones := make(map[string]int)
+	for _, workerIP := range placed {
+		zones[s.workers[workerIP].Zone]++
+	}
+
+	var picked []string
+	remaining := slices.Clone(options)
+	for len(picked) < n && len(remaining) > 0 {
+		best := 0
+		for i := 1; i < len(remaining); i++ {
+			if s.better(job, zones, remaining[i], remaining[best]) {
+				best = i
+			}
+		}
+		workerIP := remaining[best]
+		remaining = slices.Delete(remaining, best, best+1)
+		picked = append(picked, workerIP)
+		zones[s.workers[workerIP].Zone]++
+		if !job.excluded[workerIP] {
+			s.reserve(workerIP, job.JobReservation)
+		}
+	}
+	return picked
+}
+
+func (s *scheduler) better(job jobPlacement, zones map[string]int, a, b string) bool {
+	if fitsA, fitsB := s.fits(a, job.JobReservation), s.fits(b, job.JobReservation); fitsA != fitsB {
+		return fitsA
+	}
+	if preferA, preferB := s.preference(job, a), s.preference(job, b); preferA != preferB {
+		return preferA > preferB
+	}
+	if zoneA, zoneB := zones[s.workers[a].Zone], zones[s.workers[b].Zone]; zoneA != zoneB {
+		return zoneA < zoneB
+	}
+	memoryA, cpuA, diskA := s.remaining(a)
+	memoryB, cpuB, diskB := s.remaining(b)
+	if memoryA != memoryB {
+		return memoryA > memoryB
+	}
+	if cpuA != cpuB {
+		return cpuA > cpuB
+	}
+	if diskA != diskB {
+		return diskA > diskB
+	}
+	// On an even tie, stay put: rebalance only moves allocations that gain headroom.
+	if currentA, currentB := slices.Contains(job.current, a), slices.Contains(job.current, b); currentA != currentB {
+		return currentA
+	}
+	return s.workers[a].Position < s.workers[b].Position
+}
+
+func (s *scheduler) byPosition(workers []string) []string {
+	slices.SortFunc(workers, func(a, b string) int {
+		return s.workers[a].Position - s.workers[b].Position
+	})
+	return workers
+}
+
+// placeAllocations returns the workers each job should have an allocation on.
+//
+// Jobs are placed one at a time, after the jobs their placement rules name (see
+// placementOrder), and only on matching workers their hard rules allow. A job without
+// count runs on every such worker. A job with count keeps the allowed, non-disabled
+// workers it already runs on, so rebuilding does not move allocations, and fills the rest
+// from the other allowed workers (see scheduler.pick). Only when no such worker is left
+// does it keep allocations on disabled workers in place. A job that still has fewer than
+// count workers is reported by ValidateMinAllocationsCount. A job marked rebalance keeps
+// nothing and is picked afresh from all allowed workers.
+func placeAllocations(workers []data.WorkerCapacity, jobs []jobPlacement) map[string][]string {
+	s := newScheduler(workers)
+	jobs = withReverseAntiAffinity(placementOrder(jobs))
+
+	// Current placements of counted jobs are reserved up front, so headroom reflects the
+	// whole current layout whichever job is placed first.
+	for _, job := range jobs {
+		if job.Count > 0 {
+			for _, workerIP := range job.kept() {
+				s.reserve(workerIP, job.JobReservation)
+			}
+		}
+	}
+
+	for _, job := range jobs {
+		var allowed []string
+		for _, workerIP := range job.candidates {
+			if s.allowed(job, workerIP) {
+				allowed = append(allowed, workerIP)
+			}
+		}
+
+		if job.Count == 0 {
+			for _, workerIP := range allowed {
+				if !job.excluded[workerIP] {
+					s.reserve(workerIP, job.JobReservation)
+				}
+			}
+			s.placed[job.Job] = allowed
+			continue
+		}
+
+		var kept []string
+		for _, workerIP := range job.kept() {
+			s.release(workerIP, job.JobReservation)
+			if slices.Contains(allowed, workerIP) {
+				kept = append(kept, workerIP)
+			}
+		}
+		placed := s.pick(job, kept, job.Count, nil)
+
+		var free, disabled []string
+		for _, workerIP := range allowed {
+			switch {
+			case slices.Contains(placed, workerIP):
+			case !job.excluded[workerIP]:
+				free = append(free, workerIP)
+			case slices.Contains(job.current, workerIP):
+				disabled = append(disabled, workerIP)
+			}
+		}
+		placed = append(placed, s.pick(job, free, job.Count-len(placed), placed)...)
+		placed = append(placed, s.pick(job, disabled, job.Count-len(placed), placed)...)
+		s.placed[job.Job] = s.byPosition(placed)
+	}
+	return s.placed
+}
+
+// withReverseAntiAffinity adds to each job the anti-affinity rules other jobs declare
+// against it: keeping kafka off zookeeper's workers also keeps zookeeper off kafka's,
+// whichever is placed first.
+func withReverseAntiAffinity(jobs []jobPlacement) []jobPlacement {
+	reverse := make(map[string][]workspace.PlacementConstraint)
+	for _, job := range jobs {
+		for _, rule := range job.rules {
+			if rule.Anti {
+				reverse[rule.Job] = append(reverse[rule.Job], workspace.PlacementConstraint{
+					PlacementRule: workspace.PlacementRule{Job: job.Job, Mode: rule.Mode},
+					Anti:          true,
+				})
+			}
+		}
+	}
+
+	expanded := make([]jobPlacement, len(jobs))
+	for i, job := range jobs {
+		job.rules = append(slices.Clone(job.rules), reverse[job.Job]...)
+		expanded[i] = job
+	}
+	return expanded
+}
+
+// placementOrder returns jobs with every job after the jobs its own rules name. Among jobs
+// that are ready, jobs without count go first (their reservations are fixed), then by
+// name. A rule cycle is broken by the same order.
+func placementOrder(jobs []jobPlacement) []jobPlacement {
+	names := make(map[string]bool, len(jobs))
+	for _, job := range jobs {
+		names[job.Job] = true
+	}
+	before := func(a, b jobPlacement) bool {
+		if (a.Count == 0) != (b.Count == 0) {
+			return a.Count == 0
+		}
+		return a.Job < b.Job
+	}
+
+	done := make(map[string]bool, len(jobs))
+	ready := func(job jobPlacement) bool {
+		for _, rule := range job.rules {
+			if names[rule.Job] && !done[rule.Job] {
+				return false
+			}
+		}
+		return true
+	}
+
+	order := make([]jobPlacement, 0, len(jobs))
+	for len(order) < len(jobs) {
+		next := -1
+		for i, job := range jobs {
+			if !done[job.Job] && ready(job) && (next < 0 || before(job, jobs[next])) {
+				next = i
+			}
+		}
+		if next < 0 {
+			for i, job := range jobs {
+				if !done[job.Job] && (next < 0 || before(job, jobs[next])) {
+					next = i
+				}
+			}
+		}
+		done[jobs[next].Job] = true
+		order = append(order, jobs[next])
+	}
+	return order
+}
@@ -0,0 +1,217 @@
+// Copyright 2025 Kiruba Sankar Swaminathan. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package build
+
+import (
+	"testing"
+
+	"maand/data"
+	"maand/workspace"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func placementWorkers() []data.WorkerCapacity {
+	return []data.WorkerCapacity{
+		{WorkerIP: "10.0.0.1", Position: 0, Zone: "a", MemoryMB: 4096, CPUMHz: 4000},
+		{WorkerIP: "10.0.0.2", Position: 1, Zone: "a", MemoryMB: 4096, CPUMHz: 4000},
+		{WorkerIP: "10.0.0.3", Position: 2, Zone: "b", MemoryMB: 4096, CPUMHz: 4000},
+		{WorkerIP: "10.0.0.4", Position: 3, Zone: "b", MemoryMB: 4096, CPUMHz: 4000},
+	}
+}
+
+var allPlacementWorkers = []string{"10.0.0.1", "10.0.0.2", "10.0.0.3", "10.0.0.4"}
+
+func TestPlaceAllocations_withoutCountUsesEveryMatchingWorker(t *testing.T) {
+	placements := placeAllocations(placementWorkers(), []jobPlacement{
+		{JobReservation: data.JobReservation{Job: "api"}, candidates: allPlacementWorkers},
+	})
+	assert.Equal(t, allPlacementWorkers, placements["api"])
+}
+
+func TestPlaceAllocations_countSpreadsAcrossZones(t *testing.T) {
+	placements := placeAllocations(placementWorkers(), []jobPlacement{
+		{JobReservation: data.JobReservation{Job: "api", Count: 2, MemoryMB: 512}, candidates: allPlacementWorkers},
+	})
+	assert.Equal(t, []string{"10.0.0.1", "10.0.0.3"}, placements["api"])
+}
+
+func TestPlaceAllocations_countPrefersHeadroom(t *testing.T) {
+	placements := placeAllocations(placementWorkers(), []jobPlacement{
+		{JobReservation: data.JobReservation{Job: "cache", MemoryMB: 3072}, candidates: []string{"10.0.0.1", "10.0.0.3"}},
+		{JobReservation: data.JobReservation{Job: "api", Count: 2, MemoryMB: 2048}, candidates: allPlacementWorkers},
+	})
+	assert.Equal(t, []string{"10.0.0.2", "10.0.0.4"}, placements["api"])
+}
+
+func TestPlaceAllocations_countPrefersWorkersWithDisk(t *testing.T) {
+	workers := placementWorkers()
+	workers[1].DiskMB = 51200
+	workers[3].DiskMB = 51200
+	placements := placeAllocations(workers, []jobPlacement{
+		{JobReservation: data.JobReservation{Job: "db", Count: 2, DiskMB: 20480}, candidates: allPlacementWorkers},
+	})
+	assert.Equal(t, []string{"10.0.0.2", "10.0.0.4"}, placements["db"])
+}
+
+func TestPlaceAllocations_countKeepsCurrentWorkers(t *testing.T) {
+	placements := placeAllocations(placementWorkers(), []jobPlacement{
+		{
+			JobReservation: data.JobReservation{Job: "api", Count: 2},
+			candidates:     allPlacementWorkers,
+			current:        []string{"10.0.0.4", "10.0.0.2"},
+		},
+	})
+	assert.Equal(t, []string{"10.0.0.2", "10.0.0.4"}, placements["api"])
+
+	placements = placeAllocations(placementWorkers(), []jobPlacement{
+		{
+			JobReservation: data.JobReservation{Job: "api", Count: 1},
+			candidates:     allPlacementWorkers,
+			current:        []string{"10.0.0.2", "10.0.0.4"},
+		},
+	})
+	assert.Equal(t, []string{"10.0.0.2"}, placements["api"])
+}
+
+func TestPlaceAllocations_rebalanceMovesOntoHeadroom(t *testing.T) {
+	jobs := func(rebalance bool) []jobPlacement {
+		return []jobPlacement{
+			{
+				JobReservation: data.JobReservation{Job: "api", Count: 2, MemoryMB: 1024},
+				candidates:     allPlacementWorkers,
+				current:        []string{"10.0.0.1", "10.0.0.2"},
+			},
+			{
+				JobReservation: data.JobReservation{Job: "web", Count: 2, MemoryMB: 1024},
+				candidates:     allPlacementWorkers,
+				current:        []string{"10.0.0.1", "10.0.0.2"},
+				rebalance:      rebalance,
+			},
+		}
+	}
+
+	placements := placeAllocations(placementWorkers(), jobs(false))
+	assert.Equal(t, []string{"10.0.0.1", "10.0.0.2"}, placements["web"])
+
+	placements = placeAllocations(placementWorkers(), jobs(true))
+	assert.Equal(t, []string{"10.0.0.1", "10.0.0.2"}, placements["api"])
+	assert.Equal(t, []string{"10.0.0.1", "10.0.0.3"}, placements["web"])
+}
+
+func TestPlaceAllocations_rebalanceStaysOnEvenTie(t *testing.T) {
+	placements := placeAllocations(placementWorkers(), []jobPlacement{
+		{
+			JobReservation: data.JobReservation{Job: "api", Count: 1, MemoryMB: 512},
+			candidates:     allPlacementWorkers,
+			current:        []string{"10.0.0.2"},
+			rebalance:      true,
+		},
+	})
+	assert.Equal(t, []string{"10.0.0.2"}, placements["api"])
+}
+
+func TestPlaceAllocations_countMovesOffGoneAndDisabledWorkers(t *testing.T) {
+	placements := placeAllocations(placementWorkers(), []jobPlacement{
+		{
+			JobReservation: data.JobReservation{Job: "api", Count: 2},
+			candidates:     []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"},
+			current:        []string{"10.0.0.3", "10.0.0.4", "10.0.0.9"},
+			excluded:       map[string]bool{"10.0.0.3": true},
+		},
+	})
+	assert.Equal(t, []string{"10.0.0.1", "10.0.0.2"}, placements["api"])
+}
+
+func TestPlaceAllocations_countKeepsDisabledAllocationWithoutSpareWorker(t *testing.T) {
+	placements := placeAllocations(placementWorkers(), []jobPlacement{
+		{
+			JobReservation: data.JobReservation{Job: "api", Count: 2},
+			candidates:     []string{"10.0.0.1", "10.0.0.2"},
+			current:        []string{"10.0.0.1", "10.0.0.2"},
+			excluded:       map[string]bool{"10.0.0.2": true},
+		},
+	})
+	assert.Equal(t, []string{"10.0.0.1", "10.0.0.2"}, placements["api"])
+}
+
+func TestPlaceAllocations_countLargerThanCandidates(t *testing.T) {
+	placements := placeAllocations(placementWorkers(), []jobPlacement{
+		{JobReservation: data.JobReservation{Job: "api", Count: 3}, candidates: []string{"10.0.0.3"}},
+	})
+	assert.Equal(t, []string{"10.0.0.3"}, placements["api"])
+}
+
+func hardRule(job string, anti bool) workspace.PlacementConstraint {
+	return workspace.PlacementConstraint{PlacementRule: workspace.PlacementRule{Job: job, Mode: workspace.PlacementRuleHard}, Anti: anti}
+}
+
+func TestPlaceAllocations_affinityFollowsCountedJob(t *testing.T) {
+	placements := placeAllocations(placementWorkers(), []jobPlacement{
+		{
+			JobReservation: data.JobReservation{Job: "agent"},
+			candidates:     allPlacementWorkers,
+			rules:          []workspace.PlacementConstraint{hardRule("api", false)},
+		},
+		{JobReservation: data.JobReservation{Job: "api", Count: 2}, candidates: allPlacementWorkers},
+	})
+	assert.Equal(t, []string{"10.0.0.1", "10.0.0.3"}, placements["api"])
+	assert.Equal(t, []string{"10.0.0.1", "10.0.0.3"}, placements["agent"])
+}
+
+func TestPlaceAllocations_antiAffinityAvoidsWorkers(t *testing.T) {
+	placements := placeAllocations(placementWorkers(), []jobPlacement{
+		{
+			JobReservation: data.JobReservation{Job: "kafka"},
+			candidates:     allPlacementWorkers,
+			rules:          []workspace.PlacementConstraint{hardRule("zookeeper", true)},
+		},
+		{JobReservation: data.JobReservation{Job: "zookeeper", Count: 1}, candidates: allPlacementWorkers},
+	})
+	assert.Equal(t, []string{"10.0.0.1"}, placements["zookeeper"])
+	assert.Equal(t, []string{"10.0.0.2", "10.0.0.3", "10.0.0.4"}, placements["kafka"])
+
+	// The rule binds both ways: when a rule cycle places zookeeper after kafka, zookeeper
+	// avoids kafka's workers even though its own soft affinity prefers them.
+	placements = placeAllocations(placementWorkers(), []jobPlacement{
+		{
+			JobReservation: data.JobReservation{Job: "kafka"},
+			candidates:     []string{"10.0.0.1", "10.0.0.2"},
+			rules:          []workspace.PlacementConstraint{hardRule("zookeeper", true)},
+		},
+		{
+			JobReservation: data.JobReservation{Job: "zookeeper", Count: 2},
+			candidates:     allPlacementWorkers,
+			rules: []workspace.PlacementConstraint{
+				{PlacementRule: workspace.PlacementRule{Job: "kafka", Mode: workspace.PlacementRuleSoft}},
+			},
+		},
+	})
+	assert.Equal(t, []string{"10.0.0.1", "10.0.0.2"}, placements["kafka"])
+	assert.Equal(t, []string{"10.0.0.3", "10.0.0.4"}, placements["zookeeper"])
+}
+
+func TestPlaceAllocations_softRulesRankWorkers(t *testing.T) {
+	placements := placeAllocations(placementWorkers(), []jobPlacement{
+		{JobReservation: data.JobReservation{Job: "db"}, candidates: []string{"10.0.0.2", "10.0.0.4"}},
+		{
+			JobReservation: data.JobReservation{Job: "api", Count: 2},
+			candidates:     allPlacementWorkers,
+			rules: []workspace.PlacementConstraint{
+				{PlacementRule: workspace.PlacementRule{Job: "db", Mode: workspace.PlacementRuleSoft}},
+			},
+		},
+		{
+			JobReservation: data.JobReservation{Job: "batch", Count: 1},
+			candidates:     allPlacementWorkers,
+			rules: []workspace.PlacementConstraint{
+				{PlacementRule: workspace.PlacementRule{Job: "api", Mode: workspace.PlacementRuleSoft}, Anti: true},
+				{PlacementRule: workspace.PlacementRule{Job: "db", Mode: workspace.PlacementRuleSoft}, Anti: true},
+			},
+		},
+	})
+	assert.Equal(t, []string{"10.0.0.2", "10.0.0.4"}, placements["api"])
+	assert.Equal(t, []string{"10.0.0.1"}, placements["batch"])
+}
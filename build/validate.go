@@ -1,8 +1,11 @@
 package build
 
 import (
+	"cmp"
 	"database/sql"
 	"fmt"
+	"log"
+	"slices"
 	"strings"
 
 	"maand/bucket"
@@ -13,7 +16,9 @@ import (
 func ValidateWorkerResources(tx *sql.Tx) error {
 	rows, err := tx.Query(`
         SELECT
-            a.worker_ip, w.available_memory_mb, w.available_cpu_mhz, sum(j.current_memory_mb) as required_memory_mb, sum(j.current_cpu_mhz) AS required_cpu_mhz
+            a.worker_ip, w.available_memory_mb, w.available_cpu_mhz, w.available_disk_mb,
+            ifnull(w.memory_overcommit, 1), ifnull(w.cpu_overcommit, 1), ifnull(w.disk_overcommit, 1),
+            sum(j.current_memory_mb) as required_memory_mb, sum(j.current_cpu_mhz) AS required_cpu_mhz, sum(j.current_disk_mb) AS required_disk_mb
         FROM
             allocations a JOIN job j ON j.name = a.job
                 JOIN
@@ -31,8 +36,11 @@ func ValidateWorkerResources(tx *sql.Tx) error {
 		var resourceErrors []string
 	for rows.Next() {
 		var workerIP string
-		var availableMemoryMB, availableCPUMHz, requiredMemoryMB, requiredCPUMHz float64
-		err = rows.Scan(&workerIP, &availableMemoryMB, &availableCPUMHz, &requiredMemoryMB, &requiredCPUMHz)
+		var availableMemoryMB, availableCPUMHz, availableDiskMB, requiredMemoryMB, requiredCPUMHz, requiredDiskMB float64
+		var memoryOvercommit, cpuOvercommit, diskOvercommit float64
+		err = rows.Scan(&workerIP, &availableMemoryMB, &availableCPUMHz, &availableDiskMB,
+			&memoryOvercommit, &cpuOvercommit, &diskOvercommit,
+			&requiredMemoryMB, &requiredCPUMHz, &requiredDiskMB)
 		if err != nil {
 			return bucket.DatabaseError(err)
 		}
@@ -42,10 +50,10 @@ func ValidateWorkerResources(tx *sql.Tx) error {
 				"worker_ip %s must specify memory in workers.json (allocated jobs require %.2f MB)",
 				workerIP, requiredMemoryMB,
 			))
-		} else if requiredMemoryMB > 0 && availableMemoryMB < requiredMemoryMB {
+		} else if requiredMemoryMB > 0 && availableMemoryMB*memoryOvercommit < requiredMemoryMB {
 			resourceErrors = append(resourceErrors, fmt.Sprintf(
-				"worker_ip %s, available memory is %.2f MB, required memory is %.2f MB",
-				workerIP, availableMemoryMB, requiredMemoryMB,
+				"worker_ip %s, available memory is %.2f MB%s, required memory is %.2f MB",
+				workerIP, availableMemoryMB*memoryOvercommit, overcommitNote(availableMemoryMB, memoryOvercommit, "MB"), requiredMemoryMB,
 			))
 		}
 
@@ -54,10 +62,22 @@ func ValidateWorkerResources(tx *sql.Tx) error {
 				"worker_ip %s must specify cpu in workers.json (allocated jobs require %.2f MHZ)",
 				workerIP, requiredCPUMHz,
 			))
-		} else if requiredCPUMHz > 0 && availableCPUMHz < requiredCPUMHz {
+		} else if requiredCPUMHz > 0 && availableCPUMHz*cpuOvercommit < requiredCPUMHz {
 			resourceErrors = append(resourceErrors, fmt.Sprintf(
-				"worker_ip %s, available cpu is %.2f MHZ, required cpu is %.2f MHZ",
-				workerIP, availableCPUMHz, requiredCPUMHz,
+				"worker_ip %s, available cpu is %.2f MHZ%s, required cpu is %.2f MHZ",
+				workerIP, availableCPUMHz*cpuOvercommit, overcommitNote(availableCPUMHz, cpuOvercommit, "MHZ"), requiredCPUMHz,
+			))
+		}
+
+		if requiredDiskMB > 0 && availableDiskMB <= 0 {
+			resourceErrors = append(resourceErrors, fmt.Sprintf(
+				"worker_ip %s must specify disk in workers.json (allocated jobs require %.2f MB)",
+				workerIP, requiredDiskMB,
+			))
+		} else if requiredDiskMB > 0 && availableDiskMB*diskOvercommit < requiredDiskMB {
+			resourceErrors = append(resourceErrors, fmt.Sprintf(
+				"worker_ip %s, available disk is %.2f MB%s, required disk is %.2f MB",
+				workerIP, availableDiskMB*diskOvercommit, overcommitNote(availableDiskMB, diskOvercommit, "MB"), requiredDiskMB,
 			))
 		}
 	}
@@ -72,6 +92,14 @@ func ValidateWorkerResources(tx *sql.Tx) error {
 	return nil
 }
 
+// overcommitNote explains an effective capacity that overcommit scaled from workers.json.
+func overcommitNote(available, ratio float64, unit string) string {
+	if ratio == 1 {
+		return ""
+	}
+	return fmt.Sprintf(" (%.2f %s in workers.json, overcommit %g)", available, unit, ratio)
+}
+
 func ValidateMinAllocationsCount(tx *sql.Tx, jobWorkspace *workspace.DefaultWorkspace) error {
 	jobNames, err := jobWorkspace.GetJobs()
 	if err != nil {
@@ -86,7 +114,8 @@ func ValidateMinAllocationsCount(tx *sql.Tx, jobWorkspace *workspace.DefaultWork
 		}
 
 		minCount := manifest.MinRequiredAllocations()
-		if minCount == 0 {
+		wantCount := manifest.AllocationCount()
+		if minCount == 0 && wantCount == 0 {
 			continue
 		}
 
@@ -99,6 +128,11 @@ func ValidateMinAllocationsCount(tx *sql.Tx, jobWorkspace *workspace.DefaultWork
 				"job %s has %d allocation(s), min_allocations_count is %d",
 				jobName, count, minCount,
 			))
+		} else if count < wantCount {
+			validationErrors = append(validationErrors, fmt.Sprintf(
+				"job %s has %d allocation(s), count is %d; add workers matching its selectors",
+				jobName, count, wantCount,
+			))
 		}
 	}
 
@@ -108,3 +142,94 @@ func ValidateMinAllocationsCount(tx *sql.Tx, jobWorkspace *workspace.DefaultWork
 
 	return nil
 }
+
+// ValidatePlacementRules checks the affinity and anti-affinity rules of every job against
+// its non-removed allocations. Broken hard rules fail the build; broken soft rules are
+// logged.
+func ValidatePlacementRules(tx *sql.Tx) error {
+	jobNames, err := data.GetJobs(tx)
+	if err != nil {
+		return err
+	}
+
+	allocated := make(map[string][]string, len(jobNames))
+	for _, jobName := range jobNames {
+		workers, err := data.GetNonRemovedAllocations(tx, jobName)
+		if err != nil {
+			return err
+		}
+		allocated[jobName] = workers
+	}
+
+	var validationErrors []string
+	for _, jobName := range jobNames {
+		placement, err := data.GetJobPlacement(tx, jobName)
+		if err != nil {
+			return err
+		}
+		for _, rule := range placement.Rules() {
+			for _, workerIP := range allocated[jobName] {
+				colocated := slices.Contains(allocated[rule.Job], workerIP)
+				if colocated != rule.Anti {
+					continue
+				}
+
+				var violation string
+				if rule.Anti {
+					violation = fmt.Sprintf("job %s on worker %s: anti_affinity with job %s, which is also placed on that worker", jobName, workerIP, rule.Job)
+				} else {
+					violation = fmt.Sprintf("job %s on worker %s: affinity requires job %s, which is not placed on that worker", jobName, workerIP, rule.Job)
+				}
+				if rule.Hard() {
+					validationErrors = append(validationErrors, violation)
+				} else {
+					log.Printf("placement: %s (soft %s, not enforced)", violation, rule.Kind())
+				}
+			}
+		}
+	}
+
+	if len(validationErrors) != 0 {
+		return fmt.Errorf("%w\n%s", bucket.ErrPlacementConflict, strings.Join(validationErrors, "\n"))
+	}
+
+	return nil
+}
+
+// ValidatePortConflicts checks the ports of the active allocations on each worker: no two
+// job ports may share a number, and none may use a port the worker lists in reserved_ports.
+func ValidatePortConflicts(tx *sql.Tx) error {
+	ports, err := data.ListWorkerPorts(tx)
+	if err != nil {
+		return err
+	}
+	reserved, err := data.ListReservedPorts(tx)
+	if err != nil {
+		return err
+	}
+
+	active := make([]data.WorkerPort, 0, len(ports))
+	for _, port := range ports {
+		if !port.Disabled {
+			active = append(active, port)
+		}
+	}
+
+	var validationErrors []string
+	for _, conflict := range data.FindPortConflicts(active, reserved) {
+		// A clash between two job ports is reported once, from the first of the pair.
+		if !conflict.Reserved && cmp.Or(cmp.Compare(conflict.Job, conflict.OtherJob), cmp.Compare(conflict.Name, conflict.OtherName)) > 0 {
+			continue
+		}
+		validationErrors = append(validationErrors, fmt.Sprintf(
+			"worker_ip %s port %d: job %s port %s conflicts with %s",
+			conflict.WorkerIP, conflict.Port, conflict.Job, conflict.Name, conflict.Conflict(),
+		))
+	}
+
+	if len(validationErrors) != 0 {
+		return fmt.Errorf("%w\n%s", bucket.ErrPortCollision, strings.Join(validationErrors, "\n"))
+	}
+
+	return nil
+}
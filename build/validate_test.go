@@ -24,8 +24,11 @@ func openValidateTestDB(t *testing.T) *sql.DB {
 	db, err := sql.Open("sqlite3", "file:"+t.Name()+"?mode=memory&cache=shared")
 	require.NoError(t, err)
 	_, err = db.Exec(`
-		CREATE TABLE worker (worker_ip TEXT PRIMARY KEY, available_memory_mb REAL, available_cpu_mhz REAL);
-		CREATE TABLE job (name TEXT PRIMARY KEY, current_memory_mb REAL, current_cpu_mhz REAL);
+		CREATE TABLE worker (
+			worker_ip TEXT PRIMARY KEY, available_memory_mb REAL, available_cpu_mhz REAL, available_disk_mb REAL DEFAULT 0,
+			memory_overcommit REAL DEFAULT 1, cpu_overcommit REAL DEFAULT 1, disk_overcommit REAL DEFAULT 1
+		);
+		CREATE TABLE job (name TEXT PRIMARY KEY, current_memory_mb REAL, current_cpu_mhz REAL, current_disk_mb REAL DEFAULT 0);
 		CREATE TABLE allocations (job TEXT, worker_ip TEXT, removed INT, disabled INT);
 	`)
 	require.NoError(t, err)
@@ -95,6 +98,27 @@ func TestValidateWorkerResources_insufficientMemory(t *testing.T) {
 	assert.Contains(t, err.Error(), "available memory")
 }
 
+func TestValidateWorkerResources_insufficientDisk(t *testing.T) {
+	db := openValidateTestDB(t)
+	defer func() { _ = db.Close() }()
+
+	_, err := db.Exec(`
+		INSERT INTO worker (worker_ip, available_memory_mb, available_cpu_mhz, available_disk_mb) VALUES ('10.0.0.1', 0, 0, 10240);
+		INSERT INTO job (name, current_memory_mb, current_cpu_mhz, current_disk_mb) VALUES ('db', 0, 0, 8192), ('logs', 0, 0, 4096);
+		INSERT INTO allocations (job, worker_ip, removed, disabled) VALUES ('db', '10.0.0.1', 0, 0), ('logs', '10.0.0.1', 0, 0);
+	`)
+	require.NoError(t, err)
+
+	tx, err := db.Begin()
+	require.NoError(t, err)
+	defer func() { _ = tx.Rollback() }()
+
+	err = ValidateWorkerResources(tx)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, bucket.ErrInsufficientResource)
+	assert.Contains(t, err.Error(), "available disk")
+}
+
 func TestValidateWorkerResources_insufficientCPU(t *testing.T) {
 	db := openValidateTestDB(t)
 	defer func() { _ = db.Close() }()
@@ -116,6 +140,30 @@ func TestValidateWorkerResources_insufficientCPU(t *testing.T) {
 	assert.Contains(t, err.Error(), "available cpu")
 }
 
+func TestValidateWorkerResources_appliesOvercommit(t *testing.T) {
+	db := openValidateTestDB(t)
+	defer func() { _ = db.Close() }()
+
+	_, err := db.Exec(`
+		INSERT INTO worker (worker_ip, available_memory_mb, available_cpu_mhz, cpu_overcommit) VALUES ('10.0.0.1', 1024, 1000, 1.5);
+		INSERT INTO job (name, current_memory_mb, current_cpu_mhz) VALUES ('app', 1024, 1400);
+		INSERT INTO allocations (job, worker_ip, removed, disabled) VALUES ('app', '10.0.0.1', 0, 0);
+	`)
+	require.NoError(t, err)
+
+	tx, err := db.Begin()
+	require.NoError(t, err)
+	defer func() { _ = tx.Rollback() }()
+	require.NoError(t, ValidateWorkerResources(tx))
+
+	_, err = tx.Exec(`UPDATE job SET current_cpu_mhz = 1600`)
+	require.NoError(t, err)
+	err = ValidateWorkerResources(tx)
+	assert.ErrorIs(t, err, bucket.ErrInsufficientResource)
+	assert.Contains(t, err.Error(), "available cpu is 1500.00 MHZ (1000.00 MHZ in workers.json, overcommit 1.5), required cpu is 1600.00 MHZ")
+	assert.NotContains(t, err.Error(), "available memory")
+}
+
 func TestValidateWorkerResources_skipsWhenJobHasNoRequirements(t *testing.T) {
 	db := openValidateTestDB(t)
 	defer func() { _ = db.Close() }()
@@ -211,3 +259,99 @@ func TestValidateMinAllocationsCount_passesWhenMinimumMet(t *testing.T) {
 
 	require.NoError(t, ValidateMinAllocationsCount(tx, jobWorkspace))
 }
+
+func TestValidateMinAllocationsCount_rejectsWhenBelowCount(t *testing.T) {
+	db := openValidateTestDB(t)
+	defer func() { _ = db.Close() }()
+
+	_, err := db.Exec(`
+		INSERT INTO allocations (job, worker_ip, removed, disabled) VALUES ('app', '10.0.0.1', 0, 0);
+		INSERT INTO allocations (job, worker_ip, removed, disabled) VALUES ('app', '10.0.0.2', 0, 1);
+	`)
+	require.NoError(t, err)
+
+	tx, err := db.Begin()
+	require.NoError(t, err)
+	defer func() { _ = tx.Rollback() }()
+
+	jobWorkspace := setupValidateMinAllocationsWorkspace(t, `{"count":3}`)
+
+	err = ValidateMinAllocationsCount(tx, jobWorkspace)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, bucket.ErrInsufficientAllocations)
+	assert.Contains(t, err.Error(), "job app has 2 allocation(s), count is 3")
+
+	jobWorkspace = setupValidateMinAllocationsWorkspace(t, `{"count":2}`)
+	require.NoError(t, ValidateMinAllocationsCount(tx, jobWorkspace))
+}
+
+func TestValidatePlacementRules(t *testing.T) {
+	db := openBuildAllocationsTestDB(t)
+	defer func() { _ = db.Close() }()
+
+	_, err := db.Exec(`
+		INSERT INTO job (job_id, name, placement) VALUES
+			('job-api', 'api', ''),
+			('job-agent', 'agent', '{"affinity":[{"job":"api"}]}'),
+			('job-kafka', 'kafka', '{"anti_affinity":[{"job":"api","mode":"soft"},{"job":"zookeeper"}]}'),
+			('job-zookeeper', 'zookeeper', '');
+		INSERT INTO allocations (alloc_id, job, worker_ip, removed, disabled) VALUES
+			('a1', 'api', '10.0.0.1', 0, 0),
+			('a2', 'agent', '10.0.0.1', 0, 0),
+			('a3', 'agent', '10.0.0.2', 0, 0),
+			('a4', 'kafka', '10.0.0.1', 0, 0),
+			('a5', 'zookeeper', '10.0.0.3', 0, 0);
+	`)
+	require.NoError(t, err)
+
+	tx, err := db.Begin()
+	require.NoError(t, err)
+	defer func() { _ = tx.Rollback() }()
+
+	err = ValidatePlacementRules(tx)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, bucket.ErrPlacementConflict)
+	assert.Contains(t, err.Error(), "job agent on worker 10.0.0.2: affinity requires job api")
+	assert.NotContains(t, err.Error(), "kafka")
+
+	_, err = tx.Exec(`UPDATE allocations SET worker_ip = '10.0.0.3' WHERE alloc_id = 'a4'`)
+	require.NoError(t, err)
+	_, err = tx.Exec(`UPDATE allocations SET removed = 1 WHERE alloc_id = 'a3'`)
+	require.NoError(t, err)
+	err = ValidatePlacementRules(tx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "job kafka on worker 10.0.0.3: anti_affinity with job zookeeper")
+
+	_, err = tx.Exec(`UPDATE allocations SET removed = 1 WHERE alloc_id = 'a5'`)
+	require.NoError(t, err)
+	require.NoError(t, ValidatePlacementRules(tx))
+}
+
+func TestValidatePortConflicts(t *testing.T) {
+	db := openBuildAllocationsTestDB(t)
+	defer func() { _ = db.Close() }()
+
+	_, err := db.Exec(`
+		INSERT INTO worker (worker_id, worker_ip, position) VALUES ('w1', '10.0.0.1', 0), ('w2', '10.0.0.2', 1);
+		INSERT INTO worker_reserved_ports (worker_id, port) VALUES ('w1', 9100);
+		INSERT INTO job (job_id, name) VALUES ('j1', 'exporter'), ('j2', 'api');
+		INSERT INTO job_ports (job_id, name, port) VALUES ('j1', 'exporter_port', 9100), ('j2', 'api_port', 30000);
+		INSERT INTO allocations (alloc_id, worker_ip, job, disabled, removed)
+		VALUES ('a1', '10.0.0.1', 'exporter', 0, 0), ('a2', '10.0.0.2', 'exporter', 0, 0), ('a3', '10.0.0.1', 'api', 0, 0);
+	`)
+	require.NoError(t, err)
+
+	tx, err := db.Begin()
+	require.NoError(t, err)
+	defer func() { _ = tx.Rollback() }()
+
+	err = ValidatePortConflicts(tx)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, bucket.ErrPortCollision)
+	assert.Contains(t, err.Error(), "worker_ip 10.0.0.1 port 9100: job exporter port exporter_port conflicts with reserved_ports")
+	assert.NotContains(t, err.Error(), "10.0.0.2")
+
+	_, err = tx.Exec(`UPDATE allocations SET disabled = 1 WHERE alloc_id = 'a1'`)
+	require.NoError(t, err)
+	assert.NoError(t, ValidatePortConflicts(tx))
+}
@@ -17,15 +17,40 @@ import (
 	"github.com/jedib0t/go-pretty/v6/table"
 )
 
+// AllocationRow is one allocation of maand cat allocations.
+type AllocationRow struct {
+	AllocID  string `json:"alloc_id"`
+	WorkerIP string `json:"worker_ip"`
+	Zone     string `json:"zone"`
+	Job      string `json:"job"`
+	Disabled bool   `json:"disabled"`
+	Removed  bool   `json:"removed"`
+}
+
 func Allocations(jobsCSV, workersCSV string) error {
+	allocations, err := AllocationRows(jobsCSV, workersCSV)
+	if err != nil {
+		return err
+	}
+
+	t := utils.GetTable(table.Row{"allocation_id", "worker_ip", "zone", "job", "disabled", "removed"})
+	for _, alloc := range allocations {
+		t.AppendRows([]table.Row{{alloc.AllocID, alloc.WorkerIP, alloc.Zone, alloc.Job, boolInt(alloc.Disabled), boolInt(alloc.Removed)}})
+	}
+	t.Render()
+	return nil
+}
+
+// AllocationRows returns the allocations of jobsCSV on workersCSV; empty filters match all.
+func AllocationRows(jobsCSV, workersCSV string) ([]AllocationRow, error) {
 	db, err := data.OpenDatabase(true)
 	if err != nil {
-		return bucket.DatabaseError(err)
+		return nil, bucket.DatabaseError(err)
 	}
 
 	tx, err := db.Begin()
 	if err != nil {
-		return bucket.DatabaseError(err)
+		return nil, bucket.DatabaseError(err)
 	}
 	defer func() {
 		_ = tx.Rollback()
@@ -46,20 +71,20 @@ func Allocations(jobsCSV, workersCSV string) error {
 
 	allJobs, err := data.GetAllAllocatedJobs(tx)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if len(jobsFilter) > 0 && len(utils.Intersection(allJobs, jobsFilter)) == 0 {
-		return fmt.Errorf("invalid input, jobs %v", jobsFilter)
+		return nil, fmt.Errorf("invalid input, jobs %v", jobsFilter)
 	}
 
 	allWorkers, err := data.GetAllWorkers(tx)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if len(workersFilter) > 0 && len(utils.Intersection(allWorkers, workersFilter)) == 0 {
-		return fmt.Errorf("invalid input, workers %v", workersFilter)
+		return nil, fmt.Errorf("invalid input, workers %v", workersFilter)
 	}
 
 	var workerCount int
@@ -69,14 +94,12 @@ func Allocations(jobsCSV, workersCSV string) error {
 
 	err = row.Scan(&workerCount)
 	if workerCount == 0 || errors.Is(err, sql.ErrNoRows) {
-		return bucket.NotFoundError("allocations")
+		return nil, bucket.NotFoundError("allocations")
 	}
 	if err != nil {
-		return bucket.DatabaseError(err)
+		return nil, bucket.DatabaseError(err)
 	}
 
-	t := utils.GetTable(table.Row{"allocation_id", "worker_ip", "zone", "job", "disabled", "removed"})
-
 	query = "SELECT alloc_id, worker_ip, job, disabled, removed, zone FROM cat_allocations"
 	if len(jobsFilter) > 0 || len(workersFilter) > 0 {
 		query = fmt.Sprintf("%s WHERE", query)
@@ -94,12 +117,13 @@ func Allocations(jobsCSV, workersCSV string) error {
 
 	rows, err := tx.Query(query)
 	if err != nil {
-		return bucket.DatabaseError(err)
+		return nil, bucket.DatabaseError(err)
 	}
 	defer func() {
 		_ = rows.Close()
 	}()
 
+	var allocations []AllocationRow
 	for rows.Next() {
 		var allocID string
 		var workerIP string
@@ -110,21 +134,29 @@ func Allocations(jobsCSV, workersCSV string) error {
 
 		err = rows.Scan(&allocID, &workerIP, &job, &disabled, &removed, &zone)
 		if err != nil {
-			return bucket.DatabaseError(err)
+			return nil, bucket.DatabaseError(err)
 		}
 
-		t.AppendRows([]table.Row{{allocID, workerIP, zone.String, job, disabled, removed}})
+		allocations = append(allocations, AllocationRow{
+			AllocID: allocID, WorkerIP: workerIP, Zone: zone.String, Job: job, Disabled: disabled == 1, Removed: removed == 1,
+		})
 	}
 	if err := data.RowsErr(rows); err != nil {
-		return err
+		return nil, err
 	}
 
-	t.Render()
-
 	err = tx.Commit()
 	if err != nil {
-		return bucket.DatabaseError(err)
+		return nil, bucket.DatabaseError(err)
 	}
 
-	return nil
+	return allocations, nil
+}
+
+// boolInt keeps the 0/1 flag columns of the tables.
+func boolInt(v bool) int {
+	if v {
+		return 1
+	}
+	return 0
 }
@@ -0,0 +1,334 @@
+// Copyright 2025 Kiruba Sankar Swaminathan. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package cat
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"maand/bucket"
+	"maand/data"
+	"maand/utils"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+)
+
+// capacityWarnRatio is the share of a worker's capacity that, once reserved, flags it.
+const capacityWarnRatio = 0.9
+
+// workerCapacity is one worker of maand cat capacity: its effective capacity, what its
+// active allocations reserve, and the jobs they belong to.
+type workerCapacity struct {
+	data.WorkerHeadroom
+	Labels []string
+	Jobs   []string
+}
+
+// overReserved lists the resources more than capacityWarnRatio reserved, with the share,
+// e.g. "memory 95%". A resource reserved on a worker without that capacity counts as over.
+func (w workerCapacity) overReserved() []string {
+	var over []string
+	for _, resource := range []struct {
+		name               string
+		capacity, reserved float64
+	}{
+		{"memory", w.MemoryMB, w.ReservedMemoryMB},
+		{"cpu", w.CPUMHz, w.ReservedCPUMHz},
+		{"disk", w.DiskMB, w.ReservedDiskMB},
+	} {
+		switch {
+		case resource.reserved <= 0:
+		case resource.capacity <= 0:
+			over = append(over, resource.name+" no capacity")
+		case resource.reserved > resource.capacity*capacityWarnRatio:
+			over = append(over, fmt.Sprintf("%s %.0f%%", resource.name, resource.reserved/resource.capacity*100))
+		}
+	}
+	return over
+}
+
+// capacityGroup sums the workers of the fleet, of a zone, or of a label.
+type capacityGroup struct {
+	Group   string
+	Name    string
+	Workers int
+	Over    int
+	data.WorkerHeadroom
+}
+
+func (g *capacityGroup) add(worker workerCapacity) {
+	g.Workers++
+	if len(worker.overReserved()) > 0 {
+		g.Over++
+	}
+	g.MemoryMB += worker.MemoryMB
+	g.CPUMHz += worker.CPUMHz
+	g.DiskMB += worker.DiskMB
+	g.ReservedMemoryMB += worker.ReservedMemoryMB
+	g.ReservedCPUMHz += worker.ReservedCPUMHz
+	g.ReservedDiskMB += worker.ReservedDiskMB
+}
+
+// summarizeCapacity returns the fleet total, then one group per zone and per label, each
+// ordered by name. Workers without a zone are grouped under "-".
+func summarizeCapacity(workers []workerCapacity) []capacityGroup {
+	fleet := capacityGroup{Group: "fleet", Name: "all"}
+	zones := make(map[string]*capacityGroup)
+	labels := make(map[string]*capacityGroup)
+	groupOf := func(groups map[string]*capacityGroup, group, name string) *capacityGroup {
+		if groups[name] == nil {
+			groups[name] = &capacityGroup{Group: group, Name: name}
+		}
+		return groups[name]
+	}
+
+	for _, worker := range workers {
+		fleet.add(worker)
+		zone := worker.Zone
+		if zone == "" {
+			zone = "-"
+		}
+		groupOf(zones, "zone", zone).add(worker)
+		for _, label := range worker.Labels {
+			groupOf(labels, "label", label).add(worker)
+		}
+	}
+
+	summary := []capacityGroup{fleet}
+	for _, groups := range []map[string]*capacityGroup{zones, labels} {
+		names := make([]string, 0, len(groups))
+		for name := range groups {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		for _, name := range names {
+			summary = append(summary, *groups[name])
+		}
+	}
+	return summary
+}
+
+// CapacityReport is maand cat capacity: one row per worker, then the summary for the
+// fleet, each zone and each label.
+type CapacityReport struct {
+	Workers []CapacityWorkerRow `json:"workers"`
+	Summary []CapacityGroupRow  `json:"summary"`
+}
+
+// CapacityWorkerRow is one worker's effective capacity, what its active allocations
+// reserve, the headroom left, and the resources more than 90% reserved.
+type CapacityWorkerRow struct {
+	WorkerIP         string   `json:"worker_ip"`
+	Zone             string   `json:"zone"`
+	Labels           []string `json:"labels"`
+	MemoryMB         float64  `json:"memory_mb"`
+	ReservedMemoryMB float64  `json:"reserved_memory_mb"`
+	FreeMemoryMB     float64  `json:"free_memory_mb"`
+	CPUMHz           float64  `json:"cpu_mhz"`
+	ReservedCPUMHz   float64  `json:"reserved_cpu_mhz"`
+	FreeCPUMHz       float64  `json:"free_cpu_mhz"`
+	DiskMB           float64  `json:"disk_mb"`
+	ReservedDiskMB   float64  `json:"reserved_disk_mb"`
+	FreeDiskMB       float64  `json:"free_disk_mb"`
+	Jobs             []string `json:"jobs"`
+	OverReserved     []string `json:"over_reserved"`
+}
+
+// CapacityGroupRow sums the workers of the fleet (group fleet, name all), of a zone or of
+// a label. OverReservedWorkers counts the workers with any resource over 90% reserved.
+type CapacityGroupRow struct {
+	Group               string  `json:"group"`
+	Name                string  `json:"name"`
+	Workers             int     `json:"workers"`
+	MemoryMB            float64 `json:"memory_mb"`
+	ReservedMemoryMB    float64 `json:"reserved_memory_mb"`
+	FreeMemoryMB        float64 `json:"free_memory_mb"`
+	CPUMHz              float64 `json:"cpu_mhz"`
+	ReservedCPUMHz      float64 `json:"reserved_cpu_mhz"`
+	FreeCPUMHz          float64 `json:"free_cpu_mhz"`
+	DiskMB              float64 `json:"disk_mb"`
+	ReservedDiskMB      float64 `json:"reserved_disk_mb"`
+	FreeDiskMB          float64 `json:"free_disk_mb"`
+	OverReservedWorkers int     `json:"over_reserved_workers"`
+}
+
+// Capacity prints each worker's effective capacity, reservations, headroom and jobs, then
+// a summary for the fleet, each zone and each label. Workers more than 90% reserved on any
+// resource are flagged. workersCSV and labelsCSV limit it to those workers, or to workers
+// with any of those labels.
+func Capacity(workersCSV, labelsCSV string) error {
+	workers, err := listWorkerCapacity(workersCSV, labelsCSV)
+	if err != nil {
+		return err
+	}
+
+	t := utils.GetTable(table.Row{
+		"worker_ip", "zone", "labels",
+		"memory_mb", "reserved_memory_mb", "free_memory_mb",
+		"cpu_mhz", "reserved_cpu_mhz", "free_cpu_mhz",
+		"disk_mb", "reserved_disk_mb", "free_disk_mb",
+		"jobs", "over_90%",
+	})
+	for _, worker := range workers {
+		t.AppendRow(table.Row{
+			worker.WorkerIP, worker.Zone, strings.Join(worker.Labels, ","),
+			worker.MemoryMB, worker.ReservedMemoryMB, worker.FreeMemoryMB(),
+			worker.CPUMHz, worker.ReservedCPUMHz, worker.FreeCPUMHz(),
+			worker.DiskMB, worker.ReservedDiskMB, worker.FreeDiskMB(),
+			strings.Join(worker.Jobs, ","), strings.Join(worker.overReserved(), ", "),
+		})
+	}
+	t.Render()
+
+	fmt.Println()
+	printCapacitySummary(workers)
+	return nil
+}
+
+// CapacitySummary prints only the summary of Capacity: the fleet, each zone and each label.
+func CapacitySummary(workersCSV, labelsCSV string) error {
+	workers, err := listWorkerCapacity(workersCSV, labelsCSV)
+	if err != nil {
+		return err
+	}
+	printCapacitySummary(workers)
+	return nil
+}
+
+func printCapacitySummary(workers []workerCapacity) {
+	summary := utils.GetTable(table.Row{
+		"group", "name", "workers",
+		"memory_mb", "reserved_memory_mb", "free_memory_mb",
+		"cpu_mhz", "reserved_cpu_mhz", "free_cpu_mhz",
+		"disk_mb", "reserved_disk_mb", "free_disk_mb",
+		"over_90%",
+	})
+	summaryGroups := summarizeCapacity(workers)
+	for _, group := range summaryGroups {
+		summary.AppendRow(table.Row{
+			group.Group, group.Name, group.Workers,
+			group.MemoryMB, group.ReservedMemoryMB, group.FreeMemoryMB(),
+			group.CPUMHz, group.ReservedCPUMHz, group.FreeCPUMHz(),
+			group.DiskMB, group.ReservedDiskMB, group.FreeDiskMB(),
+			group.Over,
+		})
+	}
+	summary.Render()
+
+	if over := summaryGroups[0].Over; over > 0 {
+		fmt.Printf("%d worker(s) over %.0f%% reserved\n", over, capacityWarnRatio*100)
+	}
+}
+
+// CapacityRows returns what Capacity prints.
+func CapacityRows(workersCSV, labelsCSV string) (CapacityReport, error) {
+	workers, err := listWorkerCapacity(workersCSV, labelsCSV)
+	if err != nil {
+		return CapacityReport{}, err
+	}
+
+	report := CapacityReport{}
+	for _, worker := range workers {
+		over := worker.overReserved()
+		if over == nil {
+			over = []string{}
+		}
+		report.Workers = append(report.Workers, CapacityWorkerRow{
+			WorkerIP: worker.WorkerIP, Zone: worker.Zone, Labels: nonNilStrings(worker.Labels),
+			MemoryMB: worker.MemoryMB, ReservedMemoryMB: worker.ReservedMemoryMB, FreeMemoryMB: worker.FreeMemoryMB(),
+			CPUMHz: worker.CPUMHz, ReservedCPUMHz: worker.ReservedCPUMHz, FreeCPUMHz: worker.FreeCPUMHz(),
+			DiskMB: worker.DiskMB, ReservedDiskMB: worker.ReservedDiskMB, FreeDiskMB: worker.FreeDiskMB(),
+			Jobs: nonNilStrings(worker.Jobs), OverReserved: over,
+		})
+	}
+	for _, group := range summarizeCapacity(workers) {
+		report.Summary = append(report.Summary, CapacityGroupRow{
+			Group: group.Group, Name: group.Name, Workers: group.Workers,
+			MemoryMB: group.MemoryMB, ReservedMemoryMB: group.ReservedMemoryMB, FreeMemoryMB: group.FreeMemoryMB(),
+			CPUMHz: group.CPUMHz, ReservedCPUMHz: group.ReservedCPUMHz, FreeCPUMHz: group.FreeCPUMHz(),
+			DiskMB: group.DiskMB, ReservedDiskMB: group.ReservedDiskMB, FreeDiskMB: group.FreeDiskMB(),
+			OverReservedWorkers: group.Over,
+		})
+	}
+	return report, nil
+}
+
+// CapacityRecords returns what maand cat capacity writes in format: the whole report for
+// json and yaml, and only the worker rows for csv and tsv, which hold a single list.
+// summary selects the summary rows in every format.
+func CapacityRecords(report CapacityReport, format utils.OutputFormat, summary bool) any {
+	switch {
+	case summary:
+		return report.Summary
+	case format == utils.OutputCSV || format == utils.OutputTSV:
+		return report.Workers
+	}
+	return report
+}
+
+// listWorkerCapacity returns the workers of workersCSV, or with any label of labelsCSV.
+func listWorkerCapacity(workersCSV, labelsCSV string) ([]workerCapacity, error) {
+	db, err := data.OpenDatabase(true)
+	if err != nil {
+		return nil, bucket.DatabaseError(err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, bucket.DatabaseError(err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	var workersFilter, labelsFilter []string
+	if workersCSV != "" {
+		workersFilter = utils.Unique(strings.Split(workersCSV, ","))
+	}
+	if labelsCSV != "" {
+		labelsFilter = utils.Unique(strings.Split(labelsCSV, ","))
+	}
+
+	headroom, err := data.ListWorkerHeadroom(tx)
+	if err != nil {
+		return nil, err
+	}
+	if len(headroom) == 0 {
+		return nil, bucket.NotFoundError("workers")
+	}
+
+	var workers []workerCapacity
+	for _, worker := range headroom {
+		if len(workersFilter) > 0 && !slices.Contains(workersFilter, worker.WorkerIP) {
+			continue
+		}
+		workerID, err := data.GetWorkerID(tx, worker.WorkerIP)
+		if err != nil {
+			return nil, err
+		}
+		labels, err := data.GetWorkerLabels(tx, workerID)
+		if err != nil {
+			return nil, err
+		}
+		if len(labelsFilter) > 0 && len(utils.Intersection(labels, labelsFilter)) == 0 {
+			continue
+		}
+		jobs, err := data.GetActiveAllocatedJobs(tx, worker.WorkerIP)
+		if err != nil {
+			return nil, err
+		}
+		slices.Sort(jobs)
+		workers = append(workers, workerCapacity{WorkerHeadroom: worker, Labels: labels, Jobs: jobs})
+	}
+	if len(workers) == 0 {
+		return nil, fmt.Errorf("invalid input, workers %v labels %v", workersFilter, labelsFilter)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, bucket.DatabaseError(err)
+	}
+	return workers, nil
+}
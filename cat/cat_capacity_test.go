@@ -0,0 +1,91 @@
+// Copyright 2025 Kiruba Sankar Swaminathan. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package cat
+
+import (
+	"testing"
+
+	"maand/bucket"
+	"maand/data"
+	"maand/initialize"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func headroom(workerIP, zone string, memory, reservedMemory, cpu, reservedCPU float64) data.WorkerHeadroom {
+	return data.WorkerHeadroom{
+		WorkerCapacity:   data.WorkerCapacity{WorkerIP: workerIP, Zone: zone, MemoryMB: memory, CPUMHz: cpu},
+		ReservedMemoryMB: reservedMemory,
+		ReservedCPUMHz:   reservedCPU,
+	}
+}
+
+func TestWorkerCapacityOverReserved(t *testing.T) {
+	assert.Empty(t, workerCapacity{WorkerHeadroom: headroom("10.0.0.1", "a", 1024, 921, 1000, 900)}.overReserved())
+	assert.Equal(t, []string{"memory 95%"},
+		workerCapacity{WorkerHeadroom: headroom("10.0.0.1", "a", 1024, 972.8, 1000, 100)}.overReserved())
+	assert.Equal(t, []string{"cpu no capacity"},
+		workerCapacity{WorkerHeadroom: headroom("10.0.0.1", "a", 1024, 0, 0, 100)}.overReserved())
+}
+
+func TestSummarizeCapacity(t *testing.T) {
+	summary := summarizeCapacity([]workerCapacity{
+		{WorkerHeadroom: headroom("10.0.0.1", "a", 1024, 1000, 1000, 0), Labels: []string{"web", "worker"}},
+		{WorkerHeadroom: headroom("10.0.0.2", "b", 1024, 0, 1000, 500), Labels: []string{"worker"}},
+		{WorkerHeadroom: headroom("10.0.0.3", "", 2048, 512, 0, 0), Labels: []string{"batch"}},
+	})
+
+	var names []string
+	for _, group := range summary {
+		names = append(names, group.Group+"/"+group.Name)
+	}
+	assert.Equal(t, []string{"fleet/all", "zone/-", "zone/a", "zone/b", "label/batch", "label/web", "label/worker"}, names)
+
+	fleet := summary[0]
+	assert.Equal(t, 3, fleet.Workers)
+	assert.Equal(t, 1, fleet.Over)
+	assert.Equal(t, 4096.0, fleet.MemoryMB)
+	assert.Equal(t, 1512.0, fleet.ReservedMemoryMB)
+	assert.Equal(t, 1500.0, fleet.FreeCPUMHz())
+
+	worker := summary[6]
+	assert.Equal(t, 2, worker.Workers)
+	assert.Equal(t, 1, worker.Over)
+	assert.Equal(t, 1000.0, worker.ReservedMemoryMB)
+}
+
+func TestCapacityFilters(t *testing.T) {
+	root := t.TempDir()
+	orig := bucket.Location
+	bucket.Location = root
+	bucket.UpdatePath()
+	t.Cleanup(func() {
+		bucket.Location = orig
+		bucket.UpdatePath()
+	})
+
+	require.NoError(t, initialize.Execute())
+
+	db, err := data.OpenDatabase(false)
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	_, err = db.Exec(`
+		INSERT INTO worker (worker_id, worker_ip, available_memory_mb, available_cpu_mhz, position)
+		VALUES ('w1', '10.0.0.1', '1024', '2000', 0), ('w2', '10.0.0.2', '1024', '2000', 1);
+		INSERT INTO worker_labels (worker_id, label) VALUES ('w1', 'web'), ('w2', 'batch');
+		INSERT INTO job (job_id, name, current_memory_mb, current_cpu_mhz) VALUES ('j1', 'api', '1000', '500');
+		INSERT INTO allocations (alloc_id, worker_ip, job, disabled, removed, deployment_seq, new_version)
+		VALUES ('a1', '10.0.0.1', 'api', 0, 0, 0, '');
+	`)
+	require.NoError(t, err)
+
+	require.NoError(t, Capacity("", ""))
+	require.NoError(t, Capacity("10.0.0.1", ""))
+	require.NoError(t, Capacity("", "batch"))
+	assert.ErrorContains(t, Capacity("10.0.0.9", ""), "invalid input")
+	assert.ErrorContains(t, Capacity("10.0.0.1", "batch"), "invalid input")
+}
@@ -16,15 +16,58 @@ import (
 	"github.com/jedib0t/go-pretty/v6/table"
 )
 
+// DeploymentRow is one allocation of maand cat deployments: the hash build staged
+// (current_hash), the hash last promoted on the worker (previous_hash), the versions, and
+// the rollout state they imply.
+type DeploymentRow struct {
+	Job            string `json:"job"`
+	WorkerIP       string `json:"worker_ip"`
+	AllocID        string `json:"alloc_id"`
+	Rollout        string `json:"rollout"`
+	CurrentHash    string `json:"current_hash"`
+	PreviousHash   string `json:"previous_hash"`
+	CurrentVersion string `json:"current_version"`
+	NewVersion     string `json:"new_version"`
+	Disabled       bool   `json:"disabled"`
+	Removed        bool   `json:"removed"`
+}
+
 func Deployments(jobsCSV, workersCSV string, activeOnly bool) error {
+	deployments, err := DeploymentRows(jobsCSV, workersCSV, activeOnly)
+	if err != nil {
+		return err
+	}
+
+	t := utils.GetTable(table.Row{
+		"job", "worker_ip", "alloc_id", "rollout",
+		"current_hash", "previous_hash",
+		"current_version", "new_version",
+		"disabled", "removed",
+	})
+	for _, deployment := range deployments {
+		t.AppendRows([]table.Row{{
+			deployment.Job, deployment.WorkerIP, deployment.AllocID, deployment.Rollout,
+			deployment.CurrentHash, deployment.PreviousHash,
+			deployment.CurrentVersion, deployment.NewVersion,
+			boolInt(deployment.Disabled), boolInt(deployment.Removed),
+		}})
+	}
+	t.Render()
+
+	return nil
+}
+
+// DeploymentRows returns the allocations of jobsCSV on workersCSV (empty filters match
+// all), only enabled and not removed ones with activeOnly, ordered by job and worker.
+func DeploymentRows(jobsCSV, workersCSV string, activeOnly bool) ([]DeploymentRow, error) {
 	db, err := data.OpenDatabase(true)
 	if err != nil {
-		return bucket.DatabaseError(err)
+		return nil, bucket.DatabaseError(err)
 	}
 
 	tx, err := db.Begin()
 	if err != nil {
-		return bucket.DatabaseError(err)
+		return nil, bucket.DatabaseError(err)
 	}
 	defer func() {
 		_ = tx.Rollback()
@@ -34,13 +77,14 @@ func Deployments(jobsCSV, workersCSV string, activeOnly bool) error {
 	workersFilter := parseCSVFilter(workersCSV)
 
 	if err := validateDeploymentFilters(tx, jobsFilter, workersFilter); err != nil {
-		return err
+		return nil, err
 	}
 
 	query := `
 		SELECT a.alloc_id, a.worker_ip, a.job, a.disabled, a.removed,
 		       ifnull(h.current_hash, ''), ifnull(h.previous_hash, ''),
-		       ifnull(h.current_version, ''), ifnull(a.new_version, '')
+		       ifnull(h.current_version, ''), ifnull(a.new_version, ''),
+		       ifnull(h.rolled_back_hash, ''), ifnull(h.cancelled_hash, '')
 		FROM allocations a
 		LEFT JOIN hash h ON h.namespace = (a.job || '_allocation') AND h.key = a.alloc_id`
 
@@ -59,58 +103,65 @@ func Deployments(jobsCSV, workersCSV string, activeOnly bool) error {
 	}
 	query += " ORDER BY a.job, a.worker_ip"
 
+	rollouts, err := data.ListRolloutStates(tx)
+	if err != nil {
+		return nil, err
+	}
+
 	rows, err := tx.Query(query)
 	if err != nil {
-		return bucket.DatabaseError(err)
+		return nil, bucket.DatabaseError(err)
 	}
 	defer func() {
 		_ = rows.Close()
 	}()
 
-	t := utils.GetTable(table.Row{
-		"job", "worker_ip", "alloc_id", "rollout",
-		"current_hash", "previous_hash",
-		"current_version", "new_version",
-		"disabled", "removed",
-	})
-
-	rowCount := 0
+	var deployments []DeploymentRow
 	for rows.Next() {
 		var (
 			allocID, workerIP, job     string
 			disabled, removed          int
 			currentHash, previousHash  string
 			currentVersion, newVersion string
+			rolledBackHash             string
+			cancelledHash              string
 		)
 		if err := rows.Scan(
 			&allocID, &workerIP, &job, &disabled, &removed,
 			&currentHash, &previousHash, &currentVersion, &newVersion,
+			&rolledBackHash, &cancelledHash,
 		); err != nil {
-			return bucket.DatabaseError(err)
+			return nil, bucket.DatabaseError(err)
 		}
 
-		t.AppendRows([]table.Row{{
-			job, workerIP, allocID,
-			rolloutStatus(disabled, removed, currentHash, previousHash, currentVersion, newVersion),
-			currentHash, previousHash,
-			displayVersion(currentVersion), displayVersion(newVersion),
-			disabled, removed,
-		}})
-		rowCount++
+		deployments = append(deployments, DeploymentRow{
+			Job: job, WorkerIP: workerIP, AllocID: allocID,
+			Rollout: markRolloutPaused(
+				markCancelled(
+					markRolledBack(
+						rolloutStatus(disabled, removed, currentHash, previousHash, currentVersion, newVersion),
+						currentHash, rolledBackHash,
+					),
+					currentHash, cancelledHash,
+				),
+				rollouts[job], workerIP,
+			),
+			CurrentHash: currentHash, PreviousHash: previousHash,
+			CurrentVersion: displayVersion(currentVersion), NewVersion: displayVersion(newVersion),
+			Disabled: disabled == 1, Removed: removed == 1,
+		})
 	}
 	if err := data.RowsErr(rows); err != nil {
-		return err
+		return nil, err
 	}
-	if rowCount == 0 {
-		return bucket.NotFoundError("deployments")
+	if len(deployments) == 0 {
+		return nil, bucket.NotFoundError("deployments")
 	}
 
-	t.Render()
-
 	if err := tx.Commit(); err != nil {
-		return bucket.DatabaseError(err)
+		return nil, bucket.DatabaseError(err)
 	}
-	return nil
+	return deployments, nil
 }
 
 func parseCSVFilter(csv string) []string {
@@ -181,6 +232,39 @@ func rolloutStatus(disabled, removed int, currentHash, previousHash, currentVers
 	return "promoted"
 }
 
+// markRolledBack reports a pending restart as rolled_back when the staged hash is the one a
+// rollback_on_failure deploy gave up on.
+func markRolledBack(status, currentHash, rolledBackHash string) string {
+	if status == "restart" && rolledBackHash != "" && rolledBackHash == currentHash {
+		return "rolled_back"
+	}
+	return status
+}
+
+// markCancelled reports a pending start or restart as cancelled when a deploy was stopped by
+// SIGINT or SIGTERM before promoting the staged hash.
+func markCancelled(status, currentHash, cancelledHash string) string {
+	if (status == "new" || status == "restart") && cancelledHash != "" && cancelledHash == currentHash {
+		return "cancelled"
+	}
+	return status
+}
+
+// markRolloutPaused reports canary for the canaries of a paused rollout and paused for
+// allocations waiting on maand rollout promote.
+func markRolloutPaused(status string, state *data.RolloutState, workerIP string) string {
+	if state == nil || state.Status != data.RolloutStatusPaused {
+		return status
+	}
+	if _, ok := state.Canaries[workerIP]; ok {
+		return "canary"
+	}
+	if status == "new" || status == "restart" {
+		return "paused"
+	}
+	return status
+}
+
 func displayVersion(version string) string {
 	if version == "" {
 		return data.DefaultAllocationVersion
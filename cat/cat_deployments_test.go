@@ -62,6 +62,19 @@ func TestDeploymentsShowsAllocationHashState(t *testing.T) {
 	assert.Equal(t, "removed", rolloutStatus(0, 1, "", "", "", ""))
 	assert.Equal(t, "disabled", rolloutStatus(1, 0, "same", "same", "1.0.0", "1.0.0"))
 	assert.Equal(t, "disabled_restart", rolloutStatus(1, 0, "same", "same", "1.0.0", "2.0.0"))
+	assert.Equal(t, "rolled_back", markRolledBack("restart", "hash-new", "hash-new"))
+	assert.Equal(t, "restart", markRolledBack("restart", "hash-newer", "hash-new"))
+	assert.Equal(t, "promoted", markRolledBack("promoted", "same", ""))
+	assert.Equal(t, "cancelled", markCancelled("restart", "hash-new", "hash-new"))
+	assert.Equal(t, "cancelled", markCancelled("new", "hash-new", "hash-new"))
+	assert.Equal(t, "restart", markCancelled("restart", "hash-newer", "hash-new"))
+	assert.Equal(t, "promoted", markCancelled("promoted", "same", "same"))
+
+	paused := &data.RolloutState{Status: data.RolloutStatusPaused, Canaries: map[string]string{"10.0.0.1": "hash-new"}}
+	assert.Equal(t, "canary", markRolloutPaused("restart", paused, "10.0.0.1"))
+	assert.Equal(t, "paused", markRolloutPaused("restart", paused, "10.0.0.2"))
+	assert.Equal(t, "promoted", markRolloutPaused("promoted", paused, "10.0.0.3"))
+	assert.Equal(t, "restart", markRolloutPaused("restart", nil, "10.0.0.2"))
 
 	require.NoError(t, Deployments("vault", "10.0.0.1", false))
 }
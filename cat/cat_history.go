@@ -0,0 +1,305 @@
+// Copyright 2025 Kiruba Sankar Swaminathan. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package cat
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"maand/bucket"
+	"maand/data"
+	"maand/utils"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+)
+
+// HistoryJobRow is one job staged by a deploy run, for maand cat history. A run that
+// staged no job has one row with an empty job and result. Times are RFC 3339 in UTC;
+// finished_at is empty while the run is in progress.
+type HistoryJobRow struct {
+	RunID         string   `json:"run_id"`
+	StartedAt     string   `json:"started_at"`
+	FinishedAt    string   `json:"finished_at"`
+	Status        string   `json:"status"`
+	JobsFilter    []string `json:"jobs_filter"`
+	WorkersFilter []string `json:"workers_filter"`
+	Force         bool     `json:"force"`
+	SyncOnly      bool     `json:"sync_only"`
+	Job           string   `json:"job"`
+	Result        string   `json:"result"`
+	Versions      string   `json:"versions"`
+	Allocations   int      `json:"allocations"`
+	Error         string   `json:"error"`
+}
+
+// HistoryAllocationRow is one allocation of a job staged by a deploy run, for maand cat
+// history --allocations: the promoted (old) and staged (new) version and hash.
+type HistoryAllocationRow struct {
+	RunID      string `json:"run_id"`
+	StartedAt  string `json:"started_at"`
+	Job        string `json:"job"`
+	Result     string `json:"result"`
+	WorkerIP   string `json:"worker_ip"`
+	AllocID    string `json:"alloc_id"`
+	OldVersion string `json:"old_version"`
+	NewVersion string `json:"new_version"`
+	OldHash    string `json:"old_hash"`
+	NewHash    string `json:"new_hash"`
+}
+
+// History prints recorded deploy runs, one row per job (or per allocation with
+// allocations), oldest first. since accepts a duration (24h, 7d), a date, or RFC 3339 time.
+func History(jobsCSV, since string, allocations bool) error {
+	runs, err := deployRuns(jobsCSV, since)
+	if err != nil {
+		return err
+	}
+
+	if allocations {
+		renderHistoryAllocations(runs)
+	} else {
+		renderHistoryJobs(runs)
+	}
+	return nil
+}
+
+// HistoryJobRows returns the History rows of each job, oldest run first.
+func HistoryJobRows(jobsCSV, since string) ([]HistoryJobRow, error) {
+	runs, err := deployRuns(jobsCSV, since)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []HistoryJobRow
+	for _, run := range runs {
+		row := HistoryJobRow{
+			RunID:         run.RunID,
+			StartedAt:     formatRecordTime(run.StartedAt),
+			FinishedAt:    formatRecordTime(run.FinishedAt),
+			Status:        run.Status,
+			JobsFilter:    nonNilStrings(run.JobsFilter),
+			WorkersFilter: nonNilStrings(run.WorkersFilter),
+			Force:         run.Force,
+			SyncOnly:      run.SyncOnly,
+		}
+		if len(run.Jobs) == 0 {
+			row.Error = run.Error
+			result = append(result, row)
+			continue
+		}
+		for _, job := range run.Jobs {
+			row.Job = job.Job
+			row.Result = job.Result
+			row.Versions = formatVersionChanges(job.Allocations)
+			row.Allocations = len(job.Allocations)
+			row.Error = job.Error
+			result = append(result, row)
+		}
+	}
+	return result, nil
+}
+
+// HistoryAllocationRows returns the History rows of each allocation, oldest run first.
+func HistoryAllocationRows(jobsCSV, since string) ([]HistoryAllocationRow, error) {
+	runs, err := deployRuns(jobsCSV, since)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []HistoryAllocationRow
+	for _, run := range runs {
+		for _, job := range run.Jobs {
+			for _, alloc := range job.Allocations {
+				result = append(result, HistoryAllocationRow{
+					RunID:      run.RunID,
+					StartedAt:  formatRecordTime(run.StartedAt),
+					Job:        job.Job,
+					Result:     job.Result,
+					WorkerIP:   alloc.WorkerIP,
+					AllocID:    alloc.AllocID,
+					OldVersion: displayVersion(alloc.OldVersion),
+					NewVersion: displayVersion(alloc.NewVersion),
+					OldHash:    alloc.OldHash,
+					NewHash:    alloc.NewHash,
+				})
+			}
+		}
+	}
+	return result, nil
+}
+
+func deployRuns(jobsCSV, since string) ([]data.DeployRun, error) {
+	sinceTime, err := parseSince(since, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := data.OpenDatabase(true)
+	if err != nil {
+		return nil, bucket.DatabaseError(err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, bucket.DatabaseError(err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	runs, err := data.ListDeployRuns(tx, parseCSVFilter(jobsCSV), sinceTime)
+	if err != nil {
+		return nil, err
+	}
+	if len(runs) == 0 {
+		return nil, bucket.NotFoundError("deploy history")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, bucket.DatabaseError(err)
+	}
+	return runs, nil
+}
+
+func renderHistoryJobs(runs []data.DeployRun) {
+	t := utils.GetTable(table.Row{
+		"run_id", "started_at", "duration", "status", "options",
+		"job", "result", "versions", "allocations", "error",
+	})
+	for _, run := range runs {
+		prefix := table.Row{
+			run.RunID, formatHistoryTime(run.StartedAt), formatRunDuration(run),
+			run.Status, formatRunOptions(run),
+		}
+		if len(run.Jobs) == 0 {
+			t.AppendRow(append(prefix, "-", "-", "", 0, oneLine(run.Error)))
+			continue
+		}
+		for _, job := range run.Jobs {
+			t.AppendRow(append(append(table.Row{}, prefix...),
+				job.Job, job.Result, formatVersionChanges(job.Allocations),
+				len(job.Allocations), oneLine(job.Error),
+			))
+		}
+	}
+	t.Render()
+}
+
+func renderHistoryAllocations(runs []data.DeployRun) {
+	t := utils.GetTable(table.Row{
+		"run_id", "started_at", "job", "result", "worker_ip", "alloc_id",
+		"old_version", "new_version", "old_hash", "new_hash",
+	})
+	for _, run := range runs {
+		for _, job := range run.Jobs {
+			for _, alloc := range job.Allocations {
+				t.AppendRow(table.Row{
+					run.RunID, formatHistoryTime(run.StartedAt), job.Job, job.Result,
+					alloc.WorkerIP, alloc.AllocID,
+					displayVersion(alloc.OldVersion), displayVersion(alloc.NewVersion),
+					alloc.OldHash, alloc.NewHash,
+				})
+			}
+		}
+	}
+	t.Render()
+}
+
+// parseSince turns the --since value into a start time: a Go duration or N days (7d) before
+// now, a date (2006-01-02), or an RFC 3339 time. Empty means no lower bound.
+func parseSince(raw string, now time.Time) (time.Time, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return time.Time{}, nil
+	}
+	if days, ok := strings.CutSuffix(raw, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(raw); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", raw, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid --since %q: use a duration (24h, 7d), a date (2006-01-02), or an RFC 3339 time", raw)
+}
+
+func formatHistoryTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Local().Format(time.RFC3339)
+}
+
+// formatRecordTime is formatHistoryTime for JSON, YAML and CSV output: UTC, so it does not
+// depend on the host's zone.
+func formatRecordTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func nonNilStrings(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
+func formatRunDuration(run data.DeployRun) string {
+	if run.StartedAt.IsZero() || run.FinishedAt.IsZero() {
+		return ""
+	}
+	return run.FinishedAt.Sub(run.StartedAt).Truncate(time.Second).String()
+}
+
+func formatRunOptions(run data.DeployRun) string {
+	var options []string
+	if len(run.JobsFilter) > 0 {
+		options = append(options, "jobs="+strings.Join(run.JobsFilter, ","))
+	}
+	if len(run.WorkersFilter) > 0 {
+		options = append(options, "workers="+strings.Join(run.WorkersFilter, ","))
+	}
+	if run.Force {
+		options = append(options, "force")
+	}
+	if run.SyncOnly {
+		options = append(options, "sync-only")
+	}
+	return strings.Join(options, " ")
+}
+
+// formatVersionChanges lists each distinct old->new version pair of a job's allocations.
+func formatVersionChanges(allocations []data.DeployRunAllocation) string {
+	var changes []string
+	for _, alloc := range allocations {
+		oldVersion, newVersion := displayVersion(alloc.OldVersion), displayVersion(alloc.NewVersion)
+		change := newVersion
+		if alloc.OldHash == "" {
+			change = "new " + newVersion
+		} else if oldVersion != newVersion {
+			change = oldVersion + " -> " + newVersion
+		}
+		changes = append(changes, change)
+	}
+	return strings.Join(utils.Unique(changes), ", ")
+}
+
+// oneLine keeps multi-line deploy errors to a single table row.
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
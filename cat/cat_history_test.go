@@ -0,0 +1,59 @@
+// Copyright 2025 Kiruba Sankar Swaminathan. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package cat
+
+import (
+	"testing"
+	"time"
+
+	"maand/data"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2025, 6, 10, 12, 0, 0, 0, time.UTC)
+
+	since, err := parseSince("", now)
+	require.NoError(t, err)
+	assert.True(t, since.IsZero())
+
+	since, err = parseSince("36h", now)
+	require.NoError(t, err)
+	assert.Equal(t, now.Add(-36*time.Hour), since)
+
+	since, err = parseSince("7d", now)
+	require.NoError(t, err)
+	assert.Equal(t, now.AddDate(0, 0, -7), since)
+
+	since, err = parseSince("2025-06-01T08:00:00Z", now)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 6, 1, 8, 0, 0, 0, time.UTC), since)
+
+	since, err = parseSince("2025-06-01", now)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 6, 1, 0, 0, 0, 0, time.Local), since)
+
+	_, err = parseSince("last week", now)
+	require.Error(t, err)
+}
+
+func TestFormatHistoryColumns(t *testing.T) {
+	assert.Equal(t, "new 1.0, 1.0 -> 1.1, 1.1", formatVersionChanges([]data.DeployRunAllocation{
+		{OldHash: "", NewVersion: "1.0"},
+		{OldHash: "a", OldVersion: "1.0", NewVersion: "1.1"},
+		{OldHash: "a", OldVersion: "1.1", NewVersion: "1.1"},
+		{OldHash: "b", OldVersion: "1.0", NewVersion: "1.1"},
+	}))
+	assert.Equal(t, "jobs=api,web force sync-only", formatRunOptions(data.DeployRun{
+		JobsFilter: []string{"api", "web"}, Force: true, SyncOnly: true,
+	}))
+	assert.Equal(t, "workers=10.0.0.1", formatRunOptions(data.DeployRun{
+		WorkersFilter: []string{"10.0.0.1"},
+	}))
+	assert.Equal(t, "", formatRunOptions(data.DeployRun{}))
+	assert.Equal(t, "deploy failed: job api: boom", oneLine("deploy failed:\njob api:  boom\n"))
+}
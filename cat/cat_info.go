@@ -11,16 +11,46 @@ import (
 	"github.com/jedib0t/go-pretty/v6/table"
 )
 
+// InfoRow is the bucket summary of maand info.
+type InfoRow struct {
+	BucketID    string `json:"bucket_id"`
+	UpdateSeq   int    `json:"update_seq"`
+	Workers     int    `json:"workers"`
+	Jobs        int    `json:"jobs"`
+	Allocations int    `json:"allocations"`
+}
+
 func Info() error {
-	db, err := data.OpenDatabase(true)
+	info, err := BucketInfo()
 	if err != nil {
 		return err
 	}
+
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"Description", "Value"})
+	t.SetStyle(table.StyleRounded)
+	t.AppendRow(table.Row{"Bucket ID", info.BucketID})
+	t.AppendRow(table.Row{"Update Sequence", info.UpdateSeq})
+	t.AppendRow(table.Row{"Number of Workers", info.Workers})
+	t.AppendRow(table.Row{"Number of Jobs", info.Jobs})
+	t.AppendRow(table.Row{"Number of Allocations", info.Allocations})
+	t.Render()
+
+	return nil
+}
+
+// BucketInfo returns the bucket ID, update sequence, and worker, job and allocation counts.
+func BucketInfo() (InfoRow, error) {
+	db, err := data.OpenDatabase(true)
+	if err != nil {
+		return InfoRow{}, err
+	}
 	defer db.Close()
 
 	tx, err := db.Begin()
 	if err != nil {
-		return err
+		return InfoRow{}, err
 	}
 	defer func() {
 		_ = tx.Rollback()
@@ -28,39 +58,34 @@ func Info() error {
 
 	bucketID, err := data.GetBucketID(tx)
 	if err != nil {
-		return err
+		return InfoRow{}, err
 	}
 
 	updateSeq, err := data.GetBucketUpdateSeq(tx)
 	if err != nil {
-		return err
+		return InfoRow{}, err
 	}
 
 	workers, err := data.GetWorkers(tx, nil)
 	if err != nil {
-		return err
+		return InfoRow{}, err
 	}
 
 	jobs, err := data.GetJobs(tx)
 	if err != nil {
-		return err
+		return InfoRow{}, err
 	}
 
 	allocations, err := data.CountAllocations(tx, true)
 	if err != nil {
-		return err
+		return InfoRow{}, err
 	}
 
-	t := table.NewWriter()
-	t.SetOutputMirror(os.Stdout)
-	t.AppendHeader(table.Row{"Description", "Value"})
-	t.SetStyle(table.StyleRounded)
-	t.AppendRow(table.Row{"Bucket ID", bucketID})
-	t.AppendRow(table.Row{"Update Sequence", updateSeq})
-	t.AppendRow(table.Row{"Number of Workers", len(workers)})
-	t.AppendRow(table.Row{"Number of Jobs", len(jobs)})
-	t.AppendRow(table.Row{"Number of Allocations", allocations})
-	t.Render()
-
-	return nil
+	return InfoRow{
+		BucketID:    bucketID,
+		UpdateSeq:   updateSeq,
+		Workers:     len(workers),
+		Jobs:        len(jobs),
+		Allocations: allocations,
+	}, nil
 }
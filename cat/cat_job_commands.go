@@ -15,17 +15,43 @@ import (
 	"github.com/jedib0t/go-pretty/v6/table"
 )
 
+// JobCommandRow is one command of a job manifest, for maand cat job_commands.
+type JobCommandRow struct {
+	Job           string `json:"job"`
+	Command       string `json:"command"`
+	ExecutedOn    string `json:"executed_on"`
+	DemandJob     string `json:"demand_job"`
+	DemandCommand string `json:"demand_command"`
+	DemandConfig  string `json:"demand_config"`
+}
+
 func JobCommands() error {
+	commands, err := JobCommandRows()
+	if err != nil {
+		return err
+	}
+
+	t := utils.GetTable(table.Row{"job", "command_name", "executed_on", "demand_job", "demand_command", "demand_config"})
+	for _, command := range commands {
+		t.AppendRows([]table.Row{{command.Job, command.Command, command.ExecutedOn, command.DemandJob, command.DemandCommand, command.DemandConfig}})
+	}
+	t.Render()
+
+	return nil
+}
+
+// JobCommandRows returns every job command in the catalog, by job and command name.
+func JobCommandRows() ([]JobCommandRow, error) {
 	// TODO: demand job filter
 
 	db, err := data.OpenDatabase(true)
 	if err != nil {
-		return bucket.DatabaseError(err)
+		return nil, bucket.DatabaseError(err)
 	}
 
 	tx, err := db.Begin()
 	if err != nil {
-		return bucket.DatabaseError(err)
+		return nil, bucket.DatabaseError(err)
 	}
 
 	defer func() {
@@ -37,47 +63,37 @@ func JobCommands() error {
 	row := tx.QueryRow(query)
 	err = row.Scan(&jobCommandsCount)
 	if errors.Is(err, sql.ErrNoRows) || jobCommandsCount == 0 {
-		return bucket.NotFoundError("job commands")
+		return nil, bucket.NotFoundError("job commands")
 	}
 	if err != nil {
-		return bucket.DatabaseError(err)
+		return nil, bucket.DatabaseError(err)
 	}
 
 	rows, err := tx.Query(`SELECT job, command_name, executed_on, demand_job, demand_command, demand_config FROM cat_job_commands`)
 	if err != nil {
-		return bucket.DatabaseError(err)
+		return nil, bucket.DatabaseError(err)
 	}
 	defer func() {
 		_ = rows.Close()
 	}()
 
-	t := utils.GetTable(table.Row{"job", "command_name", "executed_on", "demand_job", "demand_command", "demand_config"})
-
+	var commands []JobCommandRow
 	for rows.Next() {
-		var jobName string
-		var commandName string
-		var executedOn string
-		var demandJob string
-		var demandCommand string
-		var demandConfig string
-
-		err = rows.Scan(&jobName, &commandName, &executedOn, &demandJob, &demandCommand, &demandConfig)
+		var command JobCommandRow
+		err = rows.Scan(&command.Job, &command.Command, &command.ExecutedOn, &command.DemandJob, &command.DemandCommand, &command.DemandConfig)
 		if err != nil {
-			return bucket.DatabaseError(err)
+			return nil, bucket.DatabaseError(err)
 		}
-
-		t.AppendRows([]table.Row{{jobName, commandName, executedOn, demandJob, demandCommand, demandConfig}})
+		commands = append(commands, command)
 	}
 	if err := data.RowsErr(rows); err != nil {
-		return err
+		return nil, err
 	}
 
-	t.Render()
-
 	err = tx.Commit()
 	if err != nil {
-		return bucket.DatabaseError(err)
+		return nil, bucket.DatabaseError(err)
 	}
 
-	return nil
+	return commands, nil
 }
@@ -8,6 +8,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 
 	"maand/bucket"
@@ -17,15 +18,58 @@ import (
 	"github.com/jedib0t/go-pretty/v6/table"
 )
 
+// JobRow is one job of maand cat jobs. Resources are the current values build placed with,
+// and the source of each (manifest, or the KV override that set it).
+type JobRow struct {
+	Job           string  `json:"job"`
+	Version       string  `json:"version"`
+	Disabled      bool    `json:"disabled"`
+	DeploymentSeq int     `json:"deployment_seq"`
+	CPUMHz        float64 `json:"cpu_mhz"`
+	CPUSource     string  `json:"cpu_source"`
+	MemoryMB      float64 `json:"memory_mb"`
+	MemorySource  string  `json:"memory_source"`
+	DiskMB        float64 `json:"disk_mb"`
+	DiskSource    string  `json:"disk_source"`
+	Selectors     string  `json:"selectors"`
+}
+
 func Jobs() error {
+	jobs, err := JobRows()
+	if err != nil {
+		return err
+	}
+
+	t := utils.GetTable(table.Row{"job", "version", "disabled", "deployment_seq", "cpu", "memory", "disk", "selectors"})
+	for _, job := range jobs {
+		t.AppendRows([]table.Row{
+			{
+				job.Job,
+				job.Version,
+				boolInt(job.Disabled),
+				job.DeploymentSeq,
+				formatJobCPU(formatFloat(job.CPUMHz), job.CPUSource),
+				formatJobMemory(formatFloat(job.MemoryMB), job.MemorySource),
+				formatJobDisk(formatFloat(job.DiskMB), job.DiskSource),
+				job.Selectors,
+			},
+		})
+	}
+	t.Render()
+
+	return nil
+}
+
+// JobRows returns every job in the catalog, in deployment order.
+func JobRows() ([]JobRow, error) {
 	db, err := data.OpenDatabase(true)
 	if err != nil {
-		return bucket.DatabaseError(err)
+		return nil, bucket.DatabaseError(err)
 	}
 
 	tx, err := db.Begin()
 	if err != nil {
-		return bucket.DatabaseError(err)
+		return nil, bucket.DatabaseError(err)
 	}
 	defer func() {
 		_ = tx.Rollback()
@@ -36,22 +80,21 @@ func Jobs() error {
 	row := tx.QueryRow(query)
 	err = row.Scan(&count)
 	if errors.Is(err, sql.ErrNoRows) || count == 0 {
-		return bucket.NotFoundError("jobs")
+		return nil, bucket.NotFoundError("jobs")
 	}
 	if err != nil {
-		return bucket.DatabaseError(err)
+		return nil, bucket.DatabaseError(err)
 	}
 
-	rows, err := tx.Query(`SELECT job_id, name, version, disabled, deployment_seq, selectors, current_memory_mb, current_memory_source, current_cpu_mhz, current_cpu_source FROM cat_jobs`)
+	rows, err := tx.Query(`SELECT job_id, name, version, disabled, deployment_seq, selectors, current_memory_mb, current_memory_source, current_cpu_mhz, current_cpu_source, current_disk_mb, current_disk_source FROM cat_jobs`)
 	if err != nil {
-		return bucket.DatabaseError(err)
+		return nil, bucket.DatabaseError(err)
 	}
 	defer func() {
 		_ = rows.Close()
 	}()
 
-	t := utils.GetTable(table.Row{"job", "version", "disabled", "deployment_seq", "cpu", "memory", "selectors"})
-
+	var jobs []JobRow
 	for rows.Next() {
 		var jobID string
 		var name string
@@ -63,38 +106,71 @@ func Jobs() error {
 		var memorySource string
 		var cpuMHz string
 		var cpuSource string
+		var diskMB string
+		var diskSource string
 
 		err = rows.Scan(
 			&jobID, &name, &version, &disabled, &deploymentSeq, &selectors,
-			&memoryMB, &memorySource, &cpuMHz, &cpuSource,
+			&memoryMB, &memorySource, &cpuMHz, &cpuSource, &diskMB, &diskSource,
 		)
 		if err != nil {
-			return bucket.DatabaseError(err)
+			return nil, bucket.DatabaseError(err)
 		}
 
-		t.AppendRows([]table.Row{
-			{
-				name,
-				version,
-				disabled,
-				deploymentSeq,
-				formatJobCPU(cpuMHz, cpuSource),
-				formatJobMemory(memoryMB, memorySource),
-				selectors,
-			},
-		})
+		job := JobRow{
+			Job:           name,
+			Version:       version,
+			Disabled:      disabled == 1,
+			DeploymentSeq: deploymentSeq,
+			CPUSource:     jobResourceSource(cpuSource),
+			MemorySource:  jobResourceSource(memorySource),
+			DiskSource:    jobResourceSource(diskSource),
+			Selectors:     selectors,
+		}
+		if job.CPUMHz, err = parseJobResource(name, "cpu", cpuMHz); err != nil {
+			return nil, err
+		}
+		if job.MemoryMB, err = parseJobResource(name, "memory", memoryMB); err != nil {
+			return nil, err
+		}
+		if job.DiskMB, err = parseJobResource(name, "disk", diskMB); err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
 	}
 	if err := data.RowsErr(rows); err != nil {
-		return err
+		return nil, err
 	}
 
-	t.Render()
-
 	if err := tx.Commit(); err != nil {
-		return bucket.DatabaseError(err)
+		return nil, bucket.DatabaseError(err)
 	}
 
-	return nil
+	return jobs, nil
+}
+
+func parseJobResource(job, resource, value string) (float64, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, nil
+	}
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, bucket.DatabaseError(fmt.Errorf("job %s: current %s %q: %w", job, resource, value, err))
+	}
+	return parsed, nil
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func jobResourceSource(source string) string {
+	source = strings.TrimSpace(source)
+	if source == "" {
+		return "manifest"
+	}
+	return source
 }
 
 func formatJobResource(value, unit, source string) string {
@@ -116,3 +192,7 @@ func formatJobCPU(mhz, source string) string {
 func formatJobMemory(mb, source string) string {
 	return formatJobResource(mb, "mb", source)
 }
+
+func formatJobDisk(mb, source string) string {
+	return formatJobResource(mb, "mb", source)
+}
@@ -0,0 +1,108 @@
+// Copyright 2025 Kiruba Sankar Swaminathan. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package cat
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"strings"
+
+	"maand/bucket"
+	"maand/utils"
+	"maand/workspace"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+)
+
+// EffectiveFileRow is one job file of maand cat jobs --effective: the file build reads from
+// the workspace with the selected overlay applied, and where its content comes from.
+type EffectiveFileRow struct {
+	Job    string `json:"job"`
+	Path   string `json:"path"`
+	Source string `json:"source"`
+}
+
+// EffectiveJobs prints the selected overlay and the effective file list of every workspace
+// job, marking each file as base, overlay or patched.
+func EffectiveJobs() error {
+	overlay, err := workspace.OverlayName()
+	if err != nil {
+		return err
+	}
+	files, err := EffectiveFileRows()
+	if err != nil {
+		return err
+	}
+
+	if overlay == "" {
+		overlay = "none"
+	}
+	fmt.Printf("overlay: %s\n", overlay)
+	t := utils.GetTable(table.Row{"job", "path", "source"})
+	for _, file := range files {
+		t.AppendRow(table.Row{file.Job, file.Path, file.Source})
+	}
+	t.Render()
+
+	return nil
+}
+
+// EffectiveFileRows returns the files of every workspace job, in walk order, with paths
+// relative to the job directory.
+func EffectiveFileRows() ([]EffectiveFileRow, error) {
+	jobs, err := workspace.Default().GetJobs()
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]EffectiveFileRow, 0)
+	for _, jobName := range jobs {
+		files, err := workspace.EffectiveJobFiles(jobName)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			rows = append(rows, EffectiveFileRow{
+				Job:    jobName,
+				Path:   strings.TrimPrefix(file.Path, jobName+"/"),
+				Source: file.Source,
+			})
+		}
+	}
+	return rows, nil
+}
+
+// EffectiveJobFile prints the effective content of <job>/<path>, as build would store it.
+func EffectiveJobFile(rel string) error {
+	jobName, name, ok := strings.Cut(rel, "/")
+	if !ok || jobName == "" || name == "" {
+		return fmt.Errorf("%w: --file %q must be <job>/<path>", bucket.ErrInvalidJob, rel)
+	}
+	name = path.Clean(name)
+	if path.IsAbs(name) || name == "." || name == ".." || strings.HasPrefix(name, "../") {
+		return fmt.Errorf("%w: --file %q is outside job %s", bucket.ErrInvalidJob, rel, jobName)
+	}
+	jobs, err := workspace.Default().GetJobs()
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(jobs, jobName) {
+		return fmt.Errorf("%w: job %s not found in workspace", bucket.ErrInvalidJob, jobName)
+	}
+	if err := workspace.ValidateOverlay(); err != nil {
+		return err
+	}
+	content, err := workspace.ReadJobFile(path.Join(jobName, name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("%w: job %s has no file %s", bucket.ErrInvalidJob, jobName, name)
+		}
+		return err
+	}
+	_, err = os.Stdout.Write(content)
+	return err
+}
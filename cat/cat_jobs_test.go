@@ -1,9 +1,15 @@
 package cat
 
 import (
+	"os"
+	"path"
 	"testing"
 
+	"maand/bucket"
+	"maand/initialize"
+
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestFormatJobCPU(t *testing.T) {
@@ -17,3 +23,29 @@ func TestFormatJobMemory(t *testing.T) {
 	assert.Equal(t, "256 mb (manifest)", formatJobMemory("256", "manifest"))
 	assert.Equal(t, "0 mb (manifest)", formatJobMemory("0", ""))
 }
+
+func TestEffectiveJobFileStaysInsideJob(t *testing.T) {
+	root := t.TempDir()
+	orig := bucket.Location
+	bucket.Location = root
+	bucket.UpdatePath()
+	t.Cleanup(func() {
+		bucket.Location = orig
+		bucket.UpdatePath()
+	})
+	require.NoError(t, initialize.Execute())
+	jobDir := path.Join(bucket.WorkspaceLocation, "jobs", "api")
+	require.NoError(t, os.MkdirAll(path.Join(jobDir, "config"), 0o755))
+	require.NoError(t, os.WriteFile(path.Join(jobDir, "manifest.json"), []byte(`{"version": "1.0.0"}`), 0o644))
+	require.NoError(t, os.WriteFile(path.Join(jobDir, "config", "app.conf"), []byte("port = 80\n"), 0o644))
+
+	out := captureStdout(t, func() {
+		require.NoError(t, EffectiveJobFile("api/config/../config/app.conf"))
+	})
+	assert.Equal(t, "port = 80\n", out)
+
+	for _, rel := range []string{"api/../../workers.json", "api/..", "api/config/../../../maand.conf", "../workspace/workers.json"} {
+		err := EffectiveJobFile(rel)
+		assert.ErrorIs(t, err, bucket.ErrInvalidJob, rel)
+	}
+}
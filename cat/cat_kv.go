@@ -18,10 +18,40 @@ import (
 	"github.com/jedib0t/go-pretty/v6/table"
 )
 
+// KVRow is one key of maand cat kv: its latest version, with encrypted values shown as
+// [encrypted]. The table cuts long values to 50 characters; the records carry them whole.
+type KVRow struct {
+	Namespace   string `json:"namespace"`
+	Key         string `json:"key"`
+	Value       string `json:"value"`
+	Version     int    `json:"version"`
+	TTL         int    `json:"ttl"`
+	CreatedDate string `json:"created_date"`
+	Deleted     bool   `json:"deleted"`
+}
+
 func KV(jobsCSV string, activeOnly, deletedOnly bool) error {
+	entries, err := KVRows(jobsCSV, activeOnly, deletedOnly)
+	if err != nil {
+		return err
+	}
+
+	t := utils.GetTable(table.Row{"Namespace", "Key", "Value", "Version", "ttl", "createdDate", "deleted"})
+	for _, entry := range entries {
+		value := shortKVValue(entry.Key, entry.Value)
+		t.AppendRows([]table.Row{{entry.Namespace, entry.Key, value, entry.Version, entry.TTL, entry.CreatedDate, boolInt(entry.Deleted)}})
+	}
+	t.Render()
+
+	return nil
+}
+
+// KVRows returns the keys readable by jobsCSV (all keys when empty), only live keys with
+// activeOnly or only deleted keys with deletedOnly.
+func KVRows(jobsCSV string, activeOnly, deletedOnly bool) ([]KVRow, error) {
 	db, err := data.OpenDatabase(true)
 	if err != nil {
-		return bucket.DatabaseError(err)
+		return nil, bucket.DatabaseError(err)
 	}
 	defer func() {
 		_ = db.Close()
@@ -29,7 +59,7 @@ func KV(jobsCSV string, activeOnly, deletedOnly bool) error {
 
 	tx, err := db.Begin()
 	if err != nil {
-		return bucket.DatabaseError(err)
+		return nil, bucket.DatabaseError(err)
 	}
 
 	defer func() {
@@ -38,82 +68,107 @@ func KV(jobsCSV string, activeOnly, deletedOnly bool) error {
 
 	jobsFilter := parseCSVFilter(jobsCSV)
 	if err := validateKVJobFilter(tx, jobsFilter); err != nil {
-		return err
+		return nil, err
 	}
 
 	namespaces, err := jobKVListNamespaces(tx, jobsFilter)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if len(jobsFilter) > 0 && len(namespaces) == 0 {
-		return bucket.NotFoundError("key values")
+		return nil, bucket.NotFoundError("key values")
 	}
 
 	where, err := kvListWhere(activeOnly, deletedOnly, namespaces)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
+	// cat_kv cuts values for display, so the rows read key_value for the whole value.
+	latest := `SELECT * FROM (
+		SELECT namespace, key, value, max(version) AS version, ttl, created_date, deleted
+		FROM key_value GROUP BY namespace, key
+	) t` + where
+
 	count := 0
-	row := tx.QueryRow("SELECT count(*) FROM cat_kv" + where)
+	row := tx.QueryRow("SELECT count(*) FROM (" + latest + ")")
 	err = row.Scan(&count)
 	if errors.Is(err, sql.ErrNoRows) || count == 0 {
-		return bucket.NotFoundError("key values")
+		return nil, bucket.NotFoundError("key values")
 	}
 	if err != nil {
-		return bucket.DatabaseError(err)
+		return nil, bucket.DatabaseError(err)
 	}
 
-	rows, err := tx.Query(`SELECT namespace, key, value, version, ttl, created_date, deleted FROM cat_kv` + where)
+	rows, err := tx.Query(latest + " ORDER BY namespace, key")
 	if err != nil {
-		return bucket.DatabaseError(err)
+		return nil, bucket.DatabaseError(err)
 	}
 	defer func() {
 		_ = rows.Close()
 	}()
 
-	t := utils.GetTable(table.Row{"Namespace", "Key", "Value", "Version", "ttl", "createdDate", "deleted"})
-
+	var entries []KVRow
 	for rows.Next() {
-		var namespace string
-		var key string
-		var value string
-		var version string
-		var ttl int
-		var createdDate string
+		var entry KVRow
 		var deleted int
 
-		err = rows.Scan(&namespace, &key, &value, &version, &ttl, &createdDate, &deleted)
+		err = rows.Scan(&entry.Namespace, &entry.Key, &entry.Value, &entry.Version, &entry.TTL, &entry.CreatedDate, &deleted)
 		if err != nil {
-			return bucket.DatabaseError(err)
+			return nil, bucket.DatabaseError(err)
 		}
 
-		if strings.HasPrefix(key, "certs/") {
-			value = strings.Split(value, "\n")[0]
-		}
-		if kv.IsEncryptedValue(value) {
-			value = "[encrypted]"
+		if kv.IsEncryptedValue(entry.Value) {
+			entry.Value = "[encrypted]"
 		}
-
-		t.AppendRows([]table.Row{{namespace, key, value, version, ttl, createdDate, deleted}})
+		entry.Deleted = deleted == 1
+		entries = append(entries, entry)
 	}
 	if err := data.RowsErr(rows); err != nil {
-		return err
+		return nil, err
 	}
 
-	t.Render()
-
 	if err := tx.Commit(); err != nil {
-		return bucket.DatabaseError(err)
+		return nil, bucket.DatabaseError(err)
 	}
 
-	return nil
+	return entries, nil
+}
+
+// shortKVValue is value as the KV table shows it, like the cat_kv view: certificates by
+// their first line and other values cut to 50 characters.
+func shortKVValue(key, value string) string {
+	if strings.HasPrefix(key, "certs/") {
+		return strings.Split(value, "\n")[0]
+	}
+	if runes := []rune(value); len(runes) > 50 {
+		return string(runes[:50]) + "..."
+	}
+	return value
 }
 
 func KVGet(namespace, key string, reveal bool) error {
+	entry, err := KVGetRow(namespace, key, reveal)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("namespace: %s\n", entry.Namespace)
+	fmt.Printf("key: %s\n", entry.Key)
+	fmt.Printf("value: %s\n", entry.Value)
+	fmt.Printf("version: %d\n", entry.Version)
+	fmt.Printf("ttl: %d\n", entry.TTL)
+	fmt.Printf("created_date: %s\n", entry.CreatedDate)
+
+	return nil
+}
+
+// KVGetRow returns the latest version of a live key. Encrypted values are decrypted with
+// reveal and shown as [encrypted] otherwise; values are never cut.
+func KVGetRow(namespace, key string, reveal bool) (KVRow, error) {
 	db, err := data.OpenDatabase(true)
 	if err != nil {
-		return bucket.DatabaseError(err)
+		return KVRow{}, bucket.DatabaseError(err)
 	}
 	defer func() {
 		_ = db.Close()
@@ -121,49 +176,41 @@ func KVGet(namespace, key string, reveal bool) error {
 
 	tx, err := db.Begin()
 	if err != nil {
-		return bucket.DatabaseError(err)
+		return KVRow{}, bucket.DatabaseError(err)
 	}
 	defer func() {
 		_ = tx.Rollback()
 	}()
 
-	var value string
-	var version, ttl, deleted int
-	var createdDate string
+	entry := KVRow{Namespace: namespace, Key: key}
+	var deleted int
 
 	row := tx.QueryRow(`
 		SELECT value, max(version), ttl, created_date, deleted
 		FROM key_value
 		WHERE namespace = ? AND key = ?
 		GROUP BY namespace, key`, namespace, key)
-	err = row.Scan(&value, &version, &ttl, &createdDate, &deleted)
+	err = row.Scan(&entry.Value, &entry.Version, &entry.TTL, &entry.CreatedDate, &deleted)
 	if errors.Is(err, sql.ErrNoRows) {
-		return bucket.KeyNotFoundError(namespace, key)
+		return KVRow{}, bucket.KeyNotFoundError(namespace, key)
 	}
 	if err != nil {
-		return bucket.DatabaseError(err)
+		return KVRow{}, bucket.DatabaseError(err)
 	}
 	if deleted == 1 {
-		return bucket.KeyNotFoundError(namespace, key)
+		return KVRow{}, bucket.KeyNotFoundError(namespace, key)
 	}
 
-	value, err = formatKVValue(namespace, key, value, reveal)
+	entry.Value, err = formatKVValue(namespace, key, entry.Value, reveal)
 	if err != nil {
-		return err
+		return KVRow{}, err
 	}
 
-	fmt.Printf("namespace: %s\n", namespace)
-	fmt.Printf("key: %s\n", key)
-	fmt.Printf("value: %s\n", value)
-	fmt.Printf("version: %d\n", version)
-	fmt.Printf("ttl: %d\n", ttl)
-	fmt.Printf("created_date: %s\n", createdDate)
-
 	if err := tx.Commit(); err != nil {
-		return bucket.DatabaseError(err)
+		return KVRow{}, bucket.DatabaseError(err)
 	}
 
-	return nil
+	return entry, nil
 }
 
 func validateKVJobFilter(tx *sql.Tx, jobsFilter []string) error {
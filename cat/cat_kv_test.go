@@ -3,6 +3,7 @@ package cat
 import (
 	"io"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -108,6 +109,51 @@ func TestKVActiveOnlyNotFoundWhenAllDeleted(t *testing.T) {
 	assert.NoError(t, KV("", false, true))
 }
 
+func TestKVRowsKeepWholeValues(t *testing.T) {
+	root := t.TempDir()
+	orig := bucket.Location
+	bucket.Location = root
+	bucket.UpdatePath()
+	t.Cleanup(func() {
+		bucket.Location = orig
+		bucket.UpdatePath()
+	})
+
+	require.NoError(t, initialize.Execute())
+	require.NoError(t, kv.EnsureEncryptionKey())
+	kv.ResetEncryptionKeyCacheForTest()
+	t.Cleanup(kv.ResetEncryptionKeyCacheForTest)
+	encrypted, err := kv.EncryptPlaintext("root-token-secret")
+	require.NoError(t, err)
+
+	db, err := data.OpenDatabase(true)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	long := strings.Repeat("0123456789", 8)
+	_, err = db.Exec(
+		`INSERT INTO key_value (namespace, key, value, version, ttl, created_date, deleted)
+		 VALUES ('vars/bucket', 'long', 'old', 1, 0, 1, 0),
+			('vars/bucket', 'long', ?, 2, 0, 2, 0),
+			('secrets/job/vault', 'root_token', ?, 1, 0, 1, 0)`,
+		long, encrypted,
+	)
+	require.NoError(t, err)
+
+	entries, err := KVRows("", false, false)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "[encrypted]", entries[0].Value)
+	assert.Equal(t, long, entries[1].Value)
+	assert.Equal(t, 2, entries[1].Version)
+
+	stdout := captureStdout(t, func() {
+		require.NoError(t, KV("", false, false))
+	})
+	assert.Contains(t, stdout, long[:50]+"...")
+	assert.NotContains(t, stdout, long)
+}
+
 func TestKVJobFilter(t *testing.T) {
 	root := t.TempDir()
 	orig := bucket.Location
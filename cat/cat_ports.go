@@ -17,15 +17,43 @@ import (
 	"github.com/jedib0t/go-pretty/v6/table"
 )
 
+// JobPortRow is one declared port of a job on one of its workers, for maand cat job_ports.
+// A port of a job without allocations has an empty worker_ip. Conflicts lists the other
+// job ports and reserved_ports on the same worker with the same port.
+type JobPortRow struct {
+	Job       string   `json:"job"`
+	Name      string   `json:"name"`
+	Port      int      `json:"port"`
+	WorkerIP  string   `json:"worker_ip"`
+	Conflicts []string `json:"conflicts"`
+}
+
 func JobPorts(jobsCSV string) error {
+	ports, err := JobPortRows(jobsCSV)
+	if err != nil {
+		return err
+	}
+
+	t := utils.GetTable(table.Row{"job", "name", "port", "worker_ip", "conflict"})
+	for _, port := range ports {
+		t.AppendRows([]table.Row{{port.Job, port.Name, port.Port, port.WorkerIP, strings.Join(port.Conflicts, ", ")}})
+	}
+	t.Render()
+
+	return nil
+}
+
+// JobPortRows returns the declared ports of jobsCSV (all jobs when empty), one row per
+// allocated worker.
+func JobPortRows(jobsCSV string) ([]JobPortRow, error) {
 	db, err := data.OpenDatabase(true)
 	if err != nil {
-		return bucket.DatabaseError(err)
+		return nil, bucket.DatabaseError(err)
 	}
 
 	tx, err := db.Begin()
 	if err != nil {
-		return bucket.DatabaseError(err)
+		return nil, bucket.DatabaseError(err)
 	}
 
 	defer func() {
@@ -41,10 +69,10 @@ func JobPorts(jobsCSV string) error {
 	if len(jobsFilter) > 0 {
 		allJobs, err := data.GetJobs(tx)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		if len(utils.Intersection(allJobs, jobsFilter)) == 0 {
-			return fmt.Errorf("invalid input, jobs %v", jobsFilter)
+			return nil, fmt.Errorf("invalid input, jobs %v", jobsFilter)
 		}
 	}
 
@@ -53,7 +81,7 @@ func JobPorts(jobsCSV string) error {
 	row := tx.QueryRow(query)
 	err = row.Scan(&count)
 	if errors.Is(err, sql.ErrNoRows) || count == 0 {
-		return bucket.NotFoundError("job ports")
+		return nil, bucket.NotFoundError("job ports")
 	}
 
 	sqlQuery := `SELECT j.name as job, jp.name, jp.port FROM job_ports jp JOIN job j ON jp.job_id = j.job_id`
@@ -61,16 +89,37 @@ func JobPorts(jobsCSV string) error {
 		sqlQuery = fmt.Sprintf("%s WHERE j.name IN ('%s')", sqlQuery, strings.Join(jobsFilter, "','"))
 	}
 
+	workerPorts, err := data.ListWorkerPorts(tx)
+	if err != nil {
+		return nil, err
+	}
+	reserved, err := data.ListReservedPorts(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	type portKey struct{ job, name string }
+	workersOf := make(map[portKey][]string)
+	for _, port := range workerPorts {
+		key := portKey{port.Job, port.Name}
+		workersOf[key] = append(workersOf[key], port.WorkerIP)
+	}
+	// Disabled allocations are included, so a conflict shows before the allocation is enabled.
+	conflictsOf := make(map[string][]string)
+	for _, conflict := range data.FindPortConflicts(workerPorts, reserved) {
+		key := conflict.WorkerIP + "/" + conflict.Job + "/" + conflict.Name
+		conflictsOf[key] = append(conflictsOf[key], conflict.Conflict())
+	}
+
 	rows, err := tx.Query(sqlQuery)
 	if err != nil {
-		return bucket.DatabaseError(err)
+		return nil, bucket.DatabaseError(err)
 	}
 	defer func() {
 		_ = rows.Close()
 	}()
 
-	t := utils.GetTable(table.Row{"job", "name", "port"})
-
+	var ports []JobPortRow
 	for rows.Next() {
 		var job string
 		var name string
@@ -78,20 +127,29 @@ func JobPorts(jobsCSV string) error {
 
 		err = rows.Scan(&job, &name, &port)
 		if err != nil {
-			return bucket.DatabaseError(err)
+			return nil, bucket.DatabaseError(err)
 		}
 
-		t.AppendRows([]table.Row{{job, name, port}})
+		workers := workersOf[portKey{job, name}]
+		if len(workers) == 0 {
+			ports = append(ports, JobPortRow{Job: job, Name: name, Port: port, Conflicts: []string{}})
+			continue
+		}
+		for _, workerIP := range workers {
+			conflicts := conflictsOf[workerIP+"/"+job+"/"+name]
+			if conflicts == nil {
+				conflicts = []string{}
+			}
+			ports = append(ports, JobPortRow{Job: job, Name: name, Port: port, WorkerIP: workerIP, Conflicts: conflicts})
+		}
 	}
 	if err := data.RowsErr(rows); err != nil {
-		return err
+		return nil, err
 	}
 
-	t.Render()
-
 	if err := tx.Commit(); err != nil {
-		return bucket.DatabaseError(err)
+		return nil, bucket.DatabaseError(err)
 	}
 
-	return nil
+	return ports, nil
 }
@@ -25,14 +25,47 @@ func initPrometheusCat() error {
 	return bucket.ResolveRoot()
 }
 
+// PrometheusRow is one job of maand cat prometheus: whether it is scraped and how many
+// alert rule files, runbooks and dashboards it ships under _prometheus/.
+type PrometheusRow struct {
+	Job        string `json:"job"`
+	Scrape     bool   `json:"scrape"`
+	Alerts     int    `json:"alerts"`
+	Runbooks   int    `json:"runbooks"`
+	Dashboards int    `json:"dashboards"`
+}
+
 func Prometheus(jobsCSV string) error {
-	if err := initPrometheusCat(); err != nil {
+	summaries, err := PrometheusRows(jobsCSV)
+	if err != nil {
 		return err
 	}
 
+	t := utils.GetTable(table.Row{"job", "scrape", "alerts", "runbooks", "dashboards"})
+	for _, summary := range summaries {
+		t.AppendRows([]table.Row{{
+			summary.Job,
+			boolMark(summary.Scrape),
+			strconv.Itoa(summary.Alerts),
+			strconv.Itoa(summary.Runbooks),
+			strconv.Itoa(summary.Dashboards),
+		}})
+	}
+	t.Render()
+
+	return nil
+}
+
+// PrometheusRows returns the _prometheus/ participation of jobsCSV (all jobs when empty),
+// from the catalog and the workspace.
+func PrometheusRows(jobsCSV string) ([]PrometheusRow, error) {
+	if err := initPrometheusCat(); err != nil {
+		return nil, err
+	}
+
 	db, err := data.OpenDatabase(true)
 	if err != nil {
-		return bucket.DatabaseError(err)
+		return nil, bucket.DatabaseError(err)
 	}
 	defer func() {
 		_ = db.Close()
@@ -40,7 +73,7 @@ func Prometheus(jobsCSV string) error {
 
 	tx, err := db.Begin()
 	if err != nil {
-		return bucket.DatabaseError(err)
+		return nil, bucket.DatabaseError(err)
 	}
 	defer func() {
 		_ = tx.Rollback()
@@ -48,35 +81,28 @@ func Prometheus(jobsCSV string) error {
 
 	jobsFilter := parseCSVFilter(jobsCSV)
 	if err := validatePrometheusJobFilter(tx, jobsFilter); err != nil {
-		return err
+		return nil, err
 	}
 
 	dbSummaries, err := data.ListPrometheusJobSummaries(tx, jobsFilter)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	wsSummaries, err := promconfig.ListWorkspacePrometheusSummaries(jobsFilter)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	summaries := promconfig.MergePrometheusSummaries(dbSummaries, wsSummaries)
 	if len(summaries) == 0 {
-		return bucket.NotFoundError("prometheus")
+		return nil, bucket.NotFoundError("prometheus")
 	}
 
-	t := utils.GetTable(table.Row{"job", "scrape", "alerts", "runbooks", "dashboards"})
+	result := make([]PrometheusRow, 0, len(summaries))
 	for _, summary := range summaries {
-		t.AppendRows([]table.Row{{
-			summary.Job,
-			boolMark(summary.Scrape),
-			strconv.Itoa(summary.Alerts),
-			strconv.Itoa(summary.Runbooks),
-			strconv.Itoa(summary.Dashboards),
-		}})
+		result = append(result, PrometheusRow(summary))
 	}
-	t.Render()
 
-	return tx.Commit()
+	return result, tx.Commit()
 }
 
 func PrometheusGet(job, relPath string) error {
@@ -7,6 +7,7 @@ package cat
 import (
 	"database/sql"
 	"errors"
+	"strings"
 
 	"maand/bucket"
 	"maand/data"
@@ -15,16 +16,64 @@ import (
 	"github.com/jedib0t/go-pretty/v6/table"
 )
 
+// WorkerRow is one worker of maand cat workers. Capacity is the workers.json value;
+// effective capacity is that scaled by the worker's overcommit ratio from maand.conf, and
+// is what build places and validates against.
+type WorkerRow struct {
+	WorkerID          string   `json:"worker_id"`
+	WorkerIP          string   `json:"worker_ip"`
+	Zone              string   `json:"zone"`
+	Position          int      `json:"position"`
+	Labels            []string `json:"labels"`
+	CPUMHz            float64  `json:"cpu_mhz"`
+	EffectiveCPUMHz   float64  `json:"effective_cpu_mhz"`
+	MemoryMB          float64  `json:"memory_mb"`
+	EffectiveMemoryMB float64  `json:"effective_memory_mb"`
+	DiskMB            float64  `json:"disk_mb"`
+	EffectiveDiskMB   float64  `json:"effective_disk_mb"`
+	CPUOvercommit     float64  `json:"cpu_overcommit"`
+	MemoryOvercommit  float64  `json:"memory_overcommit"`
+	DiskOvercommit    float64  `json:"disk_overcommit"`
+}
+
 func Workers() error {
+	workers, err := WorkerRows()
+	if err != nil {
+		return err
+	}
+
+	t := utils.GetTable(table.Row{
+		"Worker IP", "zone",
+		"CPU (mhz)", "Effective CPU (mhz)",
+		"Memory (mb)", "Effective memory (mb)",
+		"Disk (mb)", "Effective disk (mb)",
+		"Position", "labels",
+	})
+	for _, worker := range workers {
+		t.AppendRows([]table.Row{{
+			worker.WorkerIP, worker.Zone,
+			worker.CPUMHz, worker.EffectiveCPUMHz,
+			worker.MemoryMB, worker.EffectiveMemoryMB,
+			worker.DiskMB, worker.EffectiveDiskMB,
+			worker.Position, strings.Join(worker.Labels, ","),
+		}})
+	}
+	t.Render()
+
+	return nil
+}
+
+// WorkerRows returns every worker in the catalog, in workers.json order.
+func WorkerRows() ([]WorkerRow, error) {
 	// TODO: labels filter
 	db, err := data.OpenDatabase(true)
 	if err != nil {
-		return bucket.DatabaseError(err)
+		return nil, bucket.DatabaseError(err)
 	}
 
 	tx, err := db.Begin()
 	if err != nil {
-		return bucket.DatabaseError(err)
+		return nil, bucket.DatabaseError(err)
 	}
 
 	defer func() {
@@ -33,47 +82,50 @@ func Workers() error {
 
 	workers, err := data.GetAllWorkers(tx)
 	if errors.Is(err, bucket.ErrDatabase) {
-		return err
+		return nil, err
 	}
 	if errors.Is(err, sql.ErrNoRows) || len(workers) == 0 {
-		return bucket.NotFoundError("workers")
+		return nil, bucket.NotFoundError("workers")
 	}
 
-	rows, err := tx.Query(`SELECT worker_id, worker_ip, available_memory_mb, available_cpu_mhz, position, labels, zone FROM cat_workers`)
+	rows, err := tx.Query(`SELECT worker_id, worker_ip, available_memory_mb, available_cpu_mhz, available_disk_mb, position, labels, zone,
+		memory_overcommit, cpu_overcommit, disk_overcommit FROM cat_workers`)
 	if err != nil {
-		return bucket.DatabaseError(err)
+		return nil, bucket.DatabaseError(err)
 	}
 	defer func() {
 		_ = rows.Close()
 	}()
 
-	t := utils.GetTable(table.Row{"Worker IP", "zone", "CPU (mhz)", "Memory (mb)", "Position", "labels"})
-
+	var result []WorkerRow
 	for rows.Next() {
-		var workerID string
-		var workerIP string
-		var position string
-		var availableMemoryMB float64
-		var availableCPUMHZ float64
-		var labels string
+		var worker WorkerRow
+		var labels sql.NullString
 		var zone sql.NullString
 
-		err = rows.Scan(&workerID, &workerIP, &availableCPUMHZ, &availableMemoryMB, &position, &labels, &zone)
+		err = rows.Scan(&worker.WorkerID, &worker.WorkerIP, &worker.MemoryMB, &worker.CPUMHz, &worker.DiskMB, &worker.Position, &labels, &zone,
+			&worker.MemoryOvercommit, &worker.CPUOvercommit, &worker.DiskOvercommit)
 		if err != nil {
-			return bucket.DatabaseError(err)
+			return nil, bucket.DatabaseError(err)
 		}
 
-		t.AppendRows([]table.Row{{workerIP, zone.String, availableMemoryMB, availableCPUMHZ, position, labels}})
+		worker.Zone = zone.String
+		worker.Labels = []string{}
+		if labels.String != "" {
+			worker.Labels = strings.Split(labels.String, ",")
+		}
+		worker.EffectiveCPUMHz = worker.CPUMHz * worker.CPUOvercommit
+		worker.EffectiveMemoryMB = worker.MemoryMB * worker.MemoryOvercommit
+		worker.EffectiveDiskMB = worker.DiskMB * worker.DiskOvercommit
+		result = append(result, worker)
 	}
 	if err := data.RowsErr(rows); err != nil {
-		return err
+		return nil, err
 	}
 
-	t.Render()
-
 	if err = tx.Commit(); err != nil {
-		return bucket.DatabaseError(err)
+		return nil, bucket.DatabaseError(err)
 	}
 
-	return nil
+	return result, nil
 }
@@ -5,6 +5,8 @@
 package cat
 
 import (
+	"time"
+
 	"maand/bucket"
 	"maand/certs"
 	"maand/data"
@@ -13,10 +15,50 @@ import (
 	"github.com/jedib0t/go-pretty/v6/table"
 )
 
+// CertRow is one certificate of maand cat certs: the bucket CA or a job leaf certificate
+// from KV. Status is ok, expiring (inside certs_renewal_buffer), expired or invalid.
+type CertRow struct {
+	Scope      string    `json:"scope"`
+	Job        string    `json:"job"`
+	WorkerIP   string    `json:"worker_ip"`
+	Cert       string    `json:"cert"`
+	CommonName string    `json:"common_name"`
+	NotAfter   time.Time `json:"not_after"`
+	DaysLeft   int       `json:"days_left"`
+	Status     string    `json:"status"`
+}
+
 func Certs(jobsCSV, workersCSV string) error {
+	entries, err := CertRows(jobsCSV, workersCSV)
+	if err != nil {
+		return err
+	}
+
+	t := utils.GetTable(table.Row{
+		"scope", "job", "worker", "cert", "common_name", "not_after", "days_left", "status",
+	})
+	for _, entry := range entries {
+		t.AppendRows([]table.Row{{
+			entry.Scope,
+			entry.Job,
+			entry.WorkerIP,
+			entry.Cert,
+			entry.CommonName,
+			entry.NotAfter.Format("2006-01-02 15:04:05 UTC"),
+			entry.DaysLeft,
+			entry.Status,
+		}})
+	}
+	t.Render()
+	return nil
+}
+
+// CertRows returns the bucket CA and the job leaf certificates of jobsCSV on workersCSV;
+// empty filters match all.
+func CertRows(jobsCSV, workersCSV string) ([]CertRow, error) {
 	db, err := data.OpenDatabase(true)
 	if err != nil {
-		return bucket.DatabaseError(err)
+		return nil, bucket.DatabaseError(err)
 	}
 	defer func() {
 		_ = db.Close()
@@ -24,7 +66,7 @@ func Certs(jobsCSV, workersCSV string) error {
 
 	tx, err := db.Begin()
 	if err != nil {
-		return bucket.DatabaseError(err)
+		return nil, bucket.DatabaseError(err)
 	}
 	defer func() {
 		_ = tx.Rollback()
@@ -33,36 +75,33 @@ func Certs(jobsCSV, workersCSV string) error {
 	jobsFilter := parseCSVFilter(jobsCSV)
 	workersFilter := parseCSVFilter(workersCSV)
 	if err := validateDeploymentFilters(tx, jobsFilter, workersFilter); err != nil {
-		return err
+		return nil, err
 	}
 
 	entries, err := certs.ListCertMetrics(tx, jobsFilter, workersFilter)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if len(entries) == 0 {
-		return bucket.NotFoundError("certificates")
+		return nil, bucket.NotFoundError("certificates")
 	}
 
-	t := utils.GetTable(table.Row{
-		"scope", "job", "worker", "cert", "common_name", "not_after", "days_left", "status",
-	})
+	result := make([]CertRow, 0, len(entries))
 	for _, entry := range entries {
-		t.AppendRows([]table.Row{{
-			entry.Scope,
-			entry.Job,
-			entry.WorkerIP,
-			entry.CertName,
-			entry.CommonName,
-			entry.NotAfter.UTC().Format("2006-01-02 15:04:05 UTC"),
-			entry.DaysLeft,
-			entry.Status,
-		}})
+		result = append(result, CertRow{
+			Scope:      entry.Scope,
+			Job:        entry.Job,
+			WorkerIP:   entry.WorkerIP,
+			Cert:       entry.CertName,
+			CommonName: entry.CommonName,
+			NotAfter:   entry.NotAfter.UTC(),
+			DaysLeft:   entry.DaysLeft,
+			Status:     entry.Status,
+		})
 	}
-	t.Render()
 
 	if err := tx.Commit(); err != nil {
-		return bucket.DatabaseError(err)
+		return nil, bucket.DatabaseError(err)
 	}
-	return nil
+	return result, nil
 }
@@ -0,0 +1,209 @@
+package cat
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"flag"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"maand/bucket"
+	"maand/data"
+	"maand/utils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite testdata/*.golden from the current output")
+
+// assertGolden compares records written in format with testdata/<name>.<format>.golden.
+func assertGolden(t *testing.T, name string, format utils.OutputFormat, records any) {
+	t.Helper()
+	var out bytes.Buffer
+	require.NoError(t, utils.WriteRecords(&out, format, records))
+
+	path := filepath.Join("testdata", name+"."+string(format)+".golden")
+	if *updateGolden {
+		require.NoError(t, os.MkdirAll("testdata", 0o755))
+		require.NoError(t, os.WriteFile(path, out.Bytes(), 0o644))
+	}
+	want, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, string(want), out.String())
+}
+
+// seedGoldenCatalog keeps only the fixed bucket row of setupCatCatalogBucket, as
+// initialize.Execute generates a random bucket_id, and adds KV history.
+func seedGoldenCatalog(t *testing.T) {
+	t.Helper()
+	db, err := data.OpenDatabase(true)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	_, err = db.Exec(`DELETE FROM bucket WHERE bucket_id <> 'bucket-1'`)
+	require.NoError(t, err)
+	_, err = db.Exec(`INSERT INTO key_value (key, value, namespace, version, ttl, created_date, deleted)
+		VALUES ('name', 'api', 'maand/job/api', 1, '0', '2026-01-02T03:04:05Z', 0),
+			('name', 'api-v2', 'maand/job/api', 2, '0', '2026-01-03T03:04:05Z', 0)`)
+	require.NoError(t, err)
+}
+
+// seedGoldenReports adds what the deployments, certs, prometheus and history reports read:
+// a staged allocation hash, a fixed CA and job certificate, _prometheus/ job files and two
+// deploy runs.
+func seedGoldenReports(t *testing.T) {
+	t.Helper()
+	notAfter := time.Date(2036, 1, 2, 3, 4, 5, 0, time.UTC)
+	require.NoError(t, os.WriteFile(filepath.Join(bucket.SecretLocation, "ca.crt"), goldenCertPEM(t, "bucket-1", notAfter), 0o600))
+
+	db, err := data.OpenDatabase(true)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	tx, err := db.Begin()
+	require.NoError(t, err)
+	_, err = tx.Exec(`
+		INSERT INTO hash (namespace, key, current_hash, previous_hash, current_version)
+		VALUES ('api_allocation', 'alloc-1', 'hash-b', 'hash-a', '0.9.0');
+		INSERT INTO job_files (job_id, path, content, isdir) VALUES
+			('job-api', 'api/_prometheus/scrape.yaml', '', 0),
+			('job-api', 'api/_prometheus/alerts/api.yml', '', 0),
+			('job-api', 'api/_prometheus/runbooks/api.md', '', 0),
+			('job-api', 'api/_prometheus/dashboards/api.json', '', 0)`)
+	require.NoError(t, err)
+	_, err = tx.Exec(`INSERT INTO key_value (key, value, namespace, version, ttl, created_date, deleted)
+		VALUES ('certs/api.crt', ?, 'maand/job/api/worker/10.0.0.1', 1, '0', '2026-01-02T03:04:05Z', 0)`,
+		string(goldenCertPEM(t, "api.maand", notAfter)))
+	require.NoError(t, err)
+
+	started := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	allocations := []data.DeployRunAllocation{{
+		AllocID: "alloc-1", WorkerIP: "10.0.0.1",
+		OldVersion: "0.9.0", NewVersion: "1.0.0", OldHash: "hash-a", NewHash: "hash-b",
+	}}
+	require.NoError(t, data.SaveDeployRun(tx, data.DeployRun{
+		RunID: "run-1", UpdateSeq: 1, StartedAt: started, FinishedAt: started.Add(time.Minute),
+		Status: data.DeployRunSucceeded,
+		Jobs:   []data.DeployRunJob{{Job: "api", Result: data.DeployJobDeployed, Allocations: allocations}},
+	}))
+	require.NoError(t, data.SaveDeployRun(tx, data.DeployRun{
+		RunID: "run-2", UpdateSeq: 2, StartedAt: started.Add(time.Hour), FinishedAt: started.Add(time.Hour + time.Minute),
+		JobsFilter: []string{"api"}, WorkersFilter: []string{"10.0.0.1"}, Force: true,
+		Status: data.DeployRunFailed, Error: "deploy failed",
+		Jobs: []data.DeployRunJob{{Job: "api", Result: data.DeployJobFailed, Error: "start refused", Allocations: allocations}},
+	}))
+	require.NoError(t, tx.Commit())
+}
+
+// goldenCertPEM returns a self-signed certificate for commonName that expires at notAfter.
+func goldenCertPEM(t *testing.T, commonName string, notAfter time.Time) []byte {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    notAfter.AddDate(-10, 0, 0),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func TestCatOutputGolden(t *testing.T) {
+	setupCatCatalogBucket(t)
+	seedGoldenCatalog(t)
+
+	allocations, err := AllocationRows("", "")
+	require.NoError(t, err)
+	jobs, err := JobRows()
+	require.NoError(t, err)
+	workers, err := WorkerRows()
+	require.NoError(t, err)
+	ports, err := JobPortRows("")
+	require.NoError(t, err)
+	commands, err := JobCommandRows()
+	require.NoError(t, err)
+	kvEntries, err := KVRows("api", false, false)
+	require.NoError(t, err)
+	info, err := BucketInfo()
+	require.NoError(t, err)
+	capacity, err := CapacityRows("", "")
+	require.NoError(t, err)
+
+	seedGoldenReports(t)
+	deployments, err := DeploymentRows("", "", false)
+	require.NoError(t, err)
+	certRows, err := CertRows("", "")
+	require.NoError(t, err)
+	// days_left counts from today; the fixed not_after above is what the golden pins.
+	for i := range certRows {
+		certRows[i].DaysLeft = 0
+	}
+	prometheus, err := PrometheusRows("")
+	require.NoError(t, err)
+	history, err := HistoryJobRows("", "")
+	require.NoError(t, err)
+	historyAllocations, err := HistoryAllocationRows("", "")
+	require.NoError(t, err)
+
+	allFormats := []utils.OutputFormat{utils.OutputJSON, utils.OutputYAML, utils.OutputCSV}
+	tableFormats := []utils.OutputFormat{utils.OutputCSV, utils.OutputTSV}
+	cases := []struct {
+		name    string
+		records any
+		formats []utils.OutputFormat
+	}{
+		{"allocations", allocations, allFormats},
+		{"jobs", jobs, allFormats},
+		{"workers", workers, allFormats},
+		{"job_ports", ports, allFormats},
+		{"job_commands", commands, allFormats},
+		{"kv", kvEntries, allFormats},
+		{"info", info, allFormats},
+		{"deployments", deployments, allFormats},
+		{"certs", certRows, allFormats},
+		{"prometheus", prometheus, allFormats},
+		{"history", history, allFormats},
+		{"history_allocations", historyAllocations, allFormats},
+		{"capacity", capacity, []utils.OutputFormat{utils.OutputJSON, utils.OutputYAML}},
+		// csv and tsv hold one list: the worker rows, or the summary rows with --summary.
+		{"capacity_workers", CapacityRecords(capacity, utils.OutputCSV, false), tableFormats},
+		{"capacity_summary", CapacityRecords(capacity, utils.OutputCSV, true), tableFormats},
+	}
+	for _, tc := range cases {
+		for _, format := range tc.formats {
+			t.Run(tc.name+"/"+string(format), func(t *testing.T) {
+				assertGolden(t, tc.name, format, tc.records)
+			})
+		}
+	}
+}
+
+func TestCapacityRecords(t *testing.T) {
+	report := CapacityReport{
+		Workers: []CapacityWorkerRow{{WorkerIP: "10.0.0.1"}},
+		Summary: []CapacityGroupRow{{Group: "fleet", Name: "all", Workers: 1}},
+	}
+
+	for _, format := range []utils.OutputFormat{utils.OutputJSON, utils.OutputYAML} {
+		assert.Equal(t, report, CapacityRecords(report, format, false))
+	}
+	for _, format := range []utils.OutputFormat{utils.OutputCSV, utils.OutputTSV} {
+		assert.Equal(t, report.Workers, CapacityRecords(report, format, false))
+		var out bytes.Buffer
+		require.NoError(t, utils.WriteRecords(&out, format, CapacityRecords(report, format, false)))
+		assert.Contains(t, out.String(), "10.0.0.1")
+	}
+	for _, format := range utils.OutputFormats {
+		assert.Equal(t, report.Summary, CapacityRecords(report, format, true))
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright 2025 Kiruba Sankar Swaminathan. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"log"
+	"os"
+	"strings"
+
+	"maand/bucket"
+	"maand/snapshot"
+
+	"github.com/spf13/cobra"
+)
+
+// bucketPassphraseEnv holds the snapshot passphrase when --passphrase-file is not given.
+const bucketPassphraseEnv = "MAAND_BUCKET_PASSPHRASE"
+
+var bucketCmd = &cobra.Command{
+	Use:   "bucket",
+	Short: "Back up and restore the bucket",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		_ = cmd.Usage()
+	},
+}
+
+var bucketExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Write the bucket to a snapshot archive",
+	Long: `Write a consistent snapshot of the bucket to a zstd-compressed tar: an online
+copy of data/maand.db, maand.conf, secrets/, workspace/ and logs/, with a
+manifest of every file's SHA-256, the bucket_id, the schema version and the CA and
+kv.key fingerprints.
+
+A snapshot with secrets is encrypted (AES-256-GCM, key derived from the
+passphrase). The passphrase is read from --passphrase-file, or from
+MAAND_BUCKET_PASSPHRASE. With --no-secrets the snapshot is only encrypted when a
+passphrase is given, and import needs the bucket's secrets/ directory already in
+place.
+
+Holds the bucket operation lock so no build or deploy changes the bucket while it
+is copied.
+
+Examples:
+  MAAND_BUCKET_PASSPHRASE=... maand bucket export --out bucket.tar.zst
+  maand bucket export --out bucket.tar.zst --passphrase-file /run/secrets/maand
+  maand bucket export --out bucket.tar.zst --no-secrets`,
+	Annotations: mutatingCommand,
+	Run: func(cmd *cobra.Command, args []string) {
+		flags := cmd.Flags()
+		out, _ := flags.GetString("out")
+		noSecrets, _ := flags.GetBool("no-secrets")
+
+		passphrase, err := readBucketPassphrase(cmd)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		if err := snapshot.Export(snapshot.ExportOptions{Out: out, NoSecrets: noSecrets, Passphrase: passphrase}); err != nil {
+			log.Fatalln(err)
+		}
+	},
+}
+
+var bucketImportCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Restore a bucket from a snapshot archive",
+	Long: `Restore a snapshot written by maand bucket export into the current directory,
+which must not hold a data/maand.db.
+
+Every file is checked against the snapshot manifest, the restored maand.db must
+carry the manifest's bucket_id and a schema version this binary supports, and
+secrets/ca.crt and secrets/kv.key must match the recorded fingerprints. For a
+snapshot exported with --no-secrets, copy the bucket's secrets/ directory in
+first. Nothing is written to the bucket until every check passes.
+
+Run maand init afterwards if the snapshot's schema is older than this binary.
+
+Holds the bucket operation lock while it restores the bucket.
+
+Examples:
+  MAAND_BUCKET_PASSPHRASE=... maand bucket import --in bucket.tar.zst`,
+	Annotations: mutatingCommand,
+	Run: func(cmd *cobra.Command, args []string) {
+		in, _ := cmd.Flags().GetString("in")
+
+		passphrase, err := readBucketPassphrase(cmd)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		if err := snapshot.Import(snapshot.ImportOptions{In: in, Passphrase: passphrase}); err != nil {
+			log.Fatalln(err)
+		}
+	},
+}
+
+func readBucketPassphrase(cmd *cobra.Command) (string, error) {
+	passphraseFile, _ := cmd.Flags().GetString("passphrase-file")
+	if passphraseFile == "" {
+		return os.Getenv(bucketPassphraseEnv), nil
+	}
+	content, err := os.ReadFile(passphraseFile)
+	if err != nil {
+		return "", bucket.UnexpectedError(err)
+	}
+	return strings.TrimRight(string(content), "\r\n"), nil
+}
+
+func init() {
+	maandCmd.AddCommand(bucketCmd)
+	bucketCmd.AddCommand(bucketExportCmd)
+	bucketCmd.AddCommand(bucketImportCmd)
+
+	bucketExportCmd.Flags().String("out", "", "Snapshot file to write (default: maand-<bucket_id>-<timestamp>.tar.zst)")
+	bucketExportCmd.Flags().Bool("no-secrets", false, "Leave secrets/ out of the snapshot; a passphrase is then optional")
+	bucketExportCmd.Flags().String("passphrase-file", "", "File holding the passphrase (default: $"+bucketPassphraseEnv+")")
+
+	bucketImportCmd.Flags().String("in", "", "Snapshot file to restore")
+	_ = bucketImportCmd.MarkFlagRequired("in")
+	bucketImportCmd.Flags().String("passphrase-file", "", "File holding the passphrase (default: $"+bucketPassphraseEnv+")")
+}
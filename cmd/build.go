@@ -6,19 +6,53 @@ package cmd
 
 import (
 	"fmt"
+	"log"
 	"os"
 
 	"maand/build"
+	"maand/utils"
 
 	"github.com/spf13/cobra"
 )
 
 var buildCmd = &cobra.Command{
-	Use:   "build",
-	Short: "Plan and build objects in the bucket",
+	Use:         "build",
+	Annotations: mutatingCommand,
+	Short:       "Plan and build objects in the bucket",
+	Long: `Sync the workspace (workers.json, jobs/, disabled.json) into maand.db: workers,
+jobs, allocations, ports, variables and certs, then run post_build hooks.
+
+With --dry-run, the build runs in a transaction that is rolled back and prints the
+workers, jobs, allocations, ports and KV keys it would add, change or remove, and the
+job certs it would regenerate. post_build hooks are skipped and the bucket lock is not
+taken. KV values are never printed.
+
+Examples:
+  maand build
+  maand build --dry-run
+  maand build -n --output json`,
 	Run: func(cmd *cobra.Command, args []string) {
-		purgeJobCommandKV, _ := cmd.Flags().GetBool("purge-job-kv")
-		if err := build.Execute(build.Options{PurgeJobCommandKV: purgeJobCommandKV}); err != nil {
+		flags := cmd.Flags()
+		purgeJobCommandKV, _ := flags.GetBool("purge-job-kv")
+		options := build.Options{PurgeJobCommandKV: purgeJobCommandKV}
+
+		if dryRun, _ := flags.GetBool("dry-run"); dryRun {
+			format := outputFormat(cmd)
+			changes, err := build.DryRun(options)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, formatCommandError("build", err))
+				os.Exit(1)
+			}
+			if err := build.PrintDryRun(format, changes); err != nil {
+				log.Fatalln(err)
+			}
+			return
+		}
+		if flags.Changed("output") {
+			log.Fatalln("--output requires --dry-run")
+		}
+
+		if err := build.Execute(options); err != nil {
 			fmt.Fprintln(os.Stderr, formatCommandError("build", err))
 			os.Exit(1)
 		}
@@ -32,4 +66,6 @@ func init() {
 		false,
 		"Mark vars/job/<job> and secrets/job/<job> deleted when a job has no active allocations",
 	)
+	buildCmd.Flags().BoolP("dry-run", "n", false, "Print the catalog changes the build would make, without changing maand.db")
+	buildCmd.Flags().StringP("output", "o", string(utils.OutputTable), outputFlagUsage+" (with --dry-run)")
 }
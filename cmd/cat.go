@@ -5,17 +5,65 @@
 package cmd
 
 import (
+	"fmt"
+	"log"
+
+	"maand/utils"
+
 	"github.com/spf13/cobra"
 )
 
 var catCmd = &cobra.Command{
 	Use:   "cat",
 	Short: "Shows bucket information",
+	Long: `Show the bucket catalog. Every subcommand prints a table by default; use
+--output json, yaml, csv or tsv for scripts. Field names are stable; see
+docs/reference/cli/info.md.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		_ = cmd.Usage()
 	},
 }
 
+const outputFlagUsage = "Output format: table, json, yaml, csv or tsv"
+
+// outputFormat reads the --output flag of maand cat, maand info and maand query.
+func outputFormat(cmd *cobra.Command) utils.OutputFormat {
+	value, _ := cmd.Flags().GetString("output")
+	format, err := utils.ParseOutputFormat(value)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	return format
+}
+
+// runCatOutput prints the command's table, or for any other --output the records it
+// returns, encoded in that format.
+func runCatOutput[T any](cmd *cobra.Command, printTable func() error, records func() (T, error)) {
+	format := outputFormat(cmd)
+	if format == utils.OutputTable {
+		if err := printTable(); err != nil {
+			log.Fatalln(err)
+		}
+		return
+	}
+	result, err := records()
+	if err != nil {
+		log.Fatalln(err)
+	}
+	if err := utils.PrintRecords(format, result); err != nil {
+		log.Fatalln(err)
+	}
+}
+
+// requireTableOutput stops commands that print stored content as is, with no records to
+// encode, when --output asks for another format.
+func requireTableOutput(cmd *cobra.Command) {
+	if format := outputFormat(cmd); format != utils.OutputTable {
+		log.Fatalln(fmt.Errorf("--output %s: %s prints file content, not records; use --output table", format, cmd.CommandPath()))
+	}
+}
+
 func init() {
 	maandCmd.AddCommand(catCmd)
+	catCmd.PersistentFlags().StringP("output", "o", string(utils.OutputTable), outputFlagUsage)
 }
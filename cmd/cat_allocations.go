@@ -5,9 +5,9 @@
 package cmd
 
 import (
-	"github.com/spf13/cobra"
-	"log"
 	"maand/cat"
+
+	"github.com/spf13/cobra"
 )
 
 var catAllocationsCmd = &cobra.Command{
@@ -18,10 +18,11 @@ var catAllocationsCmd = &cobra.Command{
 		jobsStr, _ := flags.GetString("jobs")
 		workersStr, _ := flags.GetString("workers")
 
-		err := cat.Allocations(jobsStr, workersStr)
-		if err != nil {
-			log.Fatalln(err)
-		}
+		runCatOutput(cmd, func() error {
+			return cat.Allocations(jobsStr, workersStr)
+		}, func() ([]cat.AllocationRow, error) {
+			return cat.AllocationRows(jobsStr, workersStr)
+		})
 	},
 }
 
@@ -0,0 +1,54 @@
+// Copyright 2025 Kiruba Sankar Swaminathan. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"maand/cat"
+
+	"github.com/spf13/cobra"
+)
+
+var catCapacityCmd = &cobra.Command{
+	Use:   "capacity",
+	Short: "Shows worker capacity, reservations and headroom",
+	Long: `List each worker's capacity (after overcommit), the memory, cpu and disk its
+active allocations reserve, the headroom left, and the jobs using it. A summary
+follows for the fleet, each zone and each label. Workers more than 90% reserved
+on any resource are flagged. With --output json or yaml the workers and the
+summary are two lists, workers and summary; csv and tsv write the worker rows.
+--summary prints only the summary, in any output format.
+
+Examples:
+  maand cat capacity
+  maand cat capacity --labels batch
+  maand cat capacity --workers 10.0.0.1,10.0.0.2
+  maand cat capacity --summary --output csv`,
+	Run: func(cmd *cobra.Command, args []string) {
+		flags := cmd.Flags()
+		workersStr, _ := flags.GetString("workers")
+		labelsStr, _ := flags.GetString("labels")
+		summary, _ := flags.GetBool("summary")
+
+		runCatOutput(cmd, func() error {
+			if summary {
+				return cat.CapacitySummary(workersStr, labelsStr)
+			}
+			return cat.Capacity(workersStr, labelsStr)
+		}, func() (any, error) {
+			report, err := cat.CapacityRows(workersStr, labelsStr)
+			if err != nil {
+				return nil, err
+			}
+			return cat.CapacityRecords(report, outputFormat(cmd), summary), nil
+		})
+	},
+}
+
+func init() {
+	catCmd.AddCommand(catCapacityCmd)
+	catCapacityCmd.Flags().String("workers", "", "comma separated workers")
+	catCapacityCmd.Flags().String("labels", "", "comma separated labels; workers with any of them")
+	catCapacityCmd.Flags().Bool("summary", false, "print only the fleet, zone and label summary")
+}
@@ -5,7 +5,6 @@
 package cmd
 
 import (
-	"log"
 	"maand/cat"
 
 	"github.com/spf13/cobra"
@@ -19,9 +18,11 @@ var catCertsCmd = &cobra.Command{
 		jobsStr, _ := flags.GetString("jobs")
 		workersStr, _ := flags.GetString("workers")
 
-		if err := cat.Certs(jobsStr, workersStr); err != nil {
-			log.Fatalln(err)
-		}
+		runCatOutput(cmd, func() error {
+			return cat.Certs(jobsStr, workersStr)
+		}, func() ([]cat.CertRow, error) {
+			return cat.CertRows(jobsStr, workersStr)
+		})
 	},
 }
 
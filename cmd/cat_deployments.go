@@ -5,8 +5,6 @@
 package cmd
 
 import (
-	"log"
-
 	"maand/cat"
 
 	"github.com/spf13/cobra"
@@ -20,16 +18,19 @@ var catDeploymentsCmd = &cobra.Command{
 Use --jobs and --workers to filter (comma-separated, same as maand cat allocations).
 Use --active to show only allocations deploy would target (removed=0, disabled=0).
 
-Rollout: removed, disabled (catalog flags), or new, restart, promoted, health_failed (hash state).`,
+Rollout: removed, disabled (catalog flags), or new, restart, promoted, health_failed, rolled_back, cancelled (hash state).
+A paused canary rollout shows canary on its canaries and paused on allocations waiting for maand rollout promote.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		flags := cmd.Flags()
 		jobsStr, _ := flags.GetString("jobs")
 		workersStr, _ := flags.GetString("workers")
 		activeOnly, _ := flags.GetBool("active")
 
-		if err := cat.Deployments(jobsStr, workersStr, activeOnly); err != nil {
-			log.Fatalln(err)
-		}
+		runCatOutput(cmd, func() error {
+			return cat.Deployments(jobsStr, workersStr, activeOnly)
+		}, func() ([]cat.DeploymentRow, error) {
+			return cat.DeploymentRows(jobsStr, workersStr, activeOnly)
+		})
 	},
 }
 
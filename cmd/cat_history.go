@@ -0,0 +1,49 @@
+// Copyright 2025 Kiruba Sankar Swaminathan. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"maand/cat"
+
+	"github.com/spf13/cobra"
+)
+
+var catHistoryCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Show past deploy runs and their per-job results",
+	Long: `Show deploy runs recorded by maand deploy, oldest first: run ID, start time,
+duration, status (succeeded, failed, cancelled), options, and one row per job
+with its result (deployed, failed, cancelled, paused, aborted) and version change.
+
+Use --jobs to show only runs that staged those jobs, and --since to limit by start
+time (a duration such as 24h or 7d, a date such as 2025-06-01, or an RFC 3339 time).
+Use --allocations for old and new versions and hashes per allocation.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		flags := cmd.Flags()
+		jobsStr, _ := flags.GetString("jobs")
+		since, _ := flags.GetString("since")
+		allocations, _ := flags.GetBool("allocations")
+
+		printTable := func() error {
+			return cat.History(jobsStr, since, allocations)
+		}
+		if allocations {
+			runCatOutput(cmd, printTable, func() ([]cat.HistoryAllocationRow, error) {
+				return cat.HistoryAllocationRows(jobsStr, since)
+			})
+			return
+		}
+		runCatOutput(cmd, printTable, func() ([]cat.HistoryJobRow, error) {
+			return cat.HistoryJobRows(jobsStr, since)
+		})
+	},
+}
+
+func init() {
+	catCmd.AddCommand(catHistoryCmd)
+	catHistoryCmd.Flags().String("jobs", "", "Comma-separated job names")
+	catHistoryCmd.Flags().String("since", "", "Only runs started since a duration ago (24h, 7d), a date, or an RFC 3339 time")
+	catHistoryCmd.Flags().Bool("allocations", false, "Show old and new versions and hashes per allocation")
+}
@@ -5,7 +5,6 @@
 package cmd
 
 import (
-	"log"
 	"maand/cat"
 
 	"github.com/spf13/cobra"
@@ -15,10 +14,11 @@ var catJobCommandsCmd = &cobra.Command{
 	Use:   "job_commands",
 	Short: "Shows available job commands",
 	Run: func(cmd *cobra.Command, args []string) {
-		err := cat.JobCommands()
-		if err != nil {
-			log.Fatalln(err)
-		}
+		runCatOutput(cmd, func() error {
+			return cat.JobCommands()
+		}, func() ([]cat.JobCommandRow, error) {
+			return cat.JobCommandRows()
+		})
 	},
 }
 
@@ -5,7 +5,9 @@
 package cmd
 
 import (
+	"errors"
 	"log"
+
 	"maand/cat"
 
 	"github.com/spf13/cobra"
@@ -14,14 +16,43 @@ import (
 var catJobsCmd = &cobra.Command{
 	Use:   "jobs",
 	Short: "Shows available jobs",
+	Long: `Shows available jobs from the catalog.
+
+With --effective, lists the files build reads for each workspace job instead, with the
+overlay selected by workspace_overlay in maand.conf applied, and the source of each:
+base, overlay, patched (manifest.patch.json applied) or merged (vars.toml keys merged).
+--file <job>/<path> prints one effective file.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		err := cat.Jobs()
-		if err != nil {
-			log.Fatalln(err)
+		effective, _ := cmd.Flags().GetBool("effective")
+		file, _ := cmd.Flags().GetString("file")
+		if file != "" {
+			if !effective {
+				log.Fatalln(errors.New("--file requires --effective"))
+			}
+			requireTableOutput(cmd)
+			if err := cat.EffectiveJobFile(file); err != nil {
+				log.Fatalln(err)
+			}
+			return
+		}
+		if effective {
+			runCatOutput(cmd, func() error {
+				return cat.EffectiveJobs()
+			}, func() ([]cat.EffectiveFileRow, error) {
+				return cat.EffectiveFileRows()
+			})
+			return
 		}
+		runCatOutput(cmd, func() error {
+			return cat.Jobs()
+		}, func() ([]cat.JobRow, error) {
+			return cat.JobRows()
+		})
 	},
 }
 
 func init() {
 	catCmd.AddCommand(catJobsCmd)
+	catJobsCmd.Flags().Bool("effective", false, "List the workspace job files build reads, with the workspace overlay applied")
+	catJobsCmd.Flags().String("file", "", "With --effective, print the effective content of <job>/<path>")
 }
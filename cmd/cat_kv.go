@@ -5,7 +5,6 @@
 package cmd
 
 import (
-	"log"
 	"maand/cat"
 
 	"github.com/spf13/cobra"
@@ -28,10 +27,11 @@ var catKVGetCmd = &cobra.Command{
 	Args:  cobra.ExactArgs(2),
 	Run: func(cmd *cobra.Command, args []string) {
 		reveal, _ := cmd.Flags().GetBool("reveal")
-		err := cat.KVGet(args[0], args[1], reveal)
-		if err != nil {
-			log.Fatalln(err)
-		}
+		runCatOutput(cmd, func() error {
+			return cat.KVGet(args[0], args[1], reveal)
+		}, func() (cat.KVRow, error) {
+			return cat.KVGetRow(args[0], args[1], reveal)
+		})
 	},
 }
 
@@ -40,9 +40,11 @@ func runCatKVList(cmd *cobra.Command, _ []string) {
 	jobsStr, _ := flags.GetString("jobs")
 	activeOnly, _ := flags.GetBool("active")
 	deletedOnly, _ := flags.GetBool("deleted")
-	if err := cat.KV(jobsStr, activeOnly, deletedOnly); err != nil {
-		log.Fatalln(err)
-	}
+	runCatOutput(cmd, func() error {
+		return cat.KV(jobsStr, activeOnly, deletedOnly)
+	}, func() ([]cat.KVRow, error) {
+		return cat.KVRows(jobsStr, activeOnly, deletedOnly)
+	})
 }
 
 func init() {
@@ -5,7 +5,6 @@
 package cmd
 
 import (
-	"log"
 	"maand/cat"
 
 	"github.com/spf13/cobra"
@@ -18,10 +17,11 @@ var catJobPortsCmd = &cobra.Command{
 		flags := cmd.Flags()
 		jobsStr, _ := flags.GetString("jobs")
 
-		err := cat.JobPorts(jobsStr)
-		if err != nil {
-			log.Fatalln(err)
-		}
+		runCatOutput(cmd, func() error {
+			return cat.JobPorts(jobsStr)
+		}, func() ([]cat.JobPortRow, error) {
+			return cat.JobPortRows(jobsStr)
+		})
 	},
 }
 
@@ -34,6 +34,7 @@ var catPrometheusGetCmd = &cobra.Command{
 Reads from the build catalog when available; otherwise from workspace/jobs/<job>/_prometheus/.`,
 	Args: cobra.ExactArgs(2),
 	Run: func(cmd *cobra.Command, args []string) {
+		requireTableOutput(cmd)
 		if err := cat.PrometheusGet(args[0], args[1]); err != nil {
 			log.Fatalln(err)
 		}
@@ -48,12 +49,15 @@ var catPrometheusScrapeCmd = &cobra.Command{
 
 func runCatPrometheusList(cmd *cobra.Command, _ []string) {
 	jobsStr, _ := cmd.Flags().GetString("jobs")
-	if err := cat.Prometheus(jobsStr); err != nil {
-		log.Fatalln(err)
-	}
+	runCatOutput(cmd, func() error {
+		return cat.Prometheus(jobsStr)
+	}, func() ([]cat.PrometheusRow, error) {
+		return cat.PrometheusRows(jobsStr)
+	})
 }
 
 func runCatPrometheusScrape(cmd *cobra.Command, _ []string) {
+	requireTableOutput(cmd)
 	jobsStr, _ := cmd.Flags().GetString("jobs")
 	if err := cat.PrometheusScrape(jobsStr); err != nil {
 		log.Fatalln(err)
@@ -5,7 +5,6 @@
 package cmd
 
 import (
-	"log"
 	"maand/cat"
 
 	"github.com/spf13/cobra"
@@ -15,10 +14,7 @@ var catWorkersCmd = &cobra.Command{
 	Use:   "workers",
 	Short: "Shows available workers",
 	Run: func(cmd *cobra.Command, args []string) {
-		err := cat.Workers()
-		if err != nil {
-			log.Fatalln(err)
-		}
+		runCatOutput(cmd, cat.Workers, cat.WorkerRows)
 	},
 }
 
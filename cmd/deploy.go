@@ -5,27 +5,66 @@
 package cmd
 
 import (
+	"fmt"
 	"log"
 	"maand/build"
 	"maand/deploy"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 )
 
 var deployCmd = &cobra.Command{
-	Use:   "deploy",
-	Short: "Deploy bucket to workers",
+	Use:         "deploy",
+	Annotations: mutatingEvenDryRun,
+	Short:       "Deploy bucket to workers",
 	Run: func(cmd *cobra.Command, args []string) {
 		flags := cmd.Flags()
 
+		parallelJobs, _ := flags.GetInt("parallel-jobs")
+		if parallelJobs < 1 {
+			log.Fatalln("--parallel-jobs must be at least 1")
+		}
+		cancelTimeout, _ := flags.GetDuration("cancel-timeout")
+		if cancelTimeout <= 0 {
+			log.Fatalln("--cancel-timeout must be positive")
+		}
+		run := deploy.Options{ParallelJobs: parallelJobs, CancelTimeout: cancelTimeout}
+
+		planIn, _ := flags.GetString("plan-in")
+		if planIn != "" {
+			for _, name := range []string{"jobs", "workers", "build", "dry-run", "force", "sync-only", "plan-out"} {
+				if flags.Changed(name) {
+					log.Fatalf("--plan-in cannot be combined with --%s; the plan records its own options", name)
+				}
+			}
+			plan, err := deploy.ReadPlan(planIn)
+			if err != nil {
+				log.Fatalln(err)
+			}
+			if err := deploy.ApplyPlan(plan, run); err != nil {
+				log.Fatalln(err)
+			}
+			return
+		}
+
 		jobsStr, _ := flags.GetString("jobs")
 		var jobsFilter []string
 		if len(jobsStr) > 0 {
 			jobsFilter = strings.Split(strings.Trim(jobsStr, ""), ",")
 		}
 
+		workersStr, _ := flags.GetString("workers")
+		var workersFilter []string
+		if len(workersStr) > 0 {
+			workersFilter = strings.Split(workersStr, ",")
+		}
+
 		buildFlag, _ := flags.GetBool("build")
+		if dryRun, _ := flags.GetBool("dry-run"); buildFlag && dryRun {
+			log.Fatalln("--build cannot be combined with --dry-run; preview the build with maand build --dry-run")
+		}
 		if buildFlag {
 			err := build.Execute()
 			if err != nil {
@@ -36,13 +75,30 @@ var deployCmd = &cobra.Command{
 		force, _ := flags.GetBool("force")
 		syncOnly, _ := flags.GetBool("sync-only")
 		dryRun, _ := flags.GetBool("dry-run")
-		opts := deploy.Options{Force: force, SyncOnly: syncOnly}
+		planOut, _ := flags.GetString("plan-out")
+		if planOut != "" && !dryRun {
+			log.Fatalln("--plan-out requires --dry-run")
+		}
+		opts := run
+		opts.Force = force
+		opts.SyncOnly = syncOnly
+		opts.Workers = workersFilter
 		if dryRun {
 			result, err := deploy.DryRun(jobsFilter, opts)
 			if err != nil {
 				log.Fatalln(err)
 			}
 			deploy.PrintDryRun(result)
+			if planOut != "" {
+				plan, err := deploy.NewSavedPlan(jobsFilter, opts, result)
+				if err != nil {
+					log.Fatalln(err)
+				}
+				if err := deploy.WritePlan(planOut, plan); err != nil {
+					log.Fatalln(err)
+				}
+				fmt.Printf("\nplan written to %s (digest %s)\n", planOut, plan.Digest)
+			}
 			return
 		}
 
@@ -56,8 +112,13 @@ var deployCmd = &cobra.Command{
 func init() {
 	maandCmd.AddCommand(deployCmd)
 	deployCmd.Flags().StringP("jobs", "", "", "comma seperated jobs")
+	deployCmd.Flags().String("workers", "", "Comma separated worker IPs; roll out only their allocations and leave the rest pending")
 	deployCmd.Flags().BoolP("build", "b", false, "build before deploy")
 	deployCmd.Flags().BoolP("dry-run", "n", false, "show whether deploy is required using allocation hashes (no changes)")
 	deployCmd.Flags().Bool("force", false, "Redeploy jobs even when all allocations are already promoted")
 	deployCmd.Flags().Bool("sync-only", false, "Rsync and promote without start/restart/reload (fails when new allocations need start)")
+	deployCmd.Flags().Int("parallel-jobs", 1, "Deploy up to N independent jobs of the same deployment_seq wave at once")
+	deployCmd.Flags().Duration("cancel-timeout", 2*time.Minute, "On SIGINT/SIGTERM, wait this long for in-flight batches before killing them")
+	deployCmd.Flags().String("plan-out", "", "With --dry-run, write the plan to this file for a later --plan-in")
+	deployCmd.Flags().String("plan-in", "", "Deploy exactly the plan in this file; refuse when hashes, versions or update_seq changed")
 }
@@ -0,0 +1,59 @@
+// Copyright 2025 Kiruba Sankar Swaminathan. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"log"
+
+	"maand/drift"
+
+	"github.com/spf13/cobra"
+)
+
+var driftCmd = &cobra.Command{
+	Use:         "drift",
+	Annotations: mutatingWith("mark"),
+	Short:       "Compare job files on workers with the promoted catalog",
+	Long: `SSH to workers and hash the job directories under /opt/worker/<bucket_id>/jobs,
+then compare them with the file manifest deploy last promoted for each allocation.
+Files changed, removed or added on the worker are reported as modified, missing or
+extra. Templates and the bin, data, logs, _modules and _prometheus directories are
+not synced by deploy and are not compared.
+
+Exits non-zero when drift is found. With --mark, the content found on the worker is
+recorded as the allocation's promoted state, so the next maand deploy re-syncs and
+restarts (or reloads, per restart_policy) those allocations without --force.
+
+Examples:
+  maand drift
+  maand drift --jobs api -w 10.0.0.1
+  maand drift --mark && maand deploy`,
+	Run: func(cmd *cobra.Command, args []string) {
+		flags := cmd.Flags()
+
+		jobsCSV, _ := flags.GetString("jobs")
+		workersCSV, _ := flags.GetString("workers")
+		concurrency, _ := flags.GetInt("concurrency")
+		mark, _ := flags.GetBool("mark")
+
+		err := drift.Execute(drift.Options{
+			JobsCSV:     jobsCSV,
+			WorkersCSV:  workersCSV,
+			Concurrency: concurrency,
+			Mark:        mark,
+		})
+		if err != nil {
+			log.Fatalln(err)
+		}
+	},
+}
+
+func init() {
+	maandCmd.AddCommand(driftCmd)
+	driftCmd.Flags().String("jobs", "", "Comma-separated job names")
+	driftCmd.Flags().StringP("workers", "w", "", "Comma-separated worker IPs")
+	driftCmd.Flags().IntP("concurrency", "c", 4, "Workers to check in parallel")
+	driftCmd.Flags().Bool("mark", false, "Record drifted allocations so the next deploy re-syncs them")
+}
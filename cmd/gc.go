@@ -12,9 +12,10 @@ import (
 )
 
 var gcCmd = &cobra.Command{
-	Use:   "gc",
-	Short: "Cleanup unused objects in the bucket",
-	Long:  "Removes soft-deleted allocations from maand.db, purges KV references for removed allocations, deletes worker data/logs/bin for removed allocations, and purges old key_value history.",
+	Use:         "gc",
+	Annotations: mutatingCommand,
+	Short:       "Cleanup unused objects in the bucket",
+	Long:        "Removes soft-deleted allocations from maand.db, purges KV references for removed allocations, deletes worker data/logs/bin for removed allocations, and purges old key_value history.",
 	Run: func(cmd *cobra.Command, args []string) {
 		retainDays, _ := cmd.Flags().GetInt("retain-days")
 		if err := gc.Execute(retainDays); err != nil {
@@ -5,8 +5,8 @@
 package cmd
 
 import (
-	"log"
 	"maand/cat"
+	"maand/utils"
 
 	"github.com/spf13/cobra"
 )
@@ -15,13 +15,11 @@ var infoCmd = &cobra.Command{
 	Use:   "info",
 	Short: "Information about the bucket",
 	Run: func(cmd *cobra.Command, args []string) {
-		err := cat.Info()
-		if err != nil {
-			log.Fatalln(err)
-		}
+		runCatOutput(cmd, cat.Info, cat.BucketInfo)
 	},
 }
 
 func init() {
 	maandCmd.AddCommand(infoCmd)
+	infoCmd.Flags().StringP("output", "o", string(utils.OutputTable), outputFlagUsage)
 }
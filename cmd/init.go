@@ -13,8 +13,9 @@ import (
 )
 
 var initCmd = &cobra.Command{
-	Use:   "init",
-	Short: "Initialize or upgrade the bucket",
+	Use:         "init",
+	Annotations: mutatingCommand,
+	Short:       "Initialize or upgrade the bucket",
 	Long: `Create a new maand bucket in the current directory, or upgrade an existing bucket.
 
 The first run creates maand.db, workspace layout, secrets, and tmp staging directories.
@@ -13,9 +13,10 @@ import (
 )
 
 var jobCommandCmd = &cobra.Command{
-	Use:     "jobcommand <command> [job]",
-	Aliases: []string{"job_command"},
-	Short:   "Run a manifest job command across allocations",
+	Use:         "jobcommand <command> [job]",
+	Annotations: mutatingCommand,
+	Aliases:     []string{"job_command"},
+	Short:       "Run a manifest job command across allocations",
 	Long: `Run a job command registered for the cli event.
 
 When job is omitted, the command runs on every job in the catalog that defines it.`,
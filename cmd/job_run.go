@@ -13,9 +13,10 @@ import (
 )
 
 var jobRunCmd = &cobra.Command{
-	Use:   "run",
-	Short: "Runs job target, ex: start, stop and restarts",
-	Args:  cobra.ExactArgs(1),
+	Use:         "run",
+	Annotations: mutatingCommand,
+	Short:       "Runs job target, ex: start, stop and restarts",
+	Args:        cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		flags := cmd.Flags()
 		workersComma, _ := flags.GetString("allocations")
@@ -29,9 +30,10 @@ var jobRunCmd = &cobra.Command{
 }
 
 var jobStartCmd = &cobra.Command{
-	Use:   "start",
-	Short: "Runs job target start",
-	Args:  cobra.ExactArgs(1),
+	Use:         "start",
+	Annotations: mutatingCommand,
+	Short:       "Runs job target start",
+	Args:        cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		flags := cmd.Flags()
 		workersComma, _ := flags.GetString("allocations")
@@ -44,9 +46,10 @@ var jobStartCmd = &cobra.Command{
 }
 
 var jobStopCmd = &cobra.Command{
-	Use:   "stop",
-	Short: "Runs job target stop",
-	Args:  cobra.ExactArgs(1),
+	Use:         "stop",
+	Annotations: mutatingCommand,
+	Short:       "Runs job target stop",
+	Args:        cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		flags := cmd.Flags()
 		workersComma, _ := flags.GetString("allocations")
@@ -59,9 +62,10 @@ var jobStopCmd = &cobra.Command{
 }
 
 var jobRestartCmd = &cobra.Command{
-	Use:   "restart",
-	Short: "Runs job target restart",
-	Args:  cobra.ExactArgs(1),
+	Use:         "restart",
+	Annotations: mutatingCommand,
+	Short:       "Runs job target restart",
+	Args:        cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		flags := cmd.Flags()
 		workersComma, _ := flags.GetString("allocations")
@@ -0,0 +1,139 @@
+// Copyright 2025 Kiruba Sankar Swaminathan. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"maand/bucket"
+
+	"github.com/spf13/cobra"
+)
+
+// bucketLockAnnotation marks commands that change the bucket or its workers; they hold the
+// bucket operation lock while they run (except with --dry-run).
+const bucketLockAnnotation = "maand/bucket-lock"
+
+// dryRunLockAnnotation marks commands whose --dry-run still stages files in data/tmp or
+// runs hooks, so they hold the lock with --dry-run too.
+const dryRunLockAnnotation = "maand/bucket-lock-dry-run"
+
+var mutatingCommand = map[string]string{bucketLockAnnotation: "true"}
+
+// mutatingEvenDryRun marks a command that holds the lock with and without --dry-run.
+var mutatingEvenDryRun = map[string]string{bucketLockAnnotation: "true", dryRunLockAnnotation: "true"}
+
+// mutatingWith marks a command that changes the bucket only when the named bool flag is set.
+func mutatingWith(flag string) map[string]string {
+	return map[string]string{bucketLockAnnotation: flag}
+}
+
+// releaseBucketLock is set while the running command holds the bucket lock.
+var releaseBucketLock func() error
+
+func requiresBucketLock(cmd *cobra.Command) bool {
+	flag := cmd.Annotations[bucketLockAnnotation]
+	if flag == "" {
+		return false
+	}
+	if flag != "true" {
+		if set, err := cmd.Flags().GetBool(flag); err != nil || !set {
+			return false
+		}
+	}
+	if dryRun, err := cmd.Flags().GetBool("dry-run"); err == nil && dryRun {
+		return cmd.Annotations[dryRunLockAnnotation] == "true"
+	}
+	return true
+}
+
+func acquireBucketLock(cmd *cobra.Command) error {
+	if !requiresBucketLock(cmd) {
+		return nil
+	}
+	release, err := bucket.AcquireLock(cmd.CommandPath())
+	if err != nil {
+		return err
+	}
+	releaseBucketLock = release
+	return nil
+}
+
+// finishBucketLock releases the lock after a command returns normally. Commands that exit
+// through log.Fatal leave the lock behind; the next command replaces it once the PID is gone.
+func finishBucketLock() error {
+	if releaseBucketLock == nil {
+		return nil
+	}
+	release := releaseBucketLock
+	releaseBucketLock = nil
+	return release()
+}
+
+var lockCmd = &cobra.Command{
+	Use:   "lock",
+	Short: "Inspect or break the bucket operation lock",
+	Long: `Mutating commands (init, build, deploy, drift --mark, gc, job run/start/stop/restart,
+jobcommand, rebalance, rollout, run_command, worker_facts) and bucket export|import hold a bucket operation lock in data/maand.lock
+while they run, so two operators cannot change the same bucket at once.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		_ = cmd.Usage()
+	},
+}
+
+var lockStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show who holds the bucket operation lock",
+	Run: func(cmd *cobra.Command, args []string) {
+		info, err := bucket.ReadLock()
+		if err != nil {
+			log.Fatalln(formatCommandError("lock status", err))
+		}
+		if info == nil {
+			fmt.Println("unlocked")
+			return
+		}
+		state := "held"
+		if info.Stale() {
+			state = "stale (process no longer running; the next command replaces it)"
+		}
+		fmt.Printf("state:      %s\n", state)
+		fmt.Printf("holder:     %s\n", info.Holder)
+		fmt.Printf("pid:        %d\n", info.PID)
+		fmt.Printf("host:       %s\n", info.Host)
+		fmt.Printf("command:    %s\n", info.Command)
+		fmt.Printf("started_at: %s (%s ago)\n",
+			info.StartedAt.Format(time.RFC3339), time.Since(info.StartedAt).Truncate(time.Second))
+	},
+}
+
+var lockBreakCmd = &cobra.Command{
+	Use:   "break",
+	Short: "Remove the bucket operation lock",
+	Long: `Remove the bucket operation lock regardless of its holder.
+
+Use it when the holder crashed on another host, or after confirming with
+maand lock status that no command is still running against the bucket.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		info, err := bucket.BreakLock()
+		if err != nil {
+			log.Fatalln(formatCommandError("lock break", err))
+		}
+		if info == nil {
+			fmt.Println("unlocked")
+			return
+		}
+		fmt.Printf("lock held by %s removed\n", info)
+	},
+}
+
+func init() {
+	maandCmd.AddCommand(lockCmd)
+	lockCmd.AddCommand(lockStatusCmd)
+	lockCmd.AddCommand(lockBreakCmd)
+}
@@ -0,0 +1,32 @@
+// Copyright 2025 Kiruba Sankar Swaminathan. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequiresBucketLock(t *testing.T) {
+	newCommand := func(annotations map[string]string, args ...string) *cobra.Command {
+		cmd := &cobra.Command{Use: "test", Annotations: annotations}
+		cmd.Flags().Bool("dry-run", false, "")
+		cmd.Flags().Bool("mark", false, "")
+		require.NoError(t, cmd.Flags().Parse(args))
+		return cmd
+	}
+
+	assert.False(t, requiresBucketLock(newCommand(nil)))
+	assert.True(t, requiresBucketLock(newCommand(mutatingCommand)))
+	assert.False(t, requiresBucketLock(newCommand(mutatingCommand, "--dry-run")))
+	assert.False(t, requiresBucketLock(newCommand(mutatingWith("mark"))))
+	assert.True(t, requiresBucketLock(newCommand(mutatingWith("mark"), "--mark")))
+	assert.True(t, requiresBucketLock(newCommand(mutatingEvenDryRun)))
+	assert.True(t, requiresBucketLock(newCommand(mutatingEvenDryRun, "--dry-run")))
+	assert.Equal(t, mutatingEvenDryRun, deployCmd.Annotations, "deploy --dry-run stages data/tmp and runs pre_deploy hooks")
+}
@@ -15,7 +15,13 @@ var maandCmd = &cobra.Command{
 	Use:   "maand",
 	Short: "Maand is a agent less workload orchestrator",
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-		return requireCurrentSchema(cmd)
+		if err := requireCurrentSchema(cmd); err != nil {
+			return err
+		}
+		return acquireBucketLock(cmd)
+	},
+	PersistentPostRunE: func(cmd *cobra.Command, args []string) error {
+		return finishBucketLock()
 	},
 	Run: func(cmd *cobra.Command, args []string) {
 		_ = cmd.Usage()
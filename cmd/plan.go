@@ -0,0 +1,53 @@
+// Copyright 2025 Kiruba Sankar Swaminathan. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"log"
+
+	"maand/plan"
+
+	"github.com/spf13/cobra"
+)
+
+var planCmd = &cobra.Command{
+	Use:   "plan",
+	Short: "Preview catalog changes without building",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		_ = cmd.Usage()
+	},
+}
+
+var planPlacementCmd = &cobra.Command{
+	Use:   "placement",
+	Short: "Show how a build would place allocations",
+	Long: `Run the placement steps of maand build (workers, jobs and port assignment,
+allocations) and the placement and resource validations against a throwaway copy
+of the catalog. maand.db is not changed.
+
+Prints the allocations build would create, remove or move (a job with count that
+leaves one worker for another), the memory and cpu each worker would have left,
+and any validation errors. Exits non-zero when build would fail validation.
+
+Use --workspace-dir to plan an edited copy of the workspace, for example a
+workers.json with workers added or removed, before changing the real one.
+
+Examples:
+  maand plan placement
+  maand plan placement --workspace-dir /tmp/workspace-next`,
+	Run: func(cmd *cobra.Command, args []string) {
+		workspaceDir, _ := cmd.Flags().GetString("workspace-dir")
+		if err := plan.Placement(plan.PlacementOptions{WorkspaceDir: workspaceDir}); err != nil {
+			log.Fatalln(err)
+		}
+	},
+}
+
+func init() {
+	maandCmd.AddCommand(planCmd)
+	planCmd.AddCommand(planPlacementCmd)
+	planPlacementCmd.Flags().String("workspace-dir", "", "Workspace directory to plan (default: the bucket workspace)")
+}
@@ -0,0 +1,43 @@
+// Copyright 2025 Kiruba Sankar Swaminathan. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"log"
+
+	"maand/query"
+	"maand/utils"
+
+	"github.com/spf13/cobra"
+)
+
+var queryCmd = &cobra.Command{
+	Use:   "query <sql>",
+	Short: "Run a read-only SQL query over the catalog views",
+	Long: `Run a SELECT over the cat_* views (cat_allocations, cat_jobs, cat_workers,
+cat_kv, cat_deployments, ...) and these tables: worker_labels, worker_tags,
+worker_reserved_ports, job_selectors, job_ports, job_certs, deploy_run,
+deploy_run_job and deploy_run_allocation.
+
+The query runs against a private in-memory copy of those relations, taken from a
+read-only connection to maand.db; other tables do not exist there. Writes, PRAGMA
+and ATTACH are refused. cat_kv shows encrypted values as [encrypted].
+
+Examples:
+  maand query "SELECT job, count(*) AS allocations FROM cat_allocations GROUP BY job"
+  maand query "SELECT a.job, w.zone FROM cat_allocations a JOIN cat_workers w USING (worker_ip)" --output json
+  maand query "SELECT * FROM deploy_run ORDER BY started_at DESC LIMIT 5" --output csv`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := query.Execute(args[0], outputFormat(cmd)); err != nil {
+			log.Fatalln(err)
+		}
+	},
+}
+
+func init() {
+	maandCmd.AddCommand(queryCmd)
+	queryCmd.Flags().StringP("output", "o", string(utils.OutputTable), outputFlagUsage)
+}
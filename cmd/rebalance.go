@@ -0,0 +1,60 @@
+// Copyright 2025 Kiruba Sankar Swaminathan. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"log"
+	"strings"
+
+	"maand/rebalance"
+
+	"github.com/spf13/cobra"
+)
+
+var rebalanceCmd = &cobra.Command{
+	Use:         "rebalance",
+	Annotations: mutatingCommand,
+	Short:       "Move allocations of jobs with count onto a better placement",
+	Long: `Build with jobs that have count placed afresh instead of kept on the workers
+they already run on, then deploy the jobs whose allocations moved.
+
+Placement is chosen as in maand build: within the workers' memory, cpu and disk,
+following selectors and placement rules, spread across zones and onto the workers
+with the most headroom. A job stays where it is unless moving gains headroom.
+Jobs without count run on every matching worker and are not moved.
+
+Each moved job is rolled out like maand deploy: the new allocations start
+(after_allocation_started) and pass the health check, and only then are the old
+allocations stopped (after_allocation_stopped) and removed from their workers.
+If a job fails, its old allocations keep running, removed in the catalog: fix the
+job and run maand rebalance again to finish the move the same way. A plain
+maand deploy stops them before starting the new ones instead.
+
+Use --dry-run to print the moves and each worker's resulting headroom without
+building or deploying.
+
+Examples:
+  maand rebalance --dry-run
+  maand rebalance --jobs api,worker`,
+	Run: func(cmd *cobra.Command, args []string) {
+		flags := cmd.Flags()
+		jobsStr, _ := flags.GetString("jobs")
+		var jobs []string
+		if len(jobsStr) > 0 {
+			jobs = strings.Split(jobsStr, ",")
+		}
+		dryRun, _ := flags.GetBool("dry-run")
+
+		if err := rebalance.Execute(rebalance.Options{Jobs: jobs, DryRun: dryRun}); err != nil {
+			log.Fatalln(err)
+		}
+	},
+}
+
+func init() {
+	maandCmd.AddCommand(rebalanceCmd)
+	rebalanceCmd.Flags().String("jobs", "", "Comma separated jobs to rebalance (default: every job with count)")
+	rebalanceCmd.Flags().BoolP("dry-run", "n", false, "Print the moves and worker headroom without building or deploying")
+}
@@ -0,0 +1,63 @@
+// Copyright 2025 Kiruba Sankar Swaminathan. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"log"
+
+	"maand/deploy"
+
+	"github.com/spf13/cobra"
+)
+
+var rolloutCmd = &cobra.Command{
+	Use:   "rollout",
+	Short: "Promote or abort paused canary rollouts",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		_ = cmd.Usage()
+	},
+}
+
+var rolloutPromoteCmd = &cobra.Command{
+	Use:         "promote <job>",
+	Annotations: mutatingCommand,
+	Short:       "Finish a paused canary rollout",
+	Long: `Finish a canary rollout paused by maand deploy.
+
+Held-back allocations are rsynced and upgraded per restart_policy in
+max_concurrent_upgrades batches, new allocations are started, and every
+allocation is promoted. Canaries are restarted again only when their files
+changed since the pause.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := deploy.PromoteRollout(args[0]); err != nil {
+			log.Fatalln(formatCommandError("rollout promote", err))
+		}
+	},
+}
+
+var rolloutAbortCmd = &cobra.Command{
+	Use:         "abort <job>",
+	Annotations: mutatingCommand,
+	Short:       "Revert the canaries of a paused rollout",
+	Long: `Revert a canary rollout paused by maand deploy.
+
+Canary allocations are restored to the last promoted job files and restarted;
+they show as rolled_back in maand cat deployments. Held-back allocations were
+never restarted and keep running the promoted release.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := deploy.AbortRollout(args[0]); err != nil {
+			log.Fatalln(formatCommandError("rollout abort", err))
+		}
+	},
+}
+
+func init() {
+	maandCmd.AddCommand(rolloutCmd)
+	rolloutCmd.AddCommand(rolloutPromoteCmd)
+	rolloutCmd.AddCommand(rolloutAbortCmd)
+}
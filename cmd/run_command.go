@@ -17,8 +17,9 @@ import (
 )
 
 var runCommandCmd = &cobra.Command{
-	Use:   "run_command [command]",
-	Short: "Run a shell command across workers",
+	Use:         "run_command [command]",
+	Annotations: mutatingCommand,
+	Short:       "Run a shell command across workers",
 	Long: `Run a shell command on workers in the bucket.
 
 Workers are executed in batches. Use -c to set how many workers run in parallel per batch
@@ -35,6 +35,14 @@ func skipSchemaCheck(cmd *cobra.Command) bool {
 	case "init", "help", "completion":
 		return true
 	}
+	if cmd.Parent() != nil {
+		switch cmd.Parent().Name() + " " + cmd.Name() {
+		// maand schema status reports on a database that may need maand init; maand bucket
+		// import runs where there is no database yet.
+		case "schema status", "bucket import":
+			return true
+		}
+	}
 	// Bare `maand` (usage only; no subcommand).
 	return cmd.Parent() == nil
 }
@@ -0,0 +1,47 @@
+// Copyright 2025 Kiruba Sankar Swaminathan. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"log"
+
+	"maand/initialize"
+
+	"github.com/spf13/cobra"
+)
+
+var schemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Inspect the maand.db schema",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		_ = cmd.Usage()
+	},
+}
+
+var schemaStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "List applied and pending schema migrations",
+	Long: `Print the schema version recorded in maand.db and every migration this binary
+knows, with when it was applied or "pending". Migrations recorded by a newer
+maand binary are listed as "unknown".
+
+Runs without the schema check, so it works on a bucket that needs maand init.
+maand init backs up maand.db to data/backups, then applies the pending
+migrations in one transaction.
+
+Examples:
+  maand schema status`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := initialize.SchemaStatus(); err != nil {
+			log.Fatalln(err)
+		}
+	},
+}
+
+func init() {
+	maandCmd.AddCommand(schemaCmd)
+	schemaCmd.AddCommand(schemaStatusCmd)
+}
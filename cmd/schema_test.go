@@ -25,6 +25,9 @@ func TestSkipSchemaCheck(t *testing.T) {
 	assert.True(t, skipSchemaCheck(initCmd))
 	assert.True(t, skipSchemaCheck(maandCmd))
 	assert.False(t, skipSchemaCheck(buildCmd))
+	assert.True(t, skipSchemaCheck(schemaStatusCmd))
+	assert.True(t, skipSchemaCheck(bucketImportCmd))
+	assert.False(t, skipSchemaCheck(bucketExportCmd))
 
 	maandCmd.RemoveCommand(buildCmd)
 }
@@ -14,8 +14,9 @@ import (
 )
 
 var workerFactsCmd = &cobra.Command{
-	Use:   "worker_facts",
-	Short: "Probe workers and update CPU/memory in workers.json",
+	Use:         "worker_facts",
+	Annotations: mutatingCommand,
+	Short:       "Probe workers and update CPU/memory in workers.json",
 	Long: `SSH to workers listed in workspace/workers.json, read host memory and CPU
 capacity, and write the values back to workers.json.
 
@@ -0,0 +1,90 @@
+// Copyright 2025 Kiruba Sankar Swaminathan. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package data
+
+import (
+	"database/sql"
+	"fmt"
+
+	"maand/bucket"
+)
+
+// DeployedAllocation is an active allocation with promoted content: the hash and file
+// manifest deploy last rsynced to the worker.
+type DeployedAllocation struct {
+	AllocID      string
+	WorkerIP     string
+	Job          string
+	PromotedHash string
+	// Files is the promoted file manifest; HasFiles is false for allocations promoted
+	// before manifests were recorded.
+	Files    FileManifest
+	HasFiles bool
+}
+
+// ListDeployedAllocations returns active allocations with a promoted hash, ordered by job
+// and worker IP. Allocations whose promotion was reverted by a failed health check are
+// skipped.
+func ListDeployedAllocations(tx *sql.Tx) ([]DeployedAllocation, error) {
+	rows, err := tx.Query(`
+		SELECT a.alloc_id, a.worker_ip, a.job, h.previous_hash, h.previous_files
+		FROM allocations a
+		JOIN hash h ON h.namespace = (a.job || '_allocation') AND h.key = a.alloc_id
+		WHERE a.removed = 0 AND a.disabled = 0
+		  AND ifnull(h.previous_hash, '') NOT IN ('', ?)
+		ORDER BY a.job, a.worker_ip`,
+		HealthFailedPreviousHash,
+	)
+	if err != nil {
+		return nil, bucket.DatabaseError(err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var allocations []DeployedAllocation
+	for rows.Next() {
+		var (
+			alloc    DeployedAllocation
+			filesRaw sql.NullString
+		)
+		if err := rows.Scan(&alloc.AllocID, &alloc.WorkerIP, &alloc.Job, &alloc.PromotedHash, &filesRaw); err != nil {
+			return nil, bucket.DatabaseError(err)
+		}
+		alloc.Files, alloc.HasFiles, err = ParseFileManifest(filesRaw)
+		if err != nil {
+			return nil, err
+		}
+		allocations = append(allocations, alloc)
+	}
+	if err := rowsErr(rows); err != nil {
+		return nil, err
+	}
+	return allocations, nil
+}
+
+// MarkAllocationDrifted records the content observed on the worker as the allocation's
+// promoted hash and manifest, so the next deploy sees previous_hash != current_hash and
+// re-syncs it. Nothing changes when the promoted hash is no longer promotedHash (a deploy
+// ran in between); the result reports whether the row was updated.
+func MarkAllocationDrifted(tx *sql.Tx, job, allocID, promotedHash, observedHash string, observed FileManifest) (bool, error) {
+	encoded, err := observed.Encode()
+	if err != nil {
+		return false, err
+	}
+	result, err := tx.Exec(
+		`UPDATE hash SET previous_hash = ?, previous_files = ?
+		 WHERE namespace = ? AND key = ? AND previous_hash = ?`,
+		observedHash, encoded, fmt.Sprintf("%s_allocation", job), allocID, promotedHash,
+	)
+	if err != nil {
+		return false, bucket.DatabaseError(err)
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return false, bucket.DatabaseError(err)
+	}
+	return updated > 0, nil
+}
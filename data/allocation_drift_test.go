@@ -0,0 +1,68 @@
+// Copyright 2025 Kiruba Sankar Swaminathan. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package data
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListDeployedAllocations(t *testing.T) {
+	db := openMigratedTestDB(t)
+	defer func() { _ = db.Close() }()
+	tx, err := db.Begin()
+	require.NoError(t, err)
+	defer func() { _ = tx.Rollback() }()
+	seedWorkerJobAllocation(t, tx)
+
+	_, err = tx.Exec(`
+		INSERT INTO allocations (alloc_id, worker_ip, job, disabled, removed, deployment_seq)
+		VALUES ('alloc-3', '10.0.0.2', 'web', 0, 0, 1),
+		       ('alloc-4', '10.0.0.1', 'web', 0, 0, 1),
+		       ('alloc-5', '10.0.0.1', 'db', 0, 0, 1);
+		INSERT INTO hash (namespace, key, current_hash, previous_hash, previous_files)
+		VALUES ('web_allocation', 'alloc-3', 'hash-c', 'hash-c', '{"run.sh":"aa"}'),
+		       ('web_allocation', 'alloc-4', 'hash-d', ?, NULL),
+		       ('db_allocation', 'alloc-5', 'hash-e', '', NULL);
+	`, HealthFailedPreviousHash)
+	require.NoError(t, err)
+
+	allocations, err := ListDeployedAllocations(tx)
+	require.NoError(t, err)
+	assert.Equal(t, []DeployedAllocation{
+		{AllocID: "alloc-1", WorkerIP: "10.0.0.1", Job: "api", PromotedHash: "hash-a"},
+		{AllocID: "alloc-3", WorkerIP: "10.0.0.2", Job: "web", PromotedHash: "hash-c",
+			Files: FileManifest{"run.sh": "aa"}, HasFiles: true},
+	}, allocations)
+}
+
+func TestMarkAllocationDrifted(t *testing.T) {
+	db := openMigratedTestDB(t)
+	defer func() { _ = db.Close() }()
+	tx, err := db.Begin()
+	require.NoError(t, err)
+	defer func() { _ = tx.Rollback() }()
+	seedWorkerJobAllocation(t, tx)
+
+	updated, err := MarkAllocationDrifted(tx, "api", "alloc-1", "hash-other", "hash-x", FileManifest{"run.sh": "bb"})
+	require.NoError(t, err)
+	assert.False(t, updated)
+
+	updated, err = MarkAllocationDrifted(tx, "api", "alloc-1", "hash-a", "hash-x", FileManifest{"run.sh": "bb"})
+	require.NoError(t, err)
+	assert.True(t, updated)
+
+	var previousHash, currentHash string
+	var previousFiles sql.NullString
+	require.NoError(t, tx.QueryRow(
+		`SELECT previous_hash, current_hash, previous_files FROM hash WHERE namespace = 'api_allocation' AND key = 'alloc-1'`,
+	).Scan(&previousHash, &currentHash, &previousFiles))
+	assert.Equal(t, "hash-x", previousHash)
+	assert.Equal(t, "hash-a", currentHash)
+	assert.JSONEq(t, `{"run.sh":"bb"}`, previousFiles.String)
+}
@@ -0,0 +1,307 @@
+// Copyright 2025 Kiruba Sankar Swaminathan. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package data
+
+import (
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"maand/bucket"
+)
+
+// Deploy run statuses recorded in deploy_run.status.
+const (
+	DeployRunSucceeded = "succeeded"
+	DeployRunFailed    = "failed"
+	DeployRunCancelled = "cancelled"
+)
+
+// Per-job results recorded in deploy_run_job.result.
+const (
+	DeployJobDeployed  = "deployed"
+	DeployJobFailed    = "failed"
+	DeployJobCancelled = "cancelled"
+	DeployJobPaused    = "paused"
+	// DeployJobAborted marks a job staged for a wave that an earlier aborting error stopped.
+	DeployJobAborted = "aborted"
+)
+
+// DeployRun is one maand deploy as recorded in deploy_run. Times are UTC.
+type DeployRun struct {
+	RunID      string
+	UpdateSeq  int
+	StartedAt  time.Time
+	FinishedAt time.Time
+	JobsFilter []string
+	// WorkersFilter lists the workers a maand deploy --workers run was limited to.
+	WorkersFilter []string
+	Force         bool
+	SyncOnly      bool
+	Status        string
+	Error         string
+	Jobs          []DeployRunJob
+}
+
+// DeployRunJob is the result of one job staged by a deploy run.
+type DeployRunJob struct {
+	Job         string
+	Result      string
+	Error       string
+	Allocations []DeployRunAllocation
+}
+
+// DeployRunAllocation records the promoted (old) and staged (new) hash and version of one
+// allocation when the run staged its job.
+type DeployRunAllocation struct {
+	AllocID    string
+	WorkerIP   string
+	OldVersion string
+	NewVersion string
+	OldHash    string
+	NewHash    string
+}
+
+// GetDeployRunAllocations returns the promoted and staged hash and version of each
+// non-removed allocation of job, ordered by worker IP.
+func GetDeployRunAllocations(tx *sql.Tx, job string) ([]DeployRunAllocation, error) {
+	rows, err := tx.Query(`
+		SELECT a.alloc_id, a.worker_ip,
+		       ifnull(h.current_version, ''), ifnull(a.new_version, ''),
+		       ifnull(h.previous_hash, ''), ifnull(h.current_hash, '')
+		FROM allocations a
+		LEFT JOIN hash h ON h.namespace = (a.job || '_allocation') AND h.key = a.alloc_id
+		WHERE a.job = ? AND a.removed = 0
+		ORDER BY a.worker_ip`,
+		job,
+	)
+	if err != nil {
+		return nil, bucket.DatabaseError(err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var allocations []DeployRunAllocation
+	for rows.Next() {
+		var alloc DeployRunAllocation
+		if err := rows.Scan(
+			&alloc.AllocID, &alloc.WorkerIP,
+			&alloc.OldVersion, &alloc.NewVersion,
+			&alloc.OldHash, &alloc.NewHash,
+		); err != nil {
+			return nil, bucket.DatabaseError(err)
+		}
+		allocations = append(allocations, alloc)
+	}
+	if err := rowsErr(rows); err != nil {
+		return nil, err
+	}
+	return allocations, nil
+}
+
+// SaveDeployRun inserts run with its jobs and allocations, replacing an earlier record of
+// the same run ID.
+func SaveDeployRun(tx *sql.Tx, run DeployRun) error {
+	for _, table := range []string{"deploy_run_allocation", "deploy_run_job", "deploy_run"} {
+		if _, err := tx.Exec(fmt.Sprintf(`DELETE FROM %s WHERE run_id = ?`, table), run.RunID); err != nil {
+			return bucket.DatabaseError(err)
+		}
+	}
+
+	_, err := tx.Exec(
+		`INSERT INTO deploy_run (run_id, update_seq, started_at, finished_at, jobs_filter, workers_filter, force, sync_only, status, error)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		run.RunID, run.UpdateSeq, formatRunTime(run.StartedAt), formatRunTime(run.FinishedAt),
+		strings.Join(run.JobsFilter, ","), strings.Join(run.WorkersFilter, ","),
+		boolToInt(run.Force), boolToInt(run.SyncOnly),
+		run.Status, run.Error,
+	)
+	if err != nil {
+		return bucket.DatabaseError(err)
+	}
+
+	for _, job := range run.Jobs {
+		_, err := tx.Exec(
+			`INSERT INTO deploy_run_job (run_id, job, result, error) VALUES (?, ?, ?, ?)`,
+			run.RunID, job.Job, job.Result, job.Error,
+		)
+		if err != nil {
+			return bucket.DatabaseError(err)
+		}
+		for _, alloc := range job.Allocations {
+			_, err := tx.Exec(
+				`INSERT INTO deploy_run_allocation
+				 (run_id, job, alloc_id, worker_ip, old_version, new_version, old_hash, new_hash)
+				 VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+				run.RunID, job.Job, alloc.AllocID, alloc.WorkerIP,
+				alloc.OldVersion, alloc.NewVersion, alloc.OldHash, alloc.NewHash,
+			)
+			if err != nil {
+				return bucket.DatabaseError(err)
+			}
+		}
+	}
+	return nil
+}
+
+// ListDeployRuns returns deploy runs started at or after since (zero: all), oldest first.
+// With jobs, only runs that staged one of them are returned, with just those jobs.
+func ListDeployRuns(tx *sql.Tx, jobs []string, since time.Time) ([]DeployRun, error) {
+	query := `SELECT run_id, update_seq, started_at, finished_at, jobs_filter, workers_filter, force, sync_only, status, error
+		FROM deploy_run`
+	var args []any
+	if !since.IsZero() {
+		query += ` WHERE started_at >= ?`
+		args = append(args, formatRunTime(since))
+	}
+	query += ` ORDER BY started_at, rowid`
+
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		return nil, bucket.DatabaseError(err)
+	}
+	var runs []DeployRun
+	for rows.Next() {
+		var (
+			run                   DeployRun
+			startedAt, finishedAt string
+			jobsFilter            string
+			workersFilter         string
+			force, syncOnly       int
+		)
+		if err := rows.Scan(
+			&run.RunID, &run.UpdateSeq, &startedAt, &finishedAt, &jobsFilter, &workersFilter,
+			&force, &syncOnly, &run.Status, &run.Error,
+		); err != nil {
+			_ = rows.Close()
+			return nil, bucket.DatabaseError(err)
+		}
+		run.StartedAt = parseRunTime(startedAt)
+		run.FinishedAt = parseRunTime(finishedAt)
+		if jobsFilter != "" {
+			run.JobsFilter = strings.Split(jobsFilter, ",")
+		}
+		if workersFilter != "" {
+			run.WorkersFilter = strings.Split(workersFilter, ",")
+		}
+		run.Force = force == 1
+		run.SyncOnly = syncOnly == 1
+		runs = append(runs, run)
+	}
+	if err := rowsErr(rows); err != nil {
+		_ = rows.Close()
+		return nil, err
+	}
+	_ = rows.Close()
+
+	filtered := runs[:0]
+	for _, run := range runs {
+		runJobs, err := getDeployRunJobs(tx, run.RunID, jobs)
+		if err != nil {
+			return nil, err
+		}
+		if len(jobs) > 0 && len(runJobs) == 0 {
+			continue
+		}
+		run.Jobs = runJobs
+		filtered = append(filtered, run)
+	}
+	return filtered, nil
+}
+
+func getDeployRunJobs(tx *sql.Tx, runID string, jobs []string) ([]DeployRunJob, error) {
+	rows, err := tx.Query(
+		`SELECT job, result, error FROM deploy_run_job WHERE run_id = ? ORDER BY rowid`,
+		runID,
+	)
+	if err != nil {
+		return nil, bucket.DatabaseError(err)
+	}
+	var runJobs []DeployRunJob
+	for rows.Next() {
+		var job DeployRunJob
+		if err := rows.Scan(&job.Job, &job.Result, &job.Error); err != nil {
+			_ = rows.Close()
+			return nil, bucket.DatabaseError(err)
+		}
+		if len(jobs) > 0 && !slices.Contains(jobs, job.Job) {
+			continue
+		}
+		runJobs = append(runJobs, job)
+	}
+	if err := rowsErr(rows); err != nil {
+		_ = rows.Close()
+		return nil, err
+	}
+	_ = rows.Close()
+
+	for i := range runJobs {
+		allocations, err := getDeployRunAllocations(tx, runID, runJobs[i].Job)
+		if err != nil {
+			return nil, err
+		}
+		runJobs[i].Allocations = allocations
+	}
+	return runJobs, nil
+}
+
+func getDeployRunAllocations(tx *sql.Tx, runID, job string) ([]DeployRunAllocation, error) {
+	rows, err := tx.Query(
+		`SELECT alloc_id, worker_ip, old_version, new_version, old_hash, new_hash
+		 FROM deploy_run_allocation WHERE run_id = ? AND job = ? ORDER BY worker_ip`,
+		runID, job,
+	)
+	if err != nil {
+		return nil, bucket.DatabaseError(err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var allocations []DeployRunAllocation
+	for rows.Next() {
+		var alloc DeployRunAllocation
+		if err := rows.Scan(
+			&alloc.AllocID, &alloc.WorkerIP,
+			&alloc.OldVersion, &alloc.NewVersion,
+			&alloc.OldHash, &alloc.NewHash,
+		); err != nil {
+			return nil, bucket.DatabaseError(err)
+		}
+		allocations = append(allocations, alloc)
+	}
+	if err := rowsErr(rows); err != nil {
+		return nil, err
+	}
+	return allocations, nil
+}
+
+// runTimeLayout is RFC 3339 with fixed milliseconds, so stored UTC times sort as text.
+const runTimeLayout = "2006-01-02T15:04:05.000Z07:00"
+
+func formatRunTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(runTimeLayout)
+}
+
+func parseRunTime(raw string) time.Time {
+	t, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,71 @@
+// Copyright 2025 Kiruba Sankar Swaminathan. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package data
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeployRunRoundTrip(t *testing.T) {
+	db := openMigratedTestDB(t)
+	defer func() { _ = db.Close() }()
+	tx, err := db.Begin()
+	require.NoError(t, err)
+	defer func() { _ = tx.Rollback() }()
+	seedWorkerJobAllocation(t, tx)
+
+	allocations, err := GetDeployRunAllocations(tx, "api")
+	require.NoError(t, err)
+	require.Len(t, allocations, 2)
+	assert.Equal(t, DeployRunAllocation{
+		AllocID: "alloc-1", WorkerIP: "10.0.0.1",
+		OldVersion: "1.0.0", NewVersion: "2.0.0", OldHash: "hash-a", NewHash: "hash-a",
+	}, allocations[0])
+
+	started := time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC)
+	require.NoError(t, SaveDeployRun(tx, DeployRun{
+		RunID: "run-1", UpdateSeq: 3, StartedAt: started, FinishedAt: started.Add(time.Minute),
+		Status: DeployRunSucceeded,
+		Jobs:   []DeployRunJob{{Job: "api", Result: DeployJobDeployed, Allocations: allocations}},
+	}))
+	require.NoError(t, SaveDeployRun(tx, DeployRun{
+		RunID: "run-2", UpdateSeq: 4, StartedAt: started.Add(24 * time.Hour), FinishedAt: started.Add(25 * time.Hour),
+		JobsFilter: []string{"web"}, Force: true, Status: DeployRunFailed, Error: "deploy failed",
+		Jobs: []DeployRunJob{{Job: "web", Result: DeployJobFailed, Error: "start refused"}},
+	}))
+
+	runs, err := ListDeployRuns(tx, nil, time.Time{})
+	require.NoError(t, err)
+	require.Len(t, runs, 2)
+	assert.Equal(t, "run-1", runs[0].RunID)
+	assert.Equal(t, started, runs[0].StartedAt)
+	assert.Equal(t, allocations, runs[0].Jobs[0].Allocations)
+	assert.Equal(t, []string{"web"}, runs[1].JobsFilter)
+	assert.True(t, runs[1].Force)
+	assert.Equal(t, "start refused", runs[1].Jobs[0].Error)
+
+	runs, err = ListDeployRuns(tx, []string{"api"}, time.Time{})
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	assert.Equal(t, "run-1", runs[0].RunID)
+
+	runs, err = ListDeployRuns(tx, nil, started.Add(time.Hour))
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	assert.Equal(t, "run-2", runs[0].RunID)
+
+	// Saving the same run again replaces it.
+	require.NoError(t, SaveDeployRun(tx, DeployRun{RunID: "run-2", StartedAt: started, Status: DeployRunCancelled}))
+	runs, err = ListDeployRuns(tx, nil, time.Time{})
+	require.NoError(t, err)
+	require.Len(t, runs, 2)
+	assert.Equal(t, "run-2", runs[1].RunID)
+	assert.Equal(t, DeployRunCancelled, runs[1].Status)
+	assert.Empty(t, runs[1].Jobs)
+}
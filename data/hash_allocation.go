@@ -63,6 +63,8 @@ func PromoteAllocationState(tx *sql.Tx, namespace, key string) error {
 		`UPDATE hash
 		 SET previous_hash = current_hash,
 		     previous_files = current_files,
+		     rolled_back_hash = NULL,
+		     cancelled_hash = NULL,
 		     current_version = COALESCE(
 		       (SELECT new_version FROM allocations WHERE alloc_id = ?),
 		       ?
@@ -76,6 +78,34 @@ func PromoteAllocationState(tx *sql.Tx, namespace, key string) error {
 	return nil
 }
 
+// MarkAllocationRolledBack records that the staged current_hash was rolled back on the worker.
+// The promoted previous_hash is left as-is because the worker runs that content again.
+func MarkAllocationRolledBack(tx *sql.Tx, namespace, key string) error {
+	_, err := tx.Exec(
+		`UPDATE hash SET rolled_back_hash = current_hash WHERE namespace = ? AND key = ?`,
+		namespace, key,
+	)
+	if err != nil {
+		return bucket.DatabaseError(err)
+	}
+	return nil
+}
+
+// MarkJobCancelled records that a cancelled deploy left the job's staged current_hash
+// unpromoted, so maand cat deployments can tell it apart from an ordinary pending restart.
+func MarkJobCancelled(tx *sql.Tx, job string) error {
+	namespace := fmt.Sprintf("%s_allocation", job)
+	_, err := tx.Exec(
+		`UPDATE hash SET cancelled_hash = current_hash
+		 WHERE namespace = ? AND ifnull(previous_hash, '') != ifnull(current_hash, '')`,
+		namespace,
+	)
+	if err != nil {
+		return bucket.DatabaseError(err)
+	}
+	return nil
+}
+
 // MarkAllocationStartPending clears previous_hash so deploy treats a re-enabled allocation as needing start.
 func MarkAllocationStartPending(tx *sql.Tx, job, allocID string) error {
 	namespace := fmt.Sprintf("%s_allocation", job)
@@ -88,6 +88,26 @@ func GetJobVersion(tx *sql.Tx, job string) (string, error) {
 	return version, nil
 }
 
+func GetJobDiskLimits(tx *sql.Tx, job string) (string, string, error) {
+	var minDisk, maxDisk string
+	row := tx.QueryRow("SELECT min_disk_mb, max_disk_mb FROM job WHERE name = ?", job)
+	err := row.Scan(&minDisk, &maxDisk)
+	if err != nil {
+		return "", "", bucket.DatabaseError(err)
+	}
+	return minDisk, maxDisk, nil
+}
+
+func GetJobDisk(tx *sql.Tx, job string) (string, error) {
+	var disk string
+	row := tx.QueryRow("SELECT current_disk_mb FROM job WHERE name = ?", job)
+	err := row.Scan(&disk)
+	if err != nil {
+		return "", bucket.DatabaseError(err)
+	}
+	return disk, nil
+}
+
 func GetJobSelectors(tx *sql.Tx, jobName string) ([]string, error) {
 	rows, err := tx.Query(
 		`SELECT selector FROM job_selectors WHERE job_id = (SELECT job_id FROM job WHERE name = ?)`,
@@ -114,6 +134,30 @@ func GetJobSelectors(tx *sql.Tx, jobName string) ([]string, error) {
 	return selectors, nil
 }
 
+// GetJobSelector returns the selector a job is placed by: its selector expression, or the
+// labels of its selectors list. It returns nil, which matches no worker, when the job has
+// neither.
+func GetJobSelector(tx *sql.Tx, jobName string) (workspace.Selector, error) {
+	var expr string
+	err := tx.QueryRow(`SELECT selector FROM job WHERE name = ?`, jobName).Scan(&expr)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, bucket.DatabaseError(err)
+	}
+	if expr != "" {
+		selector, err := workspace.ParseSelector(expr)
+		if err != nil {
+			return nil, fmt.Errorf("%w: job %s %w", bucket.ErrInvalidManifest, jobName, err)
+		}
+		return selector, nil
+	}
+
+	labels, err := GetJobSelectors(tx, jobName)
+	if err != nil {
+		return nil, err
+	}
+	return workspace.LabelsSelector(labels), nil
+}
+
 // CopyJobCommandModule copies one command script (and parent dirs) for health-fast staging.
 func CopyJobCommandModule(tx *sql.Tx, jobName, commandName, outputPath string) error {
 	pattern := jobName + "/_modules/" + commandName + ".%"
@@ -155,8 +199,12 @@ func CopyJobCommandModule(tx *sql.Tx, jobName, commandName, outputPath string) e
 }
 
 func CopyJobFiles(tx *sql.Tx, jobName, outputPath string) error {
+	return copyJobFilesFrom(tx, "job_files", jobName, outputPath)
+}
+
+func copyJobFilesFrom(tx *sql.Tx, table, jobName, outputPath string) error {
 	rows, err := tx.Query(
-		`SELECT path, content, isdir FROM job_files WHERE job_id = (SELECT job_id FROM job WHERE name = ?) ORDER BY isdir DESC`,
+		fmt.Sprintf(`SELECT path, content, isdir FROM %s WHERE job_id = (SELECT job_id FROM job WHERE name = ?) ORDER BY isdir DESC`, table),
 		jobName,
 	)
 	if err != nil {
@@ -391,6 +439,27 @@ func GetRestartGlobs(tx *sql.Tx, job string) ([]string, error) {
 	return workspace.ParseRestartGlobs(raw)
 }
 
+// GetRollbackOnFailure reports whether deploy rolls back upgraded allocations when a
+// health gate fails (manifest rollback_on_failure).
+func GetRollbackOnFailure(tx *sql.Tx, job string) (bool, error) {
+	var rollback int
+	row := tx.QueryRow("SELECT rollback_on_failure FROM job WHERE name = ?", job)
+	if err := row.Scan(&rollback); err != nil {
+		return false, bucket.DatabaseError(err)
+	}
+	return rollback == 1, nil
+}
+
+// GetRolloutBy returns the worker tag key that groups the job's rollout batches ("" when unset).
+func GetRolloutBy(tx *sql.Tx, job string) (string, error) {
+	var rolloutBy string
+	row := tx.QueryRow("SELECT rollout_by FROM job WHERE name = ?", job)
+	if err := row.Scan(&rolloutBy); err != nil {
+		return "", bucket.DatabaseError(err)
+	}
+	return rolloutBy, nil
+}
+
 func GetJobsByDeploymentSeq(tx *sql.Tx, deploymentSeq int) ([]string, error) {
 	// Only jobs still in the catalog with active allocations. After a workspace job folder
 	// is removed, build deletes the job row and marks allocations removed=1 until gc runs.
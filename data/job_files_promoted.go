@@ -0,0 +1,132 @@
+// Copyright 2025 Kiruba Sankar Swaminathan. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package data
+
+import (
+	"crypto/md5"
+	"database/sql"
+	"encoding/hex"
+	"strings"
+
+	"maand/bucket"
+)
+
+// SnapshotPromotedJobFiles copies the job's build files into job_files_promoted.
+// Deploy calls it after promote so a later rollout can re-stage the last good tree.
+func SnapshotPromotedJobFiles(tx *sql.Tx, job string) error {
+	for _, stmt := range []string{
+		`DELETE FROM job_files_promoted WHERE job_id = (SELECT job_id FROM job WHERE name = ?)`,
+		`INSERT INTO job_files_promoted (job_id, path, content, isdir)
+		 SELECT job_id, path, content, isdir FROM job_files
+		 WHERE job_id = (SELECT job_id FROM job WHERE name = ?)`,
+	} {
+		if _, err := tx.Exec(stmt, job); err != nil {
+			return bucket.DatabaseError(err)
+		}
+	}
+	return nil
+}
+
+// HasPromotedJobFiles reports whether a promoted snapshot exists for the job.
+func HasPromotedJobFiles(tx *sql.Tx, job string) (bool, error) {
+	var count int
+	err := tx.QueryRow(
+		`SELECT count(*) FROM job_files_promoted WHERE job_id = (SELECT job_id FROM job WHERE name = ?)`,
+		job,
+	).Scan(&count)
+	if err != nil {
+		return false, bucket.DatabaseError(err)
+	}
+	return count > 0, nil
+}
+
+// CopyPromotedJobFiles writes the last promoted job tree under outputPath (same layout as CopyJobFiles).
+func CopyPromotedJobFiles(tx *sql.Tx, jobName, outputPath string) error {
+	return copyJobFilesFrom(tx, "job_files_promoted", jobName, outputPath)
+}
+
+// SeedPromotedJobFiles records the job's current build files as its promoted snapshot when
+// it has none yet, for buckets upgraded from a release without job_files_promoted. Build
+// calls it before replacing job_files. The files are only taken when every non-removed
+// allocation has promoted the tree it last staged and each file matches the digest in that
+// allocation's previous_files, so a build that was never deployed is not mistaken for the
+// last good release.
+func SeedPromotedJobFiles(tx *sql.Tx, job string) error {
+	hasSnapshot, err := HasPromotedJobFiles(tx, job)
+	if err != nil || hasSnapshot {
+		return err
+	}
+
+	rows, err := tx.Query(
+		`SELECT ifnull(h.current_hash, ''), ifnull(h.previous_hash, ''), h.previous_files
+		 FROM allocations a
+		 LEFT JOIN hash h ON h.namespace = (a.job || '_allocation') AND h.key = a.alloc_id
+		 WHERE a.job = ? AND a.removed = 0`,
+		job,
+	)
+	if err != nil {
+		return bucket.DatabaseError(err)
+	}
+	var promoted []FileManifest
+	for rows.Next() {
+		var currentHash, previousHash string
+		var previousFiles sql.NullString
+		if err := rows.Scan(&currentHash, &previousHash, &previousFiles); err != nil {
+			_ = rows.Close()
+			return bucket.DatabaseError(err)
+		}
+		files, ok, err := ParseFileManifest(previousFiles)
+		if err != nil {
+			_ = rows.Close()
+			return err
+		}
+		if previousHash == "" || currentHash != previousHash || !ok {
+			_ = rows.Close()
+			return nil
+		}
+		promoted = append(promoted, files)
+	}
+	if err := rowsErr(rows); err != nil {
+		return err
+	}
+	_ = rows.Close()
+	if len(promoted) == 0 {
+		return nil
+	}
+
+	fileRows, err := tx.Query(
+		`SELECT path, content FROM job_files
+		 WHERE job_id = (SELECT job_id FROM job WHERE name = ?) AND NOT isdir`,
+		job,
+	)
+	if err != nil {
+		return bucket.DatabaseError(err)
+	}
+	defer func() {
+		_ = fileRows.Close()
+	}()
+	for fileRows.Next() {
+		var filePath string
+		var content []byte
+		if err := fileRows.Scan(&filePath, &content); err != nil {
+			return bucket.DatabaseError(err)
+		}
+		if isPrometheusWorkspacePath(filePath) {
+			continue
+		}
+		rel := strings.TrimPrefix(filePath, job+"/")
+		sum := md5.Sum(content)
+		digest := hex.EncodeToString(sum[:])
+		for _, files := range promoted {
+			if files[rel] != digest {
+				return nil
+			}
+		}
+	}
+	if err := rowsErr(fileRows); err != nil {
+		return err
+	}
+	return SnapshotPromotedJobFiles(tx, job)
+}
@@ -0,0 +1,117 @@
+// Copyright 2025 Kiruba Sankar Swaminathan. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package data
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshotPromotedJobFiles(t *testing.T) {
+	db := openMigratedTestDB(t)
+	defer func() { _ = db.Close() }()
+
+	tx, err := db.Begin()
+	require.NoError(t, err)
+	defer func() { _ = tx.Rollback() }()
+	seedWorkerJobAllocation(t, tx)
+	_, err = tx.Exec(`
+		INSERT INTO job_files (job_id, path, content, isdir)
+		VALUES ('job-api', 'api/Makefile', 'v1', 0),
+		       ('job-api', 'api/data', '', 1);
+	`)
+	require.NoError(t, err)
+
+	ok, err := HasPromotedJobFiles(tx, "api")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, SnapshotPromotedJobFiles(tx, "api"))
+	_, err = tx.Exec(`UPDATE job_files SET content = 'v2' WHERE job_id = 'job-api' AND isdir = 0`)
+	require.NoError(t, err)
+
+	ok, err = HasPromotedJobFiles(tx, "api")
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	outDir := t.TempDir()
+	require.NoError(t, CopyPromotedJobFiles(tx, "api", outDir))
+	content, err := os.ReadFile(path.Join(outDir, "api", "Makefile"))
+	require.NoError(t, err)
+	assert.Equal(t, "v1", string(content))
+
+	require.NoError(t, SnapshotPromotedJobFiles(tx, "api"))
+	var count int
+	require.NoError(t, tx.QueryRow(`SELECT count(*) FROM job_files_promoted`).Scan(&count))
+	assert.Equal(t, 2, count)
+}
+
+func TestSeedPromotedJobFiles(t *testing.T) {
+	cases := []struct {
+		name     string
+		content  string
+		hashSQL  string
+		expected bool
+	}{
+		{"promoted files match", "v1", ``, true},
+		{"files built after the last deploy", "v2", ``, false},
+		{"staged tree never promoted", "v1", `UPDATE hash SET current_hash = 'hash-c' WHERE key = 'alloc-2'`, false},
+		{"no previous_files", "v1", `UPDATE hash SET previous_files = NULL WHERE key = 'alloc-1'`, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			db := openMigratedTestDB(t)
+			defer func() { _ = db.Close() }()
+
+			tx, err := db.Begin()
+			require.NoError(t, err)
+			defer func() { _ = tx.Rollback() }()
+			seedWorkerJobAllocation(t, tx)
+
+			sum := md5.Sum([]byte("v1"))
+			promoted, err := FileManifest{"Makefile": hex.EncodeToString(sum[:])}.Encode()
+			require.NoError(t, err)
+			_, err = tx.Exec(`UPDATE hash SET previous_files = ? WHERE namespace = 'api_allocation'`, promoted)
+			require.NoError(t, err)
+			_, err = tx.Exec(`INSERT INTO job_files (job_id, path, content, isdir)
+				VALUES ('job-api', 'api', '', 1), ('job-api', 'api/Makefile', ?, 0)`, tc.content)
+			require.NoError(t, err)
+			if tc.hashSQL != "" {
+				_, err = tx.Exec(tc.hashSQL)
+				require.NoError(t, err)
+			}
+
+			require.NoError(t, SeedPromotedJobFiles(tx, "api"))
+			ok, err := HasPromotedJobFiles(tx, "api")
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, ok)
+		})
+	}
+}
+
+func TestSeedPromotedJobFilesKeepsExistingSnapshot(t *testing.T) {
+	db := openMigratedTestDB(t)
+	defer func() { _ = db.Close() }()
+
+	tx, err := db.Begin()
+	require.NoError(t, err)
+	defer func() { _ = tx.Rollback() }()
+	seedWorkerJobAllocation(t, tx)
+	_, err = tx.Exec(`INSERT INTO job_files_promoted (job_id, path, content, isdir) VALUES ('job-api', 'api/Makefile', 'v0', 0)`)
+	require.NoError(t, err)
+	_, err = tx.Exec(`INSERT INTO job_files (job_id, path, content, isdir) VALUES ('job-api', 'api/Makefile', 'v1', 0)`)
+	require.NoError(t, err)
+
+	require.NoError(t, SeedPromotedJobFiles(tx, "api"))
+	var content string
+	require.NoError(t, tx.QueryRow(`SELECT content FROM job_files_promoted WHERE path = 'api/Makefile'`).Scan(&content))
+	assert.Equal(t, "v0", content)
+}
@@ -0,0 +1,207 @@
+// Copyright 2025 Kiruba Sankar Swaminathan. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package data
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"time"
+
+	"maand/bucket"
+)
+
+// Migration is one numbered step of the catalog schema. MigrateSchema runs pending
+// migrations in version order inside the maand init transaction and records each one
+// in schema_migrations.
+//
+// baseTableDDL creates the tables of the version 1 baseline. Every later table or column
+// goes in a new migration appended to migrations; its version becomes the latest. Catalog
+// views are recreated after the migrations, so migrations leave them alone.
+type Migration struct {
+	Version     int
+	Description string
+	Up          func(tx *sql.Tx) error
+}
+
+// migrations is the ordered schema history. Buckets created before migrations were
+// recorded report version 1 (or the obsolete 2–4) in schema_version and have no
+// schema_migrations table, and buckets migrated while the history was a single baseline
+// recorded only version 1 with every column in place. Every migration runs on them, so
+// each must stay idempotent.
+var migrations = []Migration{
+	{Version: 1, Description: "baseline catalog columns and schema_version", Up: migrateToV1},
+	{Version: 2, Description: "job rollback_on_failure, hash rolled_back_hash and job_files_promoted", Up: migrateToV2},
+	{Version: 3, Description: "job rollout strategy and rollout_state", Up: migrateToV3},
+	{Version: 4, Description: "job rollout_by", Up: migrateToV4},
+	{Version: 5, Description: "hash cancelled_hash", Up: migrateToV5},
+	{Version: 6, Description: "deploy_run, deploy_run_job and deploy_run_allocation history", Up: migrateToV6},
+	{Version: 7, Description: "deploy_run workers_filter", Up: migrateToV7},
+	{Version: 8, Description: "job allocation_count", Up: migrateToV8},
+	{Version: 9, Description: "job placement rules", Up: migrateToV9},
+	{Version: 10, Description: "job selector expression", Up: migrateToV10},
+	{Version: 11, Description: "job and worker disk resources", Up: migrateToV11},
+	{Version: 12, Description: "worker_reserved_ports", Up: migrateToV12},
+	{Version: 13, Description: "worker memory, cpu and disk overcommit", Up: migrateToV13},
+}
+
+// MigrationStatus is one row of maand schema status.
+type MigrationStatus struct {
+	Version     int
+	Description string
+	// State is "applied", "pending", or "unknown" for a migration recorded by a newer binary.
+	State     string
+	AppliedAt string
+}
+
+// latestSchemaVersion is the version of the last migration, the schema version
+// MigrateSchema brings maand.db to.
+func latestSchemaVersion() int {
+	return migrations[len(migrations)-1].Version
+}
+
+func ensureMigrationHistory(tx *sql.Tx) error {
+	return execStatements(tx, []string{
+		`CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			description TEXT NOT NULL,
+			applied_at TEXT NOT NULL
+		)`,
+	})
+}
+
+type appliedMigration struct {
+	description string
+	appliedAt   string
+}
+
+// readAppliedMigrations returns the recorded migrations by version, and whether the
+// schema_migrations table exists at all.
+func readAppliedMigrations(tx *sql.Tx) (map[int]appliedMigration, bool, error) {
+	ok, err := tableExists(tx, "schema_migrations")
+	if err != nil || !ok {
+		return nil, false, err
+	}
+
+	rows, err := tx.Query(`SELECT version, description, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, false, bucket.DatabaseError(err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	applied := make(map[int]appliedMigration)
+	for rows.Next() {
+		var version int
+		var migration appliedMigration
+		if err := rows.Scan(&version, &migration.description, &migration.appliedAt); err != nil {
+			return nil, false, bucket.DatabaseError(err)
+		}
+		applied[version] = migration
+	}
+	if err := rows.Err(); err != nil {
+		return nil, false, bucket.DatabaseError(err)
+	}
+	return applied, true, nil
+}
+
+// PendingMigrations returns the migrations not yet recorded in maand.db, in order. It fails
+// with ErrSchemaTooNew when a newer binary migrated maand.db, so nothing is backed up or
+// migrated with this binary's older definitions.
+func PendingMigrations(tx *sql.Tx) ([]Migration, error) {
+	if err := checkSchemaNotTooNew(tx); err != nil {
+		return nil, err
+	}
+	applied, _, err := readAppliedMigrations(tx)
+	if err != nil {
+		return nil, err
+	}
+	var pending []Migration
+	for _, migration := range migrations {
+		if _, ok := applied[migration.Version]; !ok {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+func applyMigration(tx *sql.Tx, migration Migration) error {
+	if err := migration.Up(tx); err != nil {
+		return fmt.Errorf("schema migration %d (%s): %w", migration.Version, migration.Description, err)
+	}
+	_, err := tx.Exec(
+		`INSERT INTO schema_migrations (version, description, applied_at) VALUES (?, ?, ?)`,
+		migration.Version, migration.Description, time.Now().UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		return bucket.DatabaseError(err)
+	}
+	return nil
+}
+
+// SchemaStatus returns the schema version recorded in maand.db and every migration this
+// binary knows, applied or pending, followed by any recorded by a newer binary.
+func SchemaStatus(tx *sql.Tx) (int, []MigrationStatus, error) {
+	currentVersion, err := readSchemaVersion(tx)
+	if err != nil {
+		return 0, nil, err
+	}
+	applied, _, err := readAppliedMigrations(tx)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var statuses []MigrationStatus
+	known := make(map[int]bool)
+	for _, migration := range migrations {
+		known[migration.Version] = true
+		status := MigrationStatus{Version: migration.Version, Description: migration.Description, State: "pending"}
+		if record, ok := applied[migration.Version]; ok {
+			status.State = "applied"
+			status.AppliedAt = record.appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	var unknown []int
+	for version := range applied {
+		if !known[version] {
+			unknown = append(unknown, version)
+		}
+	}
+	slices.Sort(unknown)
+	for _, version := range unknown {
+		record := applied[version]
+		statuses = append(statuses, MigrationStatus{
+			Version: version, Description: record.description, State: "unknown", AppliedAt: record.appliedAt,
+		})
+	}
+	return currentVersion, statuses, nil
+}
+
+// BackupDatabase writes a consistent copy of maand.db to data/backups and returns its
+// path. maand init takes one before applying pending migrations.
+func BackupDatabase(db *sql.DB) (string, error) {
+	backupDir := path.Join(bucket.Location, "data", "backups")
+	if err := os.MkdirAll(backupDir, 0o755); err != nil {
+		return "", fmt.Errorf("create directory %s: %w", backupDir, err)
+	}
+	backupPath := path.Join(backupDir, fmt.Sprintf("maand-%s.db", time.Now().UTC().Format("20060102T150405.000Z")))
+	if err := CopyDatabase(db, backupPath); err != nil {
+		return "", err
+	}
+	return backupPath, nil
+}
+
+// CopyDatabase writes a consistent copy of db to dest, which must not exist. It is safe
+// while other connections use the database.
+func CopyDatabase(db *sql.DB, dest string) error {
+	if _, err := db.Exec(`VACUUM INTO ?`, dest); err != nil {
+		return bucket.DatabaseError(err)
+	}
+	return nil
+}
@@ -0,0 +1,374 @@
+// Copyright 2025 Kiruba Sankar Swaminathan. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package data
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"testing"
+
+	"maand/bucket"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// legacyFixtureDDL is the catalog shape of the oldest buckets: no schema_version and the
+// job rollout columns under their original names.
+const legacyFixtureDDL = `
+	CREATE TABLE allocations (
+		alloc_id TEXT, worker_ip TEXT, job TEXT,
+		disabled INT, removed INT, deployment_seq INT,
+		PRIMARY KEY(worker_ip, job)
+	);
+	CREATE TABLE hash (
+		namespace TEXT, key TEXT,
+		current_hash TEXT, previous_hash TEXT,
+		PRIMARY KEY(namespace, key)
+	);
+	CREATE TABLE job (
+		job_id TEXT, name TEXT, version TEXT,
+		min_memory_mb TEXT, max_memory_mb TEXT, current_memory_mb TEXT,
+		min_cpu_mhz TEXT, max_cpu_mhz TEXT, current_cpu_mhz TEXT,
+		update_parallel_count INT NOT NULL DEFAULT 1,
+		PRIMARY KEY(name)
+	);
+	CREATE TABLE worker (
+		worker_id TEXT, worker_ip TEXT,
+		available_memory_mb TEXT, available_cpu_mhz TEXT, position INT,
+		PRIMARY KEY(worker_ip)
+	);
+	INSERT INTO job (job_id, name, version, update_parallel_count) VALUES ('job-api', 'api', '1.0.0', 2);
+	INSERT INTO worker (worker_id, worker_ip, available_memory_mb, available_cpu_mhz, position)
+		VALUES ('worker-1', '10.0.0.1', '1024', '1000', 0);
+`
+
+func migrateTestBucket(t *testing.T) {
+	t.Helper()
+	db, err := OpenDatabase(true)
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	tx, err := db.Begin()
+	require.NoError(t, err)
+	require.NoError(t, MigrateSchema(tx))
+	require.NoError(t, tx.Commit())
+}
+
+func withMigrations(t *testing.T, extra ...Migration) {
+	t.Helper()
+	orig := migrations
+	migrations = append(append([]Migration{}, orig...), extra...)
+	t.Cleanup(func() { migrations = orig })
+}
+
+func TestMigrationsAreNumberedInOrder(t *testing.T) {
+	for i, migration := range migrations {
+		assert.Equal(t, i+1, migration.Version)
+		assert.NotEmpty(t, migration.Description)
+	}
+	assert.Equal(t, len(migrations), latestSchemaVersion())
+}
+
+func TestMigrateSchemaFromEveryHistoricalVersion(t *testing.T) {
+	for version := 0; version <= legacySchemaVersionMax; version++ {
+		t.Run(fmt.Sprintf("v%d", version), func(t *testing.T) {
+			defer withTestBucket(t)()
+			require.NoError(t, os.MkdirAll(path.Join(bucket.Location, "data"), 0o755))
+
+			db, err := OpenDatabase(false)
+			require.NoError(t, err)
+			_, err = db.Exec(legacyFixtureDDL)
+			require.NoError(t, err)
+			if version > 0 {
+				_, err = db.Exec(`CREATE TABLE schema_version (id INTEGER PRIMARY KEY CHECK (id = 1), version INTEGER NOT NULL)`)
+				require.NoError(t, err)
+				_, err = db.Exec(`INSERT INTO schema_version (id, version) VALUES (1, ?)`, version)
+				require.NoError(t, err)
+			}
+			require.NoError(t, db.Close())
+
+			require.ErrorIs(t, CheckSchemaVersion(), bucket.ErrSchemaUpgradeRequired)
+			migrateTestBucket(t)
+			require.NoError(t, CheckSchemaVersion())
+
+			db, err = OpenDatabase(true)
+			require.NoError(t, err)
+			defer func() { _ = db.Close() }()
+
+			var upgrades int
+			require.NoError(t, db.QueryRow(`SELECT max_concurrent_upgrades FROM job WHERE name = 'api'`).Scan(&upgrades))
+			assert.Equal(t, 2, upgrades)
+			var overcommit float64
+			require.NoError(t, db.QueryRow(`SELECT memory_overcommit FROM worker WHERE worker_ip = '10.0.0.1'`).Scan(&overcommit))
+			assert.Equal(t, 1.0, overcommit)
+
+			var applied int
+			require.NoError(t, db.QueryRow(`SELECT count(*) FROM schema_migrations`).Scan(&applied))
+			assert.Equal(t, len(migrations), applied)
+		})
+	}
+}
+
+// fixtureSeed is catalog data in baseline columns, present at every schema version.
+const fixtureSeed = `
+	INSERT INTO job (job_id, name, version, max_concurrent_upgrades) VALUES ('job-api', 'api', '1.0.0', 2);
+	INSERT INTO worker (worker_id, worker_ip, available_memory_mb, available_cpu_mhz, position)
+		VALUES ('worker-1', '10.0.0.1', '1024', '1000', 0);
+	INSERT INTO allocations (alloc_id, worker_ip, job, disabled, removed, deployment_seq, new_version)
+		VALUES ('alloc-1', '10.0.0.1', 'api', 0, 0, 0, '1.0.0');
+	INSERT INTO hash (namespace, key, current_hash, previous_hash) VALUES ('api_allocation', 'alloc-1', 'h1', 'h1');
+`
+
+// TestMigrateSchemaFromEveryRecordedVersion opens maand.db as each older version left it
+// (testdata/schema_v<N>.sql) and upgrades it one migration at a time to the latest.
+func TestMigrateSchemaFromEveryRecordedVersion(t *testing.T) {
+	all := migrations
+	for from := 1; from < len(all); from++ {
+		t.Run(fmt.Sprintf("v%d", from), func(t *testing.T) {
+			defer withTestBucket(t)()
+			t.Cleanup(func() { migrations = all })
+			require.NoError(t, os.MkdirAll(path.Join(bucket.Location, "data"), 0o755))
+
+			fixture, err := os.ReadFile(fmt.Sprintf("testdata/schema_v%d.sql", from))
+			require.NoError(t, err)
+			db, err := OpenDatabase(false)
+			require.NoError(t, err)
+			_, err = db.Exec(string(fixture) + fixtureSeed)
+			require.NoError(t, err)
+			require.NoError(t, db.Close())
+
+			err = CheckSchemaVersion()
+			require.ErrorIs(t, err, bucket.ErrSchemaUpgradeRequired)
+			assert.Contains(t, err.Error(), fmt.Sprintf("database schema version %d", from))
+
+			for to := from + 1; to <= len(all); to++ {
+				migrations = all[:to]
+				migrateTestBucket(t)
+
+				db, err := OpenDatabase(true)
+				require.NoError(t, err)
+				var version, recorded, kept int
+				require.NoError(t, db.QueryRow(`SELECT version FROM schema_version WHERE id = 1`).Scan(&version))
+				require.NoError(t, db.QueryRow(`SELECT count(*) FROM schema_migrations WHERE version <= ?`, to).Scan(&recorded))
+				require.NoError(t, db.QueryRow(`SELECT count(*) FROM schema_migrations WHERE applied_at = '2025-06-01T00:00:00Z'`).Scan(&kept))
+				require.NoError(t, db.Close())
+				assert.Equal(t, to, version)
+				assert.Equal(t, to, recorded)
+				assert.Equal(t, from, kept, "migrations recorded in the fixture are not applied again")
+			}
+			require.NoError(t, CheckSchemaVersion())
+
+			db, err = OpenDatabase(true)
+			require.NoError(t, err)
+			defer func() { _ = db.Close() }()
+			var upgrades int
+			var previousHash, diskMB string
+			var overcommit float64
+			require.NoError(t, db.QueryRow(`SELECT max_concurrent_upgrades, current_disk_mb FROM job WHERE name = 'api'`).Scan(&upgrades, &diskMB))
+			require.NoError(t, db.QueryRow(`SELECT previous_hash FROM hash WHERE key = 'alloc-1'`).Scan(&previousHash))
+			require.NoError(t, db.QueryRow(`SELECT memory_overcommit FROM worker WHERE worker_ip = '10.0.0.1'`).Scan(&overcommit))
+			assert.Equal(t, 2, upgrades)
+			assert.Equal(t, "0", diskMB)
+			assert.Equal(t, "h1", previousHash)
+			assert.Equal(t, 1.0, overcommit)
+		})
+	}
+}
+
+func TestMigrateSchemaFromSingleBaselineHistory(t *testing.T) {
+	defer withTestBucket(t)()
+	db := openDatabaseAtSchemaVersion(t, 0)
+	require.NoError(t, db.Close())
+	migrateTestBucket(t)
+
+	// buckets migrated while the history was one baseline have every column but only
+	// version 1 recorded
+	db, err := OpenDatabase(true)
+	require.NoError(t, err)
+	_, err = db.Exec(`DELETE FROM schema_migrations WHERE version > 1; UPDATE schema_version SET version = 1`)
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+	require.ErrorIs(t, CheckSchemaVersion(), bucket.ErrSchemaUpgradeRequired)
+
+	migrateTestBucket(t)
+	require.NoError(t, CheckSchemaVersion())
+}
+
+func TestMigrateSchemaAppliesOnlyPendingMigrations(t *testing.T) {
+	defer withTestBucket(t)()
+	db := openDatabaseAtSchemaVersion(t, 0)
+	require.NoError(t, db.Close())
+	migrateTestBucket(t)
+
+	var ran []int
+	jobNotes, workerNotes := latestSchemaVersion()+1, latestSchemaVersion()+2
+	withMigrations(t,
+		Migration{Version: jobNotes, Description: "add job notes", Up: func(tx *sql.Tx) error {
+			ran = append(ran, jobNotes)
+			return ensureTableColumn(tx, "job", "notes", `ALTER TABLE job ADD COLUMN notes TEXT`)
+		}},
+		Migration{Version: workerNotes, Description: "add worker notes", Up: func(tx *sql.Tx) error {
+			ran = append(ran, workerNotes)
+			return ensureTableColumn(tx, "worker", "notes", `ALTER TABLE worker ADD COLUMN notes TEXT`)
+		}},
+	)
+
+	err := CheckSchemaVersion()
+	require.ErrorIs(t, err, bucket.ErrSchemaUpgradeRequired)
+	assert.Contains(t, err.Error(), fmt.Sprintf("binary expects %d", workerNotes))
+
+	migrateTestBucket(t)
+	migrateTestBucket(t)
+	assert.Equal(t, []int{jobNotes, workerNotes}, ran)
+	require.NoError(t, CheckSchemaVersion())
+
+	db, err = OpenDatabase(true)
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+	assert.Equal(t, 1, columnExists(t, db, "job", "notes"))
+	assert.Equal(t, 1, columnExists(t, db, "worker", "notes"))
+}
+
+func TestMigrateSchemaRollsBackFailedMigration(t *testing.T) {
+	defer withTestBucket(t)()
+	db := openDatabaseAtSchemaVersion(t, 0)
+	require.NoError(t, db.Close())
+	migrateTestBucket(t)
+
+	latest := latestSchemaVersion()
+	withMigrations(t, Migration{Version: latest + 1, Description: "broken", Up: func(tx *sql.Tx) error {
+		if _, err := tx.Exec(`ALTER TABLE job ADD COLUMN notes TEXT`); err != nil {
+			return err
+		}
+		return errors.New("boom")
+	}})
+
+	db, err := OpenDatabase(true)
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+	tx, err := db.Begin()
+	require.NoError(t, err)
+	err = MigrateSchema(tx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), fmt.Sprintf("schema migration %d (broken)", latest+1))
+	require.NoError(t, tx.Rollback())
+
+	assert.Equal(t, 0, columnExists(t, db, "job", "notes"))
+	var version int
+	require.NoError(t, db.QueryRow(`SELECT version FROM schema_version WHERE id = 1`).Scan(&version))
+	assert.Equal(t, latest, version)
+}
+
+func TestCheckSchemaVersionRequiresUpgradeForUnrecordedMigrations(t *testing.T) {
+	defer withTestBucket(t)()
+	db := openDatabaseAtSchemaVersion(t, 0)
+	require.NoError(t, db.Close())
+	migrateTestBucket(t)
+
+	db, err := OpenDatabase(true)
+	require.NoError(t, err)
+	_, err = db.Exec(`DROP TABLE schema_migrations`)
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	err = CheckSchemaVersion()
+	require.ErrorIs(t, err, bucket.ErrSchemaUpgradeRequired)
+	assert.Contains(t, err.Error(), fmt.Sprintf("%d schema migration(s) pending, starting with 1", len(migrations)))
+}
+
+func TestCheckSchemaVersionTooNewForUnknownMigration(t *testing.T) {
+	defer withTestBucket(t)()
+	db := openDatabaseAtSchemaVersion(t, 0)
+	require.NoError(t, db.Close())
+	migrateTestBucket(t)
+
+	db, err := OpenDatabase(true)
+	require.NoError(t, err)
+	_, err = db.Exec(`INSERT INTO schema_migrations (version, description, applied_at) VALUES (?, 'from a newer binary', '2026-01-01T00:00:00Z')`,
+		latestSchemaVersion()+1)
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	require.ErrorIs(t, CheckSchemaVersion(), bucket.ErrSchemaTooNew)
+}
+
+func TestMigrateSchemaRefusesUnknownMigration(t *testing.T) {
+	defer withTestBucket(t)()
+	db := openDatabaseAtSchemaVersion(t, 0)
+	require.NoError(t, db.Close())
+	migrateTestBucket(t)
+
+	db, err := OpenDatabase(true)
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+	_, err = db.Exec(`INSERT INTO schema_migrations (version, description, applied_at) VALUES (?, 'from a newer binary', '2026-01-01T00:00:00Z')`,
+		latestSchemaVersion()+1)
+	require.NoError(t, err)
+	_, err = db.Exec(`UPDATE schema_version SET version = ?`, latestSchemaVersion()+1)
+	require.NoError(t, err)
+
+	tx, err := db.Begin()
+	require.NoError(t, err)
+	defer func() { _ = tx.Rollback() }()
+
+	_, err = PendingMigrations(tx)
+	require.ErrorIs(t, err, bucket.ErrSchemaTooNew)
+	require.ErrorIs(t, MigrateSchema(tx), bucket.ErrSchemaTooNew)
+
+	version, err := readSchemaVersion(tx)
+	require.NoError(t, err)
+	assert.Equal(t, latestSchemaVersion()+1, version)
+}
+
+func TestSchemaStatus(t *testing.T) {
+	defer withTestBucket(t)()
+	db := openDatabaseAtSchemaVersion(t, 0)
+	require.NoError(t, db.Close())
+	migrateTestBucket(t)
+
+	db, err := OpenDatabase(true)
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+	latest := latestSchemaVersion()
+	_, err = db.Exec(`INSERT INTO schema_migrations (version, description, applied_at) VALUES (?, 'from a newer binary', '2026-01-01T00:00:00Z')`,
+		latest+8)
+	require.NoError(t, err)
+	withMigrations(t, Migration{Version: latest + 1, Description: "add job notes", Up: func(tx *sql.Tx) error { return nil }})
+
+	tx, err := db.Begin()
+	require.NoError(t, err)
+	defer func() { _ = tx.Rollback() }()
+	version, statuses, err := SchemaStatus(tx)
+	require.NoError(t, err)
+
+	assert.Equal(t, latest, version)
+	require.Len(t, statuses, latest+2)
+	for _, status := range statuses[:latest] {
+		assert.Equal(t, "applied", status.State)
+		assert.NotEmpty(t, status.AppliedAt)
+	}
+	assert.Equal(t, MigrationStatus{Version: latest + 1, Description: "add job notes", State: "pending"}, statuses[latest])
+	assert.Equal(t, MigrationStatus{Version: latest + 8, Description: "from a newer binary", State: "unknown", AppliedAt: "2026-01-01T00:00:00Z"}, statuses[latest+1])
+}
+
+func TestBackupDatabase(t *testing.T) {
+	defer withTestBucket(t)()
+	db := openDatabaseAtSchemaVersion(t, 1)
+	defer func() { _ = db.Close() }()
+
+	backupPath, err := BackupDatabase(db)
+	require.NoError(t, err)
+
+	backup, err := sql.Open("sqlite3", backupPath)
+	require.NoError(t, err)
+	defer func() { _ = backup.Close() }()
+	var version int
+	require.NoError(t, backup.QueryRow(`SELECT version FROM schema_version WHERE id = 1`).Scan(&version))
+	assert.Equal(t, 1, version)
+}
@@ -0,0 +1,215 @@
+// Copyright 2025 Kiruba Sankar Swaminathan. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package data
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"maand/bucket"
+	"maand/workspace"
+)
+
+// WorkerCapacity is a worker's available resources and zone tag as build places jobs.
+// Memory, cpu and disk are effective capacity: workers.json values scaled by the worker's
+// overcommit ratios from maand.conf.
+type WorkerCapacity struct {
+	WorkerIP string
+	Position int
+	Zone     string
+	MemoryMB float64
+	CPUMHz   float64
+	DiskMB   float64
+}
+
+// ListWorkerCapacity returns all workers ordered by position with their effective capacity.
+// Workers without memory, cpu or disk in workers.json report 0.
+func ListWorkerCapacity(tx *sql.Tx) ([]WorkerCapacity, error) {
+	rows, err := tx.Query(`
+		SELECT w.worker_ip, w.position,
+			ifnull((SELECT wt.value FROM worker_tags wt WHERE wt.worker_id = w.worker_id AND wt.key = 'zone'), ''),
+			CAST(ifnull(w.available_memory_mb, 0) AS REAL) * ifnull(w.memory_overcommit, 1),
+			CAST(ifnull(w.available_cpu_mhz, 0) AS REAL) * ifnull(w.cpu_overcommit, 1),
+			CAST(ifnull(w.available_disk_mb, 0) AS REAL) * ifnull(w.disk_overcommit, 1)
+		FROM worker w ORDER BY w.position, w.worker_ip`)
+	if err != nil {
+		return nil, bucket.DatabaseError(err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var workers []WorkerCapacity
+	for rows.Next() {
+		var worker WorkerCapacity
+		if err := rows.Scan(&worker.WorkerIP, &worker.Position, &worker.Zone, &worker.MemoryMB, &worker.CPUMHz, &worker.DiskMB); err != nil {
+			return nil, bucket.DatabaseError(err)
+		}
+		workers = append(workers, worker)
+	}
+	if err := rowsErr(rows); err != nil {
+		return nil, err
+	}
+	return workers, nil
+}
+
+// JobReservation is what one allocation of a job reserves on its worker, and how many
+// allocations the job asks for (0 = one on every matching worker).
+type JobReservation struct {
+	Job      string
+	Count    int
+	MemoryMB float64
+	CPUMHz   float64
+	DiskMB   float64
+}
+
+// ListJobReservations returns every catalog job ordered by name.
+func ListJobReservations(tx *sql.Tx) ([]JobReservation, error) {
+	rows, err := tx.Query(`
+		SELECT name, allocation_count,
+			CAST(ifnull(current_memory_mb, 0) AS REAL),
+			CAST(ifnull(current_cpu_mhz, 0) AS REAL),
+			CAST(ifnull(current_disk_mb, 0) AS REAL)
+		FROM job ORDER BY name`)
+	if err != nil {
+		return nil, bucket.DatabaseError(err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var jobs []JobReservation
+	for rows.Next() {
+		var job JobReservation
+		if err := rows.Scan(&job.Job, &job.Count, &job.MemoryMB, &job.CPUMHz, &job.DiskMB); err != nil {
+			return nil, bucket.DatabaseError(err)
+		}
+		jobs = append(jobs, job)
+	}
+	if err := rowsErr(rows); err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+// GetJobPlacement loads the affinity and anti-affinity rules of a job (nil when none).
+func GetJobPlacement(tx *sql.Tx, jobName string) (*workspace.ManifestPlacement, error) {
+	var raw sql.NullString
+	err := tx.QueryRow(`SELECT placement FROM job WHERE name = ?`, jobName).Scan(&raw)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, bucket.DatabaseError(err)
+	}
+	if !raw.Valid || raw.String == "" {
+		return nil, nil
+	}
+
+	var placement workspace.ManifestPlacement
+	if err := json.Unmarshal([]byte(raw.String), &placement); err != nil {
+		return nil, fmt.Errorf("%w: job %s placement: %w", bucket.ErrInvalidManifest, jobName, err)
+	}
+	return workspace.NormalizePlacement(&placement)
+}
+
+// PlacedAllocation is a non-removed allocation of a job on a worker.
+type PlacedAllocation struct {
+	Job      string
+	WorkerIP string
+	Disabled bool
+}
+
+// ListPlacedAllocations returns the non-removed allocations ordered by job and worker position.
+func ListPlacedAllocations(tx *sql.Tx) ([]PlacedAllocation, error) {
+	rows, err := tx.Query(`
+		SELECT a.job, a.worker_ip, a.disabled
+		FROM allocations a LEFT JOIN worker w ON w.worker_ip = a.worker_ip
+		WHERE a.removed = 0
+		ORDER BY a.job, ifnull(w.position, 0), a.worker_ip`)
+	if err != nil {
+		return nil, bucket.DatabaseError(err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var allocations []PlacedAllocation
+	for rows.Next() {
+		var alloc PlacedAllocation
+		if err := rows.Scan(&alloc.Job, &alloc.WorkerIP, &alloc.Disabled); err != nil {
+			return nil, bucket.DatabaseError(err)
+		}
+		allocations = append(allocations, alloc)
+	}
+	if err := rowsErr(rows); err != nil {
+		return nil, err
+	}
+	return allocations, nil
+}
+
+// WorkerHeadroom is a worker's capacity and what its active allocations reserve of it.
+type WorkerHeadroom struct {
+	WorkerCapacity
+	ReservedMemoryMB float64
+	ReservedCPUMHz   float64
+	ReservedDiskMB   float64
+}
+
+// FreeMemoryMB returns the memory left after reservations (negative when overcommitted).
+func (h WorkerHeadroom) FreeMemoryMB() float64 {
+	return h.MemoryMB - h.ReservedMemoryMB
+}
+
+// FreeCPUMHz returns the cpu left after reservations (negative when overcommitted).
+func (h WorkerHeadroom) FreeCPUMHz() float64 {
+	return h.CPUMHz - h.ReservedCPUMHz
+}
+
+// FreeDiskMB returns the disk left after reservations (negative when overcommitted).
+func (h WorkerHeadroom) FreeDiskMB() float64 {
+	return h.DiskMB - h.ReservedDiskMB
+}
+
+// ListWorkerHeadroom returns all workers ordered by position with the memory, cpu and disk their
+// active (not removed, not disabled) allocations reserve, as ValidateWorkerResources sums them.
+func ListWorkerHeadroom(tx *sql.Tx) ([]WorkerHeadroom, error) {
+	rows, err := tx.Query(`
+		SELECT w.worker_ip, w.position,
+			ifnull((SELECT wt.value FROM worker_tags wt WHERE wt.worker_id = w.worker_id AND wt.key = 'zone'), ''),
+			CAST(ifnull(w.available_memory_mb, 0) AS REAL) * ifnull(w.memory_overcommit, 1),
+			CAST(ifnull(w.available_cpu_mhz, 0) AS REAL) * ifnull(w.cpu_overcommit, 1),
+			CAST(ifnull(w.available_disk_mb, 0) AS REAL) * ifnull(w.disk_overcommit, 1),
+			CAST(ifnull((SELECT sum(j.current_memory_mb) FROM allocations a JOIN job j ON j.name = a.job
+				WHERE a.worker_ip = w.worker_ip AND a.removed = 0 AND a.disabled = 0), 0) AS REAL),
+			CAST(ifnull((SELECT sum(j.current_cpu_mhz) FROM allocations a JOIN job j ON j.name = a.job
+				WHERE a.worker_ip = w.worker_ip AND a.removed = 0 AND a.disabled = 0), 0) AS REAL),
+			CAST(ifnull((SELECT sum(j.current_disk_mb) FROM allocations a JOIN job j ON j.name = a.job
+				WHERE a.worker_ip = w.worker_ip AND a.removed = 0 AND a.disabled = 0), 0) AS REAL)
+		FROM worker w ORDER BY w.position, w.worker_ip`)
+	if err != nil {
+		return nil, bucket.DatabaseError(err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var workers []WorkerHeadroom
+	for rows.Next() {
+		var worker WorkerHeadroom
+		if err := rows.Scan(
+			&worker.WorkerIP, &worker.Position, &worker.Zone, &worker.MemoryMB, &worker.CPUMHz, &worker.DiskMB,
+			&worker.ReservedMemoryMB, &worker.ReservedCPUMHz, &worker.ReservedDiskMB,
+		); err != nil {
+			return nil, bucket.DatabaseError(err)
+		}
+		workers = append(workers, worker)
+	}
+	if err := rowsErr(rows); err != nil {
+		return nil, err
+	}
+	return workers, nil
+}
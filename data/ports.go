@@ -6,6 +6,8 @@ package data
 
 import (
 	"database/sql"
+	"fmt"
+	"slices"
 	"strconv"
 
 	"maand/bucket"
@@ -90,3 +92,119 @@ func GetJobPortMapInt(tx *sql.Tx, jobName string) (map[string]int, error) {
 	}
 	return out, nil
 }
+
+// ListReservedPorts returns the reserved_ports of each worker from workers.json, keyed by
+// worker IP.
+func ListReservedPorts(tx *sql.Tx) (map[string][]int, error) {
+	rows, err := tx.Query(`
+		SELECT w.worker_ip, rp.port
+		FROM worker_reserved_ports rp
+		JOIN worker w ON w.worker_id = rp.worker_id
+		ORDER BY w.worker_ip, rp.port
+	`)
+	if err != nil {
+		return nil, bucket.DatabaseError(err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	reserved := make(map[string][]int)
+	for rows.Next() {
+		var workerIP string
+		var port int
+		if err := rows.Scan(&workerIP, &port); err != nil {
+			return nil, bucket.DatabaseError(err)
+		}
+		reserved[workerIP] = append(reserved[workerIP], port)
+	}
+	if err := rowsErr(rows); err != nil {
+		return nil, err
+	}
+	return reserved, nil
+}
+
+// WorkerPort is a job port on a worker with a non-removed allocation of the job.
+type WorkerPort struct {
+	WorkerIP string
+	Job      string
+	Name     string
+	Port     int
+	Disabled bool
+}
+
+// ListWorkerPorts returns the ports of every non-removed allocation ordered by worker
+// position, job and port name.
+func ListWorkerPorts(tx *sql.Tx) ([]WorkerPort, error) {
+	rows, err := tx.Query(`
+		SELECT a.worker_ip, a.job, jp.name, jp.port, a.disabled
+		FROM allocations a
+		JOIN job j ON j.name = a.job
+		JOIN job_ports jp ON jp.job_id = j.job_id
+		LEFT JOIN worker w ON w.worker_ip = a.worker_ip
+		WHERE a.removed = 0
+		ORDER BY ifnull(w.position, 0), a.worker_ip, a.job, jp.name
+	`)
+	if err != nil {
+		return nil, bucket.DatabaseError(err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var ports []WorkerPort
+	for rows.Next() {
+		var port WorkerPort
+		if err := rows.Scan(&port.WorkerIP, &port.Job, &port.Name, &port.Port, &port.Disabled); err != nil {
+			return nil, bucket.DatabaseError(err)
+		}
+		ports = append(ports, port)
+	}
+	if err := rowsErr(rows); err != nil {
+		return nil, err
+	}
+	return ports, nil
+}
+
+// PortConflict is a job port on a worker whose number is reserved on that worker or also
+// used there by another job port (OtherJob, OtherName).
+type PortConflict struct {
+	WorkerPort
+	Reserved  bool
+	OtherJob  string
+	OtherName string
+}
+
+// Conflict describes what the port conflicts with.
+func (c PortConflict) Conflict() string {
+	if c.Reserved {
+		return "reserved_ports"
+	}
+	return fmt.Sprintf("job %s port %s", c.OtherJob, c.OtherName)
+}
+
+// FindPortConflicts checks ports worker by worker against each other and against the
+// reserved ports of the worker. A clash between two job ports is reported for both.
+func FindPortConflicts(ports []WorkerPort, reserved map[string][]int) []PortConflict {
+	byNumber := make(map[string]map[int][]WorkerPort)
+	for _, port := range ports {
+		if byNumber[port.WorkerIP] == nil {
+			byNumber[port.WorkerIP] = make(map[int][]WorkerPort)
+		}
+		byNumber[port.WorkerIP][port.Port] = append(byNumber[port.WorkerIP][port.Port], port)
+	}
+
+	var conflicts []PortConflict
+	for _, port := range ports {
+		if slices.Contains(reserved[port.WorkerIP], port.Port) {
+			conflicts = append(conflicts, PortConflict{WorkerPort: port, Reserved: true})
+		}
+		for _, other := range byNumber[port.WorkerIP][port.Port] {
+			if other.Job == port.Job && other.Name == port.Name {
+				continue
+			}
+			conflicts = append(conflicts, PortConflict{WorkerPort: port, OtherJob: other.Job, OtherName: other.Name})
+		}
+	}
+	return conflicts
+}
@@ -0,0 +1,62 @@
+// Copyright 2025 Kiruba Sankar Swaminathan. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package data
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindPortConflicts(t *testing.T) {
+	ports := []WorkerPort{
+		{WorkerIP: "10.0.0.1", Job: "api", Name: "api_port", Port: 8080},
+		{WorkerIP: "10.0.0.1", Job: "web", Name: "web_port", Port: 8080},
+		{WorkerIP: "10.0.0.1", Job: "metrics", Name: "metrics_port", Port: 9100},
+		{WorkerIP: "10.0.0.2", Job: "api", Name: "api_port", Port: 8080},
+	}
+	reserved := map[string][]int{"10.0.0.1": {22, 9100}}
+
+	conflicts := FindPortConflicts(ports, reserved)
+	require.Len(t, conflicts, 3)
+	assert.Equal(t, "10.0.0.1", conflicts[0].WorkerIP)
+	assert.Equal(t, "api", conflicts[0].Job)
+	assert.Equal(t, "job web port web_port", conflicts[0].Conflict())
+	assert.Equal(t, "web", conflicts[1].Job)
+	assert.Equal(t, "job api port api_port", conflicts[1].Conflict())
+	assert.Equal(t, "metrics", conflicts[2].Job)
+	assert.Equal(t, "reserved_ports", conflicts[2].Conflict())
+}
+
+func TestListWorkerPortsAndReservedPorts(t *testing.T) {
+	db := openMigratedTestDB(t)
+	defer func() { _ = db.Close() }()
+
+	_, err := db.Exec(`
+		INSERT INTO worker (worker_id, worker_ip, position) VALUES ('w1', '10.0.0.1', 0), ('w2', '10.0.0.2', 1);
+		INSERT INTO worker_reserved_ports (worker_id, port) VALUES ('w1', 22), ('w2', 9100), ('w2', 22);
+		INSERT INTO job (job_id, name) VALUES ('j1', 'api');
+		INSERT INTO job_ports (job_id, name, port) VALUES ('j1', 'api_port', 30000);
+		INSERT INTO allocations (alloc_id, worker_ip, job, disabled, removed)
+		VALUES ('a1', '10.0.0.2', 'api', 1, 0), ('a2', '10.0.0.1', 'api', 0, 0), ('a3', '10.0.0.3', 'api', 0, 1);
+	`)
+	require.NoError(t, err)
+
+	tx, err := db.Begin()
+	require.NoError(t, err)
+	defer func() { _ = tx.Rollback() }()
+
+	ports, err := ListWorkerPorts(tx)
+	require.NoError(t, err)
+	assert.Equal(t, []WorkerPort{
+		{WorkerIP: "10.0.0.1", Job: "api", Name: "api_port", Port: 30000},
+		{WorkerIP: "10.0.0.2", Job: "api", Name: "api_port", Port: 30000, Disabled: true},
+	}, ports)
+
+	reserved, err := ListReservedPorts(tx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]int{"10.0.0.1": {22}, "10.0.0.2": {22, 9100}}, reserved)
+}
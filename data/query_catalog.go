@@ -0,0 +1,158 @@
+// Copyright 2025 Kiruba Sankar Swaminathan. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"maand/bucket"
+
+	sqlite3 "github.com/mattn/go-sqlite3"
+)
+
+// QueryableTables are the base tables maand query can read besides the cat_* views. They
+// hold no secrets or file content; key_value is only reachable through cat_kv, which
+// masks encrypted values.
+var QueryableTables = []string{
+	"worker_labels",
+	"worker_tags",
+	"worker_reserved_ports",
+	"job_selectors",
+	"job_ports",
+	"job_certs",
+	"deploy_run",
+	"deploy_run_job",
+	"deploy_run_allocation",
+}
+
+// sqliteRecursive is SQLITE_RECURSIVE, which go-sqlite3 does not export.
+const sqliteRecursive = 33
+
+// OpenQueryCatalog copies the cat_* views and QueryableTables from maand.db, opened
+// read-only, into a private in-memory database. The returned connection sees only those
+// copies and is limited to SELECT: writes, PRAGMA and ATTACH are refused. Call release
+// when done.
+func OpenQueryCatalog(ctx context.Context) (conn *sql.Conn, release func(), err error) {
+	if !DatabaseExists() {
+		return nil, nil, bucket.ErrNotInitialized
+	}
+	db, err := sql.Open("sqlite3", fmt.Sprintf("file::memory:?_busy_timeout=%d", sqliteBusyTimeoutMS))
+	if err != nil {
+		return nil, nil, bucket.DatabaseError(err)
+	}
+	// Each connection to :memory: is its own database; keep the one holding the copies.
+	db.SetMaxOpenConns(1)
+	conn, err = db.Conn(ctx)
+	if err != nil {
+		_ = db.Close()
+		return nil, nil, bucket.DatabaseError(err)
+	}
+	release = func() {
+		_ = conn.Close()
+		_ = db.Close()
+	}
+	if err := copyQueryCatalog(ctx, conn); err != nil {
+		release()
+		return nil, nil, err
+	}
+	if err := restrictToSelect(ctx, conn); err != nil {
+		release()
+		return nil, nil, err
+	}
+	return conn, release, nil
+}
+
+func copyQueryCatalog(ctx context.Context, conn *sql.Conn) error {
+	if _, err := conn.ExecContext(ctx, `ATTACH DATABASE ? AS catalog`, "file:"+DatabasePath()+"?mode=ro"); err != nil {
+		return bucket.DatabaseError(err)
+	}
+	defer func() {
+		_, _ = conn.ExecContext(ctx, `DETACH DATABASE catalog`)
+	}()
+
+	// One read transaction, so every copy comes from the same catalog state.
+	if _, err := conn.ExecContext(ctx, `BEGIN`); err != nil {
+		return bucket.DatabaseError(err)
+	}
+	relations, err := queryRelations(ctx, conn)
+	if err == nil {
+		for _, name := range relations {
+			statement := fmt.Sprintf(`CREATE TABLE main."%s" AS SELECT * FROM catalog."%s"`, name, name)
+			if _, err = conn.ExecContext(ctx, statement); err != nil {
+				err = bucket.DatabaseError(fmt.Errorf("%s: %w", name, err))
+				break
+			}
+		}
+	}
+	if err != nil {
+		_, _ = conn.ExecContext(ctx, `ROLLBACK`)
+		return err
+	}
+	if _, err := conn.ExecContext(ctx, `COMMIT`); err != nil {
+		return bucket.DatabaseError(err)
+	}
+	return nil
+}
+
+// queryRelations lists the catalog views and the QueryableTables present in maand.db.
+func queryRelations(ctx context.Context, conn *sql.Conn) ([]string, error) {
+	rows, err := conn.QueryContext(ctx, `SELECT name, type FROM catalog.sqlite_master
+		WHERE type IN ('view', 'table') ORDER BY name`)
+	if err != nil {
+		return nil, bucket.DatabaseError(err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	tables := make(map[string]bool, len(QueryableTables))
+	for _, name := range QueryableTables {
+		tables[name] = true
+	}
+	var relations []string
+	for rows.Next() {
+		var name, kind string
+		if err := rows.Scan(&name, &kind); err != nil {
+			return nil, bucket.DatabaseError(err)
+		}
+		if (kind == "view" && strings.HasPrefix(name, "cat_")) || (kind == "table" && tables[name]) {
+			relations = append(relations, name)
+		}
+	}
+	if err := RowsErr(rows); err != nil {
+		return nil, err
+	}
+	return relations, nil
+}
+
+// restrictToSelect makes conn refuse anything but reading: query_only blocks writes, and
+// the authorizer denies PRAGMA, ATTACH and every other action besides SELECT, column
+// reads and functions.
+func restrictToSelect(ctx context.Context, conn *sql.Conn) error {
+	if _, err := conn.ExecContext(ctx, `PRAGMA query_only = ON`); err != nil {
+		return bucket.DatabaseError(err)
+	}
+	err := conn.Raw(func(driverConn any) error {
+		sqliteConn, ok := driverConn.(*sqlite3.SQLiteConn)
+		if !ok {
+			return fmt.Errorf("unexpected driver connection %T", driverConn)
+		}
+		sqliteConn.RegisterAuthorizer(func(action int, _, _, _ string) int {
+			switch action {
+			case sqlite3.SQLITE_SELECT, sqlite3.SQLITE_READ, sqlite3.SQLITE_FUNCTION, sqliteRecursive:
+				return sqlite3.SQLITE_OK
+			}
+			return sqlite3.SQLITE_DENY
+		})
+		return nil
+	})
+	if err != nil {
+		return bucket.DatabaseError(err)
+	}
+	return nil
+}
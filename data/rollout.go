@@ -0,0 +1,133 @@
+// Copyright 2025 Kiruba Sankar Swaminathan. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package data
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"maand/bucket"
+	"maand/workspace"
+)
+
+// RolloutStatusPaused marks a canary rollout waiting for maand rollout promote or abort.
+const RolloutStatusPaused = "paused"
+
+// RolloutState is a row in rollout_state. Canaries maps each canary worker IP to the
+// allocation hash it was upgraded to.
+type RolloutState struct {
+	Job      string
+	Status   string
+	Canaries map[string]string
+}
+
+// CanaryWorkers returns the canary worker IPs in sorted order.
+func (s RolloutState) CanaryWorkers() []string {
+	workers := make([]string, 0, len(s.Canaries))
+	for workerIP := range s.Canaries {
+		workers = append(workers, workerIP)
+	}
+	sort.Strings(workers)
+	return workers
+}
+
+// GetJobRollout loads the canary rollout spec for a job (nil for the default rolling strategy).
+func GetJobRollout(tx *sql.Tx, jobName string) (*workspace.ManifestRollout, error) {
+	var raw sql.NullString
+	err := tx.QueryRow(`SELECT rollout FROM job WHERE name = ?`, jobName).Scan(&raw)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, bucket.DatabaseError(err)
+	}
+	if !raw.Valid || raw.String == "" {
+		return nil, nil
+	}
+
+	var spec workspace.ManifestRollout
+	if err := json.Unmarshal([]byte(raw.String), &spec); err != nil {
+		return nil, fmt.Errorf("%w: job %s rollout: %w", bucket.ErrInvalidManifest, jobName, err)
+	}
+	return workspace.NormalizeRollout(&spec)
+}
+
+// GetRolloutState returns the persisted rollout state for a job (nil when none).
+func GetRolloutState(tx *sql.Tx, job string) (*RolloutState, error) {
+	var status, canaries string
+	err := tx.QueryRow(`SELECT status, canaries FROM rollout_state WHERE job = ?`, job).Scan(&status, &canaries)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, bucket.DatabaseError(err)
+	}
+	return decodeRolloutState(job, status, canaries)
+}
+
+// ListRolloutStates returns every persisted rollout state keyed by job.
+func ListRolloutStates(tx *sql.Tx) (map[string]*RolloutState, error) {
+	rows, err := tx.Query(`SELECT job, status, canaries FROM rollout_state`)
+	if err != nil {
+		return nil, bucket.DatabaseError(err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	states := make(map[string]*RolloutState)
+	for rows.Next() {
+		var job, status, canaries string
+		if err := rows.Scan(&job, &status, &canaries); err != nil {
+			return nil, bucket.DatabaseError(err)
+		}
+		state, err := decodeRolloutState(job, status, canaries)
+		if err != nil {
+			return nil, err
+		}
+		states[job] = state
+	}
+	if err := rowsErr(rows); err != nil {
+		return nil, err
+	}
+	return states, nil
+}
+
+// SaveRolloutState inserts or replaces the rollout state for state.Job.
+func SaveRolloutState(tx *sql.Tx, state RolloutState) error {
+	encoded, err := json.Marshal(state.Canaries)
+	if err != nil {
+		return bucket.UnexpectedError(err)
+	}
+	_, err = tx.Exec(
+		`INSERT OR REPLACE INTO rollout_state (job, status, canaries, updated_at)
+		 VALUES (?, ?, ?, strftime('%s', 'now'))`,
+		state.Job, state.Status, string(encoded),
+	)
+	if err != nil {
+		return bucket.DatabaseError(err)
+	}
+	return nil
+}
+
+// ClearRolloutState removes the rollout state for a job.
+func ClearRolloutState(tx *sql.Tx, job string) error {
+	if _, err := tx.Exec(`DELETE FROM rollout_state WHERE job = ?`, job); err != nil {
+		return bucket.DatabaseError(err)
+	}
+	return nil
+}
+
+func decodeRolloutState(job, status, canaries string) (*RolloutState, error) {
+	state := &RolloutState{Job: job, Status: status, Canaries: map[string]string{}}
+	if canaries != "" {
+		if err := json.Unmarshal([]byte(canaries), &state.Canaries); err != nil {
+			return nil, bucket.UnexpectedError(fmt.Errorf("rollout_state %s canaries: %w", job, err))
+		}
+	}
+	return state, nil
+}
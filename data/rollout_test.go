@@ -0,0 +1,68 @@
+// Copyright 2025 Kiruba Sankar Swaminathan. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package data
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRolloutStateRoundTrip(t *testing.T) {
+	db := openMigratedTestDB(t)
+	defer func() { _ = db.Close() }()
+
+	tx, err := db.Begin()
+	require.NoError(t, err)
+	defer func() { _ = tx.Rollback() }()
+
+	state, err := GetRolloutState(tx, "api")
+	require.NoError(t, err)
+	assert.Nil(t, state)
+
+	require.NoError(t, SaveRolloutState(tx, RolloutState{
+		Job:      "api",
+		Status:   RolloutStatusPaused,
+		Canaries: map[string]string{"10.0.0.2": "hash-b", "10.0.0.1": "hash-a"},
+	}))
+
+	state, err = GetRolloutState(tx, "api")
+	require.NoError(t, err)
+	require.NotNil(t, state)
+	assert.Equal(t, RolloutStatusPaused, state.Status)
+	assert.Equal(t, []string{"10.0.0.1", "10.0.0.2"}, state.CanaryWorkers())
+
+	states, err := ListRolloutStates(tx)
+	require.NoError(t, err)
+	assert.Contains(t, states, "api")
+
+	require.NoError(t, ClearRolloutState(tx, "api"))
+	state, err = GetRolloutState(tx, "api")
+	require.NoError(t, err)
+	assert.Nil(t, state)
+}
+
+func TestGetJobRollout(t *testing.T) {
+	db := openMigratedTestDB(t)
+	defer func() { _ = db.Close() }()
+
+	tx, err := db.Begin()
+	require.NoError(t, err)
+	defer func() { _ = tx.Rollback() }()
+	seedWorkerJobAllocation(t, tx)
+
+	spec, err := GetJobRollout(tx, "api")
+	require.NoError(t, err)
+	assert.Nil(t, spec)
+
+	_, err = tx.Exec(`UPDATE job SET rollout = '{"strategy":"canary","canary_count":2}' WHERE name = 'api'`)
+	require.NoError(t, err)
+	spec, err = GetJobRollout(tx, "api")
+	require.NoError(t, err)
+	require.NotNil(t, spec)
+	assert.Equal(t, 2, spec.CanaryCount)
+	assert.Equal(t, 1, spec.HealthChecks)
+}
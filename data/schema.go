@@ -11,15 +11,12 @@ import (
 	"maand/bucket"
 )
 
-// LatestSchemaVersion is the target schema version applied by MigrateSchema.
-const LatestSchemaVersion = 1
-
 // legacySchemaVersionMax is the highest obsolete multi-step schema number (v2–v4).
-// Databases at those versions are renumbered to LatestSchemaVersion on init.
+// Databases at those versions have no schema_migrations table and are renumbered on init.
 const legacySchemaVersionMax = 4
 
-// CheckSchemaVersion verifies maand.db exists and its schema version matches this binary.
-// Run maand init to create or upgrade the database.
+// CheckSchemaVersion verifies maand.db exists, its schema version matches this binary and
+// every migration has been applied. Run maand init to create or upgrade the database.
 func CheckSchemaVersion() error {
 	if !DatabaseExists() {
 		return bucket.ErrNotInitialized
@@ -41,25 +38,20 @@ func CheckSchemaVersion() error {
 		_ = tx.Rollback()
 	}()
 
+	if err := checkSchemaNotTooNew(tx); err != nil {
+		return err
+	}
 	currentVersion, err := readSchemaVersion(tx)
 	if err != nil {
 		return err
 	}
-
-	if currentVersion != LatestSchemaVersion {
-		if currentVersion > legacySchemaVersionMax {
-			return fmt.Errorf(
-				"%w: database schema version %d is newer than this binary supports (%d); upgrade the maand binary",
-				bucket.ErrSchemaTooNew,
-				currentVersion,
-				LatestSchemaVersion,
-			)
-		}
+	latestVersion := latestSchemaVersion()
+	if currentVersion != latestVersion {
 		return fmt.Errorf(
 			"%w: database schema version %d, binary expects %d; run maand init to upgrade",
 			bucket.ErrSchemaUpgradeRequired,
 			currentVersion,
-			LatestSchemaVersion,
+			latestVersion,
 		)
 	}
 	if err := checkRequiredSchemaColumns(db); err != nil {
@@ -68,6 +60,54 @@ func CheckSchemaVersion() error {
 	if err := checkRequiredCatalogViews(db); err != nil {
 		return err
 	}
+
+	pending, err := PendingMigrations(tx)
+	if err != nil {
+		return err
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf(
+			"%w: %d schema migration(s) pending, starting with %d (%s); run maand init to upgrade",
+			bucket.ErrSchemaUpgradeRequired,
+			len(pending),
+			pending[0].Version,
+			pending[0].Description,
+		)
+	}
+	return nil
+}
+
+// checkSchemaNotTooNew fails with ErrSchemaTooNew when a newer binary migrated maand.db:
+// it recorded a migration this binary doesn't know or, without recorded migrations, the
+// schema version is above both the latest and the obsolete v2–v4.
+func checkSchemaNotTooNew(tx *sql.Tx) error {
+	currentVersion, err := readSchemaVersion(tx)
+	if err != nil {
+		return err
+	}
+	applied, recorded, err := readAppliedMigrations(tx)
+	if err != nil {
+		return err
+	}
+
+	latestVersion := latestSchemaVersion()
+	tooNew := currentVersion > max(latestVersion, legacySchemaVersionMax)
+	if recorded {
+		tooNew = false
+		for version := range applied {
+			if version > latestVersion {
+				tooNew = true
+			}
+		}
+	}
+	if tooNew {
+		return fmt.Errorf(
+			"%w: database schema version %d is newer than this binary supports (%d); upgrade the maand binary",
+			bucket.ErrSchemaTooNew,
+			currentVersion,
+			latestVersion,
+		)
+	}
 	return nil
 }
 
@@ -80,24 +120,46 @@ var requiredSchemaColumns = map[string][]string{
 		"restart_globs",
 		"current_memory_source",
 		"current_cpu_source",
+		"rollback_on_failure",
+		"rollout",
+		"rollout_by",
+		"allocation_count",
+		"placement",
+		"selector",
+		"min_disk_mb",
+		"max_disk_mb",
+		"current_disk_mb",
+		"current_disk_source",
+	},
+	"worker": {
+		"available_disk_mb",
+		"memory_overcommit",
+		"cpu_overcommit",
+		"disk_overcommit",
 	},
 	"hash": {
 		"current_version",
 		"current_files",
 		"previous_files",
+		"rolled_back_hash",
+		"cancelled_hash",
 	},
 	"allocations": {
 		"new_version",
 	},
+	"worker_reserved_ports": {"worker_id", "port"},
+	"deploy_run":            {"run_id", "started_at", "status", "workers_filter"},
+	"deploy_run_job":        {"run_id", "job", "result"},
+	"deploy_run_allocation": {"run_id", "job", "alloc_id", "old_hash", "new_hash"},
 }
 
 var requiredCatalogViewColumns = map[string][]string{
 	"cat_allocations":   {"alloc_id", "worker_ip", "job", "disabled", "removed", "new_version", "zone"},
-	"cat_jobs":          {"job_id", "name", "version", "disabled", "deployment_seq", "selectors", "current_memory_mb", "current_memory_source", "current_cpu_mhz", "current_cpu_source"},
+	"cat_jobs":          {"job_id", "name", "version", "disabled", "deployment_seq", "selectors", "current_memory_mb", "current_memory_source", "current_cpu_mhz", "current_cpu_source", "current_disk_mb", "current_disk_source"},
 	"cat_job_commands":  {"job", "command_name", "executed_on", "demand_job", "demand_command", "demand_config"},
 	"cat_kv":            {"namespace", "key", "value", "version", "ttl", "created_date", "deleted"},
-	"cat_workers":       {"worker_id", "worker_ip", "available_memory_mb", "available_cpu_mhz", "position", "labels", "zone"},
-	"cat_deployments":   {"alloc_id", "worker_ip", "job", "disabled", "removed", "current_hash", "previous_hash", "current_version", "new_version"},
+	"cat_workers":       {"worker_id", "worker_ip", "available_memory_mb", "available_cpu_mhz", "position", "labels", "zone", "available_disk_mb", "memory_overcommit", "cpu_overcommit", "disk_overcommit"},
+	"cat_deployments":   {"alloc_id", "worker_ip", "job", "disabled", "removed", "current_hash", "previous_hash", "current_version", "new_version", "rolled_back_hash", "cancelled_hash"},
 }
 
 func checkRequiredSchemaColumns(db *sql.DB) error {
@@ -163,13 +225,30 @@ func checkRequiredCatalogViews(db *sql.DB) error {
 	return nil
 }
 
-// MigrateSchema brings an existing or new database to LatestSchemaVersion.
-// It is idempotent and safe to run on every maand init.
+// MigrateSchema brings an existing or new database to the latest schema version: it
+// creates missing tables, applies pending migrations in order and recreates the catalog
+// views. It is idempotent and safe to run on every maand init. A database migrated by a
+// newer binary fails with ErrSchemaTooNew before anything is changed.
 func MigrateSchema(tx *sql.Tx) error {
+	pending, err := PendingMigrations(tx)
+	if err != nil {
+		return err
+	}
 	if err := execStatements(tx, baseTableDDL()); err != nil {
 		return err
 	}
-	if err := migrateToV1(tx); err != nil {
+	if err := ensureMigrationHistory(tx); err != nil {
+		return err
+	}
+	for _, migration := range pending {
+		if err := applyMigration(tx, migration); err != nil {
+			return err
+		}
+	}
+	if err := recreateCatalogViews(tx); err != nil {
+		return err
+	}
+	if err := ensureCatDeploymentsView(tx); err != nil {
 		return err
 	}
 
@@ -177,10 +256,10 @@ func MigrateSchema(tx *sql.Tx) error {
 	if err != nil {
 		return err
 	}
-	if currentVersion == LatestSchemaVersion {
+	if currentVersion == latestSchemaVersion() {
 		return nil
 	}
-	return writeSchemaVersion(tx, LatestSchemaVersion)
+	return writeSchemaVersion(tx, latestSchemaVersion())
 }
 
 func readSchemaVersion(tx *sql.Tx) (int, error) {
@@ -263,6 +342,136 @@ func migrateToV1(tx *sql.Tx) error {
 	return ensureCatDeploymentsView(tx)
 }
 
+// migrateToV2 adds what rollback on a failed health gate needs: the job's
+// rollback_on_failure, the hash it rolled back from, and the promoted job files it restages.
+func migrateToV2(tx *sql.Tx) error {
+	if err := ensureTableColumn(tx, "job", "rollback_on_failure", `ALTER TABLE job ADD COLUMN rollback_on_failure INT NOT NULL DEFAULT 0`); err != nil {
+		return err
+	}
+	if err := ensureTableColumn(tx, "hash", "rolled_back_hash", `ALTER TABLE hash ADD COLUMN rolled_back_hash TEXT`); err != nil {
+		return err
+	}
+	return execStatements(tx, []string{
+		`CREATE TABLE IF NOT EXISTS job_files_promoted (job_id TEXT, path TEXT, content BLOB, isdir BOOL)`,
+	})
+}
+
+// migrateToV3 adds the job's rollout strategy and the state of paused canary rollouts.
+func migrateToV3(tx *sql.Tx) error {
+	if err := ensureTableColumn(tx, "job", "rollout", `ALTER TABLE job ADD COLUMN rollout TEXT`); err != nil {
+		return err
+	}
+	return execStatements(tx, []string{
+		`CREATE TABLE IF NOT EXISTS rollout_state (
+			job TEXT,
+			status TEXT NOT NULL,
+			canaries TEXT NOT NULL DEFAULT '{}',
+			updated_at TEXT,
+			PRIMARY KEY(job)
+		)`,
+	})
+}
+
+// migrateToV4 adds the worker tag a job rolls out by, one group at a time.
+func migrateToV4(tx *sql.Tx) error {
+	return ensureTableColumn(tx, "job", "rollout_by", `ALTER TABLE job ADD COLUMN rollout_by TEXT NOT NULL DEFAULT ''`)
+}
+
+// migrateToV5 adds the hash a cancelled deploy left staged.
+func migrateToV5(tx *sql.Tx) error {
+	return ensureTableColumn(tx, "hash", "cancelled_hash", `ALTER TABLE hash ADD COLUMN cancelled_hash TEXT`)
+}
+
+// migrateToV6 adds the deploy run history: one row per run, per job and per allocation.
+func migrateToV6(tx *sql.Tx) error {
+	return execStatements(tx, []string{
+		`CREATE TABLE IF NOT EXISTS deploy_run (
+			run_id TEXT,
+			update_seq INT,
+			started_at TEXT NOT NULL,
+			finished_at TEXT,
+			jobs_filter TEXT NOT NULL DEFAULT '',
+			force INT NOT NULL DEFAULT 0,
+			sync_only INT NOT NULL DEFAULT 0,
+			status TEXT NOT NULL,
+			error TEXT NOT NULL DEFAULT '',
+			PRIMARY KEY(run_id)
+		)`,
+		`CREATE TABLE IF NOT EXISTS deploy_run_job (
+			run_id TEXT,
+			job TEXT,
+			result TEXT NOT NULL,
+			error TEXT NOT NULL DEFAULT '',
+			PRIMARY KEY(run_id, job)
+		)`,
+		`CREATE TABLE IF NOT EXISTS deploy_run_allocation (
+			run_id TEXT,
+			job TEXT,
+			alloc_id TEXT,
+			worker_ip TEXT,
+			old_version TEXT,
+			new_version TEXT,
+			old_hash TEXT,
+			new_hash TEXT,
+			PRIMARY KEY(run_id, alloc_id)
+		)`,
+	})
+}
+
+// migrateToV7 records the --workers filter of each deploy run.
+func migrateToV7(tx *sql.Tx) error {
+	return ensureTableColumn(tx, "deploy_run", "workers_filter", `ALTER TABLE deploy_run ADD COLUMN workers_filter TEXT NOT NULL DEFAULT ''`)
+}
+
+// migrateToV8 adds the manifest count of allocations to place.
+func migrateToV8(tx *sql.Tx) error {
+	return ensureTableColumn(tx, "job", "allocation_count", `ALTER TABLE job ADD COLUMN allocation_count INT NOT NULL DEFAULT 0`)
+}
+
+// migrateToV9 adds the job's placement affinity and anti-affinity rules.
+func migrateToV9(tx *sql.Tx) error {
+	return ensureTableColumn(tx, "job", "placement", `ALTER TABLE job ADD COLUMN placement TEXT`)
+}
+
+// migrateToV10 adds the job's selector expression over worker labels and tags.
+func migrateToV10(tx *sql.Tx) error {
+	return ensureTableColumn(tx, "job", "selector", `ALTER TABLE job ADD COLUMN selector TEXT NOT NULL DEFAULT ''`)
+}
+
+// migrateToV11 adds disk as a resource: the job's disk bounds and reservation, and the
+// worker's available disk.
+func migrateToV11(tx *sql.Tx) error {
+	for _, column := range []struct{ table, name, alterDDL string }{
+		{"job", "min_disk_mb", `ALTER TABLE job ADD COLUMN min_disk_mb TEXT NOT NULL DEFAULT '0'`},
+		{"job", "max_disk_mb", `ALTER TABLE job ADD COLUMN max_disk_mb TEXT NOT NULL DEFAULT '0'`},
+		{"job", "current_disk_mb", `ALTER TABLE job ADD COLUMN current_disk_mb TEXT NOT NULL DEFAULT '0'`},
+		{"job", "current_disk_source", `ALTER TABLE job ADD COLUMN current_disk_source TEXT NOT NULL DEFAULT 'manifest'`},
+		{"worker", "available_disk_mb", `ALTER TABLE worker ADD COLUMN available_disk_mb TEXT NOT NULL DEFAULT '0'`},
+	} {
+		if err := ensureTableColumn(tx, column.table, column.name, column.alterDDL); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrateToV12 adds the ports each worker reserves in workers.json.
+func migrateToV12(tx *sql.Tx) error {
+	return execStatements(tx, []string{
+		`CREATE TABLE IF NOT EXISTS worker_reserved_ports (worker_id TEXT, port INT)`,
+	})
+}
+
+// migrateToV13 adds the worker's memory, cpu and disk overcommit ratios.
+func migrateToV13(tx *sql.Tx) error {
+	for _, column := range []string{"memory_overcommit", "cpu_overcommit", "disk_overcommit"} {
+		if err := ensureTableColumn(tx, "worker", column, `ALTER TABLE worker ADD COLUMN `+column+` REAL NOT NULL DEFAULT 1`); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func migrateJobRolloutColumns(tx *sql.Tx) error {
 	if err := ensureTableColumn(tx, "job", "update_parallel_count", `ALTER TABLE job ADD COLUMN update_parallel_count INT NOT NULL DEFAULT 1`); err != nil {
 		return err
@@ -306,11 +515,13 @@ func ensureCatDeploymentsView(tx *sql.Tx) error {
 		`DROP VIEW IF EXISTS cat_deployments`,
 		`CREATE VIEW cat_deployments (
 			alloc_id, worker_ip, job, disabled, removed,
-			current_hash, previous_hash, current_version, new_version
+			current_hash, previous_hash, current_version, new_version,
+			rolled_back_hash, cancelled_hash
 		) AS
 			SELECT a.alloc_id, a.worker_ip, a.job, a.disabled, a.removed,
 			       ifnull(h.current_hash, ''), ifnull(h.previous_hash, ''),
-			       ifnull(h.current_version, ''), ifnull(a.new_version, '')
+			       ifnull(h.current_version, ''), ifnull(a.new_version, ''),
+			       ifnull(h.rolled_back_hash, ''), ifnull(h.cancelled_hash, '')
 			FROM allocations a
 			LEFT JOIN hash h ON h.namespace = (a.job || '_allocation') AND h.key = a.alloc_id
 			ORDER BY a.job, a.worker_ip`,
@@ -336,6 +547,18 @@ func ensureTableColumn(tx *sql.Tx, table, column, alterDDL string) error {
 	return nil
 }
 
+func tableExists(tx *sql.Tx, table string) (bool, error) {
+	var count int
+	err := tx.QueryRow(
+		`SELECT count(*) FROM sqlite_master WHERE type = 'table' AND name = ?`,
+		table,
+	).Scan(&count)
+	if err != nil {
+		return false, bucket.DatabaseError(err)
+	}
+	return count > 0, nil
+}
+
 func baseTableDDL() []string {
 	return []string{
 		`CREATE TABLE IF NOT EXISTS bucket (bucket_id TEXT, update_seq INT)`,
@@ -426,13 +649,15 @@ func recreateCatalogViews(tx *sql.Tx) error {
 				 JOIN worker w ON w.worker_id = wt.worker_id
 				 WHERE w.worker_ip = a.worker_ip AND wt.key = 'zone') AS zone
 			FROM allocations a ORDER BY job`,
-		`CREATE VIEW cat_jobs (job_id, name, version, disabled, deployment_seq, selectors, current_memory_mb, current_memory_source, current_cpu_mhz, current_cpu_source) AS
+		`CREATE VIEW cat_jobs (job_id, name, version, disabled, deployment_seq, selectors, current_memory_mb, current_memory_source, current_cpu_mhz, current_cpu_source, current_disk_mb, current_disk_source) AS
 			SELECT DISTINCT job_id, name, version,
 				(CASE WHEN (SELECT COUNT(1) FROM allocations wj WHERE j.name = wj.job AND wj.disabled = 0) > 0 THEN 0 ELSE 1 END) AS disabled,
 				ifnull((SELECT DISTINCT deployment_seq FROM allocations wj WHERE wj.job = j.name), 0) AS deployment_seq,
-				ifnull((SELECT GROUP_CONCAT(selector) FROM job_selectors jl WHERE jl.job_id = j.job_id), '') as selectors,
+				(CASE WHEN j.selector != '' THEN j.selector
+					ELSE ifnull((SELECT GROUP_CONCAT(selector) FROM job_selectors jl WHERE jl.job_id = j.job_id), '') END) as selectors,
 				j.current_memory_mb, j.current_memory_source,
-				j.current_cpu_mhz, j.current_cpu_source
+				j.current_cpu_mhz, j.current_cpu_source,
+				j.current_disk_mb, j.current_disk_source
 			FROM job j ORDER BY deployment_seq, name`,
 		`CREATE VIEW cat_job_commands (job, command_name, executed_on, demand_job, demand_command, demand_config) AS
 			SELECT job, name as command_name, executed_on, demand_job, demand_command, demand_config FROM job_commands ORDER BY job, name`,
@@ -447,10 +672,12 @@ func recreateCatalogViews(tx *sql.Tx) error {
 					max(version) as version, ttl, created_date, deleted
 				FROM key_value GROUP BY namespace, key
 			) t ORDER BY namespace, key`,
-		`CREATE VIEW cat_workers (worker_id, worker_ip, available_memory_mb, available_cpu_mhz, position, labels, zone) AS
+		`CREATE VIEW cat_workers (worker_id, worker_ip, available_memory_mb, available_cpu_mhz, position, labels, zone, available_disk_mb,
+				memory_overcommit, cpu_overcommit, disk_overcommit) AS
 			SELECT w.worker_id, w.worker_ip, w.available_memory_mb, w.available_cpu_mhz, w.position,
 				(SELECT group_concat(label) AS labels FROM worker_labels WHERE worker_id = w.worker_id) AS labels,
-				(SELECT wt.value FROM worker_tags wt WHERE wt.worker_id = w.worker_id AND wt.key = 'zone') AS zone
+				(SELECT wt.value FROM worker_tags wt WHERE wt.worker_id = w.worker_id AND wt.key = 'zone') AS zone,
+				w.available_disk_mb, w.memory_overcommit, w.cpu_overcommit, w.disk_overcommit
 			FROM worker w ORDER BY position`,
 	}
 	return execStatements(tx, views)
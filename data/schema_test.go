@@ -136,7 +136,7 @@ func TestMigrateSchemaRenumbersLegacyVersion(t *testing.T) {
 
 	var version int
 	require.NoError(t, db.QueryRow(`SELECT version FROM schema_version WHERE id = 1`).Scan(&version))
-	assert.Equal(t, LatestSchemaVersion, version)
+	assert.Equal(t, latestSchemaVersion(), version)
 	require.NoError(t, CheckSchemaVersion())
 }
 
@@ -234,13 +234,7 @@ func TestCheckSchemaVersionRequiresUpgradeForMissingColumn(t *testing.T) {
 	require.NoError(t, err)
 	tx, err := db.Begin()
 	require.NoError(t, err)
-	require.NoError(t, execStatements(tx, append(baseTableDDL(),
-		`CREATE TABLE IF NOT EXISTS schema_version (
-			id INTEGER PRIMARY KEY CHECK (id = 1),
-			version INTEGER NOT NULL
-		)`,
-		`INSERT INTO schema_version (id, version) VALUES (1, 1)`,
-	)))
+	require.NoError(t, MigrateSchema(tx))
 	_, err = tx.Exec(`ALTER TABLE job DROP COLUMN restart_policy`)
 	require.NoError(t, err)
 	require.NoError(t, tx.Commit())
@@ -290,7 +284,7 @@ func TestCheckSchemaVersionTooNew(t *testing.T) {
 			version INTEGER NOT NULL
 		)`,
 	)))
-	require.NoError(t, writeSchemaVersion(tx, legacySchemaVersionMax+1))
+	require.NoError(t, writeSchemaVersion(tx, latestSchemaVersion()+1))
 	require.NoError(t, tx.Commit())
 	require.NoError(t, db.Close())
 
@@ -309,7 +303,7 @@ func TestMigrateSchemaIsIdempotent(t *testing.T) {
 
 	var version int
 	require.NoError(t, db.QueryRow(`SELECT version FROM schema_version WHERE id = 1`).Scan(&version))
-	assert.Equal(t, LatestSchemaVersion, version)
+	assert.Equal(t, latestSchemaVersion(), version)
 }
 
 func TestReadSchemaVersionWhenTableEmpty(t *testing.T) {
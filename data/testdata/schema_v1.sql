@@ -0,0 +1,85 @@
+-- maand.db catalog at schema version 1, as migrations 1-1 leave it.
+-- Generated from baseTableDDL and migrations[:1]; views are recreated by MigrateSchema.
+CREATE TABLE allocations (
+			alloc_id TEXT,
+			worker_ip TEXT,
+			job TEXT,
+			disabled INT,
+			removed INT,
+			deployment_seq INT,
+			new_version TEXT,
+			PRIMARY KEY(worker_ip, job)
+		);
+CREATE TABLE bucket (bucket_id TEXT, update_seq INT);
+CREATE TABLE hash (
+			namespace TEXT,
+			key TEXT,
+			current_hash TEXT,
+			previous_hash TEXT,
+			current_files TEXT,
+			previous_files TEXT,
+			current_version TEXT,
+			PRIMARY KEY(namespace, key)
+		);
+CREATE TABLE job (
+			job_id TEXT,
+			name TEXT,
+			version TEXT,
+			min_memory_mb TEXT,
+			max_memory_mb TEXT,
+			current_memory_mb TEXT,
+			current_memory_source TEXT NOT NULL DEFAULT 'manifest',
+			min_cpu_mhz TEXT,
+			max_cpu_mhz TEXT,
+			current_cpu_mhz TEXT,
+			current_cpu_source TEXT NOT NULL DEFAULT 'manifest',
+			max_concurrent_upgrades INT NOT NULL DEFAULT 1,
+			max_concurrent_starts INT NOT NULL DEFAULT 0,
+			restart_policy TEXT NOT NULL DEFAULT 'always',
+			restart_globs TEXT NOT NULL DEFAULT '[]',
+			health_check TEXT, update_parallel_count INT NOT NULL DEFAULT 1, deploy_parallel_count INT NOT NULL DEFAULT 0,
+			PRIMARY KEY(name)
+		);
+CREATE TABLE job_certs (job_id TEXT, name TEXT, pkcs8 INT, one INT, subject TEXT);
+CREATE TABLE job_commands (
+			job_id TEXT,
+			job TEXT,
+			name TEXT,
+			executed_on TEXT,
+			demand_job TEXT,
+			demand_command TEXT,
+			demand_config TEXT
+		);
+CREATE TABLE job_files (job_id TEXT, path TEXT, content BLOB, isdir BOOL);
+CREATE TABLE job_ports (job_id TEXT, name TEXT, port INT);
+CREATE TABLE job_selectors (job_id TEXT, selector TEXT);
+CREATE TABLE key_value (
+			key TEXT,
+			value TEXT,
+			namespace TEXT,
+			version INT,
+			ttl TEXT,
+			created_date TEXT,
+			deleted INT
+		);
+CREATE TABLE schema_migrations (
+			version INTEGER PRIMARY KEY,
+			description TEXT NOT NULL,
+			applied_at TEXT NOT NULL
+		);
+CREATE TABLE schema_version (
+			id INTEGER PRIMARY KEY CHECK (id = 1),
+			version INTEGER NOT NULL
+		);
+CREATE TABLE worker (
+			worker_id TEXT,
+			worker_ip TEXT,
+			available_memory_mb TEXT,
+			available_cpu_mhz TEXT,
+			position INT,
+			PRIMARY KEY(worker_ip)
+		);
+CREATE TABLE worker_labels (worker_id TEXT, label TEXT);
+CREATE TABLE worker_tags (worker_id TEXT, key TEXT, value TEXT);
+INSERT INTO schema_version (id, version) VALUES (1, 1);
+INSERT INTO schema_migrations (version, description, applied_at) VALUES (1, 'baseline catalog columns and schema_version', '2025-06-01T00:00:00Z');
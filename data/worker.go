@@ -139,6 +139,36 @@ func GetWorkerTags(tx *sql.Tx, workerID string) (map[string]string, error) {
 	return tags, nil
 }
 
+// GetWorkerTagValues returns the value of one worker tag keyed by worker IP. Workers without
+// the tag are omitted.
+func GetWorkerTagValues(tx *sql.Tx, key string) (map[string]string, error) {
+	rows, err := tx.Query(
+		`SELECT w.worker_ip, wt.value FROM worker_tags wt
+		 JOIN worker w ON w.worker_id = wt.worker_id
+		 WHERE wt.key = ?`,
+		key,
+	)
+	if err != nil {
+		return nil, bucket.DatabaseError(err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	values := make(map[string]string)
+	for rows.Next() {
+		var workerIP, value string
+		if err := rows.Scan(&workerIP, &value); err != nil {
+			return nil, bucket.DatabaseError(err)
+		}
+		values[workerIP] = value
+	}
+	if err := rowsErr(rows); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
 func GetAllocatedWorkerIPs(tx *sql.Tx) ([]string, error) {
 	rows, err := tx.Query(`SELECT DISTINCT worker_ip FROM allocations ORDER BY worker_ip`)
 	if err != nil {
@@ -206,6 +236,16 @@ func GetWorkerMemory(tx *sql.Tx, workerIP string) (string, error) {
 	return availableMemoryMb, nil
 }
 
+func GetWorkerDisk(tx *sql.Tx, workerIP string) (string, error) {
+	var availableDiskMb string
+	row := tx.QueryRow("SELECT available_disk_mb FROM worker WHERE worker_ip = ?", workerIP)
+	err := row.Scan(&availableDiskMb)
+	if err != nil {
+		return "", bucket.DatabaseError(err)
+	}
+	return availableDiskMb, nil
+}
+
 func GetAllocatedJobs(tx *sql.Tx, workerIP string) ([]string, error) {
 	rows, err := tx.Query("SELECT job FROM allocations WHERE worker_ip = ?", workerIP)
 	if err != nil {
@@ -6,20 +6,21 @@ package deploy
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 
 	"maand/bucket"
 	"maand/data"
-	"maand/healthcheck"
 	"maand/utils"
 )
 
-func handleNewAllocations(tx *sql.Tx, rt *bucket.Runtime, bucketID, job string) error {
+func handleNewAllocations(tx *sql.Tx, rt *bucket.Runtime, bucketID, job string, opts Options) error {
 	newAllocations, err := data.GetNewAllocations(tx, job)
 	if err != nil {
 		return err
 	}
+	newAllocations = selectWorkersForDeploy(newAllocations, opts)
 	if len(newAllocations) == 0 {
 		return nil
 	}
@@ -44,6 +45,19 @@ func handleUpdatedAllocations(tx *sql.Tx, rt *bucket.Runtime, bucketID, job stri
 	if err != nil {
 		return err
 	}
+	updatedAllocations = selectWorkersForDeploy(updatedAllocations, opts)
+	if opts.PromoteRollout {
+		updatedAllocations, err = excludeBakedCanaries(tx, job, updatedAllocations)
+		if err != nil {
+			return err
+		}
+	}
+	return upgradeAllocations(tx, rt, bucketID, job, updatedAllocations, opts)
+}
+
+// upgradeAllocations applies restart_policy to the given updated allocations in
+// max_concurrent_upgrades batches: reloads first, then restarts.
+func upgradeAllocations(tx *sql.Tx, rt *bucket.Runtime, bucketID, job string, updatedAllocations []string, opts Options) error {
 	if len(updatedAllocations) == 0 {
 		return nil
 	}
@@ -124,6 +138,10 @@ func handleUpdatedAllocations(tx *sql.Tx, rt *bucket.Runtime, bucketID, job stri
 			return runWorkerCommand(rt, workerIP, runnerCmdCtx(job, "rollout", rolloutActionRestart, bucketID), []string{restartCmd}, env)
 		}
 		if err := rolloutRestartBatches(tx, rt, bucketID, job, restartWorkers, restartFn); err != nil {
+			var healthErr *rolloutHealthError
+			if errors.As(err, &healthErr) {
+				healthErr.Upgraded = append(append([]string(nil), reloadWorkers...), healthErr.Upgraded...)
+			}
 			return err
 		}
 	}
@@ -199,18 +217,28 @@ func reconcileStoppedAllocation(
 	alloc data.StoppedAllocation,
 	assumeDead bool,
 ) error {
-	namespace := fmt.Sprintf("%s_allocation", alloc.Job)
-	allocID, err := data.GetAllocationID(tx, alloc.WorkerIP, alloc.Job)
-	if err != nil {
+	if err := stopAllocationOnWorker(tx, rt, bucketID, alloc, assumeDead); err != nil {
 		return err
 	}
+	return recordStoppedAllocation(tx, alloc)
+}
 
-	previousHash, err := data.GetPreviousHash(tx, namespace, allocID)
+// stopAllocationOnWorker stops a deployed allocation on its worker, runs the
+// after_allocation_stopped hook and, for a removed allocation, removes the job from the
+// worker. It only reads the catalog; recordStoppedAllocation records the stop.
+func stopAllocationOnWorker(
+	tx *sql.Tx,
+	rt *bucket.Runtime,
+	bucketID string,
+	alloc data.StoppedAllocation,
+	assumeDead bool,
+) error {
+	deployed, err := allocationWasDeployed(tx, alloc.WorkerIP, alloc.Job)
 	if err != nil {
 		return err
 	}
 
-	if previousHash != "" {
+	if deployed {
 		if alloc.Disabled {
 			log.Printf("deploy: stop disabled allocation %s on %s", alloc.Job, alloc.WorkerIP)
 		}
@@ -240,29 +268,44 @@ func reconcileStoppedAllocation(
 		}
 	}
 
-	if alloc.Removed {
-		if err := data.RemoveAllocationHash(tx, alloc.Job, allocID); err != nil {
-			return err
-		}
-	}
-
 	return nil
 }
 
+// recordStoppedAllocation drops the hash of a removed allocation once it is stopped.
+func recordStoppedAllocation(tx *sql.Tx, alloc data.StoppedAllocation) error {
+	if !alloc.Removed {
+		return nil
+	}
+	allocID, err := data.GetAllocationID(tx, alloc.WorkerIP, alloc.Job)
+	if err != nil {
+		return err
+	}
+	return data.RemoveAllocationHash(tx, alloc.Job, allocID)
+}
+
+// reconcileRemovedAndDisabledAllocations stops removed and disabled allocations before any
+// job deploys. Removed allocations of the deferred jobs (see movedJobs) are left running.
 func reconcileRemovedAndDisabledAllocations(
 	tx *sql.Tx,
 	rt *bucket.Runtime,
 	bucketID string,
+	deferred map[string]bool,
 ) error {
 	currentWorkers, err := data.LoadWorkerCatalog(tx)
 	if err != nil {
 		return err
 	}
 
-	stopped, err := data.ListStoppedAllocations(tx)
+	listed, err := data.ListStoppedAllocations(tx)
 	if err != nil {
 		return err
 	}
+	stopped := make([]data.StoppedAllocation, 0, len(listed))
+	for _, alloc := range listed {
+		if !alloc.Removed || !deferred[alloc.Job] {
+			stopped = append(stopped, alloc)
+		}
+	}
 
 	jobsNeedingHealthCheck := make(map[string]struct{})
 	offCatalogWorkers := make(map[string]struct{})
@@ -294,7 +337,7 @@ func reconcileRemovedAndDisabledAllocations(
 			return err
 		}
 		if activeCount > 0 {
-			if err := healthcheck.HealthCheck(tx, rt, true, job, true); err != nil {
+			if err := runHealthCheck(tx, rt, job); err != nil {
 				return err
 			}
 		}
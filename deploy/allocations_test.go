@@ -24,7 +24,7 @@ func TestHandleNewAllocations_startsUnpromotedAllocation(t *testing.T) {
 	require.NoError(t, kv.Initialize(tx))
 	require.NoError(t, prepareJobsFiles(tx, []string{"app"}))
 	require.NoError(t, updateAllocationHash(tx, []string{"app"}))
-	require.NoError(t, handleNewAllocations(tx, nil, env.bucketID, "app"))
+	require.NoError(t, handleNewAllocations(tx, nil, env.bucketID, "app", Options{}))
 	assert.True(t, rec.HasAction("10.0.0.1", "start", "app"))
 	require.NoError(t, tx.Rollback())
 }
@@ -15,9 +15,10 @@ import (
 )
 
 const (
-	deployPhaseNew    = "new"
-	deployPhaseUpdate = "update"
-	deployPhaseStop   = "stop"
+	deployPhaseNew      = "new"
+	deployPhaseUpdate   = "update"
+	deployPhaseStop     = "stop"
+	deployPhaseRollback = "rollback"
 )
 
 // BatchContext describes one start/restart/stop batch for allocation hooks.
@@ -29,6 +30,9 @@ type BatchContext struct {
 	BatchAllocation []string
 	RolloutOrder    string
 	OrderSource     string
+	// RolloutBy is the manifest rollout_by tag key and RolloutGroup the batch's tag value.
+	RolloutBy    string
+	RolloutGroup string
 }
 
 func batchCount(total, batchSize int) int {
@@ -50,6 +54,8 @@ func batchEnv(ctx BatchContext) []string {
 		fmt.Sprintf("ROLLOUT_ORDER=%s", ctx.RolloutOrder),
 		fmt.Sprintf("ROLLOUT_ORDER_SOURCE=%s", ctx.OrderSource),
 		fmt.Sprintf("JOB=%s", ctx.Job),
+		fmt.Sprintf("ROLLOUT_BY=%s", ctx.RolloutBy),
+		fmt.Sprintf("ROLLOUT_GROUP=%s", ctx.RolloutGroup),
 	}
 }
 
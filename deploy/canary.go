@@ -0,0 +1,317 @@
+// Copyright 2025 Kiruba Sankar Swaminathan. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package deploy
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"maand/bucket"
+	"maand/data"
+	"maand/jobcommand"
+	"maand/kv"
+	"maand/utils"
+	"maand/workspace"
+)
+
+// errRolloutPaused reports that a canary rollout stopped after baking its canaries.
+// Execute treats it as neither a failure nor a finished job.
+var errRolloutPaused = errors.New("rollout paused")
+
+// canarySleep waits between bake health checks; tests replace it.
+var canarySleep = time.Sleep
+
+// canaryPlan splits a job's updated allocations into canaries and the allocations held
+// back until maand rollout promote.
+type canaryPlan struct {
+	Spec     *workspace.ManifestRollout
+	Canaries []string
+	HeldBack []string
+}
+
+// planCanary returns nil when the job uses the rolling strategy, has a paused rollout
+// already, or has no more updated allocations than canary_count. With opts.Workers, only
+// allocations on those workers are split.
+func planCanary(tx *sql.Tx, job string, opts Options) (*canaryPlan, error) {
+	spec, err := data.GetJobRollout(tx, job)
+	if err != nil {
+		return nil, err
+	}
+	if spec == nil || spec.Strategy != workspace.RolloutStrategyCanary {
+		return nil, nil
+	}
+	state, err := data.GetRolloutState(tx, job)
+	if err != nil {
+		return nil, err
+	}
+	if state != nil {
+		return nil, nil
+	}
+
+	updated, err := allocationsNeedingRestart(tx, job, opts.Force)
+	if err != nil {
+		return nil, err
+	}
+	active, err := activeWorkers(selectWorkersForDeploy(updated, opts), tx, job)
+	if err != nil {
+		return nil, err
+	}
+	if len(active) <= spec.CanaryCount {
+		return nil, nil
+	}
+
+	resolved, err := ResolveRolloutOrder(tx, job, active)
+	if err != nil {
+		return nil, err
+	}
+	return &canaryPlan{
+		Spec:     spec,
+		Canaries: append([]string(nil), resolved.Ordered[:spec.CanaryCount]...),
+		HeldBack: append([]string(nil), resolved.Ordered[spec.CanaryCount:]...),
+	}, nil
+}
+
+// canarySyncWorkers drops held-back allocations from the rsync targets so they keep
+// running the promoted files until the rollout is promoted.
+func canarySyncWorkers(tx *sql.Tx, job string, workers []string, opts Options) ([]string, error) {
+	if opts.PromoteRollout || opts.SyncOnly {
+		return workers, nil
+	}
+	plan, err := planCanary(tx, job, opts)
+	if err != nil {
+		return nil, err
+	}
+	if plan == nil {
+		return workers, nil
+	}
+	return utils.Difference(workers, plan.HeldBack), nil
+}
+
+// deployCanary upgrades the canary allocations, runs the bake health checks, and persists
+// a paused rollout_state row. It reports false when the job has nothing to canary.
+func deployCanary(tx *sql.Tx, rt *bucket.Runtime, bucketID, job string, opts Options) (bool, error) {
+	plan, err := planCanary(tx, job, opts)
+	if err != nil {
+		return false, err
+	}
+	if plan == nil {
+		return false, nil
+	}
+
+	log.Printf("deploy: job %q: canary %s (%d allocations held back)",
+		job, strings.Join(plan.Canaries, ","), len(plan.HeldBack))
+	if err := upgradeAllocations(tx, rt, bucketID, job, plan.Canaries, opts); err != nil {
+		return false, err
+	}
+	if err := bakeCanaries(tx, rt, job, plan); err != nil {
+		return false, err
+	}
+
+	namespace := fmt.Sprintf("%s_allocation", job)
+	canaries := make(map[string]string, len(plan.Canaries))
+	for _, workerIP := range plan.Canaries {
+		allocID, err := data.GetAllocationID(tx, workerIP, job)
+		if err != nil {
+			return false, err
+		}
+		current, _, _, err := data.GetAllocationHash(tx, namespace, allocID)
+		if err != nil {
+			return false, err
+		}
+		canaries[workerIP] = current
+	}
+	if err := writeCatalog(tx, func(tx *sql.Tx) error {
+		return data.SaveRolloutState(tx, data.RolloutState{
+			Job:      job,
+			Status:   data.RolloutStatusPaused,
+			Canaries: canaries,
+		})
+	}); err != nil {
+		return false, err
+	}
+
+	log.Printf("deploy: job %q: rollout paused; run maand rollout promote %s or maand rollout abort %s", job, job, job)
+	if rt != nil {
+		_ = rt.LogEvent("", "rollout_paused", map[string]string{
+			"job":       job,
+			"canaries":  strings.Join(plan.Canaries, ","),
+			"held_back": strings.Join(plan.HeldBack, ","),
+		})
+	}
+	return true, nil
+}
+
+// bakeCanaries spreads health_checks probes evenly over bake_seconds; every probe must pass.
+func bakeCanaries(tx *sql.Tx, rt *bucket.Runtime, job string, plan *canaryPlan) error {
+	interval := time.Duration(plan.Spec.BakeSeconds) * time.Second / time.Duration(plan.Spec.HealthChecks)
+	for i := 0; i < plan.Spec.HealthChecks; i++ {
+		canarySleep(interval)
+		if err := deployCancelled(tx); err != nil {
+			return err
+		}
+		if err := runHealthCheck(tx, rt, job); err != nil {
+			return &rolloutHealthError{
+				Upgraded: append([]string(nil), plan.Canaries...),
+				Err:      fmt.Errorf("canary bake check %d/%d: %w", i+1, plan.Spec.HealthChecks, err),
+			}
+		}
+	}
+	return nil
+}
+
+// excludeBakedCanaries drops canaries from a promote's upgrade set when their staged hash
+// still matches the hash they were upgraded to before the pause.
+func excludeBakedCanaries(tx *sql.Tx, job string, workers []string) ([]string, error) {
+	state, err := data.GetRolloutState(tx, job)
+	if err != nil {
+		return nil, err
+	}
+	if state == nil {
+		return workers, nil
+	}
+
+	namespace := fmt.Sprintf("%s_allocation", job)
+	remaining := make([]string, 0, len(workers))
+	for _, workerIP := range workers {
+		bakedHash, ok := state.Canaries[workerIP]
+		if ok {
+			allocID, err := data.GetAllocationID(tx, workerIP, job)
+			if err != nil {
+				return nil, err
+			}
+			current, _, _, err := data.GetAllocationHash(tx, namespace, allocID)
+			if err != nil {
+				return nil, err
+			}
+			if current == bakedHash {
+				continue
+			}
+		}
+		remaining = append(remaining, workerIP)
+	}
+	return remaining, nil
+}
+
+// PromoteRollout finishes a paused canary rollout: held-back allocations are rsynced and
+// upgraded, new allocations are started, and every allocation is promoted.
+func PromoteRollout(job string) error {
+	if err := requirePausedRollout(job); err != nil {
+		return err
+	}
+	return Execute([]string{job}, Options{PromoteRollout: true})
+}
+
+// AbortRollout restores the canaries of a paused rollout to the last promoted job files
+// and clears the paused state. Held-back allocations were never restarted.
+func AbortRollout(job string) error {
+	db, err := data.OpenDatabase(true)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	if err := os.RemoveAll(bucket.TempLocation); err != nil {
+		return bucket.UnexpectedError(err)
+	}
+	defer func() {
+		_ = os.RemoveAll(bucket.TempLocation)
+	}()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return bucket.DatabaseError(err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	state, err := data.GetRolloutState(tx, job)
+	if err != nil {
+		return err
+	}
+	if state == nil {
+		return bucket.NotFoundError(fmt.Sprintf("paused rollout for job %s", job))
+	}
+
+	if err := kv.Initialize(tx); err != nil {
+		return err
+	}
+	bucketID, err := data.GetBucketID(tx)
+	if err != nil {
+		return err
+	}
+	updateSeq, err := data.GetBucketUpdateSeq(tx)
+	if err != nil {
+		return err
+	}
+
+	canaries := state.CanaryWorkers()
+	if err := checkDeployPrerequisites(canaries); err != nil {
+		return err
+	}
+
+	rt, err := setupDeployRuntime(bucketID, bucket.NewRunContext("rollout", updateSeq))
+	if err != nil {
+		return err
+	}
+	cancel := jobcommand.StartRuntimeAPI(tx)
+	defer func() {
+		cancel()
+		if rt != nil {
+			_ = rt.Stop()
+		}
+	}()
+
+	for _, workerIP := range canaries {
+		if err := prepareOneWorkerFiles(tx, workerIP); err != nil {
+			return err
+		}
+	}
+	if _, err := rollbackAllocations(tx, rt, bucketID, job, canaries); err != nil {
+		return fmt.Errorf("abort rollout %s: %w", job, err)
+	}
+	if err := data.ClearRolloutState(tx, job); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return bucket.DatabaseError(err)
+	}
+	return nil
+}
+
+func requirePausedRollout(job string) error {
+	db, err := data.OpenDatabase(true)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return bucket.DatabaseError(err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	state, err := data.GetRolloutState(tx, job)
+	if err != nil {
+		return err
+	}
+	if state == nil {
+		return bucket.NotFoundError(fmt.Sprintf("paused rollout for job %s", job))
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+// Copyright 2025 Kiruba Sankar Swaminathan. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package deploy
+
+import (
+	"database/sql"
+	"errors"
+	"path"
+	"sync"
+	"testing"
+	"time"
+
+	"maand/bucket"
+	"maand/data"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var canaryTestWorkers = []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"}
+
+// seedCanaryJob deploys app on three workers, then stages a content change. It returns
+// the recorder and the worker IPs rsynced after the initial deploy.
+func seedCanaryJob(t *testing.T, env *deployTestEnv) (*CommandRecorder, *[]string) {
+	t.Helper()
+	rec := installNoopDeployHooks(t, env.bucketID)
+	origSleep := canarySleep
+	canarySleep = func(time.Duration) {}
+	t.Cleanup(func() { canarySleep = origSleep })
+
+	tx := env.begin(t)
+	env.seedMakefileJob(t, tx, "app", canaryTestWorkers[0], 0)
+	for i, workerIP := range canaryTestWorkers[1:] {
+		env.ensureWorker(t, tx, workerIP, i+1)
+		env.insertAllocation(t, tx, "alloc-app-"+workerIP, workerIP, "app", 0, 0, 0)
+	}
+	env.insertJobFile(t, tx, "job-app", path.Join("app", "marker.txt"), "v1", false)
+	_, err := tx.Exec(`UPDATE job SET rollout = '{"strategy":"canary","canary_count":1,"health_checks":2}', rollback_on_failure = 1 WHERE name = 'app'`)
+	require.NoError(t, err)
+	require.NoError(t, tx.Commit())
+	require.NoError(t, Execute(nil, Options{}))
+
+	tx = env.begin(t)
+	_, err = tx.Exec(`UPDATE job_files SET content = 'v2' WHERE job_id = 'job-app' AND path = ?`, path.Join("app", "marker.txt"))
+	require.NoError(t, err)
+	require.NoError(t, tx.Commit())
+
+	// syncWorkers rsyncs workers in parallel.
+	var mu sync.Mutex
+	var synced []string
+	testHooks.Rsync = func(_ *bucket.Runtime, _ string, workerIP string, _ []string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		synced = append(synced, workerIP)
+		return nil
+	}
+	rec.Commands = nil
+	return rec, &synced
+}
+
+func (e *deployTestEnv) rolloutState(t *testing.T) *data.RolloutState {
+	t.Helper()
+	tx := e.begin(t)
+	defer func() { _ = tx.Rollback() }()
+	state, err := data.GetRolloutState(tx, "app")
+	require.NoError(t, err)
+	return state
+}
+
+func TestExecute_canaryPausesAfterCanaries(t *testing.T) {
+	env := setupDeployTestEnv(t)
+	rec, synced := seedCanaryJob(t, env)
+
+	require.NoError(t, Execute(nil, Options{}))
+	assert.True(t, rec.HasAction("10.0.0.1", "restart", "app"))
+	assert.False(t, rec.HasAction("10.0.0.2", "restart", "app"))
+	assert.False(t, rec.HasAction("10.0.0.3", "restart", "app"))
+	assert.NotContains(t, *synced, "10.0.0.2")
+	assert.NotContains(t, *synced, "10.0.0.3")
+
+	state := env.rolloutState(t)
+	require.NotNil(t, state)
+	assert.Equal(t, data.RolloutStatusPaused, state.Status)
+	assert.Equal(t, []string{"10.0.0.1"}, state.CanaryWorkers())
+
+	tx := env.begin(t)
+	assert.False(t, env.allocationHashPromoted(t, tx, "app", "alloc-app-10.0.0.1"))
+	require.NoError(t, tx.Rollback())
+
+	rec.Commands = nil
+	require.NoError(t, Execute(nil, Options{}))
+	assert.Empty(t, rec.Commands)
+}
+
+func TestPromoteRollout_upgradesHeldBackAllocations(t *testing.T) {
+	env := setupDeployTestEnv(t)
+	rec, _ := seedCanaryJob(t, env)
+	require.NoError(t, Execute(nil, Options{}))
+
+	rec.Commands = nil
+	require.NoError(t, PromoteRollout("app"))
+	assert.False(t, rec.HasAction("10.0.0.1", "restart", "app"))
+	assert.True(t, rec.HasAction("10.0.0.2", "restart", "app"))
+	assert.True(t, rec.HasAction("10.0.0.3", "restart", "app"))
+	assert.Nil(t, env.rolloutState(t))
+
+	tx := env.begin(t)
+	defer func() { _ = tx.Rollback() }()
+	for _, workerIP := range canaryTestWorkers {
+		assert.True(t, env.allocationHashPromoted(t, tx, "app", "alloc-app-"+workerIP))
+	}
+}
+
+func TestAbortRollout_restoresCanaries(t *testing.T) {
+	env := setupDeployTestEnv(t)
+	rec, _ := seedCanaryJob(t, env)
+	require.NoError(t, Execute(nil, Options{}))
+
+	rec.Commands = nil
+	require.NoError(t, AbortRollout("app"))
+	assert.True(t, rec.HasAction("10.0.0.1", "restart", "app"))
+	assert.False(t, rec.HasAction("10.0.0.2", "restart", "app"))
+	assert.Nil(t, env.rolloutState(t))
+
+	tx := env.begin(t)
+	defer func() { _ = tx.Rollback() }()
+	var current, rolledBack sql.NullString
+	require.NoError(t, tx.QueryRow(
+		`SELECT current_hash, rolled_back_hash FROM hash WHERE namespace = 'app_allocation' AND key = 'alloc-app-10.0.0.1'`,
+	).Scan(&current, &rolledBack))
+	assert.Equal(t, current.String, rolledBack.String)
+}
+
+func TestRolloutCommands_requirePausedRollout(t *testing.T) {
+	env := setupDeployTestEnv(t)
+	installNoopDeployHooks(t, env.bucketID)
+
+	err := PromoteRollout("app")
+	require.Error(t, err)
+	assert.ErrorIs(t, err, bucket.ErrNotFound)
+
+	err = AbortRollout("app")
+	require.Error(t, err)
+	assert.ErrorIs(t, err, bucket.ErrNotFound)
+}
+
+func TestExecute_canaryBakeFailureRollsBack(t *testing.T) {
+	env := setupDeployTestEnv(t)
+	rec, _ := seedCanaryJob(t, env)
+
+	checks := 0
+	testHooks.HealthCheck = func(*sql.Tx, *bucket.Runtime, string) error {
+		checks++
+		if checks == 2 {
+			return errors.New("probe failed")
+		}
+		return nil
+	}
+
+	err := Execute(nil, Options{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "canary bake check 1/2")
+	assert.Contains(t, err.Error(), "rolled back 10.0.0.1")
+	assert.False(t, rec.HasAction("10.0.0.2", "restart", "app"))
+	assert.Nil(t, env.rolloutState(t))
+}
@@ -0,0 +1,174 @@
+// Copyright 2025 Kiruba Sankar Swaminathan. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package deploy
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"maand/bucket"
+	"maand/data"
+)
+
+// ErrDeployCancelled reports a deploy stopped by SIGINT or SIGTERM. Jobs that finished before
+// the signal are promoted and committed; the others are left for the next maand deploy.
+var ErrDeployCancelled = errors.New("deploy cancelled")
+
+// defaultCancelTimeout bounds how long a cancelled deploy waits for in-flight batches before
+// it kills their commands.
+const defaultCancelTimeout = 2 * time.Minute
+
+// cancellation tracks a SIGINT/SIGTERM during one deploy. The first signal stops deploy from
+// starting new jobs or rollout batches; a second signal, or the timeout, interrupts the
+// runtime so in-flight commands fail and the deploy can commit what finished.
+type cancellation struct {
+	rt        *bucket.Runtime
+	timeout   time.Duration
+	once      sync.Once
+	requested chan struct{}
+	reason    string
+	timer     *time.Timer
+	mu        sync.Mutex
+}
+
+// cancellations maps a deploy transaction to its cancellation so rollout code deep in the
+// pipeline can check it without threading it through every call.
+var cancellations sync.Map
+
+// watchCancellation registers a cancellation for tx and routes SIGINT and SIGTERM to it until
+// the returned stop is called.
+func watchCancellation(tx *sql.Tx, rt *bucket.Runtime, timeout time.Duration) (*cancellation, func()) {
+	if timeout <= 0 {
+		timeout = defaultCancelTimeout
+	}
+	c := &cancellation{rt: rt, timeout: timeout, requested: make(chan struct{})}
+	cancellations.Store(tx, c)
+
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case sig := <-signals:
+				c.request(sig.String())
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return c, func() {
+		signal.Stop(signals)
+		close(done)
+		cancellations.Delete(tx)
+		c.mu.Lock()
+		if c.timer != nil {
+			c.timer.Stop()
+		}
+		c.mu.Unlock()
+	}
+}
+
+func (c *cancellation) request(reason string) {
+	first := false
+	c.once.Do(func() {
+		first = true
+		c.reason = reason
+		close(c.requested)
+	})
+	if !first {
+		log.Printf("deploy: %s again; aborting in-flight commands", reason)
+		c.abort()
+		return
+	}
+
+	log.Printf("deploy: %s received; finishing in-flight batches (up to %s), send again to abort now", reason, c.timeout)
+	if c.rt != nil {
+		_ = c.rt.LogEvent("", "deploy_cancel_requested", map[string]string{
+			"signal":  reason,
+			"timeout": c.timeout.String(),
+		})
+	}
+	c.mu.Lock()
+	c.timer = time.AfterFunc(c.timeout, func() {
+		log.Printf("deploy: in-flight batches still running after %s; aborting them", c.timeout)
+		c.abort()
+	})
+	c.mu.Unlock()
+}
+
+func (c *cancellation) abort() {
+	if c.rt != nil {
+		c.rt.Interrupt()
+	}
+}
+
+// err returns ErrDeployCancelled once a signal arrived, otherwise nil.
+func (c *cancellation) err() error {
+	select {
+	case <-c.requested:
+		return fmt.Errorf("%w by %s", ErrDeployCancelled, c.reason)
+	default:
+		return nil
+	}
+}
+
+// deployCancelled returns ErrDeployCancelled when the deploy running in tx was cancelled.
+// Deploy checks it before each job and rollout batch.
+func deployCancelled(tx *sql.Tx) error {
+	c, ok := cancellations.Load(tx)
+	if !ok {
+		return nil
+	}
+	return c.(*cancellation).err()
+}
+
+// isCancellation reports whether err comes from a cancelled deploy: a checkpoint refusing to
+// start more work, or a command killed when the in-flight batch was aborted.
+func isCancellation(err error) bool {
+	return errors.Is(err, ErrDeployCancelled) || errors.Is(err, bucket.ErrCommandInterrupted)
+}
+
+// runtimeInterrupted reports whether an aborted cancel killed rt's commands. Errors from
+// parallel worker batches are joined as text, so callers check the runtime instead.
+func runtimeInterrupted(rt *bucket.Runtime) bool {
+	return rt != nil && rt.Interrupted()
+}
+
+// markCancelledJobs marks the staged hashes of jobs left undeployed as cancelled and logs a
+// deploy_cancelled event to the run log.
+func markCancelledJobs(tx *sql.Tx, rt *bucket.Runtime, deployed, cancelled []string) error {
+	for _, job := range cancelled {
+		if err := data.MarkJobCancelled(tx, job); err != nil {
+			return err
+		}
+	}
+	if rt != nil {
+		_ = rt.LogEvent("", "deploy_cancelled", map[string]string{
+			"deployed_jobs":  strings.Join(deployed, ","),
+			"cancelled_jobs": strings.Join(cancelled, ","),
+		})
+	}
+	return nil
+}
+
+// cancelledDeployError is what Execute reports after committing a cancelled deploy.
+func cancelledDeployError(cancelErr error, deployed, cancelled []string, failures []error) error {
+	summary := fmt.Errorf("%w; committed jobs [%s], not deployed [%s]; run maand deploy to continue",
+		cancelErr, strings.Join(deployed, " "), strings.Join(cancelled, " "))
+	if len(failures) == 0 {
+		return summary
+	}
+	return fmt.Errorf("%w\n%v", summary, joinErrors("", failures))
+}
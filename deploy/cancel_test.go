@@ -0,0 +1,122 @@
+// Copyright 2025 Kiruba Sankar Swaminathan. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package deploy
+
+import (
+	"database/sql"
+	"path"
+	"testing"
+
+	"maand/bucket"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// cancelRunningDeploys requests cancellation of every running deploy, as a SIGINT would.
+func cancelRunningDeploys() {
+	cancellations.Range(func(_, c any) bool {
+		c.(*cancellation).request("interrupt")
+		return true
+	})
+}
+
+func cancelledHash(t *testing.T, tx *sql.Tx, job, allocID string) (cancelled, current string) {
+	t.Helper()
+	var cancelledHash, currentHash sql.NullString
+	require.NoError(t, tx.QueryRow(
+		`SELECT cancelled_hash, current_hash FROM hash WHERE namespace = ? AND key = ?`,
+		job+"_allocation", allocID,
+	).Scan(&cancelledHash, &currentHash))
+	return cancelledHash.String, currentHash.String
+}
+
+func TestExecute_cancelCommitsFinishedJobs(t *testing.T) {
+	env := setupDeployTestEnv(t)
+	rec := installNoopDeployHooks(t, env.bucketID)
+
+	tx := env.begin(t)
+	env.seedMakefileJob(t, tx, "api", "10.0.0.1", 0)
+	env.seedMakefileJob(t, tx, "web", "10.0.0.1", 0)
+	env.seedMakefileJob(t, tx, "worker", "10.0.0.1", 1)
+	require.NoError(t, tx.Commit())
+
+	testHooks.WorkerCommand = func(rt *bucket.Runtime, workerIP string, cmdCtx bucket.CommandContext, commands []string, env []string) error {
+		if cmdCtx.Job == "api" && cmdCtx.Action == rolloutActionStart {
+			cancelRunningDeploys()
+		}
+		return rec.Record(rt, workerIP, cmdCtx, commands, env)
+	}
+	err := Execute(nil, Options{})
+	require.ErrorIs(t, err, ErrDeployCancelled)
+	assert.Contains(t, err.Error(), "committed jobs [api]")
+	assert.Contains(t, err.Error(), "not deployed [web worker]")
+	assert.False(t, rec.HasAction("10.0.0.1", rolloutActionStart, "web"))
+	assert.False(t, rec.HasAction("10.0.0.1", rolloutActionStart, "worker"))
+
+	tx = env.begin(t)
+	assert.True(t, env.allocationHashPromoted(t, tx, "api", "alloc-api-10.0.0.1"))
+	assert.False(t, env.allocationHashPromoted(t, tx, "web", "alloc-web-10.0.0.1"))
+	cancelled, current := cancelledHash(t, tx, "web", "alloc-web-10.0.0.1")
+	assert.NotEmpty(t, current)
+	assert.Equal(t, current, cancelled)
+	require.NoError(t, tx.Rollback())
+
+	testHooks.WorkerCommand = rec.Record
+	require.NoError(t, Execute(nil, Options{}))
+	tx = env.begin(t)
+	defer func() { _ = tx.Rollback() }()
+	assert.True(t, env.allocationHashPromoted(t, tx, "web", "alloc-web-10.0.0.1"))
+	assert.True(t, env.allocationHashPromoted(t, tx, "worker", "alloc-worker-10.0.0.1"))
+	cancelled, _ = cancelledHash(t, tx, "web", "alloc-web-10.0.0.1")
+	assert.Empty(t, cancelled)
+}
+
+func TestExecute_cancelFinishesInFlightBatchOnly(t *testing.T) {
+	env := setupDeployTestEnv(t)
+	rec := installNoopDeployHooks(t, env.bucketID)
+
+	tx := env.begin(t)
+	env.seedMakefileJob(t, tx, "app", "10.0.0.1", 0)
+	env.ensureWorker(t, tx, "10.0.0.2", 1)
+	env.insertAllocation(t, tx, "alloc-app-10.0.0.2", "10.0.0.2", "app", 0, 0, 0)
+	require.NoError(t, tx.Commit())
+	require.NoError(t, Execute(nil, Options{}))
+
+	tx = env.begin(t)
+	env.insertJobFile(t, tx, "job-app", path.Join("app", "marker.txt"), "v2", false)
+	require.NoError(t, tx.Commit())
+
+	var restarted []string
+	testHooks.WorkerCommand = func(rt *bucket.Runtime, workerIP string, cmdCtx bucket.CommandContext, commands []string, env []string) error {
+		if cmdCtx.Action == rolloutActionRestart {
+			restarted = append(restarted, workerIP)
+			cancelRunningDeploys()
+		}
+		return rec.Record(rt, workerIP, cmdCtx, commands, env)
+	}
+	err := Execute(nil, Options{})
+	require.ErrorIs(t, err, ErrDeployCancelled)
+	assert.Contains(t, err.Error(), "not deployed [app]")
+	assert.Len(t, restarted, 1)
+
+	tx = env.begin(t)
+	defer func() { _ = tx.Rollback() }()
+	for _, allocID := range []string{"alloc-app-10.0.0.1", "alloc-app-10.0.0.2"} {
+		assert.False(t, env.allocationHashPromoted(t, tx, "app", allocID), allocID)
+		cancelled, current := cancelledHash(t, tx, "app", allocID)
+		assert.Equal(t, current, cancelled, allocID)
+	}
+}
+
+func TestRuntimeInterruptIsCancellation(t *testing.T) {
+	rt, err := bucket.SetupRuntime("", bucket.RunContext{})
+	require.NoError(t, err)
+	assert.False(t, runtimeInterrupted(rt))
+	assert.False(t, runtimeInterrupted(nil))
+	rt.Interrupt()
+	assert.True(t, runtimeInterrupted(rt))
+	assert.True(t, isCancellation(rt.Exec("", bucket.CommandContext{}, []string{"true"}, nil)))
+}
@@ -0,0 +1,71 @@
+// Copyright 2025 Kiruba Sankar Swaminathan. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package deploy
+
+import (
+	"database/sql"
+	"sync"
+)
+
+// catalogWriter applies catalog writes to the deploy transaction one at a time while the jobs
+// of a deployment_seq wave deploy in parallel. Writes are sent over a channel to a single
+// goroutine, so staging, hash promotion, and KV checkpoints of different jobs never interleave.
+// Reads from job goroutines use the transaction directly; database/sql serializes statements.
+//
+// KV puts that bypass the writer (syncAllocationVersionKV and the /kv handlers of
+// jobcommand.StartRuntimeAPI) only change the session kv.Store, which has its own lock. They
+// reach the transaction when persistJobCommandKV flushes the store through writeCatalog.
+type catalogWriter struct {
+	tx     *sql.Tx
+	writes chan catalogWrite
+	done   chan struct{}
+}
+
+type catalogWrite struct {
+	fn     func(tx *sql.Tx) error
+	result chan error
+}
+
+// catalogWriters maps a deploy transaction to its writer while parallel jobs run.
+var catalogWriters sync.Map
+
+// startCatalogWriter routes writeCatalog calls for tx through a serialized writer until stop.
+func startCatalogWriter(tx *sql.Tx) *catalogWriter {
+	w := &catalogWriter{
+		tx:     tx,
+		writes: make(chan catalogWrite),
+		done:   make(chan struct{}),
+	}
+	go func() {
+		defer close(w.done)
+		for write := range w.writes {
+			write.result <- write.fn(w.tx)
+		}
+	}()
+	catalogWriters.Store(tx, w)
+	return w
+}
+
+// stop unregisters the writer and waits for the last write to finish.
+func (w *catalogWriter) stop() {
+	catalogWriters.Delete(w.tx)
+	close(w.writes)
+	<-w.done
+}
+
+func (w *catalogWriter) write(fn func(tx *sql.Tx) error) error {
+	result := make(chan error, 1)
+	w.writes <- catalogWrite{fn: fn, result: result}
+	return <-result
+}
+
+// writeCatalog runs fn on the serialized writer for tx, or directly when jobs deploy one at
+// a time. fn must not call writeCatalog.
+func writeCatalog(tx *sql.Tx, fn func(tx *sql.Tx) error) error {
+	if w, ok := catalogWriters.Load(tx); ok {
+		return w.(*catalogWriter).write(fn)
+	}
+	return fn(tx)
+}
@@ -29,35 +29,41 @@ const (
 
 // AllocationPlan describes one worker allocation after plan hashes are refreshed.
 type AllocationPlan struct {
-	WorkerIP     string
-	Action       string
-	PreviousHash string
-	CurrentHash  string
-	MatchedPaths []string
+	WorkerIP     string   `json:"worker_ip"`
+	Action       string   `json:"action"`
+	PreviousHash string   `json:"previous_hash"`
+	CurrentHash  string   `json:"current_hash"`
+	MatchedPaths []string `json:"matched_paths,omitempty"`
 }
 
 // JobPlan summarizes whether a job would be deployed in the current wave.
 type JobPlan struct {
-	Job           string
-	DeploymentSeq int
-	NeedsRollout  bool
-	SkipReason    string
-	Allocations   []AllocationPlan
+	Job           string           `json:"job"`
+	Version       string           `json:"version"`
+	DeploymentSeq int              `json:"deployment_seq"`
+	NeedsRollout  bool             `json:"needs_rollout"`
+	SkipReason    string           `json:"skip_reason,omitempty"`
+	Allocations   []AllocationPlan `json:"allocations"`
 }
 
-// DryRunResult is the outcome of a deploy dry-run.
+// DryRunResult is the outcome of a deploy dry-run. UpdateSeq is the bucket update_seq the
+// plan was computed against; a deploy bumps it.
 type DryRunResult struct {
-	Jobs     []JobPlan
-	Required bool
+	BucketID  string    `json:"bucket_id"`
+	UpdateSeq int       `json:"update_seq"`
+	Jobs      []JobPlan `json:"jobs"`
+	Required  bool      `json:"required"`
 }
 
 // DryRun stages job files locally, refreshes allocation content hashes in a rolled-back
 // transaction, and reports which jobs and allocations would be deployed. Jobs with
 // pre_deploy hooks run them before plan hashes are computed (same as deploy). pre_deploy
 // may SSH to workers when a hook runs job commands there; rsync, lifecycle, and hash
-// promotion are not performed.
+// promotion are not performed. With opts.Workers, only allocations on those workers are
+// planned. It clears and restages bucket.TempLocation, so callers hold the bucket lock.
 func DryRun(jobsFilter []string, opts Options) (DryRunResult, error) {
 	var result DryRunResult
+	opts.Workers = normalizeWorkerFilter(opts.Workers)
 
 	db, err := data.OpenDatabase(true)
 	if err != nil {
@@ -97,6 +103,8 @@ func DryRun(jobsFilter []string, opts Options) (DryRunResult, error) {
 	if err != nil {
 		return result, err
 	}
+	result.BucketID = bucketID
+	result.UpdateSeq = updateSeq
 	rt, err := setupDeployRuntime(bucketID, bucket.NewRunContext("deploy", updateSeq))
 	if err != nil {
 		return result, err
@@ -109,6 +117,9 @@ func DryRun(jobsFilter []string, opts Options) (DryRunResult, error) {
 	if err != nil {
 		return result, err
 	}
+	if err := checkWorkerFilter(workers, opts.Workers); err != nil {
+		return result, err
+	}
 
 	maxDeploymentSequence, err := data.GetMaxDeploymentSeq(tx)
 	if err != nil {
@@ -168,6 +179,15 @@ func planJobRollout(tx *sql.Tx, job string, deploymentSeq int, opts Options) (Jo
 		plan.SkipReason = "no allocations"
 		return plan, nil
 	}
+	workers = selectWorkersForDeploy(workers, opts)
+	if len(workers) == 0 {
+		plan.SkipReason = "no allocations on the selected workers"
+		return plan, nil
+	}
+	plan.Version, err = data.GetJobVersion(tx, job)
+	if err != nil {
+		return plan, err
+	}
 
 	policy, err := data.GetRestartPolicy(tx, job)
 	if err != nil {
@@ -207,7 +227,7 @@ func planJobRollout(tx *sql.Tx, job string, deploymentSeq int, opts Options) (Jo
 	}
 
 	if !plan.NeedsRollout {
-		plan.SkipReason = "already promoted on all allocations"
+		plan.SkipReason = promotedSkipReason(opts)
 	}
 	return plan, nil
 }
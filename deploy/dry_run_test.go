@@ -83,6 +83,34 @@ func TestDryRun_detectsContentChange(t *testing.T) {
 	)
 }
 
+func TestDryRun_workersFilterPlansSelectedAllocations(t *testing.T) {
+	env := setupDeployTestEnv(t)
+	installNoopDeployHooks(t, env.bucketID)
+
+	tx := env.begin(t)
+	env.seedMakefileJob(t, tx, "app", "10.0.0.1", 0)
+	env.ensureWorker(t, tx, "10.0.0.2", 1)
+	env.insertAllocation(t, tx, "alloc-app-10.0.0.2", "10.0.0.2", "app", 0, 0, 0)
+	require.NoError(t, tx.Commit())
+
+	result, err := DryRun(nil, Options{Workers: []string{"10.0.0.2"}})
+	require.NoError(t, err)
+	require.True(t, result.Required)
+	require.Len(t, result.Jobs, 1)
+	require.Len(t, result.Jobs[0].Allocations, 1)
+	assert.Equal(t, "10.0.0.2", result.Jobs[0].Allocations[0].WorkerIP)
+
+	require.NoError(t, Execute(nil, Options{Workers: []string{"10.0.0.2"}}))
+	result, err = DryRun(nil, Options{Workers: []string{"10.0.0.2"}})
+	require.NoError(t, err)
+	require.False(t, result.Required)
+	assert.Equal(t, "already promoted on the selected workers", result.Jobs[0].SkipReason)
+
+	result, err = DryRun(nil, Options{})
+	require.NoError(t, err)
+	require.True(t, result.Required)
+}
+
 func TestDryRun_firstDeploy(t *testing.T) {
 	env := setupDeployTestEnv(t)
 
@@ -6,7 +6,9 @@
 //
 // Pipeline per deployment sequence: reconcile removed/disabled allocations once,
 // then per wave: stage workers → refresh plan hashes (skip detection) → per job:
-// pre_deploy → stage → rsync → hash → start/restart allocations → post_deploy → promote.
+// pre_deploy → stage → hash → rsync → start/restart allocations → post_deploy → promote.
+// Canary jobs (rollout.strategy canary) upgrade only their canaries, bake, and pause until
+// maand rollout promote or abort; held-back allocations are not rsynced until promote.
 // Before contacting workers, deploy verifies local tools (bash, ssh, rsync, python3,
 // bun when needed) and each worker has required tools (python3, make, rsync, bash, timeout,
 // and sudo/rsync when use_sudo is set).
@@ -18,13 +20,20 @@
 // updated allocation hashes are staged and rolled out again. Final rsync runs per
 // successfully deployed job (only that job's tree).
 //
-// Jobs run sequentially against the database transaction; worker rsync runs in parallel
-// without touching the transaction.
+// Jobs of a wave run one at a time by default; worker rsync runs in parallel without touching
+// the transaction. With --parallel-jobs, up to N jobs of a wave stage, rsync, restart, and
+// health-check concurrently while a single catalogWriter applies their catalog writes in turn.
+//
+// SIGINT/SIGTERM cancel a deploy: no new job or rollout batch starts, in-flight batches get
+// --cancel-timeout to finish (a second signal aborts them at once), and the jobs that
+// finished are committed like a partial deploy. The rest show as cancelled in maand cat
+// deployments and are picked up by the next maand deploy.
 package deploy
 
 import (
 	"database/sql"
 	_ "embed"
+	"errors"
 	"log"
 	"os"
 
@@ -64,8 +73,14 @@ func UpdateSeq(db *sql.DB) error {
 // Execute deploys jobs to all workers, optionally filtered by job name.
 // When opts.Force is true, jobs already promoted on all allocations are staged and
 // restarted anyway. When opts.SyncOnly is true, files are rsynced and hashes promoted
-// without lifecycle actions; new allocations are rejected.
-func Execute(jobsFilter []string, opts Options) error {
+// without lifecycle actions; new allocations are rejected. Jobs with a paused canary
+// rollout are skipped unless opts.PromoteRollout is set. With opts.Plan, deploy refuses
+// to start when update_seq moved and fails jobs whose hashes or version drifted from it.
+// opts.ParallelJobs deploys up to that many jobs of one deployment_seq wave at once.
+// opts.Workers limits the rollout to allocations on those workers; the others keep their
+// promoted hash and stay pending for a later deploy.
+// Every run that reaches the workers is recorded in the deploy_run tables.
+func Execute(jobsFilter []string, opts Options) (err error) {
 	db, err := data.OpenDatabase(true)
 	if err != nil {
 		return err
@@ -74,6 +89,12 @@ func Execute(jobsFilter []string, opts Options) error {
 		_ = db.Close()
 	}()
 
+	opts.Workers = normalizeWorkerFilter(opts.Workers)
+	history := newRunHistory(normalizeJobFilter(jobsFilter), opts)
+	defer func() {
+		history.save(db, err)
+	}()
+
 	if err := os.RemoveAll(bucket.TempLocation); err != nil {
 		return bucket.UnexpectedError(err)
 	}
@@ -81,6 +102,9 @@ func Execute(jobsFilter []string, opts Options) error {
 		_ = os.RemoveAll(bucket.TempLocation)
 	}()
 
+	if err := checkPlanUpdateSeq(db, opts.Plan); err != nil {
+		return err
+	}
 	if err := UpdateSeq(db); err != nil {
 		return err
 	}
@@ -106,6 +130,9 @@ func Execute(jobsFilter []string, opts Options) error {
 	if err != nil {
 		return err
 	}
+	if err := checkWorkerFilter(workers, opts.Workers); err != nil {
+		return err
+	}
 
 	maxDeploymentSequence, err := data.GetMaxDeploymentSeq(tx)
 	if err != nil {
@@ -117,10 +144,12 @@ func Execute(jobsFilter []string, opts Options) error {
 		return err
 	}
 
-	rt, err := setupDeployRuntime(bucketID, bucket.NewRunContext("deploy", updateSeq))
+	run := bucket.NewRunContext("deploy", updateSeq)
+	rt, err := setupDeployRuntime(bucketID, run)
 	if err != nil {
 		return err
 	}
+	history.begin(run)
 	cancel := jobcommand.StartRuntimeAPI(tx)
 	defer func() {
 		cancel()
@@ -129,9 +158,13 @@ func Execute(jobsFilter []string, opts Options) error {
 		}
 	}()
 
+	cancellation, stopWatching := watchCancellation(tx, rt, opts.CancelTimeout)
+	defer stopWatching()
+
 	var (
 		deployFailures []error
 		deployedJobs   []string
+		cancelledJobs  []string
 	)
 	jobsFilter = normalizeJobFilter(jobsFilter)
 
@@ -139,7 +172,11 @@ func Execute(jobsFilter []string, opts Options) error {
 		return err
 	}
 
-	if err := reconcileRemovedAndDisabledAllocations(tx, rt, bucketID); err != nil {
+	deferredStops, err := movedJobs(tx, jobsFilter, opts)
+	if err != nil {
+		return err
+	}
+	if err := reconcileRemovedAndDisabledAllocations(tx, rt, bucketID, deferredStops); err != nil {
 		return err
 	}
 
@@ -152,6 +189,13 @@ func Execute(jobsFilter []string, opts Options) error {
 		if len(jobs) == 0 {
 			continue
 		}
+		if cancellation.err() != nil {
+			cancelledJobs = append(cancelledJobs, jobs...)
+			for _, job := range jobs {
+				history.result(job, data.DeployJobCancelled)
+			}
+			continue
+		}
 
 		if err := prepareWorkersFiles(tx, workers); err != nil {
 			return err
@@ -161,16 +205,45 @@ func Execute(jobsFilter []string, opts Options) error {
 		for _, job := range jobs {
 			if err := refreshPlanHashesForJobPlan(tx, rt, job); err != nil {
 				deployFailures = append(deployFailures, err)
+				history.result(job, data.DeployJobFailed, err)
 				continue
 			}
+			if opts.Plan != nil {
+				current, err := planJobRollout(tx, job, deploymentSeq, opts)
+				if err != nil {
+					return err
+				}
+				if err := checkJobAgainstPlan(opts.Plan, current); err != nil {
+					deployFailures = append(deployFailures, &JobError{Job: job, Err: err})
+					history.result(job, data.DeployJobFailed, err)
+					continue
+				}
+			}
+
+			if !opts.PromoteRollout {
+				paused, err := data.GetRolloutState(tx, job)
+				if err != nil {
+					return err
+				}
+				if paused != nil {
+					log.Printf("deploy: skip job %q (canary rollout paused; run maand rollout promote or abort)", job)
+					if rt != nil {
+						_ = rt.LogEvent("", "deploy_skip", map[string]string{
+							"job":    job,
+							"reason": "rollout_paused",
+						})
+					}
+					continue
+				}
+			}
 
 			if !opts.Force {
-				needsRollout, err := JobNeedsRollout(tx, job)
+				needsRollout, err := jobNeedsDeploy(tx, job, deploymentSeq, opts)
 				if err != nil {
 					return err
 				}
 				if !needsRollout {
-					log.Printf("deploy: skip job %q (already promoted on all allocations)", job)
+					log.Printf("deploy: skip job %q (%s)", job, promotedSkipReason(opts))
 					if rt != nil {
 						_ = rt.LogEvent("", "deploy_skip", map[string]string{
 							"job":    job,
@@ -181,6 +254,9 @@ func Execute(jobsFilter []string, opts Options) error {
 				}
 			}
 
+			if err := history.stage(tx, job); err != nil {
+				return err
+			}
 			jobsToStage = append(jobsToStage, job)
 		}
 
@@ -188,40 +264,47 @@ func Execute(jobsFilter []string, opts Options) error {
 			continue
 		}
 
-		for _, job := range jobsToStage {
-			if err := prepareJobsFiles(tx, []string{job}); err != nil {
-				return err
-			}
-			if err := syncWorkers(rt, bucketID, workers, []string{job}, true); err != nil {
-				return err
-			}
-			if err := updateAllocationHash(tx, []string{job}); err != nil {
-				return err
+		var abortErrs []error
+		for _, outcome := range deployWave(tx, rt, bucketID, jobsToStage, workers, opts) {
+			history.outcome(outcome)
+			if outcome.Err != nil {
+				abortErrs = append(abortErrs, outcome.Err)
+				continue
 			}
-
-			deployErr := deployJob(tx, rt, bucketID, job, opts)
-			if persistErr := persistJobCommandKV(tx, job); persistErr != nil {
-				deployFailures = append(deployFailures, persistErr)
+			deployFailures = append(deployFailures, outcome.Failures...)
+			if outcome.Deployed {
+				deployedJobs = append(deployedJobs, outcome.Job)
 			}
-			if deployErr != nil {
-				deployFailures = append(deployFailures, deployErr)
-				continue
+			if outcome.Cancelled {
+				cancelledJobs = append(cancelledJobs, outcome.Job)
 			}
-			deployedJobs = append(deployedJobs, job)
+		}
+		if len(abortErrs) > 0 {
+			return errors.Join(abortErrs...)
 		}
 	}
 
 	if len(deployedJobs) > 0 {
-		if err := finalSyncDeployedJobs(tx, rt, bucketID, deployedJobs); err != nil {
+		if err := finalSyncDeployedJobs(tx, rt, bucketID, deployedJobs, opts); err != nil {
 			deployFailures = append(deployFailures, err)
 		}
 	}
 
+	cancelErr := cancellation.err()
+	if cancelErr != nil {
+		if err := markCancelledJobs(tx, rt, deployedJobs, cancelledJobs); err != nil {
+			return err
+		}
+	}
+
 	if err := tx.Commit(); err != nil {
 		return bucket.DatabaseError(err)
 	}
 
 	certs.PushMetrics(db)
 
+	if cancelErr != nil {
+		return cancelledDeployError(cancelErr, deployedJobs, cancelledJobs, deployFailures)
+	}
 	return joinErrors("deploy failed", deployFailures)
 }
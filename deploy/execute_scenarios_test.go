@@ -3,6 +3,7 @@ package deploy
 import (
 	"path"
 	"strings"
+	"sync"
 	"testing"
 
 	"maand/bucket"
@@ -153,3 +154,63 @@ func TestExecute_rollingUpgradeParallelismFromJob(t *testing.T) {
 	assert.Equal(t, 2, parallelism)
 	require.NoError(t, tx.Rollback())
 }
+
+func TestExecute_workersFilterLeavesOtherAllocationsPending(t *testing.T) {
+	env := setupDeployTestEnv(t)
+	rec := installNoopDeployHooks(t, env.bucketID)
+
+	tx := env.begin(t)
+	env.seedMakefileJob(t, tx, "app", "10.0.0.1", 0)
+	env.ensureWorker(t, tx, "10.0.0.2", 1)
+	env.insertAllocation(t, tx, "alloc-app-10.0.0.2", "10.0.0.2", "app", 0, 0, 0)
+	require.NoError(t, tx.Commit())
+	require.NoError(t, Execute(nil, Options{}))
+
+	tx = env.begin(t)
+	env.insertJobFile(t, tx, "job-app", path.Join("app", "marker.txt"), "v2", false)
+	require.NoError(t, tx.Commit())
+
+	var (
+		mu     sync.Mutex
+		synced []string
+	)
+	testHooks.Rsync = func(_ *bucket.Runtime, _, workerIP string, _ []string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		synced = append(synced, workerIP)
+		return nil
+	}
+	rec.Commands = nil
+	require.NoError(t, Execute(nil, Options{Workers: []string{"10.0.0.1"}}))
+	assert.True(t, rec.HasAction("10.0.0.1", rolloutActionRestart, "app"))
+	assert.False(t, rec.HasAction("10.0.0.2", rolloutActionRestart, "app"))
+	assert.NotContains(t, synced, "10.0.0.2")
+
+	tx = env.begin(t)
+	assert.True(t, env.allocationHashPromoted(t, tx, "app", "alloc-app-10.0.0.1"))
+	assert.False(t, env.allocationHashPromoted(t, tx, "app", "alloc-app-10.0.0.2"))
+	needsRollout, err := JobNeedsRollout(tx, "app")
+	require.NoError(t, err)
+	assert.True(t, needsRollout)
+	require.NoError(t, tx.Rollback())
+
+	require.NoError(t, Execute(nil, Options{}))
+	assert.True(t, rec.HasAction("10.0.0.2", rolloutActionRestart, "app"))
+	tx = env.begin(t)
+	defer func() { _ = tx.Rollback() }()
+	assert.True(t, env.allocationHashPromoted(t, tx, "app", "alloc-app-10.0.0.2"))
+}
+
+func TestExecute_workersFilterRejectsUnknownWorker(t *testing.T) {
+	env := setupDeployTestEnv(t)
+	rec := installNoopDeployHooks(t, env.bucketID)
+
+	tx := env.begin(t)
+	env.seedMakefileJob(t, tx, "app", "10.0.0.1", 0)
+	require.NoError(t, tx.Commit())
+
+	err := Execute(nil, Options{Workers: []string{"10.0.0.9"}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown workers 10.0.0.9")
+	assert.Empty(t, rec.Commands)
+}
@@ -5,6 +5,7 @@
 package deploy
 
 import (
+	"fmt"
 	"strings"
 
 	"maand/utils"
@@ -38,6 +39,39 @@ func selectJobsForDeploy(availableJobs, jobsFilter []string) []string {
 	return selected
 }
 
+func normalizeWorkerFilter(workersFilter []string) []string {
+	out := make([]string, 0, len(workersFilter))
+	for _, workerIP := range workersFilter {
+		workerIP = strings.TrimSpace(workerIP)
+		if workerIP != "" {
+			out = append(out, workerIP)
+		}
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return utils.Unique(out)
+}
+
+// checkWorkerFilter rejects --workers entries that are not in the worker catalog, so a typo
+// does not silently deploy nothing.
+func checkWorkerFilter(workers, workersFilter []string) error {
+	unknown := utils.Difference(workersFilter, workers)
+	if len(unknown) > 0 {
+		return fmt.Errorf("--workers: unknown workers %s", strings.Join(unknown, ","))
+	}
+	return nil
+}
+
+// selectWorkersForDeploy keeps the workers listed in opts.Workers, in workers order; without
+// a worker filter it returns workers unchanged.
+func selectWorkersForDeploy(workers []string, opts Options) []string {
+	if len(opts.Workers) == 0 {
+		return workers
+	}
+	return utils.Intersection(opts.Workers, workers)
+}
+
 func parseJobsCSV(jobsCSV string) []string {
 	jobsCSV = strings.TrimSpace(jobsCSV)
 	if jobsCSV == "" {
@@ -32,3 +32,24 @@ func TestParseJobsCSV(t *testing.T) {
 		t.Fatal("expected nil for blank csv")
 	}
 }
+
+func TestSelectWorkersForDeploy(t *testing.T) {
+	workers := []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"}
+	got := selectWorkersForDeploy(workers, Options{Workers: []string{"10.0.0.3", "10.0.0.1"}})
+	if len(got) != 2 || got[0] != "10.0.0.1" || got[1] != "10.0.0.3" {
+		t.Fatalf("got %#v", got)
+	}
+	if got := selectWorkersForDeploy(workers, Options{}); len(got) != 3 {
+		t.Fatalf("got %#v", got)
+	}
+}
+
+func TestCheckWorkerFilter(t *testing.T) {
+	workers := []string{"10.0.0.1", "10.0.0.2"}
+	if err := checkWorkerFilter(workers, normalizeWorkerFilter([]string{" 10.0.0.2", ""})); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkWorkerFilter(workers, []string{"10.0.0.2", "10.0.0.9"}); err == nil {
+		t.Fatal("expected error for unknown worker")
+	}
+}
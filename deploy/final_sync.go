@@ -12,13 +12,14 @@ import (
 )
 
 // finalSyncDeployedJobs rsyncs each successfully deployed job only to workers with an
-// active allocation for that job. Worker metadata (worker.json, jobs.json) is refreshed
+// active allocation for that job (and in opts.Workers, when set). Worker metadata (worker.json, jobs.json) is refreshed
 // once per worker before the first job sync for that worker.
 func finalSyncDeployedJobs(
 	tx *sql.Tx,
 	rt *bucket.Runtime,
 	bucketID string,
 	deployedJobs []string,
+	opts Options,
 ) error {
 	refreshedWorkers := make(map[string]bool, len(deployedJobs))
 
@@ -27,6 +28,7 @@ func finalSyncDeployedJobs(
 		if err != nil {
 			return err
 		}
+		workers = selectWorkersForDeploy(workers, opts)
 		if len(workers) == 0 {
 			continue
 		}
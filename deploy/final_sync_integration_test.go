@@ -36,7 +36,7 @@ func TestFinalSyncDeployedJobs_writesFilteredRules(t *testing.T) {
 	require.NoError(t, tx.Commit())
 
 	tx = env.begin(t)
-	require.NoError(t, finalSyncDeployedJobs(tx, nil, env.bucketID, []string{"app"}))
+	require.NoError(t, finalSyncDeployedJobs(tx, nil, env.bucketID, []string{"app"}, Options{}))
 	require.NoError(t, tx.Rollback())
 
 	assert.Contains(t, captured, "+ jobs/app/")
@@ -59,11 +59,12 @@ func updateJobAllocationHashes(tx *sql.Tx, job string) error {
 	return nil
 }
 
-func promoteAllocationHash(tx *sql.Tx, job string) error {
+func promoteAllocationHash(tx *sql.Tx, job string, opts Options) error {
 	workers, err := data.GetNonRemovedAllocations(tx, job)
 	if err != nil {
 		return err
 	}
+	workers = selectWorkersForDeploy(workers, opts)
 
 	namespace := fmt.Sprintf("%s_allocation", job)
 	for _, workerIP := range workers {
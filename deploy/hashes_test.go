@@ -43,7 +43,7 @@ func TestUpdateJobAllocationHashes_andPromote(t *testing.T) {
 	assert.Equal(t, "2.0.0", newVersion.String)
 	assertAllocationVersionKV(t, "app", "10.0.0.1", "2.0.0")
 
-	require.NoError(t, promoteAllocationHash(tx, "app"))
+	require.NoError(t, promoteAllocationHash(tx, "app", Options{}))
 	assert.True(t, env.allocationHashPromoted(t, tx, "app", "alloc-app-10.0.0.1"))
 
 	err = tx.QueryRow(
@@ -166,7 +166,7 @@ func TestPromoteAllocationHash_multipleWorkers(t *testing.T) {
 	require.NoError(t, kv.Initialize(tx))
 	require.NoError(t, prepareJobsFiles(tx, []string{"app"}))
 	require.NoError(t, updateAllocationHash(tx, []string{"app"}))
-	require.NoError(t, promoteAllocationHash(tx, "app"))
+	require.NoError(t, promoteAllocationHash(tx, "app", Options{}))
 
 	for _, allocID := range []string{"alloc-app-10.0.0.1", "alloc-app-10.0.0.2"} {
 		assert.True(t, env.allocationHashPromoted(t, tx, "app", allocID))
@@ -186,7 +186,7 @@ func TestPromoteHash_promotesDisabledAllocation(t *testing.T) {
 	require.NoError(t, kv.Initialize(tx))
 	require.NoError(t, prepareJobsFiles(tx, []string{"app"}))
 	require.NoError(t, updateAllocationHash(tx, []string{"app"}))
-	require.NoError(t, promoteAllocationHash(tx, "app"))
+	require.NoError(t, promoteAllocationHash(tx, "app", Options{}))
 
 	var previousHash sql.NullString
 	err = tx.QueryRow(
@@ -0,0 +1,139 @@
+// Copyright 2025 Kiruba Sankar Swaminathan. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package deploy
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"slices"
+	"time"
+
+	"maand/bucket"
+	"maand/data"
+)
+
+// runHistory collects what one deploy did and writes it to the deploy_run tables once the
+// deploy transaction has committed or rolled back, so failed and aborted runs are kept too.
+type runHistory struct {
+	run  data.DeployRun
+	opts Options
+	jobs map[string]int
+}
+
+func newRunHistory(jobsFilter []string, opts Options) *runHistory {
+	return &runHistory{
+		run: data.DeployRun{
+			StartedAt:     time.Now().UTC(),
+			JobsFilter:    jobsFilter,
+			WorkersFilter: opts.Workers,
+			Force:         opts.Force,
+			SyncOnly:      opts.SyncOnly,
+		},
+		opts: opts,
+		jobs: make(map[string]int),
+	}
+}
+
+// begin attaches the run ID and update sequence once the deploy runtime exists. Runs that
+// fail before that never reached a worker and are not recorded.
+func (h *runHistory) begin(run bucket.RunContext) {
+	h.run.RunID = run.RunID
+	h.run.UpdateSeq = run.UpdateSeq
+}
+
+// stage records the promoted and staged hashes of job's allocations before it rolls out.
+// With a worker filter only allocations on those workers are recorded.
+func (h *runHistory) stage(tx *sql.Tx, job string) error {
+	all, err := data.GetDeployRunAllocations(tx, job)
+	if err != nil {
+		return err
+	}
+	allocations := all
+	if len(h.opts.Workers) > 0 {
+		allocations = nil
+		for _, alloc := range all {
+			if slices.Contains(h.opts.Workers, alloc.WorkerIP) {
+				allocations = append(allocations, alloc)
+			}
+		}
+	}
+	h.jobs[job] = len(h.run.Jobs)
+	h.run.Jobs = append(h.run.Jobs, data.DeployRunJob{Job: job, Allocations: allocations})
+	return nil
+}
+
+// result sets the outcome of job; jobs not staged yet are added without allocations.
+func (h *runHistory) result(job, result string, errs ...error) {
+	i, ok := h.jobs[job]
+	if !ok {
+		i = len(h.run.Jobs)
+		h.jobs[job] = i
+		h.run.Jobs = append(h.run.Jobs, data.DeployRunJob{Job: job})
+	}
+	h.run.Jobs[i].Result = result
+	if err := joinErrors("", errs); err != nil {
+		h.run.Jobs[i].Error = err.Error()
+	}
+}
+
+func (h *runHistory) outcome(outcome jobOutcome) {
+	switch {
+	case outcome.Err != nil:
+		h.result(outcome.Job, data.DeployJobFailed, outcome.Err)
+	case outcome.Cancelled:
+		h.result(outcome.Job, data.DeployJobCancelled, outcome.Failures...)
+	case len(outcome.Failures) > 0:
+		h.result(outcome.Job, data.DeployJobFailed, outcome.Failures...)
+	case outcome.Deployed:
+		h.result(outcome.Job, data.DeployJobDeployed)
+	default:
+		h.result(outcome.Job, data.DeployJobPaused)
+	}
+}
+
+// save writes the run with the status derived from deployErr. A failure to record history
+// is logged and does not change the deploy result.
+func (h *runHistory) save(db *sql.DB, deployErr error) {
+	if h.run.RunID == "" {
+		return
+	}
+	h.run.FinishedAt = time.Now().UTC()
+	h.run.Status = data.DeployRunSucceeded
+	if deployErr != nil {
+		h.run.Status = data.DeployRunFailed
+		if errors.Is(deployErr, ErrDeployCancelled) {
+			h.run.Status = data.DeployRunCancelled
+		}
+		h.run.Error = deployErr.Error()
+	}
+	// Jobs staged for a wave that an aborting error stopped never got an outcome.
+	for i := range h.run.Jobs {
+		if h.run.Jobs[i].Result == "" {
+			h.run.Jobs[i].Result = data.DeployJobAborted
+		}
+	}
+
+	if err := saveRunHistory(db, h.run); err != nil {
+		log.Printf("deploy: record run %s in history: %v", h.run.RunID, err)
+	}
+}
+
+func saveRunHistory(db *sql.DB, run data.DeployRun) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return bucket.DatabaseError(err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	if err := data.SaveDeployRun(tx, run); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return bucket.DatabaseError(err)
+	}
+	return nil
+}
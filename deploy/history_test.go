@@ -0,0 +1,90 @@
+// Copyright 2025 Kiruba Sankar Swaminathan. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package deploy
+
+import (
+	"fmt"
+	"path"
+	"testing"
+	"time"
+
+	"maand/bucket"
+	"maand/data"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func listDeployRuns(t *testing.T, env *deployTestEnv) []data.DeployRun {
+	t.Helper()
+	tx := env.begin(t)
+	defer func() { _ = tx.Rollback() }()
+	runs, err := data.ListDeployRuns(tx, nil, time.Time{})
+	require.NoError(t, err)
+	return runs
+}
+
+func TestExecute_recordsRunHistory(t *testing.T) {
+	env := setupDeployTestEnv(t)
+	rec := installNoopDeployHooks(t, env.bucketID)
+
+	tx := env.begin(t)
+	env.seedMakefileJob(t, tx, "api", "10.0.0.1", 0)
+	env.seedMakefileJob(t, tx, "web", "10.0.0.1", 0)
+	require.NoError(t, tx.Commit())
+	require.NoError(t, Execute(nil, Options{}))
+
+	runs := listDeployRuns(t, env)
+	require.Len(t, runs, 1)
+	first := runs[0]
+	assert.NotEmpty(t, first.RunID)
+	assert.Equal(t, data.DeployRunSucceeded, first.Status)
+	assert.False(t, first.FinishedAt.Before(first.StartedAt))
+	require.Len(t, first.Jobs, 2)
+	assert.Equal(t, "api", first.Jobs[0].Job)
+	assert.Equal(t, data.DeployJobDeployed, first.Jobs[0].Result)
+	require.Len(t, first.Jobs[0].Allocations, 1)
+	alloc := first.Jobs[0].Allocations[0]
+	assert.Equal(t, "alloc-api-10.0.0.1", alloc.AllocID)
+	assert.Empty(t, alloc.OldHash)
+	assert.NotEmpty(t, alloc.NewHash)
+
+	tx = env.begin(t)
+	env.insertJobFile(t, tx, "job-web", path.Join("web", "marker.txt"), "v2", false)
+	require.NoError(t, tx.Commit())
+	testHooks.WorkerCommand = func(rt *bucket.Runtime, workerIP string, cmdCtx bucket.CommandContext, commands []string, env []string) error {
+		if cmdCtx.Job == "web" && cmdCtx.Action == rolloutActionRestart {
+			return fmt.Errorf("restart refused")
+		}
+		return rec.Record(rt, workerIP, cmdCtx, commands, env)
+	}
+	require.Error(t, Execute([]string{"web"}, Options{Force: true}))
+
+	runs = listDeployRuns(t, env)
+	require.Len(t, runs, 2)
+	second := runs[1]
+	assert.Equal(t, data.DeployRunFailed, second.Status)
+	assert.Contains(t, second.Error, "restart refused")
+	assert.Equal(t, []string{"web"}, second.JobsFilter)
+	assert.True(t, second.Force)
+	require.Len(t, second.Jobs, 1)
+	assert.Equal(t, data.DeployJobFailed, second.Jobs[0].Result)
+	assert.Contains(t, second.Jobs[0].Error, "restart refused")
+	webAlloc := second.Jobs[0].Allocations[0]
+	assert.NotEmpty(t, webAlloc.OldHash)
+	assert.NotEqual(t, webAlloc.OldHash, webAlloc.NewHash)
+
+	testHooks.WorkerCommand = func(rt *bucket.Runtime, workerIP string, cmdCtx bucket.CommandContext, commands []string, env []string) error {
+		cancelRunningDeploys()
+		return rec.Record(rt, workerIP, cmdCtx, commands, env)
+	}
+	require.ErrorIs(t, Execute(nil, Options{}), ErrDeployCancelled)
+
+	runs = listDeployRuns(t, env)
+	require.Len(t, runs, 3)
+	assert.Equal(t, data.DeployRunCancelled, runs[2].Status)
+	require.Len(t, runs[2].Jobs, 1)
+	assert.Equal(t, data.DeployJobCancelled, runs[2].Jobs[0].Result)
+}
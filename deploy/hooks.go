@@ -5,7 +5,11 @@
 package deploy
 
 import (
+	"database/sql"
+	"sync"
+
 	"maand/bucket"
+	"maand/healthcheck"
 	"maand/worker"
 )
 
@@ -15,6 +19,7 @@ type TestHooks struct {
 	Rsync                    func(rt *bucket.Runtime, bucketID, workerIP string, jobs []string) error
 	SetupRuntime             func(bucketID string, run bucket.RunContext) (*bucket.Runtime, error)
 	CheckWorkerPrerequisites func(rt *bucket.Runtime, workers []string) error
+	HealthCheck              func(tx *sql.Tx, rt *bucket.Runtime, job string) error
 }
 
 var testHooks *TestHooks
@@ -33,6 +38,7 @@ func ClearTestHooks() {
 type CommandRecorder struct {
 	BucketID string
 	Commands []RecordedCommand
+	mu       sync.Mutex
 }
 
 // RecordedCommand is one worker command invocation.
@@ -43,6 +49,8 @@ type RecordedCommand struct {
 
 // Record implements TestHooks.WorkerCommand.
 func (r *CommandRecorder) Record(_ *bucket.Runtime, workerIP string, _ bucket.CommandContext, commands []string, _ []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	for _, cmd := range commands {
 		r.Commands = append(r.Commands, RecordedCommand{WorkerIP: workerIP, Command: cmd})
 	}
@@ -52,6 +60,8 @@ func (r *CommandRecorder) Record(_ *bucket.Runtime, workerIP string, _ bucket.Co
 // HasAction reports whether a runner action was recorded for a job on a worker.
 func (r *CommandRecorder) HasAction(workerIP, action, job string) bool {
 	want := runnerCommand(r.BucketID, action, job)
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	for _, c := range r.Commands {
 		if c.WorkerIP == workerIP && c.Command == want {
 			return true
@@ -74,6 +84,13 @@ func runRsync(rt *bucket.Runtime, bucketID, workerIP string, jobs []string) erro
 	return rsync(rt, bucketID, workerIP, jobs)
 }
 
+func runHealthCheck(tx *sql.Tx, rt *bucket.Runtime, job string) error {
+	if testHooks != nil && testHooks.HealthCheck != nil {
+		return testHooks.HealthCheck(tx, rt, job)
+	}
+	return healthcheck.HealthCheck(tx, rt, true, job, true)
+}
+
 func setupDeployRuntime(bucketID string, run bucket.RunContext) (*bucket.Runtime, error) {
 	if testHooks != nil && testHooks.SetupRuntime != nil {
 		return testHooks.SetupRuntime(bucketID, run)
@@ -7,22 +7,23 @@ package deploy
 import (
 	"database/sql"
 	"fmt"
+	"log"
 	"strings"
 
 	"maand/bucket"
 	"maand/data"
-	"maand/healthcheck"
 	"maand/jobcommand"
 )
 
 // deployJob runs the full deploy pipeline for one job on the current deployment sequence.
-// Called sequentially per job because *sql.Tx is not safe for concurrent use (SQLite).
+// With --parallel-jobs it runs concurrently with other jobs of the wave; catalog writes then
+// go through writeCatalog.
 func deployJob(tx *sql.Tx, rt *bucket.Runtime, bucketID, job string, opts Options) error {
 	if opts.SyncOnly {
 		if err := validateSyncOnlyRollout(tx, job); err != nil {
 			return &JobError{Job: job, Err: err}
 		}
-		return finalizeJobDeploy(tx, rt, job)
+		return finalizeJobDeploy(tx, rt, job, opts)
 	}
 
 	commands, err := data.GetJobCommands(tx, job, "job_control")
@@ -34,14 +35,26 @@ func deployJob(tx *sql.Tx, rt *bucket.Runtime, bucketID, job string, opts Option
 		return deployJobWithCommands(tx, rt, job, commands, opts)
 	}
 
-	if err := handleNewAllocations(tx, rt, bucketID, job); err != nil {
+	if !opts.PromoteRollout {
+		paused, err := deployCanary(tx, rt, bucketID, job, opts)
+		if err != nil {
+			err = rollbackOnHealthFailure(tx, rt, bucketID, job, err)
+			return &JobError{Job: job, Err: fmt.Errorf("canary allocations: %w", err)}
+		}
+		if paused {
+			return errRolloutPaused
+		}
+	}
+
+	if err := handleNewAllocations(tx, rt, bucketID, job, opts); err != nil {
 		return &JobError{Job: job, Err: fmt.Errorf("start new allocations: %w", err)}
 	}
 	if err := handleUpdatedAllocations(tx, rt, bucketID, job, opts); err != nil {
+		err = rollbackOnHealthFailure(tx, rt, bucketID, job, err)
 		return &JobError{Job: job, Err: fmt.Errorf("restart updated allocations: %w", err)}
 	}
 
-	return finalizeJobDeploy(tx, rt, job)
+	return finalizeJobDeploy(tx, rt, job, opts)
 }
 
 func deployJobWithCommands(tx *sql.Tx, rt *bucket.Runtime, job string, commands []string, opts Options) error {
@@ -68,6 +81,8 @@ func deployJobWithCommands(tx *sql.Tx, rt *bucket.Runtime, job string, commands
 	if err != nil {
 		return &JobError{Job: job, Err: err}
 	}
+	newAllocations = selectWorkersForDeploy(newAllocations, opts)
+	updatedAllocations = selectWorkersForDeploy(updatedAllocations, opts)
 
 	extraEnv := []string{
 		fmt.Sprintf("UPDATED_ALLOCATIONS=%s", strings.Join(updatedAllocations, ",")),
@@ -82,16 +97,39 @@ func deployJobWithCommands(tx *sql.Tx, rt *bucket.Runtime, job string, commands
 		}
 	}
 
-	if err := healthcheck.HealthCheck(tx, rt, true, job, true); err != nil {
+	if err := runHealthCheck(tx, rt, job); err != nil {
 		return &JobError{Job: job, Err: err}
 	}
-	return finalizeJobDeploy(tx, rt, job)
+	return finalizeJobDeploy(tx, rt, job, opts)
 }
 
 // finalizeJobDeploy runs post_deploy hooks and promotes allocation hashes after rollout.
-func finalizeJobDeploy(tx *sql.Tx, rt *bucket.Runtime, job string) error {
+// The promoted job files are kept as the rollback source for the next deploy, and any
+// paused canary rollout for the job is finished. With opts.Workers only those allocations
+// are promoted; while others are still pending, the old rollback source and rollout state
+// are kept for them.
+func finalizeJobDeploy(tx *sql.Tx, rt *bucket.Runtime, job string, opts Options) error {
 	if err := executePostJobCommands(tx, rt, job); err != nil {
 		return err
 	}
-	return promoteAllocationHash(tx, job)
+	return writeCatalog(tx, func(tx *sql.Tx) error {
+		if err := promoteAllocationHash(tx, job, opts); err != nil {
+			return err
+		}
+		if len(opts.Workers) > 0 {
+			pending, err := JobNeedsRollout(tx, job)
+			if err != nil {
+				return err
+			}
+			if pending {
+				log.Printf("deploy: job %q: promoted on %s; other allocations are still pending",
+					job, strings.Join(opts.Workers, ","))
+				return nil
+			}
+		}
+		if err := data.SnapshotPromotedJobFiles(tx, job); err != nil {
+			return err
+		}
+		return data.ClearRolloutState(tx, job)
+	})
 }
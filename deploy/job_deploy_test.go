@@ -72,7 +72,7 @@ func TestFinalizeJobDeploy_runsPostDeployCommands(t *testing.T) {
 	require.NoError(t, prepareJobsFiles(tx, []string{"app"}))
 	require.NoError(t, updateAllocationHash(tx, []string{"app"}))
 
-	err = finalizeJobDeploy(tx, nil, "app")
+	err = finalizeJobDeploy(tx, nil, "app", Options{})
 	require.Error(t, err)
 	var jobErr *JobError
 	require.ErrorAs(t, err, &jobErr)
@@ -87,7 +87,7 @@ func TestFinalizeJobDeploy_promotesHashes(t *testing.T) {
 	require.NoError(t, kv.Initialize(tx))
 	require.NoError(t, prepareJobsFiles(tx, []string{"app"}))
 	require.NoError(t, updateAllocationHash(tx, []string{"app"}))
-	require.NoError(t, finalizeJobDeploy(tx, nil, "app"))
+	require.NoError(t, finalizeJobDeploy(tx, nil, "app", Options{}))
 	assert.True(t, env.allocationHashPromoted(t, tx, "app", "alloc-app-10.0.0.1"))
 	require.NoError(t, tx.Rollback())
 }
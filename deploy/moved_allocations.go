@@ -0,0 +1,142 @@
+// Copyright 2025 Kiruba Sankar Swaminathan. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package deploy
+
+import (
+	"database/sql"
+	"errors"
+	"slices"
+
+	"maand/bucket"
+	"maand/data"
+)
+
+// movedJobs returns the jobs whose running allocations maand rebalance moved: a removed
+// allocation still running on a catalog worker, and an active allocation not deployed yet.
+// With opts.StopMovedAfterStart deploy leaves their removed allocations running until
+// stopMovedAllocations; otherwise it returns nil and they are stopped up front as usual.
+func movedJobs(tx *sql.Tx, jobsFilter []string, opts Options) (map[string]bool, error) {
+	if !opts.StopMovedAfterStart {
+		return nil, nil
+	}
+
+	currentWorkers, err := data.LoadWorkerCatalog(tx)
+	if err != nil {
+		return nil, err
+	}
+	stopped, err := data.ListStoppedAllocations(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	moved := make(map[string]bool)
+	for _, alloc := range stopped {
+		if !alloc.Removed || moved[alloc.Job] || !currentWorkers.Contains(alloc.WorkerIP) {
+			continue
+		}
+		if len(jobsFilter) > 0 && !slices.Contains(jobsFilter, alloc.Job) {
+			continue
+		}
+		running, err := allocationWasDeployed(tx, alloc.WorkerIP, alloc.Job)
+		if err != nil {
+			return nil, err
+		}
+		if !running {
+			continue
+		}
+
+		active, err := data.GetActiveAllocations(tx, alloc.Job)
+		if err != nil {
+			return nil, err
+		}
+		for _, workerIP := range active {
+			deployed, err := allocationWasDeployed(tx, workerIP, alloc.Job)
+			if err != nil {
+				return nil, err
+			}
+			if !deployed {
+				moved[alloc.Job] = true
+				break
+			}
+		}
+	}
+	return moved, nil
+}
+
+// UnfinishedMoves returns the jobs of jobsFilter (every job when empty), in name order, whose
+// moved allocations were not rolled out yet: an old allocation still runs while a new one
+// was never deployed, as a failed maand rebalance leaves them.
+func UnfinishedMoves(tx *sql.Tx, jobsFilter []string) ([]string, error) {
+	moved, err := movedJobs(tx, jobsFilter, Options{StopMovedAfterStart: true})
+	if err != nil {
+		return nil, err
+	}
+	jobs := make([]string, 0, len(moved))
+	for job := range moved {
+		jobs = append(jobs, job)
+	}
+	slices.Sort(jobs)
+	return jobs, nil
+}
+
+// stopMovedAllocations stops the job's removed allocations that are still running, after
+// its new allocations started and passed the health check. Each stop runs the
+// after_allocation_stopped hook and removes the job from the worker; the job is health
+// checked again once they are gone. The worker commands run outside writeCatalog, so the
+// other jobs of the wave keep writing meanwhile; the stops are recorded in one write
+// afterwards, including those that finished before a failed one.
+func stopMovedAllocations(tx *sql.Tx, rt *bucket.Runtime, bucketID, job string) error {
+	listed, err := data.ListStoppedAllocations(tx)
+	if err != nil {
+		return err
+	}
+	var moved []data.StoppedAllocation
+	for _, alloc := range listed {
+		if alloc.Job != job || !alloc.Removed {
+			continue
+		}
+		running, err := allocationWasDeployed(tx, alloc.WorkerIP, alloc.Job)
+		if err != nil {
+			return err
+		}
+		if running {
+			moved = append(moved, alloc)
+		}
+	}
+	if len(moved) == 0 {
+		return nil
+	}
+
+	var stopped []data.StoppedAllocation
+	var stopErr error
+	for _, alloc := range moved {
+		if stopErr = stopAllocationOnWorker(tx, rt, bucketID, alloc, false); stopErr != nil {
+			break
+		}
+		stopped = append(stopped, alloc)
+	}
+	if err := writeCatalog(tx, func(tx *sql.Tx) error {
+		for _, alloc := range stopped {
+			if err := recordStoppedAllocation(tx, alloc); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		return errors.Join(stopErr, err)
+	}
+	if stopErr != nil {
+		return stopErr
+	}
+
+	activeCount, err := countActiveAllocations(tx, job)
+	if err != nil {
+		return err
+	}
+	if activeCount == 0 {
+		return nil
+	}
+	return runHealthCheck(tx, rt, job)
+}
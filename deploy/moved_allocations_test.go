@@ -0,0 +1,84 @@
+package deploy
+
+import (
+	"path"
+	"testing"
+
+	"maand/bucket"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func seedMovedJob(t *testing.T, env *deployTestEnv) {
+	t.Helper()
+	tx := env.begin(t)
+	env.ensureWorker(t, tx, "10.0.0.1", 0)
+	env.ensureWorker(t, tx, "10.0.0.2", 1)
+	jobID := env.insertJob(t, tx, "app", 0, 1)
+	env.insertAllocation(t, tx, "alloc-app-1", "10.0.0.1", "app", 0, 0, 1)
+	env.insertAllocation(t, tx, "alloc-app-2", "10.0.0.2", "app", 0, 0, 0)
+	env.setAllocationHash(t, tx, "app", "alloc-app-1", "c", "p")
+	env.insertJobFile(t, tx, jobID, path.Join("app", "Makefile"), makefileContent(), false)
+
+	env.insertJob(t, tx, "gone", 0, 1)
+	env.insertAllocation(t, tx, "alloc-gone-1", "10.0.0.1", "gone", 0, 0, 1)
+	env.setAllocationHash(t, tx, "gone", "alloc-gone-1", "c", "p")
+	require.NoError(t, tx.Commit())
+}
+
+func TestMovedJobs(t *testing.T) {
+	env := setupDeployTestEnv(t)
+	seedMovedJob(t, env)
+
+	tx := env.begin(t)
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	moved, err := movedJobs(tx, nil, Options{})
+	require.NoError(t, err)
+	assert.Nil(t, moved)
+
+	moved, err = movedJobs(tx, nil, Options{StopMovedAfterStart: true})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{"app": true}, moved)
+
+	moved, err = movedJobs(tx, []string{"gone"}, Options{StopMovedAfterStart: true})
+	require.NoError(t, err)
+	assert.Empty(t, moved)
+}
+
+func TestStopMovedAllocations_stopsOldWorkerOnly(t *testing.T) {
+	env := setupDeployTestEnv(t)
+	var stoppedOn []string
+	SetTestHooks(&TestHooks{
+		WorkerCommand: func(_ *bucket.Runtime, workerIP string, _ bucket.CommandContext, commands []string, _ []string) error {
+			for _, c := range commands {
+				if containsCmd(c, "stop") {
+					stoppedOn = append(stoppedOn, workerIP)
+				}
+			}
+			return nil
+		},
+		Rsync:        func(*bucket.Runtime, string, string, []string) error { return nil },
+		SetupRuntime: func(string, bucket.RunContext) (*bucket.Runtime, error) { return nil, nil },
+	})
+	t.Cleanup(ClearTestHooks)
+	seedMovedJob(t, env)
+
+	tx := env.begin(t)
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	require.NoError(t, stopMovedAllocations(tx, nil, env.bucketID, "app"))
+	assert.Equal(t, []string{"10.0.0.1"}, stoppedOn)
+
+	running, err := allocationWasDeployed(tx, "10.0.0.1", "app")
+	require.NoError(t, err)
+	assert.False(t, running)
+
+	stoppedOn = nil
+	require.NoError(t, stopMovedAllocations(tx, nil, env.bucketID, "app"))
+	assert.Empty(t, stoppedOn)
+}
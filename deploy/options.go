@@ -4,8 +4,28 @@
 
 package deploy
 
+import "time"
+
 // Options configures deploy and dry-run behavior.
 type Options struct {
 	Force    bool
 	SyncOnly bool
+	// PromoteRollout finishes paused canary rollouts instead of starting new ones.
+	PromoteRollout bool
+	// Workers limits staging, rsync, lifecycle actions, and hash promotion to allocations on
+	// these worker IPs; the job's other allocations stay pending for a later deploy.
+	Workers []string
+	// Plan restricts deploy to a saved plan; jobs that drifted from it fail.
+	Plan *SavedPlan
+	// ParallelJobs deploys up to this many jobs of one deployment_seq wave at once (0 or 1: one at a time).
+	ParallelJobs int
+	// CancelTimeout is how long a SIGINT/SIGTERM waits for in-flight batches before killing
+	// their commands (0: defaultCancelTimeout).
+	CancelTimeout time.Duration
+	// StopMovedAfterStart stops the removed allocations of a moved job (maand rebalance) only
+	// after the job's new allocations started and passed the health check, instead of before
+	// any job deploys. If the job fails, its old allocations keep running until a later
+	// deploy with StopMovedAfterStart (see UnfinishedMoves); a deploy without it stops them
+	// up front.
+	StopMovedAfterStart bool
 }
@@ -164,3 +164,61 @@ func ResolveRolloutOrder(tx *sql.Tx, job string, candidates []string) (ResolvedR
 		Source:    orderSourceKV,
 	}, nil
 }
+
+// rolloutBatch is one start or upgrade batch. Group is the rollout_by tag value shared by the
+// batch's allocations; LastInGroup marks the batch that finishes its group.
+type rolloutBatch struct {
+	Group       string
+	Workers     []string
+	LastInGroup bool
+}
+
+// planRolloutBatches splits ordered allocations into batches of batchSize (< 1: one batch per
+// group). When the job sets rollout_by, allocations are first grouped by that worker tag so no
+// batch spans two groups. Groups follow the rollout order of their first allocation; workers
+// without the tag form one group with an empty value. It returns the rollout_by tag key.
+func planRolloutBatches(tx *sql.Tx, job string, ordered []string, batchSize int) (string, []rolloutBatch, error) {
+	rolloutBy, err := data.GetRolloutBy(tx, job)
+	if err != nil {
+		return "", nil, err
+	}
+
+	groups := [][]string{ordered}
+	names := []string{""}
+	if rolloutBy != "" {
+		values, err := data.GetWorkerTagValues(tx, rolloutBy)
+		if err != nil {
+			return "", nil, err
+		}
+		groups, names = nil, nil
+		index := make(map[string]int)
+		for _, workerIP := range ordered {
+			value := values[workerIP]
+			i, ok := index[value]
+			if !ok {
+				i = len(groups)
+				index[value] = i
+				groups = append(groups, nil)
+				names = append(names, value)
+			}
+			groups[i] = append(groups[i], workerIP)
+		}
+	}
+
+	var batches []rolloutBatch
+	for i, group := range groups {
+		size := effectiveBatchSize(batchSize, len(group))
+		for start := 0; start < len(group); start += size {
+			end := start + size
+			if end > len(group) {
+				end = len(group)
+			}
+			batches = append(batches, rolloutBatch{
+				Group:       names[i],
+				Workers:     group[start:end],
+				LastInGroup: end == len(group),
+			})
+		}
+	}
+	return rolloutBy, batches, nil
+}
@@ -63,3 +63,66 @@ func TestBatchEnv(t *testing.T) {
 	assert.Contains(t, env, "DEPLOY_PHASE=new")
 	assert.Contains(t, env, "ROLLOUT_ORDER_SOURCE=kv")
 }
+
+// seedZonedJob puts app on five workers tagged zone a, b, a, (none), b.
+func seedZonedJob(t *testing.T, env *deployTestEnv) []string {
+	t.Helper()
+	workers := []string{"10.0.0.1", "10.0.0.2", "10.0.0.3", "10.0.0.4", "10.0.0.5"}
+	zones := []string{"a", "b", "a", "", "b"}
+	tx := env.begin(t)
+	env.seedMakefileJob(t, tx, "app", workers[0], 0)
+	for i, workerIP := range workers {
+		env.ensureWorker(t, tx, workerIP, i)
+		if i > 0 {
+			env.insertAllocation(t, tx, "alloc-app-"+workerIP, workerIP, "app", 0, 0, 0)
+		}
+		if zones[i] != "" {
+			_, err := tx.Exec(
+				`INSERT INTO worker_tags (worker_id, key, value)
+				 VALUES ((SELECT worker_id FROM worker WHERE worker_ip = ?), 'zone', ?)`,
+				workerIP, zones[i],
+			)
+			require.NoError(t, err)
+		}
+	}
+	_, err := tx.Exec(`UPDATE job SET rollout_by = 'zone', max_concurrent_upgrades = 2 WHERE name = 'app'`)
+	require.NoError(t, err)
+	require.NoError(t, tx.Commit())
+	return workers
+}
+
+func TestPlanRolloutBatches_groupsByTag(t *testing.T) {
+	env := setupDeployTestEnv(t)
+	workers := seedZonedJob(t, env)
+
+	tx := env.begin(t)
+	defer func() { _ = tx.Rollback() }()
+
+	rolloutBy, batches, err := planRolloutBatches(tx, "app", workers, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "zone", rolloutBy)
+	assert.Equal(t, []rolloutBatch{
+		{Group: "a", Workers: []string{"10.0.0.1"}},
+		{Group: "a", Workers: []string{"10.0.0.3"}, LastInGroup: true},
+		{Group: "b", Workers: []string{"10.0.0.2"}},
+		{Group: "b", Workers: []string{"10.0.0.5"}, LastInGroup: true},
+		{Group: "", Workers: []string{"10.0.0.4"}, LastInGroup: true},
+	}, batches)
+
+	_, batches, err = planRolloutBatches(tx, "app", []string{"10.0.0.5", "10.0.0.4", "10.0.0.1"}, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []rolloutBatch{
+		{Group: "b", Workers: []string{"10.0.0.5"}, LastInGroup: true},
+		{Group: "", Workers: []string{"10.0.0.4"}, LastInGroup: true},
+		{Group: "a", Workers: []string{"10.0.0.1"}, LastInGroup: true},
+	}, batches)
+
+	_, err = tx.Exec(`UPDATE job SET rollout_by = '' WHERE name = 'app'`)
+	require.NoError(t, err)
+	rolloutBy, batches, err = planRolloutBatches(tx, "app", workers, 2)
+	require.NoError(t, err)
+	assert.Empty(t, rolloutBy)
+	require.Len(t, batches, 3)
+	assert.Equal(t, []string{"10.0.0.1", "10.0.0.2"}, batches[0].Workers)
+	assert.True(t, batches[2].LastInGroup)
+}
@@ -2,15 +2,19 @@ package deploy
 
 import (
 	"fmt"
+	"sync"
 	"sync/atomic"
 	"testing"
 )
 
 func TestRunWorkerBatchesIndexWorkers(t *testing.T) {
 	workers := []string{"w1", "w2", "w3"}
+	var mu sync.Mutex
 	var seen []string
 
 	err := runWorkerBatches(workers, 2, func(ip string) error {
+		mu.Lock()
+		defer mu.Unlock()
 		seen = append(seen, ip)
 		return nil
 	})
@@ -0,0 +1,243 @@
+// Copyright 2025 Kiruba Sankar Swaminathan. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package deploy
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"maand/bucket"
+	"maand/data"
+)
+
+// savedPlanFormat is the saved plan file format written by WritePlan.
+const savedPlanFormat = 1
+
+// ErrStalePlan reports a saved plan that no longer matches the bucket.
+var ErrStalePlan = errors.New("saved deploy plan is stale")
+
+// SavedPlan is a dry-run result written by maand deploy --plan-out and applied with
+// --plan-in. Digest is the SHA-256 of the plan encoded with an empty Digest.
+type SavedPlan struct {
+	Format        int          `json:"format"`
+	JobsFilter    []string     `json:"jobs_filter,omitempty"`
+	WorkersFilter []string     `json:"workers_filter,omitempty"`
+	Force         bool         `json:"force"`
+	SyncOnly      bool         `json:"sync_only"`
+	Result        DryRunResult `json:"plan"`
+	Digest        string       `json:"digest"`
+}
+
+// NewSavedPlan records a dry-run result with the filter and options it was computed with.
+func NewSavedPlan(jobsFilter []string, opts Options, result DryRunResult) (SavedPlan, error) {
+	plan := SavedPlan{
+		Format:        savedPlanFormat,
+		JobsFilter:    normalizeJobFilter(jobsFilter),
+		WorkersFilter: normalizeWorkerFilter(opts.Workers),
+		Force:         opts.Force,
+		SyncOnly:      opts.SyncOnly,
+		Result:        result,
+	}
+	digest, err := plan.digest()
+	if err != nil {
+		return plan, err
+	}
+	plan.Digest = digest
+	return plan, nil
+}
+
+func (p SavedPlan) digest() (string, error) {
+	p.Digest = ""
+	encoded, err := json.Marshal(p)
+	if err != nil {
+		return "", bucket.UnexpectedError(err)
+	}
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Options returns the deploy options the plan was computed with.
+func (p SavedPlan) Options() Options {
+	return Options{Force: p.Force, SyncOnly: p.SyncOnly, Workers: p.WorkersFilter, Plan: &p}
+}
+
+// WritePlan writes the plan as indented JSON.
+func WritePlan(filePath string, plan SavedPlan) error {
+	encoded, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		return bucket.UnexpectedError(err)
+	}
+	if err := os.WriteFile(filePath, append(encoded, '\n'), 0o644); err != nil {
+		return bucket.UnexpectedError(err)
+	}
+	return nil
+}
+
+// ReadPlan loads a plan written by WritePlan and verifies its digest.
+func ReadPlan(filePath string) (SavedPlan, error) {
+	var plan SavedPlan
+	raw, err := os.ReadFile(filePath)
+	if err != nil {
+		return plan, bucket.UnexpectedError(err)
+	}
+	if err := json.Unmarshal(raw, &plan); err != nil {
+		return plan, fmt.Errorf("%w: %s: %w", ErrStalePlan, filePath, err)
+	}
+	if plan.Format != savedPlanFormat {
+		return plan, fmt.Errorf("%w: %s: unsupported format %d", ErrStalePlan, filePath, plan.Format)
+	}
+	digest, err := plan.digest()
+	if err != nil {
+		return plan, err
+	}
+	if digest != plan.Digest {
+		return plan, fmt.Errorf("%w: %s: digest mismatch (plan file was modified)", ErrStalePlan, filePath)
+	}
+	return plan, nil
+}
+
+// ApplyPlan recomputes the dry-run and deploys only when it matches the saved plan.
+// pre_deploy hooks run during the check, as with maand deploy --dry-run. Each job is
+// checked again right before it is staged, so a change between the check and the
+// rollout fails that job instead of deploying unreviewed content. Only ParallelJobs and
+// CancelTimeout are taken from run; they do not change what is deployed.
+func ApplyPlan(plan SavedPlan, run Options) error {
+	opts := plan.Options()
+	opts.ParallelJobs = run.ParallelJobs
+	opts.CancelTimeout = run.CancelTimeout
+	current, err := DryRun(plan.JobsFilter, opts)
+	if err != nil {
+		return err
+	}
+	if diffs := diffDryRun(plan.Result, current); len(diffs) > 0 {
+		return fmt.Errorf("%w:\n%s", ErrStalePlan, strings.Join(diffs, "\n"))
+	}
+	return Execute(plan.JobsFilter, opts)
+}
+
+// checkPlanUpdateSeq refuses a deploy when another deploy ran since the plan was made.
+// Call it before UpdateSeq bumps the sequence.
+func checkPlanUpdateSeq(db *sql.DB, plan *SavedPlan) error {
+	if plan == nil {
+		return nil
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return bucket.DatabaseError(err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	bucketID, err := data.GetBucketID(tx)
+	if err != nil {
+		return err
+	}
+	updateSeq, err := data.GetBucketUpdateSeq(tx)
+	if err != nil {
+		return err
+	}
+	if plan.Result.BucketID != bucketID {
+		return fmt.Errorf("%w: bucket_id plan=%s now=%s", ErrStalePlan, plan.Result.BucketID, bucketID)
+	}
+	if plan.Result.UpdateSeq != updateSeq {
+		return fmt.Errorf("%w: update_seq plan=%d now=%d", ErrStalePlan, plan.Result.UpdateSeq, updateSeq)
+	}
+	return nil
+}
+
+// checkJobAgainstPlan compares the job's freshly computed rollout with the saved plan.
+func checkJobAgainstPlan(plan *SavedPlan, current JobPlan) error {
+	if plan == nil {
+		return nil
+	}
+	for _, want := range plan.Result.Jobs {
+		if want.Job == current.Job {
+			if diffs := diffJobPlan(want, current); len(diffs) > 0 {
+				return fmt.Errorf("%w: %s", ErrStalePlan, strings.Join(diffs, "; "))
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: job %s is not in the plan", ErrStalePlan, current.Job)
+}
+
+func diffDryRun(want, got DryRunResult) []string {
+	var diffs []string
+	if want.BucketID != got.BucketID {
+		diffs = append(diffs, fmt.Sprintf("bucket_id plan=%s now=%s", want.BucketID, got.BucketID))
+	}
+	if want.UpdateSeq != got.UpdateSeq {
+		diffs = append(diffs, fmt.Sprintf("update_seq plan=%d now=%d", want.UpdateSeq, got.UpdateSeq))
+	}
+
+	gotJobs := make(map[string]JobPlan, len(got.Jobs))
+	for _, job := range got.Jobs {
+		gotJobs[job.Job] = job
+	}
+	for _, job := range want.Jobs {
+		current, ok := gotJobs[job.Job]
+		if !ok {
+			diffs = append(diffs, fmt.Sprintf("job %s: no longer deployable", job.Job))
+			continue
+		}
+		diffs = append(diffs, diffJobPlan(job, current)...)
+		delete(gotJobs, job.Job)
+	}
+	for _, job := range got.Jobs {
+		if _, ok := gotJobs[job.Job]; ok {
+			diffs = append(diffs, fmt.Sprintf("job %s: not in the plan", job.Job))
+		}
+	}
+	return diffs
+}
+
+func diffJobPlan(want, got JobPlan) []string {
+	var diffs []string
+	if want.Version != got.Version {
+		diffs = append(diffs, fmt.Sprintf("job %s: version plan=%s now=%s", want.Job, want.Version, got.Version))
+	}
+	if want.DeploymentSeq != got.DeploymentSeq {
+		diffs = append(diffs, fmt.Sprintf("job %s: deployment_seq plan=%d now=%d", want.Job, want.DeploymentSeq, got.DeploymentSeq))
+	}
+	if want.NeedsRollout != got.NeedsRollout {
+		diffs = append(diffs, fmt.Sprintf("job %s: needs_rollout plan=%t now=%t", want.Job, want.NeedsRollout, got.NeedsRollout))
+	}
+
+	gotAllocs := make(map[string]AllocationPlan, len(got.Allocations))
+	for _, alloc := range got.Allocations {
+		gotAllocs[alloc.WorkerIP] = alloc
+	}
+	for _, alloc := range want.Allocations {
+		current, ok := gotAllocs[alloc.WorkerIP]
+		if !ok {
+			diffs = append(diffs, fmt.Sprintf("job %s: allocation %s no longer present", want.Job, alloc.WorkerIP))
+			continue
+		}
+		delete(gotAllocs, alloc.WorkerIP)
+		if alloc.Action != current.Action {
+			diffs = append(diffs, fmt.Sprintf("job %s: allocation %s action plan=%s now=%s", want.Job, alloc.WorkerIP, alloc.Action, current.Action))
+		}
+		if alloc.CurrentHash != current.CurrentHash {
+			diffs = append(diffs, fmt.Sprintf("job %s: allocation %s current_hash plan=%s now=%s", want.Job, alloc.WorkerIP, alloc.CurrentHash, current.CurrentHash))
+		}
+		if alloc.PreviousHash != current.PreviousHash {
+			diffs = append(diffs, fmt.Sprintf("job %s: allocation %s previous_hash plan=%s now=%s", want.Job, alloc.WorkerIP, alloc.PreviousHash, current.PreviousHash))
+		}
+	}
+	for _, alloc := range got.Allocations {
+		if _, ok := gotAllocs[alloc.WorkerIP]; ok {
+			diffs = append(diffs, fmt.Sprintf("job %s: allocation %s not in the plan", want.Job, alloc.WorkerIP))
+		}
+	}
+	return diffs
+}
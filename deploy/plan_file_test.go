@@ -0,0 +1,127 @@
+// Copyright 2025 Kiruba Sankar Swaminathan. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package deploy
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// seedPlannedChange deploys app, stages a content change, and returns a saved plan for it.
+func seedPlannedChange(t *testing.T, env *deployTestEnv) (*CommandRecorder, SavedPlan) {
+	t.Helper()
+	rec := installNoopDeployHooks(t, env.bucketID)
+
+	tx := env.begin(t)
+	env.seedMakefileJob(t, tx, "app", "10.0.0.1", 0)
+	require.NoError(t, tx.Commit())
+	require.NoError(t, Execute(nil, Options{}))
+
+	tx = env.begin(t)
+	env.insertJobFile(t, tx, "job-app", path.Join("app", "marker.txt"), "v2", false)
+	require.NoError(t, tx.Commit())
+
+	result, err := DryRun(nil, Options{})
+	require.NoError(t, err)
+	require.True(t, result.Required)
+	plan, err := NewSavedPlan(nil, Options{}, result)
+	require.NoError(t, err)
+	rec.Commands = nil
+	return rec, plan
+}
+
+func TestSavedPlan_roundTripAndTamper(t *testing.T) {
+	env := setupDeployTestEnv(t)
+	_, plan := seedPlannedChange(t, env)
+
+	planFile := path.Join(t.TempDir(), "plan.json")
+	require.NoError(t, WritePlan(planFile, plan))
+	loaded, err := ReadPlan(planFile)
+	require.NoError(t, err)
+	assert.Equal(t, plan.Digest, loaded.Digest)
+	assert.Equal(t, plan.Result.UpdateSeq, loaded.Result.UpdateSeq)
+	require.Len(t, loaded.Result.Jobs, 1)
+	assert.Equal(t, plan.Result.Jobs[0].Allocations, loaded.Result.Jobs[0].Allocations)
+
+	loaded.Force = true
+	require.NoError(t, WritePlan(planFile, loaded))
+	_, err = ReadPlan(planFile)
+	require.ErrorIs(t, err, ErrStalePlan)
+
+	require.NoError(t, os.WriteFile(planFile, []byte(`{"format":99}`), 0o644))
+	_, err = ReadPlan(planFile)
+	require.ErrorIs(t, err, ErrStalePlan)
+}
+
+func TestApplyPlan_deploysUnchangedBucket(t *testing.T) {
+	env := setupDeployTestEnv(t)
+	rec, plan := seedPlannedChange(t, env)
+
+	require.NoError(t, ApplyPlan(plan, Options{}))
+	assert.True(t, rec.HasAction("10.0.0.1", rolloutActionRestart, "app"))
+
+	result, err := DryRun(nil, Options{})
+	require.NoError(t, err)
+	assert.False(t, result.Required)
+}
+
+func TestApplyPlan_refusesChangedContent(t *testing.T) {
+	env := setupDeployTestEnv(t)
+	rec, plan := seedPlannedChange(t, env)
+
+	tx := env.begin(t)
+	_, err := tx.Exec(`UPDATE job_files SET content = 'v3' WHERE job_id = 'job-app' AND path = ?`, path.Join("app", "marker.txt"))
+	require.NoError(t, err)
+	require.NoError(t, tx.Commit())
+
+	err = ApplyPlan(plan, Options{})
+	require.ErrorIs(t, err, ErrStalePlan)
+	assert.Contains(t, err.Error(), "current_hash")
+	assert.Empty(t, rec.Commands)
+}
+
+func TestApplyPlan_refusesAfterAnotherDeploy(t *testing.T) {
+	env := setupDeployTestEnv(t)
+	_, plan := seedPlannedChange(t, env)
+
+	require.NoError(t, Execute(nil, Options{}))
+
+	err := ApplyPlan(plan, Options{})
+	require.ErrorIs(t, err, ErrStalePlan)
+	assert.Contains(t, err.Error(), "update_seq")
+}
+
+func TestCheckJobAgainstPlan(t *testing.T) {
+	plan := &SavedPlan{Result: DryRunResult{Jobs: []JobPlan{{
+		Job:          "app",
+		Version:      "1.0",
+		NeedsRollout: true,
+		Allocations: []AllocationPlan{
+			{WorkerIP: "10.0.0.1", Action: rolloutActionRestart, PreviousHash: "a", CurrentHash: "b"},
+		},
+	}}}}
+
+	require.NoError(t, checkJobAgainstPlan(nil, JobPlan{Job: "app"}))
+	require.NoError(t, checkJobAgainstPlan(plan, plan.Result.Jobs[0]))
+
+	changed := plan.Result.Jobs[0]
+	changed.Version = "1.1"
+	changed.Allocations = []AllocationPlan{
+		{WorkerIP: "10.0.0.1", Action: rolloutActionRestart, PreviousHash: "a", CurrentHash: "c"},
+		{WorkerIP: "10.0.0.2", Action: rolloutActionStart, CurrentHash: "c"},
+	}
+	err := checkJobAgainstPlan(plan, changed)
+	require.ErrorIs(t, err, ErrStalePlan)
+	assert.Contains(t, err.Error(), "version plan=1.0 now=1.1")
+	assert.Contains(t, err.Error(), "allocation 10.0.0.1 current_hash plan=b now=c")
+	assert.Contains(t, err.Error(), "allocation 10.0.0.2 not in the plan")
+
+	err = checkJobAgainstPlan(plan, JobPlan{Job: "worker"})
+	require.ErrorIs(t, err, ErrStalePlan)
+}
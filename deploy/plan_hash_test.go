@@ -48,7 +48,7 @@ func TestRefreshPlanHashesForJobs_detectsContentChange(t *testing.T) {
 	tx = env.begin(t)
 	require.NoError(t, kv.Initialize(tx))
 	require.NoError(t, refreshPlanHashesForJobs(tx, []string{"app"}))
-	require.NoError(t, promoteAllocationHash(tx, "app"))
+	require.NoError(t, promoteAllocationHash(tx, "app", Options{}))
 	require.NoError(t, tx.Commit())
 
 	tx = env.begin(t)
@@ -115,9 +115,19 @@ func prepareJobsFiles(tx *sql.Tx, jobs []string) error {
 }
 
 func prepareJobOnWorker(tx *sql.Tx, job, workerIP string) error {
+	return stageJobOnWorker(tx, job, workerIP, data.CopyJobFiles)
+}
+
+// stageJobOnWorker writes one job tree under tmp/workers/<ip>/jobs/ using copyFiles as the
+// file source, then renders templates, Prometheus assets, and certs.
+func stageJobOnWorker(
+	tx *sql.Tx,
+	job, workerIP string,
+	copyFiles func(tx *sql.Tx, jobName, outputPath string) error,
+) error {
 	workerDirPath := bucket.GetTempWorkerPath(workerIP)
 
-	if err := data.CopyJobFiles(tx, job, path.Join(workerDirPath, "jobs")); err != nil {
+	if err := copyFiles(tx, job, path.Join(workerDirPath, "jobs")); err != nil {
 		return err
 	}
 
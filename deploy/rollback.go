@@ -0,0 +1,207 @@
+// Copyright 2025 Kiruba Sankar Swaminathan. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package deploy
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"strings"
+
+	"maand/bucket"
+	"maand/data"
+	"maand/utils"
+)
+
+// rollbackOnHealthFailure restores upgraded allocations to the last promoted job files when
+// err is a failed upgrade health gate and the job sets rollback_on_failure. The returned
+// error keeps err and notes the rollback outcome; hashes are never promoted here.
+func rollbackOnHealthFailure(tx *sql.Tx, rt *bucket.Runtime, bucketID, job string, err error) error {
+	var healthErr *rolloutHealthError
+	if !errors.As(err, &healthErr) {
+		return err
+	}
+	// A probe killed by an aborted cancel did not fail on its own; leave the allocations as-is.
+	if isCancellation(err) || runtimeInterrupted(rt) {
+		return err
+	}
+
+	enabled, lookupErr := data.GetRollbackOnFailure(tx, job)
+	if lookupErr != nil {
+		return fmt.Errorf("%w; rollback skipped: %v", err, lookupErr)
+	}
+	if !enabled {
+		return err
+	}
+
+	rolledBack, rollbackErr := rollbackAllocations(tx, rt, bucketID, job, healthErr.Upgraded)
+	if rollbackErr != nil {
+		return fmt.Errorf("%w; rollback failed: %v", err, rollbackErr)
+	}
+	if len(rolledBack) == 0 {
+		return err
+	}
+	return fmt.Errorf("%w; rolled back %s", err, strings.Join(rolledBack, ","))
+}
+
+// rollbackAllocations re-stages the promoted job tree for candidates, rsyncs it, restarts the
+// allocations in the forward rollout's batches (max_concurrent_upgrades, split by rollout_by),
+// and marks their staged hash rolled back.
+// Health is checked once after every batch has restarted so a flaky probe cannot leave the
+// job split between releases again.
+func rollbackAllocations(
+	tx *sql.Tx,
+	rt *bucket.Runtime,
+	bucketID, job string,
+	candidates []string,
+) ([]string, error) {
+	active, err := activeWorkers(utils.Unique(candidates), tx, job)
+	if err != nil {
+		return nil, err
+	}
+	if len(active) == 0 {
+		return nil, nil
+	}
+
+	hasSnapshot, err := data.HasPromotedJobFiles(tx, job)
+	if err != nil {
+		return nil, err
+	}
+	if !hasSnapshot {
+		return nil, fmt.Errorf("no promoted job files recorded for job %s yet; they are recorded by build or on the next successful deploy", job)
+	}
+
+	log.Printf("deploy: job %q: rolling back %s to the last promoted files", job, strings.Join(active, ","))
+	if rt != nil {
+		_ = rt.LogEvent("", "rollback_begin", map[string]string{
+			"job":         job,
+			"allocations": strings.Join(active, ","),
+		})
+	}
+
+	namespace := fmt.Sprintf("%s_allocation", job)
+	if err := writeCatalog(tx, func(tx *sql.Tx) error {
+		for _, workerIP := range active {
+			jobDir := path.Join(bucket.GetTempWorkerPath(workerIP), "jobs", job)
+			if err := os.RemoveAll(jobDir); err != nil {
+				return bucket.UnexpectedError(err)
+			}
+			if err := stageJobOnWorker(tx, job, workerIP, data.CopyPromotedJobFiles); err != nil {
+				return err
+			}
+			if err := checkRollbackTree(tx, namespace, job, workerIP); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := syncWorkers(rt, bucketID, active, []string{job}, true); err != nil {
+		return nil, err
+	}
+
+	resolved, err := ResolveRolloutOrder(tx, job, active)
+	if err != nil {
+		return nil, err
+	}
+	parallelism, err := data.GetMaxConcurrentUpgrades(tx, job)
+	if err != nil {
+		return nil, err
+	}
+	if parallelism < 1 {
+		parallelism = 1
+	}
+
+	restartCmd := runnerCommand(bucketID, rolloutActionRestart, job)
+	restartFn := func(workerIP string) error {
+		env, err := rollbackVersionEnv(tx, job, workerIP)
+		if err != nil {
+			return err
+		}
+		return runWorkerCommand(rt, workerIP, runnerCmdCtx(job, "rollback", rolloutActionRestart, bucketID), []string{restartCmd}, env)
+	}
+
+	// Same batches as the forward rollout: with rollout_by, no batch spans two groups.
+	rolloutBy, batches, err := planRolloutBatches(tx, job, resolved.Ordered, parallelism)
+	if err != nil {
+		return nil, err
+	}
+	for i, batch := range batches {
+		logRolloutGroup(job, rolloutBy, batches, i)
+		if err := runParallelWorkers(batch.Workers, len(batch.Workers), restartFn); err != nil {
+			return nil, fmt.Errorf("restart rolled back allocations: %w", err)
+		}
+		ctx := BatchContext{
+			Job:             job,
+			Phase:           deployPhaseRollback,
+			BatchIndex:      i,
+			BatchCount:      len(batches),
+			BatchAllocation: append([]string(nil), batch.Workers...),
+			RolloutOrder:    resolved.FullOrder,
+			OrderSource:     resolved.Source,
+			RolloutBy:       rolloutBy,
+			RolloutGroup:    batch.Group,
+		}
+		if err := executeAfterAllocationStarted(tx, rt, job, batch.Workers, ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := writeCatalog(tx, func(tx *sql.Tx) error {
+		for _, workerIP := range resolved.Ordered {
+			allocID, err := data.GetAllocationID(tx, workerIP, job)
+			if err != nil {
+				return err
+			}
+			if err := data.MarkAllocationRolledBack(tx, namespace, allocID); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := runHealthCheck(tx, rt, job); err != nil {
+		return resolved.Ordered, fmt.Errorf("health check after rollback: %w", err)
+	}
+
+	if rt != nil {
+		_ = rt.LogEvent("", "rollback_end", map[string]string{
+			"job":         job,
+			"allocations": strings.Join(resolved.Ordered, ","),
+		})
+	}
+	return resolved.Ordered, nil
+}
+
+// checkRollbackTree warns when the re-staged tree differs from the promoted hash. Templates
+// render against the current KV store, so a rollback can differ from what was promoted.
+func checkRollbackTree(tx *sql.Tx, namespace, job, workerIP string) error {
+	allocID, err := data.GetAllocationID(tx, workerIP, job)
+	if err != nil {
+		return err
+	}
+	previous, err := data.GetPreviousHash(tx, namespace, allocID)
+	if err != nil {
+		return err
+	}
+	tree, err := utils.HashDirectoryTree(path.Join(bucket.GetTempWorkerPath(workerIP), "jobs", job))
+	if err != nil {
+		return err
+	}
+	if previous != "" && tree.Aggregate != previous {
+		log.Printf(
+			"deploy: job %q on %s: rollback tree hash %s differs from promoted hash %s (templates re-rendered)",
+			job, workerIP, tree.Aggregate, previous,
+		)
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+// Copyright 2025 Kiruba Sankar Swaminathan. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package deploy
+
+import (
+	"database/sql"
+	"errors"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"testing"
+
+	"maand/bucket"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// seedRollbackJob promotes marker.txt=v1, stages v2, fails the first health check after
+// the upgrade, and records the marker content seen by every rsync.
+func seedRollbackJob(t *testing.T, env *deployTestEnv, rollback bool) (*CommandRecorder, *[]string) {
+	t.Helper()
+	rec := installNoopDeployHooks(t, env.bucketID)
+
+	tx := env.begin(t)
+	env.seedMakefileJob(t, tx, "app", "10.0.0.1", 0)
+	env.insertJobFile(t, tx, "job-app", path.Join("app", "marker.txt"), "v1", false)
+	if rollback {
+		_, err := tx.Exec(`UPDATE job SET rollback_on_failure = 1 WHERE name = 'app'`)
+		require.NoError(t, err)
+	}
+	require.NoError(t, tx.Commit())
+	require.NoError(t, Execute(nil, Options{}))
+
+	tx = env.begin(t)
+	_, err := tx.Exec(`UPDATE job_files SET content = 'v2' WHERE job_id = 'job-app' AND path = ?`, path.Join("app", "marker.txt"))
+	require.NoError(t, err)
+	require.NoError(t, tx.Commit())
+
+	checks := 0
+	testHooks.HealthCheck = func(*sql.Tx, *bucket.Runtime, string) error {
+		checks++
+		if checks == 1 {
+			return errors.New("probe failed")
+		}
+		return nil
+	}
+	var synced []string
+	testHooks.Rsync = func(_ *bucket.Runtime, _ string, workerIP string, _ []string) error {
+		marker, err := os.ReadFile(path.Join(bucket.GetTempWorkerPath(workerIP), "jobs", "app", "marker.txt"))
+		if err != nil {
+			return err
+		}
+		synced = append(synced, strings.TrimSpace(string(marker)))
+		return nil
+	}
+	rec.Commands = nil
+	return rec, &synced
+}
+
+func countAction(rec *CommandRecorder, workerIP, action, job string) int {
+	want := runnerCommand(rec.BucketID, action, job)
+	n := 0
+	for _, c := range rec.Commands {
+		if c.WorkerIP == workerIP && c.Command == want {
+			n++
+		}
+	}
+	return n
+}
+
+func TestExecute_rollbackOnFailureRestoresPromotedFiles(t *testing.T) {
+	env := setupDeployTestEnv(t)
+	rec, synced := seedRollbackJob(t, env, true)
+
+	err := Execute(nil, Options{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "rolled back 10.0.0.1")
+	assert.Equal(t, 2, countAction(rec, "10.0.0.1", "restart", "app"))
+
+	require.NotEmpty(t, *synced)
+	assert.Equal(t, "v2", (*synced)[0])
+	assert.Equal(t, "v1", (*synced)[len(*synced)-1])
+
+	tx := env.begin(t)
+	defer func() { _ = tx.Rollback() }()
+	var current, previous, rolledBack sql.NullString
+	require.NoError(t, tx.QueryRow(
+		`SELECT current_hash, previous_hash, rolled_back_hash FROM hash WHERE namespace = 'app_allocation' AND key = 'alloc-app-10.0.0.1'`,
+	).Scan(&current, &previous, &rolledBack))
+	assert.NotEqual(t, current.String, previous.String)
+	assert.Equal(t, current.String, rolledBack.String)
+}
+
+func TestExecute_rollbackOnFailureDisabledLeavesUpgrade(t *testing.T) {
+	env := setupDeployTestEnv(t)
+	rec, _ := seedRollbackJob(t, env, false)
+
+	err := Execute(nil, Options{})
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "rolled back")
+	assert.Equal(t, 1, countAction(rec, "10.0.0.1", "restart", "app"))
+
+	tx := env.begin(t)
+	defer func() { _ = tx.Rollback() }()
+	var rolledBack sql.NullString
+	require.NoError(t, tx.QueryRow(
+		`SELECT rolled_back_hash FROM hash WHERE namespace = 'app_allocation' AND key = 'alloc-app-10.0.0.1'`,
+	).Scan(&rolledBack))
+	assert.False(t, rolledBack.Valid)
+}
+
+func TestRollbackAllocations_requiresPromotedSnapshot(t *testing.T) {
+	env := setupDeployTestEnv(t)
+	installNoopDeployHooks(t, env.bucketID)
+
+	tx := env.begin(t)
+	defer func() { _ = tx.Rollback() }()
+	env.seedMakefileJob(t, tx, "app", "10.0.0.1", 0)
+
+	_, err := rollbackAllocations(tx, nil, env.bucketID, "app", []string{"10.0.0.1"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no promoted job files")
+}
+
+func TestRollbackOnHealthFailure_ignoresOtherErrors(t *testing.T) {
+	env := setupDeployTestEnv(t)
+	installNoopDeployHooks(t, env.bucketID)
+
+	tx := env.begin(t)
+	defer func() { _ = tx.Rollback() }()
+	env.seedMakefileJob(t, tx, "app", "10.0.0.1", 0)
+	_, err := tx.Exec(`UPDATE job SET rollback_on_failure = 1 WHERE name = 'app'`)
+	require.NoError(t, err)
+
+	orig := errors.New("restart failed")
+	assert.Equal(t, orig, rollbackOnHealthFailure(tx, nil, env.bucketID, "app", orig))
+}
+
+func TestExecute_rollbackBatchesFollowRolloutBy(t *testing.T) {
+	env := setupDeployTestEnv(t)
+	installNoopDeployHooks(t, env.bucketID)
+	seedZonedJob(t, env)
+
+	tx := env.begin(t)
+	env.insertJobFile(t, tx, "job-app", path.Join("app", "marker.txt"), "v1", false)
+	_, err := tx.Exec(`UPDATE job SET rollback_on_failure = 1 WHERE name = 'app'`)
+	require.NoError(t, err)
+	require.NoError(t, tx.Commit())
+	require.NoError(t, Execute(nil, Options{}))
+
+	tx = env.begin(t)
+	_, err = tx.Exec(`UPDATE job_files SET content = 'v2' WHERE job_id = 'job-app' AND path = ?`, path.Join("app", "marker.txt"))
+	require.NoError(t, err)
+	require.NoError(t, tx.Commit())
+
+	// Zone a passes its health gate, zone b fails it: both zones are rolled back.
+	checks := 0
+	testHooks.HealthCheck = func(*sql.Tx, *bucket.Runtime, string) error {
+		checks++
+		if checks == 2 {
+			return errors.New("probe failed")
+		}
+		return nil
+	}
+	var mu sync.Mutex
+	var rolledBack []string
+	testHooks.WorkerCommand = func(_ *bucket.Runtime, workerIP string, cmdCtx bucket.CommandContext, _ []string, _ []string) error {
+		if cmdCtx.Phase == deployPhaseRollback {
+			mu.Lock()
+			rolledBack = append(rolledBack, workerIP)
+			mu.Unlock()
+		}
+		return nil
+	}
+
+	err = Execute(nil, Options{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "rolled back")
+
+	// max_concurrent_upgrades is 2; without rollout_by the first batch would mix zones.
+	require.Len(t, rolledBack, 4)
+	assert.ElementsMatch(t, []string{"10.0.0.1", "10.0.0.3"}, rolledBack[:2])
+	assert.ElementsMatch(t, []string{"10.0.0.2", "10.0.0.5"}, rolledBack[2:])
+}
@@ -7,12 +7,27 @@ package deploy
 import (
 	"database/sql"
 	"fmt"
+	"log"
 
 	"maand/bucket"
 	"maand/data"
-	"maand/healthcheck"
 )
 
+// rolloutHealthError reports a failed health gate after an upgrade batch. Upgraded lists
+// every allocation restarted or reloaded so far, including the failed batch.
+type rolloutHealthError struct {
+	Upgraded []string
+	Err      error
+}
+
+func (e *rolloutHealthError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *rolloutHealthError) Unwrap() error {
+	return e.Err
+}
+
 func effectiveBatchSize(requested, total int) int {
 	if requested < 1 {
 		return total
@@ -51,34 +66,41 @@ func rolloutStartBatches(
 	if err != nil {
 		return err
 	}
-	batchSize := effectiveBatchSize(parallelism, len(resolved.Ordered))
-	totalBatches := batchCount(len(resolved.Ordered), batchSize)
+	rolloutBy, batches, err := planRolloutBatches(tx, job, resolved.Ordered, parallelism)
+	if err != nil {
+		return err
+	}
 
-	for i := 0; i < len(resolved.Ordered); i += batchSize {
-		end := i + batchSize
-		if end > len(resolved.Ordered) {
-			end = len(resolved.Ordered)
+	// Without rollout_by there is one group, so health is checked once after every start.
+	for i, batch := range batches {
+		if err := deployCancelled(tx); err != nil {
+			return err
 		}
-		batch := resolved.Ordered[i:end]
-		if err := runParallelWorkers(batch, len(batch), startFn); err != nil {
+		logRolloutGroup(job, rolloutBy, batches, i)
+		if err := runParallelWorkers(batch.Workers, len(batch.Workers), startFn); err != nil {
 			return err
 		}
 		ctx := BatchContext{
 			Job:             job,
 			Phase:           deployPhaseNew,
-			BatchIndex:      i / batchSize,
-			BatchCount:      totalBatches,
-			BatchAllocation: append([]string(nil), batch...),
+			BatchIndex:      i,
+			BatchCount:      len(batches),
+			BatchAllocation: append([]string(nil), batch.Workers...),
 			RolloutOrder:    resolved.FullOrder,
 			OrderSource:     resolved.Source,
+			RolloutBy:       rolloutBy,
+			RolloutGroup:    batch.Group,
 		}
-		if err := executeAfterAllocationStarted(tx, rt, job, batch, ctx); err != nil {
+		if err := executeAfterAllocationStarted(tx, rt, job, batch.Workers, ctx); err != nil {
 			return err
 		}
+		if batch.LastInGroup {
+			if err := runHealthCheck(tx, rt, job); err != nil {
+				return err
+			}
+		}
 	}
-
-	err = healthcheck.HealthCheck(tx, rt, true, job, true)
-	return err
+	return nil
 }
 
 func rolloutRestartBatches(
@@ -112,37 +134,60 @@ func rolloutRestartBatches(
 	if parallelism < 1 {
 		parallelism = 1
 	}
-	totalBatches := batchCount(len(resolved.Ordered), parallelism)
+	rolloutBy, batches, err := planRolloutBatches(tx, job, resolved.Ordered, parallelism)
+	if err != nil {
+		return err
+	}
 
-	for i := 0; i < len(resolved.Ordered); i += parallelism {
-		end := i + parallelism
-		if end > len(resolved.Ordered) {
-			end = len(resolved.Ordered)
+	// Health is checked after every batch, so with rollout_by the next group starts only
+	// after the previous group's last batch passed.
+	var upgraded []string
+	for i, batch := range batches {
+		if err := deployCancelled(tx); err != nil {
+			return err
 		}
-		batch := resolved.Ordered[i:end]
-		if err := runParallelWorkers(batch, len(batch), restartFn); err != nil {
+		logRolloutGroup(job, rolloutBy, batches, i)
+		if err := runParallelWorkers(batch.Workers, len(batch.Workers), restartFn); err != nil {
 			return fmt.Errorf("restart updated allocations: %w", err)
 		}
+		upgraded = append(upgraded, batch.Workers...)
 		ctx := BatchContext{
 			Job:             job,
 			Phase:           deployPhaseUpdate,
-			BatchIndex:      i / parallelism,
-			BatchCount:      totalBatches,
-			BatchAllocation: append([]string(nil), batch...),
+			BatchIndex:      i,
+			BatchCount:      len(batches),
+			BatchAllocation: append([]string(nil), batch.Workers...),
 			RolloutOrder:    resolved.FullOrder,
 			OrderSource:     resolved.Source,
+			RolloutBy:       rolloutBy,
+			RolloutGroup:    batch.Group,
 		}
-		if err := executeAfterAllocationStarted(tx, rt, job, batch, ctx); err != nil {
+		if err := executeAfterAllocationStarted(tx, rt, job, batch.Workers, ctx); err != nil {
 			return err
 		}
-		if err := healthcheck.HealthCheck(tx, rt, true, job, true); err != nil {
-			return err
+		if err := runHealthCheck(tx, rt, job); err != nil {
+			return &rolloutHealthError{
+				Upgraded: append([]string(nil), upgraded...),
+				Err:      err,
+			}
 		}
 	}
 
 	return nil
 }
 
+// logRolloutGroup logs when batch i is the first batch of a rollout_by group.
+func logRolloutGroup(job, rolloutBy string, batches []rolloutBatch, i int) {
+	if rolloutBy == "" || (i > 0 && batches[i-1].Group == batches[i].Group) {
+		return
+	}
+	group := batches[i].Group
+	if group == "" {
+		group = "(untagged)"
+	}
+	log.Printf("deploy: job %q: rollout %s=%s", job, rolloutBy, group)
+}
+
 // JobNeedsRollout reports whether the job still needs a deploy wave: new workers,
 // staged content or versions not yet promoted, or missing hash rows on non-removed
 // allocations (including disabled). Reconcile stop for newly disabled allocations
@@ -192,3 +237,23 @@ func JobNeedsRollout(tx *sql.Tx, job string) (bool, error) {
 	}
 	return len(versionPending) > 0, nil
 }
+
+// jobNeedsDeploy is JobNeedsRollout limited to opts.Workers: with a worker filter, only
+// allocations on those workers count, as in the dry-run plan.
+func jobNeedsDeploy(tx *sql.Tx, job string, deploymentSeq int, opts Options) (bool, error) {
+	if len(opts.Workers) == 0 {
+		return JobNeedsRollout(tx, job)
+	}
+	plan, err := planJobRollout(tx, job, deploymentSeq, opts)
+	if err != nil {
+		return false, err
+	}
+	return plan.NeedsRollout, nil
+}
+
+func promotedSkipReason(opts Options) string {
+	if len(opts.Workers) > 0 {
+		return "already promoted on the selected workers"
+	}
+	return "already promoted on all allocations"
+}
@@ -0,0 +1,99 @@
+// Copyright 2025 Kiruba Sankar Swaminathan. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package deploy
+
+import (
+	"database/sql"
+	"fmt"
+	"path"
+	"sort"
+	"sync"
+	"testing"
+
+	"maand/bucket"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordRolloutSteps records restarts and health checks of app in call order.
+func recordRolloutSteps(t *testing.T, env *deployTestEnv, failHealthAfter int) *[]string {
+	t.Helper()
+	var (
+		steps []string
+		mu    sync.Mutex
+	)
+	rec := &CommandRecorder{BucketID: env.bucketID}
+	testHooks.WorkerCommand = func(rt *bucket.Runtime, workerIP string, cmdCtx bucket.CommandContext, commands []string, extraEnv []string) error {
+		if cmdCtx.Action == rolloutActionRestart {
+			mu.Lock()
+			steps = append(steps, "restart "+workerIP)
+			mu.Unlock()
+		}
+		return rec.Record(rt, workerIP, cmdCtx, commands, extraEnv)
+	}
+	checks := 0
+	testHooks.HealthCheck = func(*sql.Tx, *bucket.Runtime, string) error {
+		steps = append(steps, "health")
+		checks++
+		if failHealthAfter > 0 && checks >= failHealthAfter {
+			return fmt.Errorf("zone unhealthy")
+		}
+		return nil
+	}
+	return &steps
+}
+
+func stageZonedChange(t *testing.T, env *deployTestEnv) {
+	t.Helper()
+	installNoopDeployHooks(t, env.bucketID)
+	seedZonedJob(t, env)
+	require.NoError(t, Execute(nil, Options{}))
+
+	tx := env.begin(t)
+	env.insertJobFile(t, tx, "job-app", path.Join("app", "marker.txt"), "v2", false)
+	require.NoError(t, tx.Commit())
+}
+
+func TestExecute_rolloutByUpgradesOneZoneAtATime(t *testing.T) {
+	env := setupDeployTestEnv(t)
+	stageZonedChange(t, env)
+	steps := recordRolloutSteps(t, env, 0)
+
+	require.NoError(t, Execute(nil, Options{}))
+	assert.Equal(t, []string{
+		"restart 10.0.0.1", "restart 10.0.0.3", "health",
+		"restart 10.0.0.2", "restart 10.0.0.5", "health",
+		"restart 10.0.0.4", "health",
+	}, normalizeBatchSteps(*steps))
+}
+
+func TestExecute_rolloutByStopsBeforeNextZone(t *testing.T) {
+	env := setupDeployTestEnv(t)
+	stageZonedChange(t, env)
+	steps := recordRolloutSteps(t, env, 1)
+
+	err := Execute(nil, Options{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "zone unhealthy")
+	assert.Equal(t, []string{"restart 10.0.0.1", "restart 10.0.0.3", "health"}, normalizeBatchSteps(*steps))
+}
+
+// normalizeBatchSteps sorts restarts inside each batch; workers of one batch restart in parallel.
+func normalizeBatchSteps(steps []string) []string {
+	out := make([]string, 0, len(steps))
+	start := 0
+	for i, step := range steps {
+		if step != "health" {
+			continue
+		}
+		batch := append([]string(nil), steps[start:i]...)
+		sort.Strings(batch)
+		out = append(out, batch...)
+		out = append(out, step)
+		start = i + 1
+	}
+	return append(out, steps[start:]...)
+}
@@ -66,17 +66,17 @@ func rsync(rt *bucket.Runtime, bucketID, workerIP string, jobs []string) error {
 		"--executability",
 		"--compress",
 		"--verbose",
-		"--exclude=jobs/*/bin",
-		"--exclude=jobs/*/data",
-		"--exclude=jobs/*/logs",
-		"--exclude=jobs/*/_modules",
-		"--exclude=jobs/*/_prometheus",
-		"--rsync-path=" + remoteRS,
-		"--filter=merge " + ruleFilePath,
-		"--rsh=" + worker.RSHShell(keyFilePath, workerIP),
-		workerDir + string(filepath.Separator),
-		fmt.Sprintf("%s@%s:/opt/worker/%s", user, workerIP, bucketID),
 	}
+	for _, dir := range syncExcludedJobDirs {
+		args = append(args, "--exclude=jobs/*/"+dir)
+	}
+	args = append(args,
+		"--rsync-path="+remoteRS,
+		"--filter=merge "+ruleFilePath,
+		"--rsh="+worker.RSHShell(keyFilePath, workerIP),
+		workerDir+string(filepath.Separator),
+		fmt.Sprintf("%s@%s:/opt/worker/%s", user, workerIP, bucketID),
+	)
 
 	cmd := exec.Command("rsync", args...)
 	cmdCtx := bucket.CommandContext{
@@ -90,3 +90,27 @@ func rsync(rt *bucket.Runtime, bucketID, workerIP string, jobs []string) error {
 	}
 	return nil
 }
+
+// syncExcludedJobDirs are the job subdirectories rsync neither copies to workers nor deletes
+// there; workers own their contents.
+var syncExcludedJobDirs = []string{"bin", "data", "logs", "_modules", "_prometheus"}
+
+// SyncExcludedJobDirs returns the job subdirectories deploy leaves alone on workers.
+func SyncExcludedJobDirs() []string {
+	return append([]string(nil), syncExcludedJobDirs...)
+}
+
+// SyncedJobFile reports whether deploy rsyncs the job-relative file path to workers.
+// Templates (*.tpl) and files under SyncExcludedJobDirs are never synced.
+func SyncedJobFile(rel string) bool {
+	if strings.HasSuffix(rel, ".tpl") {
+		return false
+	}
+	top, _, _ := strings.Cut(rel, "/")
+	for _, dir := range syncExcludedJobDirs {
+		if top == dir {
+			return false
+		}
+	}
+	return true
+}
@@ -45,3 +45,13 @@ func TestRsync_executesWithLocalStaging(t *testing.T) {
 	_, err = filepath.Abs(workerDir)
 	require.NoError(t, err)
 }
+
+func TestSyncedJobFile(t *testing.T) {
+	require.True(t, SyncedJobFile("Makefile"))
+	require.True(t, SyncedJobFile("conf/app.yml"))
+	require.True(t, SyncedJobFile("conf/bin/tool"))
+	require.False(t, SyncedJobFile("app.conf.tpl"))
+	require.False(t, SyncedJobFile("conf/app.yml.tpl"))
+	require.False(t, SyncedJobFile("bin/start.sh"))
+	require.False(t, SyncedJobFile("_modules/deploy.py"))
+}
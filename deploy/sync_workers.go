@@ -34,14 +34,17 @@ func syncWorkerFiles(rt *bucket.Runtime, bucketID, workerIP string, jobs []strin
 	return runRsync(rt, bucketID, workerIP, jobs)
 }
 
-func syncWorkers(rt *bucket.Runtime, bucketID string, workers []string, jobs []string, applyRules bool) error {
-	mergeFiles := make([]string, 0, len(workers))
-	defer func() {
-		for _, file := range mergeFiles {
-			_ = os.Remove(file)
-		}
-	}()
+// workerSyncLocks holds one mutex per worker IP. The rsync filter file is per worker, so jobs
+// deploying in parallel take turns syncing to the same worker.
+var workerSyncLocks sync.Map
 
+func lockWorkerSync(workerIP string) func() {
+	mu, _ := workerSyncLocks.LoadOrStore(workerIP, &sync.Mutex{})
+	mu.(*sync.Mutex).Lock()
+	return mu.(*sync.Mutex).Unlock
+}
+
+func syncWorkers(rt *bucket.Runtime, bucketID string, workers []string, jobs []string, applyRules bool) error {
 	if err := worker.EnsureSSHStateDir(); err != nil {
 		return err
 	}
@@ -54,19 +57,13 @@ func syncWorkers(rt *bucket.Runtime, bucketID string, workers []string, jobs []s
 	)
 
 	for _, workerIP := range workers {
-		mergePath, err := writeRsyncFilter(workerIP, jobs, applyRules)
-		if err != nil {
-			return err
-		}
-		mergeFiles = append(mergeFiles, mergePath)
-
 		wg.Add(1)
 		sem <- struct{}{}
 		go func(ip string) {
 			defer wg.Done()
 			defer func() { <-sem }()
 
-			if err := syncWorkerFiles(rt, bucketID, ip, jobs); err != nil {
+			if err := syncWorkerWithFilter(rt, bucketID, ip, jobs, applyRules); err != nil {
 				mu.Lock()
 				errs = append(errs, fmt.Errorf("worker %s: %w", ip, err))
 				mu.Unlock()
@@ -78,6 +75,22 @@ func syncWorkers(rt *bucket.Runtime, bucketID string, workers []string, jobs []s
 	return joinErrors("rsync failed", errs)
 }
 
+// syncWorkerWithFilter writes the worker's rsync filter and syncs it while holding the
+// worker's sync lock, then removes the filter.
+func syncWorkerWithFilter(rt *bucket.Runtime, bucketID, workerIP string, jobs []string, applyRules bool) error {
+	unlock := lockWorkerSync(workerIP)
+	defer unlock()
+
+	mergePath, err := writeRsyncFilter(workerIP, jobs, applyRules)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.Remove(mergePath)
+	}()
+	return syncWorkerFiles(rt, bucketID, workerIP, jobs)
+}
+
 func buildRsyncFilterLines(jobs []string, applyRules bool) []string {
 	lines := make([]string, 0, len(jobs)+3)
 	if applyRules {
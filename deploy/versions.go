@@ -28,6 +28,21 @@ func allocationVersionEnv(tx *sql.Tx, job, workerIP string) ([]string, error) {
 	}, nil
 }
 
+// rollbackVersionEnv reports the running version as both current and target so Makefile
+// targets restart the last promoted release during a rollback.
+func rollbackVersionEnv(tx *sql.Tx, job, workerIP string) ([]string, error) {
+	versions, err := allocationVersionsForWorker(tx, job, workerIP)
+	if err != nil {
+		return nil, err
+	}
+	return []string{
+		fmt.Sprintf("CURRENT_VERSION=%s", versions.CurrentVersion),
+		fmt.Sprintf("NEW_VERSION=%s", versions.CurrentVersion),
+	}, nil
+}
+
+// syncAllocationVersionKV records the staged version in the session KV store. It does not
+// touch the transaction, so it is safe outside writeCatalog (see catalogWriter).
 func syncAllocationVersionKV(job, workerIP string, versions data.AllocationVersions) error {
 	store := kv.GetKVStore()
 	if store == nil {
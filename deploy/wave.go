@@ -0,0 +1,121 @@
+// Copyright 2025 Kiruba Sankar Swaminathan. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package deploy
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+
+	"maand/bucket"
+)
+
+// jobOutcome is the result of staging and deploying one job of a wave. Err is a staging or
+// rsync failure that aborts the whole deploy; Failures are the job's own deploy errors.
+// Cancelled jobs were skipped or stopped part-way because the deploy was cancelled.
+type jobOutcome struct {
+	Job       string
+	Deployed  bool
+	Cancelled bool
+	Failures  []error
+	Err       error
+}
+
+// deployWave stages and deploys the jobs of one deployment_seq wave and returns their
+// outcomes in jobs order. With opts.ParallelJobs > 1, up to that many jobs run at once and
+// their catalog writes go through a catalogWriter; otherwise jobs run one at a time and the
+// wave stops at the first aborting error.
+func deployWave(tx *sql.Tx, rt *bucket.Runtime, bucketID string, jobs, workers []string, opts Options) []jobOutcome {
+	outcomes := make([]jobOutcome, len(jobs))
+	if opts.ParallelJobs <= 1 || len(jobs) == 1 {
+		for i, job := range jobs {
+			outcomes[i] = stageAndDeployJob(tx, rt, bucketID, job, workers, opts)
+			if outcomes[i].Err != nil {
+				return outcomes[:i+1]
+			}
+		}
+		return outcomes
+	}
+
+	writer := startCatalogWriter(tx)
+	defer writer.stop()
+
+	var (
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, opts.ParallelJobs)
+	)
+	for i, job := range jobs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, job string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			outcomes[i] = stageAndDeployJob(tx, rt, bucketID, job, workers, opts)
+		}(i, job)
+	}
+	wg.Wait()
+	return outcomes
+}
+
+// stageAndDeployJob stages one job's files, updates its allocation hashes, rsyncs it, runs
+// deployJob, and checkpoints job-command KV. It does not start once the deploy is cancelled.
+func stageAndDeployJob(tx *sql.Tx, rt *bucket.Runtime, bucketID, job string, workers []string, opts Options) jobOutcome {
+	outcome := jobOutcome{Job: job}
+	if deployCancelled(tx) != nil {
+		outcome.Cancelled = true
+		return outcome
+	}
+
+	var syncTargets []string
+	if err := writeCatalog(tx, func(tx *sql.Tx) error {
+		if err := prepareJobsFiles(tx, []string{job}); err != nil {
+			return err
+		}
+		if err := updateAllocationHash(tx, []string{job}); err != nil {
+			return err
+		}
+		var err error
+		syncTargets, err = canarySyncWorkers(tx, job, selectWorkersForDeploy(workers, opts), opts)
+		return err
+	}); err != nil {
+		outcome.Err = err
+		return outcome
+	}
+	if err := syncWorkers(rt, bucketID, syncTargets, []string{job}, true); err != nil {
+		if isCancellation(err) || runtimeInterrupted(rt) {
+			outcome.Cancelled = true
+			return outcome
+		}
+		outcome.Err = err
+		return outcome
+	}
+
+	deployErr := deployJob(tx, rt, bucketID, job, opts)
+	if deployErr == nil && opts.StopMovedAfterStart {
+		if err := stopMovedAllocations(tx, rt, bucketID, job); err != nil {
+			deployErr = &JobError{Job: job, Err: fmt.Errorf("stop moved allocations: %w", err)}
+		}
+	}
+	if persistErr := writeCatalog(tx, func(tx *sql.Tx) error {
+		return persistJobCommandKV(tx, job)
+	}); persistErr != nil {
+		outcome.Failures = append(outcome.Failures, persistErr)
+	}
+	if errors.Is(deployErr, errRolloutPaused) {
+		return outcome
+	}
+	if isCancellation(deployErr) {
+		outcome.Cancelled = true
+		return outcome
+	}
+	if deployErr != nil {
+		outcome.Cancelled = runtimeInterrupted(rt)
+		outcome.Failures = append(outcome.Failures, deployErr)
+		return outcome
+	}
+	outcome.Deployed = true
+	return outcome
+}
@@ -0,0 +1,132 @@
+// Copyright 2025 Kiruba Sankar Swaminathan. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package deploy
+
+import (
+	"database/sql"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"maand/bucket"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCatalogWriter_serializesWrites(t *testing.T) {
+	env := setupDeployTestEnv(t)
+	tx := env.begin(t)
+	defer func() { _ = tx.Rollback() }()
+
+	writer := startCatalogWriter(tx)
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		active  int
+		maxSeen int
+	)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, writeCatalog(tx, func(*sql.Tx) error {
+				mu.Lock()
+				active++
+				if active > maxSeen {
+					maxSeen = active
+				}
+				mu.Unlock()
+				time.Sleep(time.Millisecond)
+				mu.Lock()
+				active--
+				mu.Unlock()
+				return nil
+			}))
+		}()
+	}
+	wg.Wait()
+	writer.stop()
+	assert.Equal(t, 1, maxSeen)
+
+	_, registered := catalogWriters.Load(tx)
+	assert.False(t, registered)
+	require.EqualError(t, writeCatalog(tx, func(*sql.Tx) error { return fmt.Errorf("direct") }), "direct")
+}
+
+// startBarrier fails any start command that does not meet the other n-1 jobs' start commands
+// within a second, so only a parallel deploy can pass it.
+func startBarrier(t *testing.T, rec *CommandRecorder, n int) {
+	t.Helper()
+	var (
+		mu      sync.Mutex
+		arrived int
+		all     = make(chan struct{})
+	)
+	testHooks.WorkerCommand = func(rt *bucket.Runtime, workerIP string, cmdCtx bucket.CommandContext, commands []string, env []string) error {
+		if cmdCtx.Action == rolloutActionStart {
+			mu.Lock()
+			arrived++
+			if arrived == n {
+				close(all)
+			}
+			mu.Unlock()
+			select {
+			case <-all:
+			case <-time.After(time.Second):
+				return fmt.Errorf("job %s started alone", cmdCtx.Job)
+			}
+		}
+		return rec.Record(rt, workerIP, cmdCtx, commands, env)
+	}
+}
+
+func TestExecute_parallelJobsDeployWaveConcurrently(t *testing.T) {
+	env := setupDeployTestEnv(t)
+	rec := installNoopDeployHooks(t, env.bucketID)
+	jobs := []string{"api", "web", "worker"}
+
+	tx := env.begin(t)
+	for _, job := range jobs {
+		env.seedMakefileJob(t, tx, job, "10.0.0.1", 0)
+	}
+	require.NoError(t, tx.Commit())
+
+	startBarrier(t, rec, len(jobs))
+	require.NoError(t, Execute(nil, Options{ParallelJobs: len(jobs)}))
+
+	tx = env.begin(t)
+	defer func() { _ = tx.Rollback() }()
+	for _, job := range jobs {
+		assert.True(t, rec.HasAction("10.0.0.1", rolloutActionStart, job), job)
+		assert.True(t, env.allocationHashPromoted(t, tx, job, "alloc-"+job+"-10.0.0.1"), job)
+	}
+}
+
+func TestExecute_parallelJobsKeepsFailuresPerJob(t *testing.T) {
+	env := setupDeployTestEnv(t)
+	rec := installNoopDeployHooks(t, env.bucketID)
+
+	tx := env.begin(t)
+	env.seedMakefileJob(t, tx, "api", "10.0.0.1", 0)
+	env.seedMakefileJob(t, tx, "web", "10.0.0.1", 0)
+	require.NoError(t, tx.Commit())
+
+	testHooks.WorkerCommand = func(rt *bucket.Runtime, workerIP string, cmdCtx bucket.CommandContext, commands []string, env []string) error {
+		if cmdCtx.Job == "web" && cmdCtx.Action == rolloutActionStart {
+			return fmt.Errorf("start refused")
+		}
+		return rec.Record(rt, workerIP, cmdCtx, commands, env)
+	}
+	err := Execute(nil, Options{ParallelJobs: 2})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "start refused")
+
+	tx = env.begin(t)
+	defer func() { _ = tx.Rollback() }()
+	assert.True(t, env.allocationHashPromoted(t, tx, "api", "alloc-api-10.0.0.1"))
+	assert.False(t, env.allocationHashPromoted(t, tx, "web", "alloc-web-10.0.0.1"))
+}
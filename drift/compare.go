@@ -0,0 +1,94 @@
+// Copyright 2025 Kiruba Sankar Swaminathan. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package drift
+
+import (
+	"sort"
+
+	"maand/data"
+	"maand/deploy"
+	"maand/utils"
+)
+
+// Allocation drift statuses.
+const (
+	StatusOK      = "ok"
+	StatusDrifted = "drifted"
+	// StatusMissing means the job directory is gone from the worker.
+	StatusMissing = "missing"
+	// StatusUnknown means no promoted file manifest is recorded to compare against.
+	StatusUnknown = "unknown"
+	// StatusError means the worker could not be checked.
+	StatusError = "error"
+)
+
+// allocationDrift is the result of checking one allocation against its promoted manifest.
+type allocationDrift struct {
+	Allocation data.DeployedAllocation
+	Status     string
+	Modified   []string
+	Missing    []string
+	Extra      []string
+	// Observed is the promoted manifest with the synced files replaced by what the worker
+	// has; ObservedHash is its aggregate, recorded by --mark.
+	Observed     data.FileManifest
+	ObservedHash string
+	Err          error
+}
+
+func (d allocationDrift) drifted() bool {
+	return d.Status == StatusDrifted || d.Status == StatusMissing
+}
+
+// compareAllocation compares the files deploy syncs (see deploy.SyncedJobFile) in the
+// promoted manifest with the tree found on the worker.
+func compareAllocation(alloc data.DeployedAllocation, tree remoteJobTree) allocationDrift {
+	result := allocationDrift{Allocation: alloc, Status: StatusOK}
+	if !alloc.HasFiles {
+		result.Status = StatusUnknown
+		return result
+	}
+
+	observed := make(data.FileManifest, len(alloc.Files))
+	expected := make(map[string]string, len(alloc.Files))
+	for rel, digest := range alloc.Files {
+		if deploy.SyncedJobFile(rel) {
+			expected[rel] = digest
+		} else {
+			observed[rel] = digest
+		}
+	}
+	for rel, digest := range tree.Files {
+		if !deploy.SyncedJobFile(rel) {
+			continue
+		}
+		observed[rel] = digest
+		want, ok := expected[rel]
+		switch {
+		case !ok:
+			result.Extra = append(result.Extra, rel)
+		case want != digest:
+			result.Modified = append(result.Modified, rel)
+		}
+	}
+	for rel := range expected {
+		if _, ok := tree.Files[rel]; !ok {
+			result.Missing = append(result.Missing, rel)
+		}
+	}
+	sort.Strings(result.Modified)
+	sort.Strings(result.Missing)
+	sort.Strings(result.Extra)
+
+	switch {
+	case tree.Missing:
+		result.Status = StatusMissing
+	case len(result.Modified) > 0 || len(result.Missing) > 0 || len(result.Extra) > 0:
+		result.Status = StatusDrifted
+	}
+	result.Observed = observed
+	result.ObservedHash = utils.AggregateFileHashes(observed)
+	return result
+}
@@ -0,0 +1,70 @@
+// Copyright 2025 Kiruba Sankar Swaminathan. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package drift
+
+import (
+	"testing"
+
+	"maand/data"
+	"maand/utils"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompareAllocation(t *testing.T) {
+	alloc := data.DeployedAllocation{
+		AllocID: "alloc-1", WorkerIP: "10.0.0.1", Job: "api", PromotedHash: "hash-a", HasFiles: true,
+		Files: data.FileManifest{
+			"run.sh":       digestA,
+			"conf/app.yml": digestA,
+			"Makefile":     digestA,
+			"app.conf.tpl": digestA,
+			"data/seed":    digestA,
+		},
+	}
+
+	result := compareAllocation(alloc, remoteJobTree{Files: map[string]string{
+		"run.sh":       digestA,
+		"conf/app.yml": digestA,
+		"Makefile":     digestA,
+	}})
+	assert.Equal(t, StatusOK, result.Status)
+	assert.False(t, result.drifted())
+
+	result = compareAllocation(alloc, remoteJobTree{Files: map[string]string{
+		"run.sh":       digestB,
+		"Makefile":     digestA,
+		"conf/new.yml": digestB,
+		"logs/out.log": digestB,
+	}})
+	assert.Equal(t, StatusDrifted, result.Status)
+	assert.Equal(t, []string{"run.sh"}, result.Modified)
+	assert.Equal(t, []string{"conf/app.yml"}, result.Missing)
+	assert.Equal(t, []string{"conf/new.yml"}, result.Extra)
+	observed := data.FileManifest{
+		"run.sh":       digestB,
+		"Makefile":     digestA,
+		"conf/new.yml": digestB,
+		"app.conf.tpl": digestA,
+		"data/seed":    digestA,
+	}
+	assert.Equal(t, observed, result.Observed)
+	assert.Equal(t, utils.AggregateFileHashes(observed), result.ObservedHash)
+}
+
+func TestCompareAllocationMissingDirectory(t *testing.T) {
+	alloc := data.DeployedAllocation{Job: "api", HasFiles: true, Files: data.FileManifest{"run.sh": digestA}}
+
+	result := compareAllocation(alloc, remoteJobTree{Missing: true})
+	assert.Equal(t, StatusMissing, result.Status)
+	assert.True(t, result.drifted())
+	assert.Equal(t, []string{"run.sh"}, result.Missing)
+}
+
+func TestCompareAllocationWithoutManifest(t *testing.T) {
+	result := compareAllocation(data.DeployedAllocation{Job: "api"}, remoteJobTree{Files: map[string]string{"run.sh": digestA}})
+	assert.Equal(t, StatusUnknown, result.Status)
+	assert.False(t, result.drifted())
+}
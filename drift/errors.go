@@ -0,0 +1,53 @@
+// Copyright 2025 Kiruba Sankar Swaminathan. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package drift
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ErrDriftDetected reports allocations whose files on the worker differ from what deploy
+// last promoted. maand drift returns it unless --mark recorded them for re-sync.
+var ErrDriftDetected = errors.New("drift detected")
+
+func errConcurrencyTooLow() error {
+	return fmt.Errorf("concurrency must be at least 1")
+}
+
+func errUnknownJobs(jobs []string) error {
+	return fmt.Errorf("unknown jobs: %s", strings.Join(jobs, ", "))
+}
+
+func errUnknownWorkers(hosts []string) error {
+	return fmt.Errorf("unknown workers: %s", strings.Join(hosts, ", "))
+}
+
+func errNoDeployedAllocations() error {
+	return fmt.Errorf("no deployed allocations matched the requested filters")
+}
+
+func errDrifted(count int) error {
+	return fmt.Errorf("%w on %d allocation(s); run maand drift --mark, then maand deploy, to re-sync them", ErrDriftDetected, count)
+}
+
+func errProbeFailures(failures map[string]error) error {
+	if len(failures) == 0 {
+		return nil
+	}
+
+	hosts := make([]string, 0, len(failures))
+	for host := range failures {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+	lines := make([]string, 0, len(failures))
+	for _, host := range hosts {
+		lines = append(lines, fmt.Sprintf("worker %s: %v", host, failures[host]))
+	}
+	return fmt.Errorf("drift check failed:\n%s", strings.Join(lines, "\n"))
+}
@@ -0,0 +1,274 @@
+// Copyright 2025 Kiruba Sankar Swaminathan. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+// Package drift compares the job files on workers with what deploy last promoted.
+//
+// For every active allocation with a promoted hash, maand drift hashes the job directory
+// under /opt/worker/<bucket_id>/jobs/<job> over SSH (MD5 per file, as allocation hashes
+// are computed) and compares the files deploy syncs with the promoted file manifest.
+// With --mark, drifted allocations get the observed content recorded as their promoted
+// hash, so the next maand deploy restages and re-syncs them without --force.
+package drift
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+
+	"maand/bucket"
+	"maand/data"
+	"maand/prereq"
+	"maand/utils"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+)
+
+// Options configures maand drift.
+type Options struct {
+	JobsCSV     string
+	WorkersCSV  string
+	Concurrency int
+	Mark        bool
+}
+
+// Execute checks the selected allocations, prints the result, and with opts.Mark records
+// drifted allocations for re-sync.
+func Execute(opts Options) error {
+	if opts.Concurrency < 1 {
+		return errConcurrencyTooLow()
+	}
+
+	db, err := data.OpenDatabase(true)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	bucketID, allocations, err := selectAllocations(db, parseCSVList(opts.JobsCSV), parseCSVList(opts.WorkersCSV))
+	if err != nil {
+		return err
+	}
+	if err := prereq.CheckLocalRunCommand(); err != nil {
+		return err
+	}
+
+	results, failures := checkAllocations(allocations, opts.Concurrency, sshTreeProbe(bucketID))
+	renderResults(results)
+
+	var drifted []allocationDrift
+	for _, result := range results {
+		if result.drifted() {
+			drifted = append(drifted, result)
+		}
+	}
+	if len(drifted) > 0 {
+		if !opts.Mark {
+			return joinFailures(errDrifted(len(drifted)), failures)
+		}
+		marked, err := markDrifted(db, drifted)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("marked %d drifted allocation(s); run maand deploy to re-sync them\n", marked)
+	}
+	return errProbeFailures(failures)
+}
+
+// selectAllocations reads the deployed allocations matching the filters in a short read
+// transaction, so no lock is held while workers are probed.
+func selectAllocations(db *sql.DB, jobsFilter, workersFilter []string) (string, []data.DeployedAllocation, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return "", nil, bucket.DatabaseError(err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	bucketID, err := data.GetBucketID(tx)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(jobsFilter) > 0 {
+		jobs, err := data.GetAllAllocatedJobs(tx)
+		if err != nil {
+			return "", nil, err
+		}
+		if unknown := utils.Difference(jobsFilter, jobs); len(unknown) > 0 {
+			return "", nil, errUnknownJobs(unknown)
+		}
+	}
+	if len(workersFilter) > 0 {
+		workers, err := data.GetWorkers(tx, nil)
+		if err != nil {
+			return "", nil, err
+		}
+		if unknown := utils.Difference(workersFilter, workers); len(unknown) > 0 {
+			return "", nil, errUnknownWorkers(unknown)
+		}
+	}
+
+	deployed, err := data.ListDeployedAllocations(tx)
+	if err != nil {
+		return "", nil, err
+	}
+	selected := filterAllocations(deployed, jobsFilter, workersFilter)
+	if len(selected) == 0 {
+		return "", nil, errNoDeployedAllocations()
+	}
+	return bucketID, selected, nil
+}
+
+func filterAllocations(allocations []data.DeployedAllocation, jobsFilter, workersFilter []string) []data.DeployedAllocation {
+	selected := make([]data.DeployedAllocation, 0, len(allocations))
+	for _, alloc := range allocations {
+		if len(jobsFilter) > 0 && !slices.Contains(jobsFilter, alloc.Job) {
+			continue
+		}
+		if len(workersFilter) > 0 && !slices.Contains(workersFilter, alloc.WorkerIP) {
+			continue
+		}
+		selected = append(selected, alloc)
+	}
+	return selected
+}
+
+// checkAllocations probes each worker once for all of its selected jobs, up to concurrency
+// workers at a time, and returns one result per allocation in allocations order.
+func checkAllocations(allocations []data.DeployedAllocation, concurrency int, probe treeProbe) ([]allocationDrift, map[string]error) {
+	jobsByWorker := make(map[string][]string)
+	var workers []string
+	for _, alloc := range allocations {
+		if _, ok := jobsByWorker[alloc.WorkerIP]; !ok {
+			workers = append(workers, alloc.WorkerIP)
+		}
+		jobsByWorker[alloc.WorkerIP] = append(jobsByWorker[alloc.WorkerIP], alloc.Job)
+	}
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		trees    = make(map[string]map[string]remoteJobTree, len(workers))
+		failures = make(map[string]error)
+		sem      = make(chan struct{}, concurrency)
+	)
+	for _, workerIP := range workers {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(workerIP string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			found, err := probe(workerIP, jobsByWorker[workerIP])
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				failures[workerIP] = err
+				return
+			}
+			trees[workerIP] = found
+		}(workerIP)
+	}
+	wg.Wait()
+
+	results := make([]allocationDrift, 0, len(allocations))
+	for _, alloc := range allocations {
+		if err, failed := failures[alloc.WorkerIP]; failed {
+			results = append(results, allocationDrift{Allocation: alloc, Status: StatusError, Err: err})
+			continue
+		}
+		results = append(results, compareAllocation(alloc, trees[alloc.WorkerIP][alloc.Job]))
+	}
+	return results, failures
+}
+
+// markDrifted records the observed content of drifted allocations as promoted. An
+// allocation redeployed since it was checked is left alone.
+func markDrifted(db *sql.DB, drifted []allocationDrift) (int, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, bucket.DatabaseError(err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	marked := 0
+	for _, result := range drifted {
+		alloc := result.Allocation
+		updated, err := data.MarkAllocationDrifted(tx, alloc.Job, alloc.AllocID, alloc.PromotedHash, result.ObservedHash, result.Observed)
+		if err != nil {
+			return 0, err
+		}
+		if !updated {
+			log.Printf("drift: job %s on %s was redeployed during the check; not marked", alloc.Job, alloc.WorkerIP)
+			continue
+		}
+		marked++
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, bucket.DatabaseError(err)
+	}
+	return marked, nil
+}
+
+func renderResults(results []allocationDrift) {
+	summary := utils.GetTable(table.Row{"job", "worker_ip", "alloc_id", "status", "modified", "missing", "extra"})
+	for _, result := range results {
+		summary.AppendRow(table.Row{
+			result.Allocation.Job, result.Allocation.WorkerIP, result.Allocation.AllocID, result.Status,
+			len(result.Modified), len(result.Missing), len(result.Extra),
+		})
+	}
+	summary.Render()
+
+	details := utils.GetTable(table.Row{"job", "worker_ip", "change", "path"})
+	rows := 0
+	for _, result := range results {
+		for _, change := range []struct {
+			name  string
+			paths []string
+		}{
+			{"modified", result.Modified},
+			{"missing", result.Missing},
+			{"extra", result.Extra},
+		} {
+			for _, rel := range change.paths {
+				details.AppendRow(table.Row{result.Allocation.Job, result.Allocation.WorkerIP, change.name, rel})
+				rows++
+			}
+		}
+	}
+	if rows > 0 {
+		fmt.Println()
+		details.Render()
+	}
+}
+
+func joinFailures(err error, failures map[string]error) error {
+	if failed := errProbeFailures(failures); failed != nil {
+		return fmt.Errorf("%w\n%v", err, failed)
+	}
+	return err
+}
+
+func parseCSVList(csv string) []string {
+	if strings.TrimSpace(csv) == "" {
+		return nil
+	}
+	parts := strings.Split(csv, ",")
+	values := make([]string, 0, len(parts))
+	for _, part := range parts {
+		part = strings.TrimSpace(part)
+		if part != "" {
+			values = append(values, part)
+		}
+	}
+	return utils.Unique(values)
+}
@@ -0,0 +1,68 @@
+// Copyright 2025 Kiruba Sankar Swaminathan. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package drift
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"maand/data"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckAllocations(t *testing.T) {
+	allocations := []data.DeployedAllocation{
+		{AllocID: "a1", WorkerIP: "10.0.0.1", Job: "api", HasFiles: true, Files: data.FileManifest{"run.sh": digestA}},
+		{AllocID: "a2", WorkerIP: "10.0.0.2", Job: "api", HasFiles: true, Files: data.FileManifest{"run.sh": digestA}},
+		{AllocID: "a3", WorkerIP: "10.0.0.1", Job: "web", HasFiles: true, Files: data.FileManifest{"run.sh": digestA}},
+	}
+
+	var mu sync.Mutex
+	probed := make(map[string][]string)
+	probe := func(workerIP string, jobs []string) (map[string]remoteJobTree, error) {
+		mu.Lock()
+		probed[workerIP] = jobs
+		mu.Unlock()
+		if workerIP == "10.0.0.2" {
+			return nil, errors.New("connection refused")
+		}
+		return map[string]remoteJobTree{
+			"api": {Files: map[string]string{"run.sh": digestA}},
+			"web": {Files: map[string]string{"run.sh": digestB}},
+		}, nil
+	}
+
+	results, failures := checkAllocations(allocations, 1, probe)
+	assert.Equal(t, map[string][]string{"10.0.0.1": {"api", "web"}, "10.0.0.2": {"api"}}, probed)
+	require.Len(t, results, 3)
+	statuses := []string{results[0].Status, results[1].Status, results[2].Status}
+	assert.Equal(t, []string{StatusOK, StatusError, StatusDrifted}, statuses)
+	assert.EqualError(t, results[1].Err, "connection refused")
+
+	require.Len(t, failures, 1)
+	assert.EqualError(t, errProbeFailures(failures), "drift check failed:\nworker 10.0.0.2: connection refused")
+}
+
+func TestFilterAllocations(t *testing.T) {
+	allocations := []data.DeployedAllocation{
+		{AllocID: "a1", WorkerIP: "10.0.0.1", Job: "api"},
+		{AllocID: "a2", WorkerIP: "10.0.0.2", Job: "api"},
+		{AllocID: "a3", WorkerIP: "10.0.0.1", Job: "web"},
+	}
+
+	assert.Len(t, filterAllocations(allocations, nil, nil), 3)
+	selected := filterAllocations(allocations, []string{"api"}, []string{"10.0.0.2"})
+	require.Len(t, selected, 1)
+	assert.Equal(t, "a2", selected[0].AllocID)
+}
+
+func TestErrDrifted(t *testing.T) {
+	err := joinFailures(errDrifted(2), nil)
+	assert.ErrorIs(t, err, ErrDriftDetected)
+	assert.Contains(t, err.Error(), "on 2 allocation(s)")
+}
@@ -0,0 +1,99 @@
+// Copyright 2025 Kiruba Sankar Swaminathan. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package drift
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"maand/deploy"
+	"maand/worker"
+)
+
+// remoteJobTree is one job directory as found on a worker: file digests keyed by
+// job-relative path, or Missing when the directory does not exist.
+type remoteJobTree struct {
+	Missing bool
+	Files   map[string]string
+}
+
+// treeProbe hashes the given job directories on one worker.
+type treeProbe func(workerIP string, jobs []string) (map[string]remoteJobTree, error)
+
+func sshTreeProbe(bucketID string) treeProbe {
+	return func(workerIP string, jobs []string) (map[string]remoteJobTree, error) {
+		output, err := worker.RunRemoteScriptCombined(workerIP, strings.NewReader(treeScript(bucketID, jobs)))
+		if err != nil {
+			return nil, err
+		}
+		return parseTreeOutput(output, jobs)
+	}
+}
+
+// treeScript prints a JOB header per job followed by MISSING or one md5sum line per file.
+// Like utils.HashDirectoryTree it skips __pycache__, and it prunes the directories deploy
+// never syncs so large data directories are not read.
+func treeScript(bucketID string, jobs []string) string {
+	prune := []string{"-path '*__pycache__*'"}
+	for _, dir := range deploy.SyncExcludedJobDirs() {
+		prune = append(prune, "-path "+worker.ShellQuote("./"+dir))
+	}
+	quoted := make([]string, len(jobs))
+	for i, job := range jobs {
+		quoted[i] = worker.ShellQuote(job)
+	}
+
+	var script strings.Builder
+	script.WriteString("set -eu\n")
+	fmt.Fprintf(&script, "base=%s\n", worker.ShellQuote(fmt.Sprintf("/opt/worker/%s/jobs", bucketID)))
+	fmt.Fprintf(&script, "for job in %s; do\n", strings.Join(quoted, " "))
+	script.WriteString("  printf 'JOB %s\\n' \"$job\"\n")
+	script.WriteString("  if [ ! -d \"$base/$job\" ]; then echo MISSING; continue; fi\n")
+	fmt.Fprintf(&script, "  (cd \"$base/$job\" && find . \\( %s \\) -prune -o -type f -print0 | xargs -0 -r md5sum)\n",
+		strings.Join(prune, " -o "))
+	script.WriteString("done\n")
+	return script.String()
+}
+
+var md5sumLine = regexp.MustCompile(`^\\?([0-9a-f]{32})  (.+)$`)
+
+// parseTreeOutput reads treeScript output. Lines before the first JOB header (SSH notices)
+// are ignored; anything else unexpected is an error so unreadable files are not reported
+// as missing.
+func parseTreeOutput(output string, jobs []string) (map[string]remoteJobTree, error) {
+	trees := make(map[string]remoteJobTree, len(jobs))
+	current := ""
+	for _, line := range strings.Split(output, "\n") {
+		if job, ok := strings.CutPrefix(line, "JOB "); ok {
+			current = job
+			trees[current] = remoteJobTree{Files: make(map[string]string)}
+			continue
+		}
+		if current == "" || line == "" {
+			continue
+		}
+		if line == "MISSING" {
+			trees[current] = remoteJobTree{Missing: true}
+			continue
+		}
+		match := md5sumLine.FindStringSubmatch(line)
+		if match == nil {
+			return nil, fmt.Errorf("job %s: unexpected output %q", current, line)
+		}
+		rel := strings.TrimPrefix(match[2], "./")
+		if strings.HasPrefix(line, `\`) {
+			// md5sum escapes backslashes and newlines in names it prints.
+			rel = strings.NewReplacer(`\\`, `\`, `\n`, "\n").Replace(rel)
+		}
+		trees[current].Files[rel] = match[1]
+	}
+	for _, job := range jobs {
+		if _, ok := trees[job]; !ok {
+			return nil, fmt.Errorf("job %s: no output from worker", job)
+		}
+	}
+	return trees, nil
+}
@@ -0,0 +1,49 @@
+// Copyright 2025 Kiruba Sankar Swaminathan. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package drift
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	digestA = "0cc175b9c0f1b6a831c399e269772661"
+	digestB = "92eb5ffee6ae2fec3ad71c777531578f"
+)
+
+func TestTreeScript(t *testing.T) {
+	script := treeScript("bucket-1", []string{"api", "web"})
+	assert.Contains(t, script, "base='/opt/worker/bucket-1/jobs'\n")
+	assert.Contains(t, script, "for job in 'api' 'web'; do\n")
+	assert.Contains(t, script, "-path '*__pycache__*' -o -path './bin' -o -path './data'")
+	assert.Contains(t, script, "xargs -0 -r md5sum")
+}
+
+func TestParseTreeOutput(t *testing.T) {
+	output := "Warning: Permanently added host\n" +
+		"JOB api\n" +
+		digestA + "  ./run.sh\n" +
+		"\\" + digestB + "  ./conf/a\\\\b\n" +
+		"JOB web\n" +
+		"MISSING\n"
+
+	trees, err := parseTreeOutput(output, []string{"api", "web"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]remoteJobTree{
+		"api": {Files: map[string]string{"run.sh": digestA, `conf/a\b`: digestB}},
+		"web": {Missing: true},
+	}, trees)
+}
+
+func TestParseTreeOutputErrors(t *testing.T) {
+	_, err := parseTreeOutput("JOB api\nmd5sum: ./secret: Permission denied\n", []string{"api"})
+	assert.ErrorContains(t, err, "job api: unexpected output")
+
+	_, err = parseTreeOutput("JOB api\n"+digestA+"  ./run.sh\n", []string{"api", "web"})
+	assert.ErrorContains(t, err, "job web: no output from worker")
+}
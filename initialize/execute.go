@@ -10,6 +10,7 @@ import (
 	"crypto/rsa"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"database/sql"
 	"encoding/pem"
 	"fmt"
 	"math/big"
@@ -40,6 +41,12 @@ func Execute() error {
 		_ = db.Close()
 	}()
 
+	if !isNewDatabase {
+		if err := backupBeforeMigrating(db); err != nil {
+			return err
+		}
+	}
+
 	tx, err := db.Begin()
 	if err != nil {
 		return bucket.DatabaseError(err)
@@ -51,6 +58,10 @@ func Execute() error {
 	if err := data.MigrateSchema(tx); err != nil {
 		return err
 	}
+	schemaVersion, _, err := data.SchemaStatus(tx)
+	if err != nil {
+		return err
+	}
 
 	bucketInitialized, err := data.BucketInitialized(tx)
 	if err != nil {
@@ -90,12 +101,37 @@ func Execute() error {
 		if isNewDatabase || !bucketInitialized {
 			fmt.Println("maand bucket initialized")
 		} else {
-			fmt.Printf("maand bucket upgraded (schema version %d)\n", data.LatestSchemaVersion)
+			fmt.Printf("maand bucket upgraded (schema version %d)\n", schemaVersion)
 		}
 	}
 	return nil
 }
 
+// backupBeforeMigrating copies maand.db to data/backups when init has migrations to apply.
+func backupBeforeMigrating(db *sql.DB) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return bucket.DatabaseError(err)
+	}
+	pending, err := data.PendingMigrations(tx)
+	_ = tx.Rollback()
+	if err != nil {
+		return err
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	backupPath, err := data.BackupDatabase(db)
+	if err != nil {
+		return fmt.Errorf("backup maand.db before schema migration: %w", err)
+	}
+	if !bucket.QuietCLIOutput() {
+		fmt.Printf("maand.db backed up to %s before %d schema migration(s)\n", backupPath, len(pending))
+	}
+	return nil
+}
+
 func ensureBucketDirectories() error {
 	dirs := []string{
 		path.Join(bucket.Location, "data"),
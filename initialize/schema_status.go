@@ -0,0 +1,60 @@
+// Copyright 2025 Kiruba Sankar Swaminathan. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package initialize
+
+import (
+	"fmt"
+
+	"maand/bucket"
+	"maand/data"
+	"maand/utils"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+)
+
+// SchemaStatus prints the schema version of maand.db and each migration this binary
+// knows, applied or pending. Migrations recorded by a newer binary are listed as unknown.
+func SchemaStatus() error {
+	db, err := data.OpenDatabase(true)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return bucket.DatabaseError(err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	currentVersion, statuses, err := data.SchemaStatus(tx)
+	if err != nil {
+		return err
+	}
+
+	expectedVersion, pending := 0, 0
+	t := utils.GetTable(table.Row{"version", "description", "status", "applied_at"})
+	for _, status := range statuses {
+		switch status.State {
+		case "pending":
+			pending++
+			expectedVersion = status.Version
+		case "applied":
+			expectedVersion = status.Version
+		}
+		t.AppendRow(table.Row{status.Version, status.Description, status.State, status.AppliedAt})
+	}
+	fmt.Printf("schema version %d, binary expects %d\n", currentVersion, expectedVersion)
+	t.Render()
+
+	if pending > 0 {
+		fmt.Printf("%d migration(s) pending; run maand init to apply them\n", pending)
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+// Copyright 2025 Kiruba Sankar Swaminathan. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package plan
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrPlanInvalid reports a plan that maand build would reject. maand plan placement prints
+// the validation errors and returns it so scripts can gate on the exit code.
+var ErrPlanInvalid = errors.New("plan fails validation")
+
+func errWorkspaceDir(dir string, err error) error {
+	return fmt.Errorf("workspace dir %s: %w", dir, err)
+}
+
+func errNotADirectory(dir string) error {
+	return fmt.Errorf("workspace dir %s is not a directory", dir)
+}
+
+func errInvalid(count int) error {
+	return fmt.Errorf("%w with %d error(s); maand build would fail", ErrPlanInvalid, count)
+}
@@ -0,0 +1,271 @@
+// Copyright 2025 Kiruba Sankar Swaminathan. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+// Package plan previews catalog changes without touching maand.db.
+//
+// maand plan placement copies the catalog to a scratch database, runs the build steps that
+// decide placement (workers, jobs and ports, allocations) and the placement and resource
+// validations against the workspace, and prints how allocations would change and how much
+// memory, cpu and disk each worker would have left. The copy is deleted afterwards.
+package plan
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path"
+	"slices"
+
+	"maand/bucket"
+	"maand/build"
+	"maand/data"
+	"maand/utils"
+	"maand/workspace"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+)
+
+// Allocation changes reported by maand plan placement.
+const (
+	ChangeCreate = "create"
+	ChangeRemove = "remove"
+	ChangeMove   = "move"
+)
+
+// PlacementOptions configures maand plan placement.
+type PlacementOptions struct {
+	// WorkspaceDir replaces the bucket workspace directory (workers.json, jobs/, disabled.json).
+	WorkspaceDir string
+	// Rebalance plans maand rebalance: counted jobs are placed afresh instead of kept on
+	// their current workers. Jobs limits it to these jobs; empty means every counted job.
+	Rebalance bool
+	Jobs      []string
+}
+
+// AllocationChange is one allocation build would create, remove or move. From is the
+// worker a moved allocation leaves.
+type AllocationChange struct {
+	Job      string
+	Change   string
+	WorkerIP string
+	From     string
+}
+
+// PlacementPlan is the outcome of planning placement against the workspace.
+type PlacementPlan struct {
+	Changes  []AllocationChange
+	Headroom []data.WorkerHeadroom
+	Errors   []error
+}
+
+// Placement plans placement for the workspace, prints the plan, and returns ErrPlanInvalid
+// when build would reject it.
+func Placement(opts PlacementOptions) error {
+	if opts.WorkspaceDir != "" {
+		info, err := os.Stat(opts.WorkspaceDir)
+		if err != nil {
+			return errWorkspaceDir(opts.WorkspaceDir, err)
+		}
+		if !info.IsDir() {
+			return errNotADirectory(opts.WorkspaceDir)
+		}
+		bucket.WorkspaceLocation = opts.WorkspaceDir
+	}
+
+	db, err := data.OpenDatabase(true)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	scratch, cleanup, err := copyCatalog(db)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	result, err := planPlacement(scratch, workspace.Default(), opts)
+	if err != nil {
+		return err
+	}
+	renderPlacement(result)
+	if len(result.Errors) > 0 {
+		return errInvalid(len(result.Errors))
+	}
+	return nil
+}
+
+// copyCatalog snapshots db into a scratch database in a temporary directory. cleanup
+// closes and deletes it.
+func copyCatalog(db *sql.DB) (*sql.DB, func(), error) {
+	dir, err := os.MkdirTemp("", "maand-plan-")
+	if err != nil {
+		return nil, nil, bucket.UnexpectedError(err)
+	}
+	removeDir := func() {
+		_ = os.RemoveAll(dir)
+	}
+
+	scratchPath := path.Join(dir, "maand.db")
+	if _, err := db.Exec(`VACUUM INTO ?`, scratchPath); err != nil {
+		removeDir()
+		return nil, nil, bucket.DatabaseError(err)
+	}
+	scratch, err := sql.Open("sqlite3", "file:"+scratchPath)
+	if err != nil {
+		removeDir()
+		return nil, nil, bucket.DatabaseError(err)
+	}
+	return scratch, func() {
+		_ = scratch.Close()
+		removeDir()
+	}, nil
+}
+
+// planPlacement runs placement against db in a transaction it rolls back. Errors from the
+// build steps are returned; validation errors are collected in the plan.
+func planPlacement(db *sql.DB, jobWorkspace *workspace.DefaultWorkspace, opts PlacementOptions) (*PlacementPlan, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, bucket.DatabaseError(err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	before, err := data.ListPlacedAllocations(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := build.BuildWorkers(tx, jobWorkspace); err != nil {
+		return nil, err
+	}
+	if _, err := build.BuildJobs(tx, jobWorkspace); err != nil {
+		return nil, err
+	}
+	if opts.Rebalance {
+		if err := build.RebalanceAllocations(tx, jobWorkspace, opts.Jobs); err != nil {
+			return nil, err
+		}
+	} else if err := build.BuildAllocations(tx, jobWorkspace); err != nil {
+		return nil, err
+	}
+
+	after, err := data.ListPlacedAllocations(tx)
+	if err != nil {
+		return nil, err
+	}
+	jobs, err := data.ListJobReservations(tx)
+	if err != nil {
+		return nil, err
+	}
+	counted := make(map[string]bool, len(jobs))
+	for _, job := range jobs {
+		counted[job.Job] = job.Count > 0
+	}
+
+	result := &PlacementPlan{Changes: DiffAllocations(before, after, counted)}
+	for _, validate := range []func() error{
+		func() error { return build.ValidateMinAllocationsCount(tx, jobWorkspace) },
+		func() error { return build.ValidatePlacementRules(tx) },
+		func() error { return build.ValidatePortConflicts(tx) },
+		func() error { return build.ValidateWorkerResources(tx) },
+	} {
+		if err := validate(); err != nil {
+			result.Errors = append(result.Errors, err)
+		}
+	}
+
+	result.Headroom, err = data.ListWorkerHeadroom(tx)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// DiffAllocations compares the allocations before and after a build. For jobs with count,
+// an allocation removed from one worker and created on another is reported as a move.
+func DiffAllocations(before, after []data.PlacedAllocation, counted map[string]bool) []AllocationChange {
+	workersOf := func(allocations []data.PlacedAllocation) (map[string][]string, []string) {
+		workers := make(map[string][]string)
+		var jobs []string
+		for _, alloc := range allocations {
+			if _, ok := workers[alloc.Job]; !ok {
+				jobs = append(jobs, alloc.Job)
+			}
+			workers[alloc.Job] = append(workers[alloc.Job], alloc.WorkerIP)
+		}
+		return workers, jobs
+	}
+	beforeWorkers, beforeJobs := workersOf(before)
+	afterWorkers, afterJobs := workersOf(after)
+
+	jobs := utils.Unique(append(beforeJobs, afterJobs...))
+	slices.Sort(jobs)
+
+	var changes []AllocationChange
+	for _, job := range jobs {
+		removed := utils.Difference(beforeWorkers[job], afterWorkers[job])
+		created := utils.Difference(afterWorkers[job], beforeWorkers[job])
+		if counted[job] {
+			for len(removed) > 0 && len(created) > 0 {
+				changes = append(changes, AllocationChange{Job: job, Change: ChangeMove, WorkerIP: created[0], From: removed[0]})
+				removed, created = removed[1:], created[1:]
+			}
+		}
+		for _, workerIP := range created {
+			changes = append(changes, AllocationChange{Job: job, Change: ChangeCreate, WorkerIP: workerIP})
+		}
+		for _, workerIP := range removed {
+			changes = append(changes, AllocationChange{Job: job, Change: ChangeRemove, WorkerIP: workerIP})
+		}
+	}
+	return changes
+}
+
+func renderPlacement(result *PlacementPlan) {
+	counts := make(map[string]int)
+	for _, change := range result.Changes {
+		counts[change.Change]++
+	}
+	if len(result.Changes) == 0 {
+		fmt.Println("no allocation changes")
+	} else {
+		changes := utils.GetTable(table.Row{"job", "change", "worker_ip", "from"})
+		for _, change := range result.Changes {
+			changes.AppendRow(table.Row{change.Job, change.Change, change.WorkerIP, change.From})
+		}
+		changes.Render()
+		fmt.Printf("%d to create, %d to remove, %d to move\n",
+			counts[ChangeCreate], counts[ChangeRemove], counts[ChangeMove])
+	}
+
+	fmt.Println()
+	headroom := utils.GetTable(table.Row{
+		"worker_ip", "zone",
+		"memory_mb", "reserved_memory_mb", "free_memory_mb",
+		"cpu_mhz", "reserved_cpu_mhz", "free_cpu_mhz",
+		"disk_mb", "reserved_disk_mb", "free_disk_mb",
+	})
+	for _, worker := range result.Headroom {
+		headroom.AppendRow(table.Row{
+			worker.WorkerIP, worker.Zone,
+			worker.MemoryMB, worker.ReservedMemoryMB, worker.FreeMemoryMB(),
+			worker.CPUMHz, worker.ReservedCPUMHz, worker.FreeCPUMHz(),
+			worker.DiskMB, worker.ReservedDiskMB, worker.FreeDiskMB(),
+		})
+	}
+	headroom.Render()
+
+	if len(result.Errors) > 0 {
+		fmt.Println()
+		fmt.Println("validation errors:")
+		for _, err := range result.Errors {
+			fmt.Println(err)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright 2025 Kiruba Sankar Swaminathan. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package plan
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"maand/bucket"
+	"maand/build"
+	"maand/data"
+	"maand/initialize"
+	"maand/workspace"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffAllocations(t *testing.T) {
+	before := []data.PlacedAllocation{
+		{Job: "agent", WorkerIP: "10.0.0.1"},
+		{Job: "agent", WorkerIP: "10.0.0.2"},
+		{Job: "api", WorkerIP: "10.0.0.1"},
+		{Job: "api", WorkerIP: "10.0.0.2"},
+		{Job: "old", WorkerIP: "10.0.0.1"},
+	}
+	after := []data.PlacedAllocation{
+		{Job: "agent", WorkerIP: "10.0.0.1"},
+		{Job: "agent", WorkerIP: "10.0.0.3"},
+		{Job: "api", WorkerIP: "10.0.0.1"},
+		{Job: "api", WorkerIP: "10.0.0.3"},
+		{Job: "new", WorkerIP: "10.0.0.3"},
+	}
+
+	changes := DiffAllocations(before, after, map[string]bool{"api": true})
+	assert.Equal(t, []AllocationChange{
+		{Job: "agent", Change: ChangeCreate, WorkerIP: "10.0.0.3"},
+		{Job: "agent", Change: ChangeRemove, WorkerIP: "10.0.0.2"},
+		{Job: "api", Change: ChangeMove, WorkerIP: "10.0.0.3", From: "10.0.0.2"},
+		{Job: "new", Change: ChangeCreate, WorkerIP: "10.0.0.3"},
+		{Job: "old", Change: ChangeRemove, WorkerIP: "10.0.0.1"},
+	}, changes)
+}
+
+func TestPlanPlacement_leavesCatalogUnchanged(t *testing.T) {
+	root := t.TempDir()
+	orig := bucket.Location
+	bucket.Location = root
+	bucket.UpdatePath()
+	t.Cleanup(func() {
+		bucket.Location = orig
+		bucket.UpdatePath()
+	})
+	require.NoError(t, initialize.Execute())
+
+	workersPath := path.Join(bucket.WorkspaceLocation, "workers.json")
+	require.NoError(t, os.WriteFile(workersPath, []byte(`[
+		{"host":"10.0.0.1","memory":"1024","cpu":"2000","position":0},
+		{"host":"10.0.0.2","memory":"1024","cpu":"2000","position":1}
+	]`), 0o644))
+	jobPath := path.Join(bucket.WorkspaceLocation, "jobs", "api")
+	require.NoError(t, os.MkdirAll(jobPath, 0o755))
+	require.NoError(t, os.WriteFile(path.Join(jobPath, "manifest.json"), []byte(`{
+		"selectors": ["worker"],
+		"count": 1,
+		"resources": {"memory": {"min": "512", "max": "512"}, "cpu": {"min": "500", "max": "500"}}
+	}`), 0o644))
+	require.NoError(t, os.WriteFile(path.Join(jobPath, "Makefile"), []byte(""), 0o644))
+	require.NoError(t, build.Execute())
+
+	// Replace 10.0.0.1, which runs api, with a worker too small for it.
+	require.NoError(t, os.WriteFile(workersPath, []byte(`[
+		{"host":"10.0.0.2","memory":"1024","cpu":"2000","position":1},
+		{"host":"10.0.0.3","memory":"256","cpu":"2000","position":2}
+	]`), 0o644))
+
+	db, err := data.OpenDatabase(true)
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	scratch, cleanup, err := copyCatalog(db)
+	require.NoError(t, err)
+	result, err := planPlacement(scratch, workspace.Default(), PlacementOptions{})
+	cleanup()
+	require.NoError(t, err)
+
+	assert.Equal(t, []AllocationChange{
+		{Job: "api", Change: ChangeMove, WorkerIP: "10.0.0.2", From: "10.0.0.1"},
+	}, result.Changes)
+	assert.Empty(t, result.Errors)
+	require.Len(t, result.Headroom, 2)
+	assert.Equal(t, "10.0.0.2", result.Headroom[0].WorkerIP)
+	assert.Equal(t, 512.0, result.Headroom[0].FreeMemoryMB())
+	assert.Equal(t, 1500.0, result.Headroom[0].FreeCPUMHz())
+	assert.Equal(t, 256.0, result.Headroom[1].FreeMemoryMB())
+
+	var workerIP string
+	require.NoError(t, db.QueryRow(`SELECT worker_ip FROM allocations WHERE job = 'api' AND removed = 0`).Scan(&workerIP))
+	assert.Equal(t, "10.0.0.1", workerIP)
+}
+
+func TestPlanPlacement_reportsValidationErrors(t *testing.T) {
+	root := t.TempDir()
+	orig := bucket.Location
+	bucket.Location = root
+	bucket.UpdatePath()
+	t.Cleanup(func() {
+		bucket.Location = orig
+		bucket.UpdatePath()
+	})
+	require.NoError(t, initialize.Execute())
+
+	require.NoError(t, os.WriteFile(path.Join(bucket.WorkspaceLocation, "workers.json"), []byte(`[
+		{"host":"10.0.0.1","memory":"256","cpu":"2000","position":0}
+	]`), 0o644))
+	jobPath := path.Join(bucket.WorkspaceLocation, "jobs", "api")
+	require.NoError(t, os.MkdirAll(jobPath, 0o755))
+	require.NoError(t, os.WriteFile(path.Join(jobPath, "manifest.json"), []byte(`{
+		"selectors": ["worker"],
+		"count": 2,
+		"resources": {"memory": {"min": "512", "max": "512"}}
+	}`), 0o644))
+	require.NoError(t, os.WriteFile(path.Join(jobPath, "Makefile"), []byte(""), 0o644))
+
+	err := Placement(PlacementOptions{})
+	require.ErrorIs(t, err, ErrPlanInvalid)
+	assert.ErrorContains(t, err, "2 error(s)")
+
+	db, err := data.OpenDatabase(true)
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+	var count int
+	require.NoError(t, db.QueryRow(`SELECT count(*) FROM allocations`).Scan(&count))
+	assert.Zero(t, count)
+}
@@ -0,0 +1,85 @@
+// Copyright 2025 Kiruba Sankar Swaminathan. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package query
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"maand/data"
+	"maand/utils"
+)
+
+// ErrInvalidQuery is returned for statements maand query cannot run: syntax errors,
+// relations outside the catalog views and QueryableTables, and anything but a SELECT.
+var ErrInvalidQuery = errors.New("invalid query")
+
+// Execute runs a read-only SQL statement over the cat_* views and data.QueryableTables
+// and prints the result in format.
+func Execute(statement string, format utils.OutputFormat) error {
+	columns, rows, err := Run(statement)
+	if err != nil {
+		return err
+	}
+	return utils.PrintRows(format, columns, rows)
+}
+
+// Run evaluates statement against a private copy of the catalog and returns the column
+// names and rows. Values are nil, int64, float64, string or []byte as SQLite returns them.
+func Run(statement string) ([]string, [][]any, error) {
+	if strings.TrimSpace(statement) == "" {
+		return nil, nil, fmt.Errorf("%w: empty statement", ErrInvalidQuery)
+	}
+
+	ctx := context.Background()
+	conn, release, err := data.OpenQueryCatalog(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer release()
+
+	rows, err := conn.QueryContext(ctx, statement)
+	if err != nil {
+		return nil, nil, invalidQuery(err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, nil, invalidQuery(err)
+	}
+	var result [][]any
+	for rows.Next() {
+		values := make([]any, len(columns))
+		pointers := make([]any, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, nil, invalidQuery(err)
+		}
+		result = append(result, values)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, invalidQuery(err)
+	}
+	return columns, result, nil
+}
+
+func invalidQuery(err error) error {
+	message := err.Error()
+	switch {
+	case strings.HasPrefix(message, "no such table"):
+		return fmt.Errorf("%w: %s; only cat_* views and %s can be queried",
+			ErrInvalidQuery, message, strings.Join(data.QueryableTables, ", "))
+	case strings.Contains(message, "not authorized"), strings.Contains(message, "readonly"):
+		return fmt.Errorf("%w: %s; only SELECT statements can be run", ErrInvalidQuery, message)
+	}
+	return fmt.Errorf("%w: %w", ErrInvalidQuery, err)
+}
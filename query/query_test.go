@@ -0,0 +1,133 @@
+// Copyright 2025 Kiruba Sankar Swaminathan. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package query
+
+import (
+	"testing"
+
+	"maand/bucket"
+	"maand/data"
+	"maand/initialize"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupQueryBucket(t *testing.T) {
+	t.Helper()
+	root := t.TempDir()
+	orig := bucket.Location
+	bucket.Location = root
+	bucket.UpdatePath()
+	t.Cleanup(func() {
+		bucket.Location = orig
+		bucket.UpdatePath()
+	})
+	require.NoError(t, initialize.Execute())
+
+	db, err := data.OpenDatabase(true)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	_, err = db.Exec(`
+		INSERT INTO worker (worker_id, worker_ip, available_memory_mb, available_cpu_mhz, position)
+		VALUES ('w1', '10.0.0.1', '1024', '2000', 0), ('w2', '10.0.0.2', '1024', '2000', 1);
+		INSERT INTO worker_tags (worker_id, key, value) VALUES ('w1', 'zone', 'a'), ('w2', 'zone', 'b');
+		INSERT INTO allocations (alloc_id, worker_ip, job, disabled, removed, deployment_seq, new_version)
+		VALUES ('alloc-1', '10.0.0.1', 'api', 0, 0, 0, '1.0.0'),
+			('alloc-2', '10.0.0.2', 'api', 0, 0, 0, '1.0.0'),
+			('alloc-3', '10.0.0.2', 'web', 0, 0, 0, NULL);
+		INSERT INTO key_value (namespace, key, value, version, ttl, created_date, deleted)
+		VALUES ('secrets/job/api', 'token', 'enc:v1:c2VjcmV0', 1, 0, '0', 0),
+			('vars/bucket', 'region', 'eu-west-1', 1, 0, '0', 0);
+		INSERT INTO job_files (job_id, path, content, isdir) VALUES ('job-api', 'Makefile', 'start:', 0);
+	`)
+	require.NoError(t, err)
+}
+
+func TestRunJoinsCatalogViews(t *testing.T) {
+	setupQueryBucket(t)
+
+	columns, rows, err := Run(`SELECT a.job, w.zone, count(*) AS allocations
+		FROM cat_allocations a JOIN cat_workers w USING (worker_ip)
+		GROUP BY a.job, w.zone ORDER BY a.job, w.zone`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"job", "zone", "allocations"}, columns)
+	assert.Equal(t, [][]any{
+		{"api", "a", int64(1)},
+		{"api", "b", int64(1)},
+		{"web", "b", int64(1)},
+	}, rows)
+
+	_, rows, err = Run(`SELECT label FROM worker_labels`)
+	require.NoError(t, err)
+	assert.Empty(t, rows)
+}
+
+func TestRunKeepsEncryptedValuesMasked(t *testing.T) {
+	setupQueryBucket(t)
+
+	_, rows, err := Run(`SELECT namespace, key, value FROM cat_kv ORDER BY namespace`)
+	require.NoError(t, err)
+	assert.Equal(t, [][]any{
+		{"secrets/job/api", "token", "[encrypted]"},
+		{"vars/bucket", "region", "eu-west-1"},
+	}, rows)
+}
+
+func TestRunRejectsTablesOutsideWhitelist(t *testing.T) {
+	setupQueryBucket(t)
+
+	for _, statement := range []string{
+		`SELECT value FROM key_value`,
+		`SELECT content FROM job_files`,
+		`SELECT * FROM cat_kv JOIN hash ON hash.key = cat_kv.key`,
+		`SELECT * FROM catalog.key_value`,
+	} {
+		_, _, err := Run(statement)
+		require.ErrorIs(t, err, ErrInvalidQuery, statement)
+	}
+}
+
+func TestRunRejectsAnythingButSelect(t *testing.T) {
+	setupQueryBucket(t)
+
+	for _, statement := range []string{
+		`DELETE FROM cat_allocations`,
+		`UPDATE worker_tags SET value = 'c'`,
+		`DROP TABLE worker_tags`,
+		`CREATE TABLE scratch (x)`,
+		`ATTACH DATABASE '` + data.DatabasePath() + `' AS raw`,
+		`PRAGMA query_only = OFF`,
+		`SELECT 1; DELETE FROM worker_tags`,
+		"",
+	} {
+		_, _, err := Run(statement)
+		require.ErrorIs(t, err, ErrInvalidQuery, statement)
+	}
+
+	_, rows, err := Run(`WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n WHERE i < 3) SELECT i FROM n`)
+	require.NoError(t, err)
+	assert.Len(t, rows, 3)
+
+	db, err := data.OpenDatabase(true)
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+	var count int
+	require.NoError(t, db.QueryRow(`SELECT count(*) FROM worker_tags WHERE value IN ('a', 'b')`).Scan(&count))
+	assert.Equal(t, 2, count)
+}
+
+func TestRunRequiresBucket(t *testing.T) {
+	orig := bucket.Location
+	bucket.Location = t.TempDir()
+	bucket.UpdatePath()
+	t.Cleanup(func() {
+		bucket.Location = orig
+		bucket.UpdatePath()
+	})
+
+	_, _, err := Run(`SELECT * FROM cat_jobs`)
+	require.ErrorIs(t, err, bucket.ErrNotInitialized)
+}
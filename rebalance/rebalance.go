@@ -0,0 +1,138 @@
+// Copyright 2025 Kiruba Sankar Swaminathan. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+// Package rebalance moves allocations of jobs with count onto a better placement.
+//
+// Build keeps a counted job on the workers it already runs on, so adding workers or changing
+// labels leaves the old workers carrying most of the reserved memory and cpu. maand rebalance
+// builds with those jobs placed afresh within the resource limits, then deploys only the
+// jobs that moved: each new allocation is started (after_allocation_started) and health
+// checked before the old one is stopped (after_allocation_stopped) and removed.
+package rebalance
+
+import (
+	"log"
+	"slices"
+
+	"maand/bucket"
+	"maand/build"
+	"maand/data"
+	"maand/deploy"
+	"maand/plan"
+)
+
+// Options configures maand rebalance.
+type Options struct {
+	// Jobs limits the rebalance to these jobs; empty means every job with count.
+	Jobs []string
+	// DryRun prints the moves and the resulting worker headroom without building or deploying.
+	DryRun bool
+}
+
+// Execute rebalances the bucket. It builds with the jobs placed afresh and deploys the jobs
+// whose allocations moved, together with the moves an earlier failed rebalance left
+// unfinished; with opts.DryRun it only plans (see plan.Placement).
+func Execute(opts Options) error {
+	if opts.DryRun {
+		return plan.Placement(plan.PlacementOptions{Rebalance: true, Jobs: opts.Jobs})
+	}
+
+	before, _, err := placedAllocations()
+	if err != nil {
+		return err
+	}
+	if err := build.Execute(build.Options{Rebalance: true, RebalanceJobs: opts.Jobs}); err != nil {
+		return err
+	}
+	after, counted, err := placedAllocations()
+	if err != nil {
+		return err
+	}
+
+	moved := movedJobs(plan.DiffAllocations(before, after, counted))
+	unfinished, err := unfinishedMoves(opts.Jobs)
+	if err != nil {
+		return err
+	}
+	for _, job := range unfinished {
+		if counted[job] && !slices.Contains(moved, job) {
+			log.Printf("rebalance: finish moving job %s", job)
+			moved = append(moved, job)
+		}
+	}
+	if len(moved) == 0 {
+		log.Println("rebalance: no allocations to move")
+		return nil
+	}
+	return deploy.Execute(moved, deploy.Options{StopMovedAfterStart: true})
+}
+
+// movedJobs logs the moves among changes and returns the jobs they belong to.
+func movedJobs(changes []plan.AllocationChange) []string {
+	var jobs []string
+	for _, change := range changes {
+		if change.Change != plan.ChangeMove {
+			continue
+		}
+		log.Printf("rebalance: move job %s from %s to %s", change.Job, change.From, change.WorkerIP)
+		if !slices.Contains(jobs, change.Job) {
+			jobs = append(jobs, change.Job)
+		}
+	}
+	return jobs
+}
+
+// placedAllocations reads the non-removed allocations and, per job, whether it has count.
+func placedAllocations() ([]data.PlacedAllocation, map[string]bool, error) {
+	db, err := data.OpenDatabase(true)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, nil, bucket.DatabaseError(err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	allocations, err := data.ListPlacedAllocations(tx)
+	if err != nil {
+		return nil, nil, err
+	}
+	jobs, err := data.ListJobReservations(tx)
+	if err != nil {
+		return nil, nil, err
+	}
+	counted := make(map[string]bool, len(jobs))
+	for _, job := range jobs {
+		counted[job.Job] = job.Count > 0
+	}
+	return allocations, counted, nil
+}
+
+// unfinishedMoves returns the jobs whose moved allocations are not rolled out yet (see
+// deploy.UnfinishedMoves).
+func unfinishedMoves(jobs []string) ([]string, error) {
+	db, err := data.OpenDatabase(true)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, bucket.DatabaseError(err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	return deploy.UnfinishedMoves(tx, jobs)
+}
@@ -0,0 +1,179 @@
+// Copyright 2025 Kiruba Sankar Swaminathan. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package rebalance
+
+import (
+	"errors"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"maand/bucket"
+	"maand/build"
+	"maand/data"
+	"maand/deploy"
+	"maand/initialize"
+	"maand/plan"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMovedJobs(t *testing.T) {
+	changes := []plan.AllocationChange{
+		{Job: "web", WorkerIP: "10.0.0.3", From: "10.0.0.2", Change: plan.ChangeMove},
+		{Job: "api", WorkerIP: "10.0.0.4", Change: plan.ChangeCreate},
+		{Job: "web", WorkerIP: "10.0.0.4", From: "10.0.0.1", Change: plan.ChangeMove},
+		{Job: "db", WorkerIP: "10.0.0.1", Change: plan.ChangeRemove},
+		{Job: "cache", WorkerIP: "10.0.0.2", From: "10.0.0.1", Change: plan.ChangeMove},
+	}
+	assert.Equal(t, []string{"web", "cache"}, movedJobs(changes))
+	assert.Empty(t, movedJobs(nil))
+}
+
+// setupRebalanceBucket builds and deploys job web (count 2) on the two zone a workers,
+// then adds a zone b worker, which a plain build leaves unused.
+func setupRebalanceBucket(t *testing.T) {
+	t.Helper()
+	root := t.TempDir()
+	orig := bucket.Location
+	bucket.Location = root
+	bucket.UpdatePath()
+	t.Cleanup(func() {
+		bucket.Location = orig
+		bucket.UpdatePath()
+	})
+	require.NoError(t, initialize.Execute())
+
+	jobDir := path.Join(bucket.WorkspaceLocation, "jobs", "web")
+	require.NoError(t, os.MkdirAll(jobDir, 0o755))
+	require.NoError(t, os.WriteFile(path.Join(jobDir, "manifest.json"),
+		[]byte(`{"version": "1.0.0", "selectors": ["web"], "count": 2}`), 0o644))
+	require.NoError(t, os.WriteFile(path.Join(jobDir, "Makefile"), []byte("start:\nstop:\nrestart:\n"), 0o644))
+
+	writeWorkers(t, `[
+		{"host": "10.0.0.1", "labels": ["web"], "tags": {"zone": "a"}},
+		{"host": "10.0.0.2", "labels": ["web"], "tags": {"zone": "a"}}
+	]`)
+	require.NoError(t, build.Execute())
+	installDeployHooks(t)
+	require.NoError(t, deploy.Execute(nil, deploy.Options{}))
+
+	writeWorkers(t, `[
+		{"host": "10.0.0.1", "labels": ["web"], "tags": {"zone": "a"}},
+		{"host": "10.0.0.2", "labels": ["web"], "tags": {"zone": "a"}},
+		{"host": "10.0.0.3", "labels": ["web"], "tags": {"zone": "b"}}
+	]`)
+	require.NoError(t, build.Execute())
+	assert.Equal(t, []string{"10.0.0.1", "10.0.0.2"}, activeWorkers(t, "web"))
+}
+
+func writeWorkers(t *testing.T, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path.Join(bucket.WorkspaceLocation, "workers.json"), []byte(content), 0o644))
+}
+
+// installDeployHooks replaces the worker side effects of deploy and returns the runner
+// actions it ran, as "<worker> <action>", in order. Actions listed in failing run and fail.
+func installDeployHooks(t *testing.T, failing ...string) func() []string {
+	t.Helper()
+	var mu sync.Mutex
+	var actions []string
+	deploy.SetTestHooks(&deploy.TestHooks{
+		WorkerCommand: func(_ *bucket.Runtime, workerIP string, _ bucket.CommandContext, commands []string, _ []string) error {
+			mu.Lock()
+			defer mu.Unlock()
+			for _, command := range commands {
+				fields := strings.Fields(command)
+				if i := slices.Index(fields, "--jobs"); i >= 1 {
+					action := workerIP + " " + fields[i-1]
+					actions = append(actions, action)
+					if slices.Contains(failing, action) {
+						return errors.New(action + " failed")
+					}
+				}
+			}
+			return nil
+		},
+		Rsync: func(*bucket.Runtime, string, string, []string) error {
+			return nil
+		},
+		SetupRuntime: func(string, bucket.RunContext) (*bucket.Runtime, error) {
+			return nil, nil
+		},
+		CheckWorkerPrerequisites: func(*bucket.Runtime, []string) error {
+			return nil
+		},
+	})
+	t.Cleanup(deploy.ClearTestHooks)
+	return func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return slices.Clone(actions)
+	}
+}
+
+func activeWorkers(t *testing.T, job string) []string {
+	t.Helper()
+	db, err := data.OpenDatabase(true)
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+	tx, err := db.Begin()
+	require.NoError(t, err)
+	defer func() { _ = tx.Rollback() }()
+	workers, err := data.GetActiveAllocations(tx, job)
+	require.NoError(t, err)
+	return workers
+}
+
+func TestExecute_movesAllocationAndStopsOldOneAfterStart(t *testing.T) {
+	setupRebalanceBucket(t)
+	actions := installDeployHooks(t)
+
+	require.NoError(t, Execute(Options{}))
+
+	assert.Equal(t, []string{"10.0.0.1", "10.0.0.3"}, activeWorkers(t, "web"))
+	ran := actions()
+	started := slices.Index(ran, "10.0.0.3 start")
+	stopped := slices.Index(ran, "10.0.0.2 stop")
+	require.NotEqual(t, -1, started, ran)
+	require.NotEqual(t, -1, stopped, ran)
+	assert.Less(t, started, stopped, "the moved allocation stops only after its replacement started")
+	assert.NotContains(t, ran, "10.0.0.1 stop")
+}
+
+func TestExecute_failedDeployKeepsOldAllocationUntilRetried(t *testing.T) {
+	setupRebalanceBucket(t)
+	actions := installDeployHooks(t, "10.0.0.3 start")
+
+	require.Error(t, Execute(Options{}))
+	assert.Equal(t, []string{"10.0.0.1", "10.0.0.3"}, activeWorkers(t, "web"))
+	ran := actions()
+	assert.Contains(t, ran, "10.0.0.3 start")
+	assert.NotContains(t, ran, "10.0.0.2 stop", "the old allocation keeps running when its replacement fails")
+
+	actions = installDeployHooks(t)
+	require.NoError(t, Execute(Options{}))
+	ran = actions()
+	started := slices.Index(ran, "10.0.0.3 start")
+	stopped := slices.Index(ran, "10.0.0.2 stop")
+	require.NotEqual(t, -1, started, ran)
+	require.NotEqual(t, -1, stopped, ran)
+	assert.Less(t, started, stopped, "the retried move stops the old allocation only after its replacement started")
+}
+
+func TestExecute_rejectsUnknownJob(t *testing.T) {
+	setupRebalanceBucket(t)
+	actions := installDeployHooks(t)
+
+	err := Execute(Options{Jobs: []string{"web", "missing"}})
+	assert.ErrorIs(t, err, bucket.ErrNotFound)
+	assert.ErrorContains(t, err, "missing")
+	assert.Empty(t, actions())
+	assert.Equal(t, []string{"10.0.0.1", "10.0.0.2"}, activeWorkers(t, "web"))
+}
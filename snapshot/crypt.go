@@ -0,0 +1,214 @@
+// Copyright 2025 Kiruba Sankar Swaminathan. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package snapshot
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// An encrypted snapshot is encryptedMagic, a version byte, the PBKDF2 iteration count and
+// salt, a nonce prefix, then AES-256-GCM sealed chunks. Each chunk is a final flag byte and
+// a big-endian length ahead of the ciphertext; the flag is authenticated, so a truncated
+// snapshot fails to decrypt instead of importing a partial bucket.
+const (
+	encryptedMagic   = "MAANDENC"
+	encryptedVersion = 1
+	saltSize         = 16
+	noncePrefixSize  = 4
+	chunkSize        = 64 * 1024
+	maxIterations    = 10_000_000
+)
+
+// passphraseIterations is the PBKDF2-HMAC-SHA256 work factor for new snapshots.
+var passphraseIterations = 600_000
+
+// minPassphraseLength is the shortest passphrase export accepts.
+const minPassphraseLength = 12
+
+func deriveKey(passphrase string, salt []byte, iterations int) []byte {
+	return pbkdf2.Key([]byte(passphrase), salt, iterations, 32, sha256.New)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("aes cipher: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("gcm: %w", err)
+	}
+	return gcm, nil
+}
+
+func chunkNonce(gcm cipher.AEAD, prefix []byte, counter uint64) []byte {
+	nonce := make([]byte, gcm.NonceSize())
+	copy(nonce, prefix)
+	binary.BigEndian.PutUint64(nonce[noncePrefixSize:], counter)
+	return nonce
+}
+
+type encryptWriter struct {
+	w       io.Writer
+	gcm     cipher.AEAD
+	prefix  []byte
+	counter uint64
+	buf     []byte
+}
+
+// newEncryptWriter writes the snapshot header to w and returns a writer that seals what is
+// written to it. Close writes the final chunk; it does not close w.
+func newEncryptWriter(w io.Writer, passphrase string) (io.WriteCloser, error) {
+	header := make([]byte, 0, len(encryptedMagic)+1+4+saltSize+noncePrefixSize)
+	header = append(header, encryptedMagic...)
+	header = append(header, encryptedVersion)
+	header = binary.BigEndian.AppendUint32(header, uint32(passphraseIterations))
+	random := make([]byte, saltSize+noncePrefixSize)
+	if _, err := io.ReadFull(rand.Reader, random); err != nil {
+		return nil, err
+	}
+	header = append(header, random...)
+	salt, prefix := random[:saltSize], random[saltSize:]
+
+	gcm, err := newGCM(deriveKey(passphrase, salt, passphraseIterations))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(header); err != nil {
+		return nil, err
+	}
+	return &encryptWriter{w: w, gcm: gcm, prefix: prefix, buf: make([]byte, 0, chunkSize)}, nil
+}
+
+func (e *encryptWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		n := min(chunkSize-len(e.buf), len(p))
+		e.buf = append(e.buf, p[:n]...)
+		p = p[n:]
+		written += n
+		if len(e.buf) == chunkSize {
+			if err := e.seal(false); err != nil {
+				return written, err
+			}
+		}
+	}
+	return written, nil
+}
+
+func (e *encryptWriter) Close() error {
+	return e.seal(true)
+}
+
+func (e *encryptWriter) seal(final bool) error {
+	flag := []byte{0}
+	if final {
+		flag[0] = 1
+	}
+	sealed := e.gcm.Seal(nil, chunkNonce(e.gcm, e.prefix, e.counter), e.buf, flag)
+	e.counter++
+	e.buf = e.buf[:0]
+
+	frame := binary.BigEndian.AppendUint32(flag, uint32(len(sealed)))
+	if _, err := e.w.Write(frame); err != nil {
+		return err
+	}
+	_, err := e.w.Write(sealed)
+	return err
+}
+
+type decryptReader struct {
+	r       io.Reader
+	gcm     cipher.AEAD
+	prefix  []byte
+	counter uint64
+	plain   []byte
+	done    bool
+}
+
+// isEncrypted reports whether r starts with the encrypted snapshot header, without
+// consuming it.
+func isEncrypted(r *bufio.Reader) bool {
+	magic, err := r.Peek(len(encryptedMagic))
+	return err == nil && string(magic) == encryptedMagic
+}
+
+// newDecryptReader reads the snapshot header from r and returns a reader of the plaintext.
+func newDecryptReader(r io.Reader, passphrase string) (io.Reader, error) {
+	header := make([]byte, len(encryptedMagic)+1+4+saltSize+noncePrefixSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, fmt.Errorf("%w: read header: %w", ErrInvalidSnapshot, err)
+	}
+	if string(header[:len(encryptedMagic)]) != encryptedMagic {
+		return nil, fmt.Errorf("%w: not an encrypted snapshot", ErrInvalidSnapshot)
+	}
+	rest := header[len(encryptedMagic):]
+	if rest[0] != encryptedVersion {
+		return nil, fmt.Errorf("%w: unsupported encryption version %d", ErrInvalidSnapshot, rest[0])
+	}
+	iterations := binary.BigEndian.Uint32(rest[1:5])
+	if iterations == 0 || iterations > maxIterations {
+		return nil, fmt.Errorf("%w: invalid key derivation iterations %d", ErrInvalidSnapshot, iterations)
+	}
+	salt, prefix := rest[5:5+saltSize], rest[5+saltSize:]
+
+	gcm, err := newGCM(deriveKey(passphrase, salt, int(iterations)))
+	if err != nil {
+		return nil, err
+	}
+	return &decryptReader{r: r, gcm: gcm, prefix: prefix}, nil
+}
+
+func (d *decryptReader) Read(p []byte) (int, error) {
+	for len(d.plain) == 0 {
+		if d.done {
+			return 0, io.EOF
+		}
+		if err := d.open(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, d.plain)
+	d.plain = d.plain[n:]
+	return n, nil
+}
+
+func (d *decryptReader) open() error {
+	var frame [5]byte
+	if _, err := io.ReadFull(d.r, frame[:]); err != nil {
+		return fmt.Errorf("%w: snapshot is truncated", ErrDecryptFailed)
+	}
+	flag, size := frame[:1], binary.BigEndian.Uint32(frame[1:])
+	if flag[0] > 1 || size > chunkSize+uint32(d.gcm.Overhead()) {
+		return fmt.Errorf("%w: invalid chunk", ErrDecryptFailed)
+	}
+	sealed := make([]byte, size)
+	if _, err := io.ReadFull(d.r, sealed); err != nil {
+		return fmt.Errorf("%w: snapshot is truncated", ErrDecryptFailed)
+	}
+	plain, err := d.gcm.Open(sealed[:0], chunkNonce(d.gcm, d.prefix, d.counter), sealed, flag)
+	if err != nil {
+		return fmt.Errorf("%w: wrong passphrase or corrupted snapshot", ErrDecryptFailed)
+	}
+	d.counter++
+	d.plain = plain
+	if flag[0] == 1 {
+		d.done = true
+		var extra [1]byte
+		if n, _ := d.r.Read(extra[:]); n > 0 {
+			return fmt.Errorf("%w: data after the final chunk", ErrDecryptFailed)
+		}
+	}
+	return nil
+}
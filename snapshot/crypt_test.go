@@ -0,0 +1,67 @@
+// Copyright 2025 Kiruba Sankar Swaminathan. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package snapshot
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDeriveKeyIsPBKDF2SHA256 pins the key derivation of existing snapshots to a published
+// PBKDF2-HMAC-SHA256 test vector.
+func TestDeriveKeyIsPBKDF2SHA256(t *testing.T) {
+	key := deriveKey("password", []byte("salt"), 4096)
+	assert.Equal(t, "c5e478d59288c841aa530db6845c4c8d962893a001ce4e11a4963873aa98134a", hex.EncodeToString(key))
+}
+
+func encryptForTest(t *testing.T, plaintext []byte) []byte {
+	t.Helper()
+	orig := passphraseIterations
+	passphraseIterations = 1000
+	t.Cleanup(func() { passphraseIterations = orig })
+
+	var sealed bytes.Buffer
+	w, err := newEncryptWriter(&sealed, testPassphrase)
+	require.NoError(t, err)
+	_, err = w.Write(plaintext)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return sealed.Bytes()
+}
+
+func TestEncryptRoundTripAcrossChunks(t *testing.T) {
+	plaintext := bytes.Repeat([]byte("maand"), chunkSize/2)
+	sealed := encryptForTest(t, plaintext)
+
+	r := bufio.NewReader(bytes.NewReader(sealed))
+	require.True(t, isEncrypted(r))
+	plain, err := newDecryptReader(r, testPassphrase)
+	require.NoError(t, err)
+	got, err := io.ReadAll(plain)
+	require.NoError(t, err)
+	assert.Equal(t, plaintext, got)
+}
+
+func TestDecryptDetectsDroppedFinalChunk(t *testing.T) {
+	plaintext := bytes.Repeat([]byte{7}, chunkSize)
+	sealed := encryptForTest(t, plaintext)
+	// The final chunk is empty: its frame and GCM tag are the last 5+16 bytes.
+	truncated := sealed[:len(sealed)-21]
+
+	plain, err := newDecryptReader(bytes.NewReader(truncated), testPassphrase)
+	require.NoError(t, err)
+	_, err = io.ReadAll(plain)
+	require.ErrorIs(t, err, ErrDecryptFailed)
+}
+
+func TestIsEncryptedPlainArchive(t *testing.T) {
+	assert.False(t, isEncrypted(bufio.NewReader(bytes.NewReader([]byte{0x1f, 0x8b, 8, 0, 0, 0, 0, 0}))))
+}
@@ -0,0 +1,20 @@
+// Copyright 2025 Kiruba Sankar Swaminathan. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package snapshot
+
+import "errors"
+
+var (
+	// ErrInvalidSnapshot is returned when an archive is malformed or does not match its manifest.
+	ErrInvalidSnapshot = errors.New("invalid bucket snapshot")
+	// ErrDecryptFailed is returned for a wrong passphrase or a corrupted encrypted snapshot.
+	ErrDecryptFailed = errors.New("failed to decrypt bucket snapshot")
+	// ErrPassphraseRequired is returned when secrets are exported or imported without a passphrase.
+	ErrPassphraseRequired = errors.New("passphrase required")
+	// ErrBucketExists is returned when import would overwrite an existing bucket.
+	ErrBucketExists = errors.New("bucket already exists")
+	// ErrFingerprintMismatch is returned when the CA or kv.key differs from the snapshot's.
+	ErrFingerprintMismatch = errors.New("fingerprint mismatch")
+)
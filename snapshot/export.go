@@ -0,0 +1,258 @@
+// Copyright 2025 Kiruba Sankar Swaminathan. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package snapshot
+
+import (
+	"archive/tar"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"maand/bucket"
+	"maand/data"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// ExportOptions configures Export.
+type ExportOptions struct {
+	// Out is the archive to write; it must not exist. Empty picks a name from the bucket ID.
+	Out string
+	// NoSecrets leaves secrets/ out of the snapshot. Import then needs the bucket's CA and
+	// kv.key already in place.
+	NoSecrets bool
+	// Passphrase encrypts the snapshot. Required unless NoSecrets is set.
+	Passphrase string
+}
+
+// Export writes the bucket to a zstd-compressed tar: an online copy of maand.db,
+// maand.conf, workspace/, logs/ and, unless NoSecrets is set, secrets/. A manifest records
+// the bucket ID, schema version, CA and kv.key fingerprints, and the SHA-256 of every
+// file. Snapshots with secrets are encrypted with the passphrase.
+func Export(opts ExportOptions) (err error) {
+	if !opts.NoSecrets && opts.Passphrase == "" {
+		return fmt.Errorf("%w: the snapshot includes secrets; set a passphrase or use --no-secrets", ErrPassphraseRequired)
+	}
+	if opts.Passphrase != "" && len(opts.Passphrase) < minPassphraseLength {
+		return fmt.Errorf("%w: passphrase must be at least %d characters", ErrPassphraseRequired, minPassphraseLength)
+	}
+
+	db, err := data.OpenDatabase(true)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	manifest := Manifest{FormatVersion: formatVersion, CreatedAt: time.Now().UTC(), Secrets: !opts.NoSecrets}
+	tx, err := db.Begin()
+	if err != nil {
+		return bucket.DatabaseError(err)
+	}
+	manifest.BucketID, err = data.GetBucketID(tx)
+	if err == nil {
+		manifest.SchemaVersion, _, err = data.SchemaStatus(tx)
+	}
+	_ = tx.Rollback()
+	if err != nil {
+		return err
+	}
+
+	if manifest.CAFingerprint, err = caFingerprint(bucket.SecretLocation); err != nil {
+		return err
+	}
+	if manifest.KVKeyFingerprint, err = kvKeyFingerprint(bucket.SecretLocation); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(bucket.TempLocation, 0o755); err != nil {
+		return bucket.UnexpectedError(err)
+	}
+	scratchDir, err := os.MkdirTemp(bucket.TempLocation, "export-")
+	if err != nil {
+		return bucket.UnexpectedError(err)
+	}
+	defer func() {
+		_ = os.RemoveAll(scratchDir)
+	}()
+	databaseCopy := path.Join(scratchDir, "maand.db")
+	if err := data.CopyDatabase(db, databaseCopy); err != nil {
+		return err
+	}
+
+	out := opts.Out
+	if out == "" {
+		out = fmt.Sprintf("maand-%s-%s.tar.zst", manifest.BucketID, manifest.CreatedAt.Format("20060102T150405Z"))
+	}
+	outFile, err := os.OpenFile(out, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return bucket.UnexpectedError(err)
+	}
+	defer func() {
+		_ = outFile.Close()
+		if err != nil {
+			_ = os.Remove(out)
+		}
+	}()
+	outInfo, err := outFile.Stat()
+	if err != nil {
+		return bucket.UnexpectedError(err)
+	}
+
+	var archive io.Writer = outFile
+	var encrypter io.WriteCloser
+	if opts.Passphrase != "" {
+		if encrypter, err = newEncryptWriter(outFile, opts.Passphrase); err != nil {
+			return bucket.UnexpectedError(err)
+		}
+		archive = encrypter
+	}
+	zw, err := zstd.NewWriter(archive)
+	if err != nil {
+		return bucket.UnexpectedError(err)
+	}
+	tw := tar.NewWriter(zw)
+
+	w := &archiveWriter{tw: tw, root: bucket.Location, skip: outInfo}
+	if err := w.addFile(databasePath, databaseCopy); err != nil {
+		return err
+	}
+	roots := exportRoots
+	if !opts.NoSecrets {
+		roots = append([]string{secretRoot}, roots...)
+	}
+	for _, root := range roots {
+		if err := w.addTree(root); err != nil {
+			return err
+		}
+	}
+	manifest.Files = w.files
+
+	encoded, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return bucket.UnexpectedError(err)
+	}
+	header := &tar.Header{Name: manifestName, Mode: 0o644, Size: int64(len(encoded)), ModTime: manifest.CreatedAt}
+	if err := tw.WriteHeader(header); err != nil {
+		return bucket.UnexpectedError(err)
+	}
+	if _, err := tw.Write(encoded); err != nil {
+		return bucket.UnexpectedError(err)
+	}
+	if err := tw.Close(); err != nil {
+		return bucket.UnexpectedError(err)
+	}
+	if err := zw.Close(); err != nil {
+		return bucket.UnexpectedError(err)
+	}
+	if encrypter != nil {
+		if err := encrypter.Close(); err != nil {
+			return bucket.UnexpectedError(err)
+		}
+	}
+	if err := outFile.Sync(); err != nil {
+		return bucket.UnexpectedError(err)
+	}
+
+	if !bucket.QuietCLIOutput() {
+		fmt.Printf("exported bucket %s (schema version %d, %d files) to %s\n",
+			manifest.BucketID, manifest.SchemaVersion, len(manifest.Files), out)
+		if opts.NoSecrets {
+			fmt.Println("secrets not included: import needs this bucket's secrets/ directory in place")
+		}
+	}
+	return nil
+}
+
+// archiveWriter adds bucket files to a tar and records them for the manifest.
+type archiveWriter struct {
+	tw    *tar.Writer
+	root  string
+	skip  os.FileInfo
+	files []File
+}
+
+// addTree adds rel (a file or directory under the bucket root) and everything below it.
+// A missing root is skipped; logs/ may not exist yet.
+func (w *archiveWriter) addTree(rel string) error {
+	top := path.Join(w.root, rel)
+	if _, err := os.Lstat(top); os.IsNotExist(err) {
+		return nil
+	}
+	return filepath.WalkDir(top, func(hostPath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return bucket.UnexpectedError(err)
+		}
+		relPath, err := filepath.Rel(w.root, hostPath)
+		if err != nil {
+			return bucket.UnexpectedError(err)
+		}
+		relPath = filepath.ToSlash(relPath)
+
+		info, err := entry.Info()
+		if err != nil {
+			return bucket.UnexpectedError(err)
+		}
+		switch {
+		case os.SameFile(info, w.skip):
+			return nil
+		case entry.IsDir():
+			w.files = append(w.files, File{Path: relPath, Mode: info.Mode().Perm(), Dir: true})
+			return w.writeHeader(&tar.Header{
+				Typeflag: tar.TypeDir, Name: relPath + "/", Mode: int64(info.Mode().Perm()), ModTime: info.ModTime(),
+			})
+		case entry.Type().IsRegular():
+			return w.addFile(relPath, hostPath)
+		default:
+			return bucket.UnexpectedError(fmt.Errorf("%s: only regular files and directories can be exported", relPath))
+		}
+	})
+}
+
+// addFile archives hostPath as rel, hashing it as it is copied.
+func (w *archiveWriter) addFile(rel, hostPath string) error {
+	file, err := os.Open(hostPath)
+	if err != nil {
+		return bucket.UnexpectedError(err)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+	info, err := file.Stat()
+	if err != nil {
+		return bucket.UnexpectedError(err)
+	}
+
+	header := &tar.Header{
+		Typeflag: tar.TypeReg, Name: rel, Mode: int64(info.Mode().Perm()), Size: info.Size(), ModTime: info.ModTime(),
+	}
+	if err := w.writeHeader(header); err != nil {
+		return err
+	}
+	digest := sha256.New()
+	// A log appended to while exporting is cut at the size in its header.
+	if _, err := io.CopyN(w.tw, io.TeeReader(file, digest), info.Size()); err != nil {
+		return bucket.UnexpectedError(fmt.Errorf("%s: %w", rel, err))
+	}
+	w.files = append(w.files, File{
+		Path: rel, Mode: info.Mode().Perm(), Size: info.Size(), SHA256: hex.EncodeToString(digest.Sum(nil)),
+	})
+	return nil
+}
+
+func (w *archiveWriter) writeHeader(header *tar.Header) error {
+	if err := w.tw.WriteHeader(header); err != nil {
+		return bucket.UnexpectedError(fmt.Errorf("%s: %w", header.Name, err))
+	}
+	return nil
+}
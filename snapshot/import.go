@@ -0,0 +1,346 @@
+// Copyright 2025 Kiruba Sankar Swaminathan. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package snapshot
+
+import (
+	"archive/tar"
+	"bufio"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"maand/bucket"
+	"maand/data"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// maxManifestSize bounds the manifest entry read into memory.
+const maxManifestSize = 64 << 20
+
+// ImportOptions configures Import.
+type ImportOptions struct {
+	// In is the archive written by Export.
+	In string
+	// Passphrase decrypts an encrypted snapshot.
+	Passphrase string
+}
+
+// Import restores a snapshot into the bucket location, which must not hold a maand.db.
+// Every file is checked against the manifest, and the restored maand.db must carry the
+// manifest's bucket_id and a schema this binary can run. The CA and kv.key, from the
+// snapshot or already in secrets/ when it was exported without them, must match the
+// recorded fingerprints. Nothing is moved into place until every check passes.
+func Import(opts ImportOptions) error {
+	if data.DatabaseExists() {
+		return fmt.Errorf("%w: %s exists; import into an empty directory", ErrBucketExists, data.DatabasePath())
+	}
+
+	in, err := os.Open(opts.In)
+	if err != nil {
+		return bucket.UnexpectedError(err)
+	}
+	defer func() {
+		_ = in.Close()
+	}()
+
+	buffered := bufio.NewReader(in)
+	var archive io.Reader = buffered
+	if isEncrypted(buffered) {
+		if opts.Passphrase == "" {
+			return fmt.Errorf("%w: %s is encrypted", ErrPassphraseRequired, opts.In)
+		}
+		if archive, err = newDecryptReader(buffered, opts.Passphrase); err != nil {
+			return err
+		}
+	}
+	zr, err := zstd.NewReader(archive)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidSnapshot, err)
+	}
+	defer zr.Close()
+
+	if err := os.MkdirAll(bucket.Location, 0o755); err != nil {
+		return bucket.UnexpectedError(err)
+	}
+	staging, err := os.MkdirTemp(bucket.Location, ".maand-import-")
+	if err != nil {
+		return bucket.UnexpectedError(err)
+	}
+	defer func() {
+		_ = os.RemoveAll(staging)
+	}()
+
+	manifest, extracted, err := extract(tar.NewReader(zr), staging)
+	if err != nil {
+		return err
+	}
+	if err := verifyFiles(manifest, extracted); err != nil {
+		return err
+	}
+	if err := verifyDatabase(manifest, path.Join(staging, databasePath)); err != nil {
+		return err
+	}
+	secretsDir := bucket.SecretLocation
+	if manifest.Secrets {
+		secretsDir = path.Join(staging, secretRoot)
+	}
+	if err := verifyFingerprints(manifest, secretsDir); err != nil {
+		return err
+	}
+
+	if err := install(manifest, staging); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(bucket.TempLocation, 0o755); err != nil {
+		return bucket.UnexpectedError(err)
+	}
+
+	if !bucket.QuietCLIOutput() {
+		fmt.Printf("imported bucket %s (schema version %d, %d files)\n",
+			manifest.BucketID, manifest.SchemaVersion, len(manifest.Files))
+		if errors.Is(data.CheckSchemaVersion(), bucket.ErrSchemaUpgradeRequired) {
+			fmt.Println("run maand init to upgrade the schema")
+		}
+	}
+	return nil
+}
+
+// extract writes the archive entries under staging and returns the manifest and what was
+// extracted, by path.
+func extract(tr *tar.Reader, staging string) (Manifest, map[string]File, error) {
+	var manifest Manifest
+	var haveManifest bool
+	extracted := make(map[string]File)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return Manifest{}, nil, fmt.Errorf("%w: %w", ErrInvalidSnapshot, err)
+		}
+
+		name := strings.TrimSuffix(header.Name, "/")
+		if name == manifestName && header.Typeflag == tar.TypeReg {
+			if err := json.NewDecoder(io.LimitReader(tr, maxManifestSize)).Decode(&manifest); err != nil {
+				return Manifest{}, nil, fmt.Errorf("%w: %s: %w", ErrInvalidSnapshot, manifestName, err)
+			}
+			haveManifest = true
+			continue
+		}
+		if !allowedPath(name) {
+			return Manifest{}, nil, fmt.Errorf("%w: unexpected entry %q", ErrInvalidSnapshot, header.Name)
+		}
+		if _, ok := extracted[name]; ok {
+			return Manifest{}, nil, fmt.Errorf("%w: duplicate entry %q", ErrInvalidSnapshot, name)
+		}
+
+		target := path.Join(staging, name)
+		mode := os.FileMode(header.Mode).Perm()
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0o700); err != nil {
+				return Manifest{}, nil, bucket.UnexpectedError(err)
+			}
+			extracted[name] = File{Path: name, Mode: mode, Dir: true}
+		case tar.TypeReg:
+			file, err := extractFile(tr, target, name, mode)
+			if err != nil {
+				return Manifest{}, nil, err
+			}
+			extracted[name] = file
+		default:
+			return Manifest{}, nil, fmt.Errorf("%w: %s is not a regular file or directory", ErrInvalidSnapshot, name)
+		}
+	}
+	if !haveManifest {
+		return Manifest{}, nil, fmt.Errorf("%w: %s is missing", ErrInvalidSnapshot, manifestName)
+	}
+	if manifest.FormatVersion != formatVersion {
+		return Manifest{}, nil, fmt.Errorf("%w: unsupported format version %d", ErrInvalidSnapshot, manifest.FormatVersion)
+	}
+	return manifest, extracted, nil
+}
+
+func extractFile(r io.Reader, target, name string, mode os.FileMode) (File, error) {
+	if err := os.MkdirAll(path.Dir(target), 0o700); err != nil {
+		return File{}, bucket.UnexpectedError(err)
+	}
+	file, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return File{}, bucket.UnexpectedError(err)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	digest := sha256.New()
+	size, err := io.Copy(io.MultiWriter(file, digest), r)
+	if err != nil {
+		return File{}, fmt.Errorf("%w: %s: %w", ErrInvalidSnapshot, name, err)
+	}
+	if err := file.Close(); err != nil {
+		return File{}, bucket.UnexpectedError(err)
+	}
+	return File{Path: name, Mode: mode, Size: size, SHA256: hex.EncodeToString(digest.Sum(nil))}, nil
+}
+
+// allowedPath reports whether name is a clean relative path export could have written.
+func allowedPath(name string) bool {
+	if name == "" || path.IsAbs(name) || path.Clean(name) != name || strings.HasPrefix(name, "..") {
+		return false
+	}
+	if name == databasePath {
+		return true
+	}
+	for _, root := range append([]string{secretRoot}, exportRoots...) {
+		if name == root || strings.HasPrefix(name, root+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// verifyFiles checks the extracted entries are exactly the manifest's.
+func verifyFiles(manifest Manifest, extracted map[string]File) error {
+	if len(manifest.Files) != len(extracted) {
+		return fmt.Errorf("%w: manifest lists %d files, archive holds %d", ErrInvalidSnapshot, len(manifest.Files), len(extracted))
+	}
+	haveDatabase := false
+	for _, want := range manifest.Files {
+		got, ok := extracted[want.Path]
+		if !ok {
+			return fmt.Errorf("%w: %s is missing", ErrInvalidSnapshot, want.Path)
+		}
+		if got != want {
+			return fmt.Errorf("%w: %s does not match the manifest", ErrInvalidSnapshot, want.Path)
+		}
+		if !manifest.Secrets && (want.Path == secretRoot || strings.HasPrefix(want.Path, secretRoot+"/")) {
+			return fmt.Errorf("%w: %s in a snapshot without secrets", ErrInvalidSnapshot, want.Path)
+		}
+		haveDatabase = haveDatabase || want.Path == databasePath
+	}
+	if !haveDatabase {
+		return fmt.Errorf("%w: %s is missing", ErrInvalidSnapshot, databasePath)
+	}
+	return nil
+}
+
+// verifyDatabase checks the restored maand.db is the manifest's bucket at the manifest's
+// schema version, and not newer than this binary.
+func verifyDatabase(manifest Manifest, dbPath string) error {
+	db, err := sql.Open("sqlite3", "file:"+dbPath+"?mode=ro")
+	if err != nil {
+		return bucket.DatabaseError(err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+	tx, err := db.Begin()
+	if err != nil {
+		return bucket.DatabaseError(err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	bucketID, err := data.GetBucketID(tx)
+	if err != nil {
+		return err
+	}
+	if bucketID != manifest.BucketID {
+		return fmt.Errorf("%w: maand.db bucket_id %s, manifest %s", ErrInvalidSnapshot, bucketID, manifest.BucketID)
+	}
+	version, statuses, err := data.SchemaStatus(tx)
+	if err != nil {
+		return err
+	}
+	if version != manifest.SchemaVersion {
+		return fmt.Errorf("%w: maand.db schema version %d, manifest %d", ErrInvalidSnapshot, version, manifest.SchemaVersion)
+	}
+	for _, status := range statuses {
+		if status.State == "unknown" {
+			return fmt.Errorf("%w: snapshot schema migration %d (%s) is unknown; upgrade the maand binary",
+				bucket.ErrSchemaTooNew, status.Version, status.Description)
+		}
+	}
+	return nil
+}
+
+// verifyFingerprints checks ca.crt and kv.key in secretsDir against the manifest.
+func verifyFingerprints(manifest Manifest, secretsDir string) error {
+	caPrint, err := caFingerprint(secretsDir)
+	if err != nil {
+		return fmt.Errorf("%w: ca.crt: %w", ErrFingerprintMismatch, err)
+	}
+	if caPrint != manifest.CAFingerprint {
+		return fmt.Errorf("%w: ca.crt is %s, snapshot recorded %s", ErrFingerprintMismatch, caPrint, manifest.CAFingerprint)
+	}
+	kvPrint, err := kvKeyFingerprint(secretsDir)
+	if err != nil {
+		return fmt.Errorf("%w: kv.key: %w", ErrFingerprintMismatch, err)
+	}
+	if kvPrint != manifest.KVKeyFingerprint {
+		return fmt.Errorf("%w: kv.key is %s, snapshot recorded %s", ErrFingerprintMismatch, kvPrint, manifest.KVKeyFingerprint)
+	}
+	return nil
+}
+
+// install moves the staged files into the bucket. It fails before moving anything if a
+// file would be overwritten; existing directories are reused.
+func install(manifest Manifest, staging string) error {
+	for _, file := range manifest.Files {
+		if file.Dir {
+			continue
+		}
+		if _, err := os.Lstat(path.Join(bucket.Location, file.Path)); err == nil {
+			return fmt.Errorf("%w: %s exists", ErrBucketExists, file.Path)
+		} else if !os.IsNotExist(err) {
+			return bucket.UnexpectedError(err)
+		}
+	}
+
+	// maand.db goes last: until it is in place the directory is not a bucket, and a failed
+	// import can be retried after clearing what was moved.
+	var database File
+	for _, file := range manifest.Files {
+		if file.Path == databasePath {
+			database = file
+			continue
+		}
+		if err := installFile(file, staging); err != nil {
+			return err
+		}
+	}
+	return installFile(database, staging)
+}
+
+func installFile(file File, staging string) error {
+	target := path.Join(bucket.Location, file.Path)
+	if file.Dir {
+		if err := os.MkdirAll(target, file.Mode); err != nil {
+			return bucket.UnexpectedError(err)
+		}
+		return nil
+	}
+	if err := os.MkdirAll(path.Dir(target), 0o755); err != nil {
+		return bucket.UnexpectedError(err)
+	}
+	if err := os.Chmod(path.Join(staging, file.Path), file.Mode); err != nil {
+		return bucket.UnexpectedError(err)
+	}
+	if err := os.Rename(path.Join(staging, file.Path), target); err != nil {
+		return bucket.UnexpectedError(err)
+	}
+	return nil
+}
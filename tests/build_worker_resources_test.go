@@ -158,3 +158,32 @@ func TestWorkerResourcesKVUpdated(t *testing.T) {
 	value, _ = GetKey("maand/worker/10.0.0.1", "worker_cpu_mhz")
 	assert.Equal(t, "200", value)
 }
+
+func TestWorkerOvercommit(t *testing.T) {
+	_ = os.RemoveAll(bucket.Location)
+
+	err := initialize.Execute()
+	assert.NoError(t, err)
+
+	confPath := path.Join(bucket.Location, "maand.conf")
+	conf, err := os.ReadFile(confPath)
+	assert.NoError(t, err)
+	conf = append(conf, []byte("\n[overcommit]\ncpu = 1.5\n\n[overcommit.labels.batch]\ncpu = 2.0\n")...)
+	assert.NoError(t, os.WriteFile(confPath, conf, os.ModePerm))
+
+	_ = os.WriteFile(path.Join(bucket.WorkspaceLocation, "workers.json"), []byte(`[
+		{ "host": "10.0.0.1", "cpu": "1000" },
+		{ "host": "10.0.0.2", "cpu": "1000", "labels": ["batch"] }
+	]`), os.ModePerm)
+
+	err = executeBuildErr(t)
+	assert.NoError(t, err)
+
+	var memoryOvercommit, cpuOvercommit float64
+	GetRowValues("SELECT memory_overcommit, cpu_overcommit FROM worker WHERE worker_ip = '10.0.0.1'", &memoryOvercommit, &cpuOvercommit)
+	assert.Equal(t, 1.0, memoryOvercommit)
+	assert.Equal(t, 1.5, cpuOvercommit)
+
+	GetRowValues("SELECT cpu_overcommit FROM worker WHERE worker_ip = '10.0.0.2'", &cpuOvercommit)
+	assert.Equal(t, 2.0, cpuOvercommit)
+}
@@ -112,7 +112,7 @@ func syncWorkerLabelsFromJobs(tb testing.TB) {
 }
 
 func shouldSyncJobNameLabel(manifest workspace.Manifest) bool {
-	return len(manifest.Selectors) == 0
+	return len(manifest.Selectors) == 0 && manifest.Selector == ""
 }
 
 func labelStrings(raw any) []string {
@@ -192,7 +192,7 @@ func mustViewExists(t *testing.T, viewName string) bool {
 
 func requireLatestSchema(t *testing.T) {
 	t.Helper()
-	require.Equal(t, data.LatestSchemaVersion, mustGetSchemaVersion(t))
+	require.NoError(t, data.CheckSchemaVersion())
 }
 
 func runBuild(t *testing.T) {
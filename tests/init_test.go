@@ -5,6 +5,7 @@
 package tests
 
 import (
+	"database/sql"
 	"os"
 	"path"
 	"testing"
@@ -116,3 +117,30 @@ func TestInitIncompleteCAFails(t *testing.T) {
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "CA is incomplete")
 }
+
+func TestInitUpgradeSkipsBackupWithoutPendingMigrations(t *testing.T) {
+	initFreshBucket(t)
+	upgradeBucket(t)
+
+	assert.NoDirExists(t, path.Join(bucket.Location, "data", "backups"))
+}
+
+func TestInitBacksUpDatabaseBeforeMigrating(t *testing.T) {
+	initFreshBucket(t)
+	originalBucketID := mustGetBucketID(t)
+
+	// A bucket from before migrations were recorded.
+	require.NoError(t, withDatabase(func(db *sql.DB) error {
+		_, err := db.Exec(`DROP TABLE schema_migrations`)
+		return err
+	}))
+
+	upgradeBucket(t)
+
+	backups, err := os.ReadDir(path.Join(bucket.Location, "data", "backups"))
+	require.NoError(t, err)
+	require.Len(t, backups, 1)
+	assert.True(t, mustTableExists(t, "schema_migrations"))
+	assert.Equal(t, originalBucketID, mustGetBucketID(t))
+	requireLatestSchema(t)
+}
@@ -5,10 +5,13 @@
 package tests
 
 import (
+	"os"
+	"path"
 	"testing"
 
 	"maand/bucket"
 	"maand/data"
+	"maand/initialize"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -73,6 +76,26 @@ func TestUpgradeBucketFromLegacySchemaVersion(t *testing.T) {
 	require.NoError(t, data.CheckSchemaVersion())
 }
 
+func TestInitRefusesSchemaFromNewerBinary(t *testing.T) {
+	initFreshBucket(t)
+
+	db, err := data.OpenDatabase(true)
+	require.NoError(t, err)
+	_, err = db.Exec(`INSERT INTO schema_migrations (version, description, applied_at)
+		VALUES (99, 'from a newer binary', '2026-01-01T00:00:00Z')`)
+	require.NoError(t, err)
+	_, err = db.Exec(`UPDATE schema_version SET version = 99`)
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	err = initialize.Execute()
+	require.ErrorIs(t, err, bucket.ErrSchemaTooNew)
+
+	assert.Equal(t, 99, mustGetSchemaVersion(t))
+	_, err = os.Stat(path.Join(bucket.Location, "data", "backups"))
+	assert.True(t, os.IsNotExist(err), "init must not back up maand.db before refusing")
+}
+
 func TestCatJobsViewQueryableAfterUpgrade(t *testing.T) {
 	initFreshBucket(t)
 	writeWorkersJSON(t, `[{"host":"10.0.0.1"}]`)
@@ -81,7 +81,6 @@ func HashDirectoryTree(root string) (DirectoryTree, error) {
 
 	sort.Strings(filePaths)
 
-	fileHashes := make(map[string]string, len(filePaths))
 	relFiles := make(map[string]string, len(filePaths))
 	var (
 		wg       sync.WaitGroup
@@ -119,7 +118,6 @@ func HashDirectoryTree(root string) (DirectoryTree, error) {
 			}
 			rel = filepath.ToSlash(rel)
 			mu.Lock()
-			fileHashes[path] = digest
 			relFiles[rel] = digest
 			mu.Unlock()
 		}(filePath)
@@ -129,18 +127,28 @@ func HashDirectoryTree(root string) (DirectoryTree, error) {
 		return DirectoryTree{}, firstErr
 	}
 
-	combined := md5.New()
-	for _, filePath := range filePaths {
-		if _, err := combined.Write([]byte(fileHashes[filePath])); err != nil {
-			return DirectoryTree{}, err
-		}
-	}
 	return DirectoryTree{
-		Aggregate: hex.EncodeToString(combined.Sum(nil)),
+		Aggregate: AggregateFileHashes(relFiles),
 		Files:     relFiles,
 	}, nil
 }
 
+// AggregateFileHashes returns the MD5 over file digests ordered by relative path, the same
+// aggregate HashDirectoryTree computes for a directory holding those files.
+func AggregateFileHashes(files map[string]string) string {
+	paths := make([]string, 0, len(files))
+	for rel := range files {
+		paths = append(paths, rel)
+	}
+	sort.Strings(paths)
+
+	combined := md5.New()
+	for _, rel := range paths {
+		combined.Write([]byte(files[rel]))
+	}
+	return hex.EncodeToString(combined.Sum(nil))
+}
+
 // CalculateFileMD5 is deprecated; use HashFile.
 func CalculateFileMD5(filePath string) (string, error) {
 	return HashFile(filePath)
@@ -0,0 +1,322 @@
+// Copyright 2025 Kiruba Sankar Swaminathan. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package utils
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"gopkg.in/yaml.v3"
+)
+
+// OutputFormat selects how a command renders its result: a table for people, or JSON,
+// YAML, CSV or TSV for scripts.
+type OutputFormat string
+
+const (
+	OutputTable OutputFormat = "table"
+	OutputJSON  OutputFormat = "json"
+	OutputYAML  OutputFormat = "yaml"
+	OutputCSV   OutputFormat = "csv"
+	OutputTSV   OutputFormat = "tsv"
+)
+
+// OutputFormats lists the formats accepted by ParseOutputFormat.
+var OutputFormats = []OutputFormat{OutputTable, OutputJSON, OutputYAML, OutputCSV, OutputTSV}
+
+// ParseOutputFormat validates an --output flag value.
+func ParseOutputFormat(value string) (OutputFormat, error) {
+	names := make([]string, 0, len(OutputFormats))
+	for _, format := range OutputFormats {
+		if string(format) == value {
+			return format, nil
+		}
+		names = append(names, string(format))
+	}
+	return "", fmt.Errorf("--output: unknown format %q (one of %s)", value, strings.Join(names, ", "))
+}
+
+// PrintRows renders rows to stdout, or nowhere when CLI output is quiet.
+func PrintRows(format OutputFormat, columns []string, rows [][]any) error {
+	return WriteRows(tableOutput(), format, columns, rows)
+}
+
+// WriteRows renders rows under columns to w. JSON and YAML are a list of objects keyed by
+// column in column order, with SQL NULL as null; the other formats print NULL as an empty
+// cell.
+func WriteRows(w io.Writer, format OutputFormat, columns []string, rows [][]any) error {
+	switch format {
+	case OutputTable:
+		writeTable(w, columns, rows)
+		return nil
+	case OutputJSON:
+		return writeJSON(w, columns, rows)
+	case OutputYAML:
+		return writeYAML(w, columns, rows)
+	case OutputCSV:
+		return writeDelimited(w, ',', columns, rows)
+	case OutputTSV:
+		return writeDelimited(w, '\t', columns, rows)
+	}
+	_, err := ParseOutputFormat(string(format))
+	return err
+}
+
+func writeTable(w io.Writer, columns []string, rows [][]any) {
+	header := make(table.Row, len(columns))
+	for i, column := range columns {
+		header[i] = column
+	}
+	t := table.NewWriter()
+	t.SetOutputMirror(w)
+	t.AppendHeader(header)
+	t.SetStyle(table.StyleRounded)
+	for _, row := range rows {
+		cells := make(table.Row, len(row))
+		for i, value := range row {
+			cells[i] = cellText(value)
+		}
+		t.AppendRow(cells)
+	}
+	t.Render()
+}
+
+func writeJSON(w io.Writer, columns []string, rows [][]any) error {
+	// Objects are built by hand: encoding a map would sort the keys.
+	var buf bytes.Buffer
+	buf.WriteByte('[')
+	for i, row := range rows {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteByte('{')
+		for j, value := range row {
+			if j > 0 {
+				buf.WriteByte(',')
+			}
+			key, err := json.Marshal(columns[j])
+			if err != nil {
+				return err
+			}
+			encoded, err := json.Marshal(jsonValue(value))
+			if err != nil {
+				return fmt.Errorf("column %s: %w", columns[j], err)
+			}
+			buf.Write(key)
+			buf.WriteByte(':')
+			buf.Write(encoded)
+		}
+		buf.WriteByte('}')
+	}
+	buf.WriteByte(']')
+
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, buf.Bytes(), "", "  "); err != nil {
+		return err
+	}
+	indented.WriteByte('\n')
+	_, err := indented.WriteTo(w)
+	return err
+}
+
+func writeYAML(w io.Writer, columns []string, rows [][]any) error {
+	list := &yaml.Node{Kind: yaml.SequenceNode}
+	if len(rows) == 0 {
+		list.Style = yaml.FlowStyle
+	}
+	for _, row := range rows {
+		object := &yaml.Node{Kind: yaml.MappingNode}
+		for i, value := range row {
+			encoded := &yaml.Node{}
+			if err := encoded.Encode(jsonValue(value)); err != nil {
+				return fmt.Errorf("column %s: %w", columns[i], err)
+			}
+			object.Content = append(object.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: columns[i]}, encoded)
+		}
+		list.Content = append(list.Content, object)
+	}
+	return encodeYAML(w, list)
+}
+
+func encodeYAML(w io.Writer, value any) error {
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(value); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+func writeDelimited(w io.Writer, comma rune, columns []string, rows [][]any) error {
+	writer := csv.NewWriter(w)
+	writer.Comma = comma
+	if err := writer.Write(columns); err != nil {
+		return err
+	}
+	record := make([]string, len(columns))
+	for _, row := range rows {
+		for i, value := range row {
+			record[i] = cellText(value)
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// jsonValue keeps numbers and NULL typed and turns database text into strings.
+func jsonValue(value any) any {
+	switch v := value.(type) {
+	case []byte:
+		return string(v)
+	case time.Time:
+		return v.UTC().Format(time.RFC3339)
+	}
+	return value
+}
+
+func cellText(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case []byte:
+		return string(v)
+	case []string:
+		return strings.Join(v, ",")
+	case time.Time:
+		return v.UTC().Format(time.RFC3339)
+	}
+	return fmt.Sprint(value)
+}
+
+// PrintRecords renders records to stdout, or nowhere when CLI output is quiet.
+func PrintRecords(format OutputFormat, records any) error {
+	return WriteRecords(tableOutput(), format, records)
+}
+
+// WriteRecords renders records, a slice of structs or a single struct, to w. Keys and
+// columns are the fields' json tags, in field order. JSON and YAML encode the value as is,
+// so a single struct becomes one object and nested structs are kept; the table, CSV and
+// TSV formats need flat fields and write a single struct as one row.
+func WriteRecords(w io.Writer, format OutputFormat, records any) error {
+	value := reflect.ValueOf(records)
+	switch format {
+	case OutputJSON:
+		encoded, err := json.MarshalIndent(nonNilRecords(records), "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(append(encoded, '\n'))
+		return err
+	case OutputYAML:
+		return writeRecordsYAML(w, records)
+	}
+
+	if value.Kind() == reflect.Struct {
+		list := reflect.MakeSlice(reflect.SliceOf(value.Type()), 1, 1)
+		list.Index(0).Set(value)
+		value = list
+	}
+	columns, rows, err := recordRows(value)
+	if err != nil {
+		return err
+	}
+	return WriteRows(w, format, columns, rows)
+}
+
+// nonNilRecords turns a nil slice into an empty one, so it encodes as [] and not null.
+func nonNilRecords(records any) any {
+	value := reflect.ValueOf(records)
+	if value.Kind() == reflect.Slice && value.IsNil() {
+		return reflect.MakeSlice(value.Type(), 0, 0).Interface()
+	}
+	return records
+}
+
+// writeRecordsYAML encodes records through their JSON form, so YAML keys are the json tags
+// in the same order.
+func writeRecordsYAML(w io.Writer, records any) error {
+	encoded, err := json.Marshal(nonNilRecords(records))
+	if err != nil {
+		return err
+	}
+	var document yaml.Node
+	if err := yaml.Unmarshal(encoded, &document); err != nil {
+		return err
+	}
+	plainYAMLStyle(&document)
+	return encodeYAML(w, &document)
+}
+
+// plainYAMLStyle drops the JSON quoting and flow style, leaving the encoder to quote only
+// the strings that need it. Empty lists stay [] and empty objects {}.
+func plainYAMLStyle(node *yaml.Node) {
+	if len(node.Content) > 0 || node.Kind == yaml.ScalarNode {
+		node.Style = 0
+	}
+	for _, child := range node.Content {
+		plainYAMLStyle(child)
+	}
+}
+
+// recordRows flattens a slice of structs into columns named by json tag and rows of
+// field values.
+func recordRows(list reflect.Value) ([]string, [][]any, error) {
+	if list.Kind() != reflect.Slice || list.Type().Elem().Kind() != reflect.Struct {
+		return nil, nil, fmt.Errorf("%s is not a list of records", list.Type())
+	}
+	recordType := list.Type().Elem()
+	var columns []string
+	var fields []int
+	for i := 0; i < recordType.NumField(); i++ {
+		field := recordType.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if !field.IsExported() || name == "-" {
+			continue
+		}
+		if !flatField(field.Type) {
+			return nil, nil, fmt.Errorf("%s.%s cannot be written as a table column; use json or yaml", recordType.Name(), field.Name)
+		}
+		if name == "" {
+			name = field.Name
+		}
+		columns = append(columns, name)
+		fields = append(fields, i)
+	}
+
+	rows := make([][]any, list.Len())
+	for i := range rows {
+		record := list.Index(i)
+		row := make([]any, len(fields))
+		for j, field := range fields {
+			row[j] = record.Field(field).Interface()
+		}
+		rows[i] = row
+	}
+	return columns, rows, nil
+}
+
+func flatField(fieldType reflect.Type) bool {
+	if fieldType == reflect.TypeOf(time.Time{}) || fieldType == reflect.TypeOf([]string(nil)) {
+		return true
+	}
+	switch fieldType.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
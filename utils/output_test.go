@@ -0,0 +1,152 @@
+// Copyright 2025 Kiruba Sankar Swaminathan. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package utils
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	outputColumns = []string{"job", "zone", "allocations", "note"}
+	outputRows    = [][]any{
+		{"api", []byte("a"), int64(2), nil},
+		{"web", "b,c", 1.5, "say \"hi\""},
+	}
+)
+
+func TestWriteRowsJSONKeepsColumnOrderAndNulls(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, WriteRows(&out, OutputJSON, outputColumns, outputRows))
+	assert.Equal(t, `[
+  {
+    "job": "api",
+    "zone": "a",
+    "allocations": 2,
+    "note": null
+  },
+  {
+    "job": "web",
+    "zone": "b,c",
+    "allocations": 1.5,
+    "note": "say \"hi\""
+  }
+]
+`, out.String())
+
+	out.Reset()
+	require.NoError(t, WriteRows(&out, OutputJSON, outputColumns, nil))
+	assert.Equal(t, "[]\n", out.String())
+}
+
+func TestWriteRowsYAML(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, WriteRows(&out, OutputYAML, outputColumns, outputRows))
+	assert.Equal(t, `- job: api
+  zone: a
+  allocations: 2
+  note: null
+- job: web
+  zone: b,c
+  allocations: 1.5
+  note: say "hi"
+`, out.String())
+
+	out.Reset()
+	require.NoError(t, WriteRows(&out, OutputYAML, outputColumns, nil))
+	assert.Equal(t, "[]\n", out.String())
+}
+
+func TestWriteRowsDelimited(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, WriteRows(&out, OutputCSV, outputColumns, outputRows))
+	assert.Equal(t, "job,zone,allocations,note\napi,a,2,\nweb,\"b,c\",1.5,\"say \"\"hi\"\"\"\n", out.String())
+
+	out.Reset()
+	require.NoError(t, WriteRows(&out, OutputTSV, outputColumns, outputRows))
+	assert.Equal(t, "job\tzone\tallocations\tnote\napi\ta\t2\t\nweb\tb,c\t1.5\t\"say \"\"hi\"\"\"\n", out.String())
+}
+
+func TestWriteRowsTable(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, WriteRows(&out, OutputTable, outputColumns, outputRows))
+	assert.Contains(t, out.String(), "ALLOCATIONS")
+	assert.Contains(t, out.String(), "b,c")
+}
+
+func TestParseOutputFormat(t *testing.T) {
+	format, err := ParseOutputFormat("tsv")
+	require.NoError(t, err)
+	assert.Equal(t, OutputTSV, format)
+
+	_, err = ParseOutputFormat("xml")
+	require.ErrorContains(t, err, "table, json, yaml, csv, tsv")
+	require.Error(t, WriteRows(&bytes.Buffer{}, OutputFormat("xml"), nil, nil))
+}
+
+type outputRecord struct {
+	Job       string   `json:"job"`
+	Labels    []string `json:"labels"`
+	Count     int      `json:"count"`
+	Version   string   `json:"version"`
+	Disabled  bool     `json:"disabled"`
+	internal  string
+	Reference string `json:"-"`
+}
+
+func TestWriteRecords(t *testing.T) {
+	records := []outputRecord{
+		{Job: "api", Labels: []string{"web", "edge"}, Count: 2, Version: "1.0", internal: "x", Reference: "y"},
+		{Job: "db", Labels: []string{}, Version: "true", Disabled: true},
+	}
+
+	var out bytes.Buffer
+	require.NoError(t, WriteRecords(&out, OutputYAML, records))
+	assert.Equal(t, `- job: api
+  labels:
+    - web
+    - edge
+  count: 2
+  version: "1.0"
+  disabled: false
+- job: db
+  labels: []
+  count: 0
+  version: "true"
+  disabled: true
+`, out.String())
+
+	out.Reset()
+	require.NoError(t, WriteRecords(&out, OutputCSV, records))
+	assert.Equal(t, "job,labels,count,version,disabled\napi,\"web,edge\",2,1.0,false\ndb,,0,true,true\n", out.String())
+
+	out.Reset()
+	require.NoError(t, WriteRecords(&out, OutputJSON, []outputRecord(nil)))
+	assert.Equal(t, "[]\n", out.String())
+
+	out.Reset()
+	require.NoError(t, WriteRecords(&out, OutputYAML, []outputRecord(nil)))
+	assert.Equal(t, "[]\n", out.String())
+
+	out.Reset()
+	require.NoError(t, WriteRecords(&out, OutputJSON, records[1]))
+	assert.Equal(t, "{\n  \"job\": \"db\",\n  \"labels\": [],\n  \"count\": 0,\n  \"version\": \"true\",\n  \"disabled\": true\n}\n", out.String())
+
+	out.Reset()
+	require.NoError(t, WriteRecords(&out, OutputTSV, records[0]))
+	assert.Equal(t, "job\tlabels\tcount\tversion\tdisabled\napi\tweb,edge\t2\t1.0\tfalse\n", out.String())
+}
+
+func TestWriteRecordsRejectsNestedFieldsInCSV(t *testing.T) {
+	type report struct {
+		Records []outputRecord `json:"records"`
+	}
+	err := WriteRecords(&bytes.Buffer{}, OutputCSV, report{})
+	require.ErrorContains(t, err, "use json or yaml")
+	require.NoError(t, WriteRecords(&bytes.Buffer{}, OutputYAML, report{}))
+}
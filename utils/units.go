@@ -31,6 +31,16 @@ var cpuUnitToMHz = map[string]float64{
 
 // ParseMemoryMB parses strings like "512MB", "1 GB", or plain megabyte numbers.
 func ParseMemoryMB(raw string) (float64, error) {
+	return parseSizeMB(raw, "memory")
+}
+
+// ParseDiskMB parses disk sizes the way ParseMemoryMB parses memory: "100GB", "1 TB", or
+// plain megabyte numbers.
+func ParseDiskMB(raw string) (float64, error) {
+	return parseSizeMB(raw, "disk")
+}
+
+func parseSizeMB(raw, kind string) (float64, error) {
 	raw = strings.TrimSpace(raw)
 	if raw == "" {
 		return 0, nil
@@ -42,7 +52,7 @@ func ParseMemoryMB(raw string) (float64, error) {
 
 	matches := memorySizePattern.FindStringSubmatch(raw)
 	if matches == nil {
-		return 0, fmt.Errorf("invalid memory size %q", raw)
+		return 0, fmt.Errorf("invalid %s size %q", kind, raw)
 	}
 
 	size, err := strconv.ParseFloat(matches[1], 64)
@@ -57,7 +67,7 @@ func ParseMemoryMB(raw string) (float64, error) {
 
 	multiplier, ok := memoryUnitToMB[unit]
 	if !ok {
-		return 0, fmt.Errorf("unsupported memory unit %q", unit)
+		return 0, fmt.Errorf("unsupported %s unit %q", kind, unit)
 	}
 	return size * multiplier, nil
 }
@@ -76,8 +76,8 @@ func controlSocketName(workerIP string) string {
 	return replacer.Replace(workerIP)
 }
 
-// shellQuote wraps a value for a single-quoted POSIX shell argument.
-func shellQuote(value string) string {
+// ShellQuote wraps a value for a single-quoted POSIX shell argument.
+func ShellQuote(value string) string {
 	return "'" + strings.ReplaceAll(value, "'", `'"'"'`) + "'"
 }
 
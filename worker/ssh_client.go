@@ -86,7 +86,7 @@ func remoteShellOutput(workerIP, command string, timeout time.Duration) (string,
 
 	remoteCommand := command
 	if useSudo {
-		remoteCommand = fmt.Sprintf("sudo -E bash -lc %s", shellQuote(command))
+		remoteCommand = fmt.Sprintf("sudo -E bash -lc %s", ShellQuote(command))
 	}
 
 	args := SSHClientArgs(keyPath, workerIP)
@@ -6,10 +6,10 @@ import (
 )
 
 func TestShellQuote(t *testing.T) {
-	if shellQuote("abc") != "'abc'" {
-		t.Fatalf("got %q", shellQuote("abc"))
+	if ShellQuote("abc") != "'abc'" {
+		t.Fatalf("got %q", ShellQuote("abc"))
 	}
-	quoted := shellQuote("it's fine")
+	quoted := ShellQuote("it's fine")
 	if !strings.Contains(quoted, `'`) {
 		t.Fatalf("expected escaped quote: %q", quoted)
 	}
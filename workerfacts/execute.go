@@ -215,9 +215,12 @@ func previewChanges(workers []workspace.WorkerRecord, updates map[string]workspa
 			NewMemory: workspace.FormatMemoryMB(facts.MemoryMB),
 			OldCPU:    w.CPU,
 			NewCPU:    workspace.FormatCPUMHz(facts.CPUMHz),
+			OldDisk:   w.Disk,
+			NewDisk:   workspace.FormatDiskMB(facts.DiskMB),
 		}
 		if !memoryChanged(change.OldMemory, change.NewMemory) &&
-			!cpuChanged(change.OldCPU, change.NewCPU) {
+			!cpuChanged(change.OldCPU, change.NewCPU) &&
+			!diskChanged(change.OldDisk, change.NewDisk) {
 			continue
 		}
 		changes = append(changes, change)
@@ -234,6 +237,15 @@ func memoryChanged(left, right string) bool {
 	return strings.TrimSpace(left) != strings.TrimSpace(right)
 }
 
+func diskChanged(left, right string) bool {
+	leftMB, leftErr := utils.ParseDiskMB(left)
+	rightMB, rightErr := utils.ParseDiskMB(right)
+	if leftErr == nil && rightErr == nil {
+		return int(leftMB+0.5) != int(rightMB+0.5)
+	}
+	return strings.TrimSpace(left) != strings.TrimSpace(right)
+}
+
 func cpuChanged(left, right string) bool {
 	leftMHz, leftErr := utils.ParseCPUMHz(left)
 	rightMHz, rightErr := utils.ParseCPUMHz(right)
@@ -252,6 +264,7 @@ func printChanges(changes []workspace.WorkerFactsChange, dryRun bool) {
 		prefix = "would update"
 	}
 	for _, change := range changes {
-		fmt.Printf("%s %s memory %q -> %q cpu %q -> %q\n", prefix, change.Host, change.OldMemory, change.NewMemory, change.OldCPU, change.NewCPU)
+		fmt.Printf("%s %s memory %q -> %q cpu %q -> %q disk %q -> %q\n", prefix, change.Host,
+			change.OldMemory, change.NewMemory, change.OldCPU, change.NewCPU, change.OldDisk, change.NewDisk)
 	}
 }
@@ -26,12 +26,21 @@ if [ -z "${mhz}" ] || [ "${mhz}" = 0 ]; then
   exit 1
 fi
 cpu_mhz=$(( cpus * mhz ))
-printf 'MEMORY_MB=%s\nCPU_MHZ=%s\n' "${mem_mb}" "${cpu_mhz}"
+disk_dir=/opt/worker
+while [ ! -d "${disk_dir}" ]; do
+  disk_dir=$(dirname "${disk_dir}")
+done
+disk_mb=$(df -Pm "${disk_dir}" | awk 'NR==2 {print $4}')
+if [ -d /opt/worker ]; then
+  disk_mb=$(( disk_mb + $(du -sxm /opt/worker | awk '{print $1}') ))
+fi
+printf 'MEMORY_MB=%s\nCPU_MHZ=%s\nDISK_MB=%s\n' "${mem_mb}" "${cpu_mhz}" "${disk_mb}"
 `
 
 type probeOutput struct {
 	MemoryMB float64
 	CPUMHz   float64
+	DiskMB   float64
 }
 
 func parseProbeOutput(output string) (probeOutput, error) {
@@ -72,9 +81,22 @@ func parseProbeOutput(output string) (probeOutput, error) {
 		return probeOutput{}, fmt.Errorf("probe reported invalid cpu %s", cpuRaw)
 	}
 
+	diskRaw, ok := values["DISK_MB"]
+	if !ok || diskRaw == "" {
+		return probeOutput{}, fmt.Errorf("probe output missing DISK_MB")
+	}
+	diskMB, err := strconv.ParseFloat(diskRaw, 64)
+	if err != nil {
+		return probeOutput{}, fmt.Errorf("parse DISK_MB %q: %w", diskRaw, err)
+	}
+	if diskMB < 0 {
+		return probeOutput{}, fmt.Errorf("probe reported invalid disk %s", diskRaw)
+	}
+
 	return probeOutput{
 		MemoryMB: memoryMB,
 		CPUMHz:   cpuMHz,
+		DiskMB:   diskMB,
 	}, nil
 }
 
@@ -82,5 +104,6 @@ func toWorkerFacts(probe probeOutput) workspace.WorkerFacts {
 	return workspace.WorkerFacts{
 		MemoryMB: probe.MemoryMB,
 		CPUMHz:   probe.CPUMHz,
+		DiskMB:   probe.DiskMB,
 	}
 }
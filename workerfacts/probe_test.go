@@ -14,10 +14,11 @@ import (
 )
 
 func TestParseProbeOutput(t *testing.T) {
-	output, err := parseProbeOutput("MEMORY_MB=8192\nCPU_MHZ=9600\n")
+	output, err := parseProbeOutput("MEMORY_MB=8192\nCPU_MHZ=9600\nDISK_MB=51200\n")
 	require.NoError(t, err)
 	assert.Equal(t, 8192.0, output.MemoryMB)
 	assert.Equal(t, 9600.0, output.CPUMHz)
+	assert.Equal(t, 51200.0, output.DiskMB)
 }
 
 func TestParseProbeOutputMissingFields(t *testing.T) {
@@ -26,6 +27,26 @@ func TestParseProbeOutputMissingFields(t *testing.T) {
 
 	_, err = parseProbeOutput("MEMORY_MB=0\nCPU_MHZ=2400\n")
 	assert.Error(t, err)
+
+	_, err = parseProbeOutput("MEMORY_MB=1024\nCPU_MHZ=2400\n")
+	assert.Error(t, err)
+
+	_, err = parseProbeOutput("MEMORY_MB=1024\nCPU_MHZ=2400\nDISK_MB=-1\n")
+	assert.Error(t, err)
+}
+
+func TestPreviewChangesDisk(t *testing.T) {
+	changes := previewChanges(
+		[]workspace.WorkerRecord{
+			{Host: "10.0.0.1", Memory: "8192 mb", CPU: "9600 mhz"},
+		},
+		map[string]workspace.WorkerFacts{
+			"10.0.0.1": {MemoryMB: 8192, CPUMHz: 9600, DiskMB: 51200},
+		},
+	)
+	require.Len(t, changes, 1)
+	assert.Equal(t, "", changes[0].OldDisk)
+	assert.Equal(t, "51200 mb", changes[0].NewDisk)
 }
 
 func TestPreviewChanges(t *testing.T) {
@@ -19,6 +19,10 @@ func TestValidateHealthCheck(t *testing.T) {
 				Min string `json:"min"`
 				Max string `json:"max"`
 			} `json:"cpu"`
+			Disk struct {
+				Min string `json:"min"`
+				Max string `json:"max"`
+			} `json:"disk"`
 			Ports ManifestPorts `json:"ports"`
 		}{
 			Ports: ManifestPorts{"api_port": ManifestPortBinding{}},
@@ -59,6 +63,10 @@ func TestValidateHealthCheck_allowsManifestAndCommand(t *testing.T) {
 				Min string `json:"min"`
 				Max string `json:"max"`
 			} `json:"cpu"`
+			Disk struct {
+				Min string `json:"min"`
+				Max string `json:"max"`
+			} `json:"disk"`
 			Ports ManifestPorts `json:"ports"`
 		}{
 			Ports: ManifestPorts{"api_port": ManifestPortBinding{}},
@@ -36,6 +36,22 @@ func (m Manifest) MaxCPU() string {
 	return m.Resources.CPU.Max
 }
 
+// MinDisk returns the manifest minimum disk requirement with a default.
+func (m Manifest) MinDisk() string {
+	if m.Resources.Disk.Min == "" {
+		return "0 mb"
+	}
+	return m.Resources.Disk.Min
+}
+
+// MaxDisk returns the manifest maximum disk requirement with a default.
+func (m Manifest) MaxDisk() string {
+	if m.Resources.Disk.Max == "" {
+		return "0 mb"
+	}
+	return m.Resources.Disk.Max
+}
+
 // JobVersion returns the manifest version with a default.
 func (m Manifest) JobVersion() string {
 	if m.Version == "" {
@@ -66,6 +82,15 @@ func (m Manifest) MinRequiredAllocations() int {
 	return m.MinAllocationsCount
 }
 
+// AllocationCount returns the manifest count: how many matching workers run the job
+// (0 = every matching worker).
+func (m Manifest) AllocationCount() int {
+	if m.Count < 0 {
+		return 0
+	}
+	return m.Count
+}
+
 // PlacementSelectors returns worker labels required to place this job.
 // When manifest selectors are set, only those are used; otherwise the job name is the selector.
 func PlacementSelectors(jobName string, manifest Manifest) []string {
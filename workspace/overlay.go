@@ -0,0 +1,267 @@
+// Copyright 2025 Kiruba Sankar Swaminathan. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package workspace
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path"
+	"strings"
+
+	"maand/bucket"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+const (
+	manifestFileName      = "manifest.json"
+	manifestPatchFileName = "manifest.patch.json"
+	jobVarsFileName       = "vars.toml"
+)
+
+// Sources of an effective job file (maand cat jobs --effective).
+const (
+	SourceBase    = "base"    // workspace/jobs/<job>/<path>
+	SourceOverlay = "overlay" // overlays/<name>/jobs/<job>/<path> replaces or adds the file
+	SourcePatched = "patched" // manifest.json with the overlay manifest.patch.json applied
+	SourceMerged  = "merged"  // vars.toml with the overlay keys over the base keys
+)
+
+// overlayRejectedPaths cannot appear in an overlay job directory: manifest.json is changed
+// through manifest.patch.json, _modules and _prometheus are read from the base job
+// directory, and data, logs and bin are reserved on workers.
+var overlayRejectedPaths = []string{manifestFileName, "_modules", "_prometheus", "data", "logs", "bin"}
+
+// OverlayName returns the workspace_overlay of maand.conf, or "" when none is selected.
+func OverlayName() (string, error) {
+	conf, err := bucket.GetMaandConf()
+	if err != nil {
+		return "", err
+	}
+	name := strings.TrimSpace(conf.WorkspaceOverlay)
+	if name != "" && (name == "." || name == ".." || strings.ContainsAny(name, `/\`)) {
+		return "", fmt.Errorf("%w: workspace_overlay %q must be a directory name under workspace/overlays", bucket.ErrInvalidMaandConf, name)
+	}
+	return name, nil
+}
+
+// OverlayLocation returns workspace/overlays/<name> for the selected overlay, or "" when
+// none is selected.
+func OverlayLocation() (string, error) {
+	name, err := OverlayName()
+	if err != nil || name == "" {
+		return "", err
+	}
+	return path.Join(bucket.WorkspaceLocation, "overlays", name), nil
+}
+
+// overlayFile returns the overlay's copy of rel, a path relative to the workspace, when an
+// overlay is selected and holds rel as a regular file.
+func overlayFile(rel string) (string, bool) {
+	location, err := OverlayLocation()
+	if err != nil || location == "" {
+		return "", false
+	}
+	overlayPath := path.Join(location, rel)
+	info, err := os.Stat(overlayPath)
+	if err != nil || info.IsDir() {
+		return "", false
+	}
+	return overlayPath, true
+}
+
+// ValidateOverlay checks the selected overlay: its directory exists, every job in it exists
+// in workspace/jobs, it holds none of the rejected paths, and each manifest.patch.json is a
+// JSON object.
+func ValidateOverlay() error {
+	location, err := OverlayLocation()
+	if err != nil || location == "" {
+		return err
+	}
+	info, err := os.Stat(location)
+	if err != nil || !info.IsDir() {
+		return fmt.Errorf("%w: workspace_overlay: %s is not a directory", bucket.ErrInvalidMaandConf, location)
+	}
+
+	entries, err := os.ReadDir(path.Join(location, "jobs"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("%w: %w", bucket.ErrUnexpectedError, err)
+	}
+	for _, entry := range entries {
+		jobName := entry.Name()
+		if !entry.IsDir() {
+			return fmt.Errorf("%w: overlay %s: jobs/%s is not a job directory", bucket.ErrInvalidJob, location, jobName)
+		}
+		if _, err := os.Stat(JobFilePath(path.Join(jobName, manifestFileName))); err != nil {
+			return fmt.Errorf("%w: overlay %s: job %s not found in workspace/jobs", bucket.ErrInvalidJob, location, jobName)
+		}
+		for _, rejected := range overlayRejectedPaths {
+			if _, err := os.Stat(path.Join(location, "jobs", jobName, rejected)); err == nil {
+				return fmt.Errorf("%w: overlay %s: job %s can't override %s", bucket.ErrInvalidJob, location, jobName, rejected)
+			}
+		}
+		if _, err := ReadJobFile(path.Join(jobName, manifestFileName)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadJobFile returns the effective content of jobs/<rel>: the overlay's copy when it has
+// one, manifest.json with manifest.patch.json applied, vars.toml with the overlay keys
+// merged over the base keys, and the base file otherwise.
+func ReadJobFile(rel string) ([]byte, error) {
+	jobName, name, _ := strings.Cut(rel, "/")
+	switch name {
+	case manifestFileName:
+		base, err := os.ReadFile(JobFilePath(rel))
+		if err != nil {
+			return nil, err
+		}
+		patchPath, ok := overlayFile(path.Join("jobs", jobName, manifestPatchFileName))
+		if !ok {
+			return base, nil
+		}
+		return patchManifest(jobName, base, patchPath)
+	case jobVarsFileName:
+		overlayPath, ok := overlayFile(path.Join("jobs", rel))
+		if !ok {
+			return os.ReadFile(JobFilePath(rel))
+		}
+		base, err := os.ReadFile(JobFilePath(rel))
+		if errors.Is(err, fs.ErrNotExist) {
+			return os.ReadFile(overlayPath)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return mergeJobVars(rel, base, overlayPath)
+	}
+	if overlayPath, ok := overlayFile(path.Join("jobs", rel)); ok {
+		return os.ReadFile(overlayPath)
+	}
+	return os.ReadFile(JobFilePath(rel))
+}
+
+// patchManifest applies the JSON merge patch (RFC 7386) at patchPath to base: objects are
+// merged key by key, null removes a key, and any other value replaces it.
+func patchManifest(jobName string, base []byte, patchPath string) ([]byte, error) {
+	patchData, err := os.ReadFile(patchPath)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", bucket.ErrUnexpectedError, err)
+	}
+	var manifest, patch any
+	if err := decodeJSON(base, &manifest); err != nil {
+		return nil, fmt.Errorf("%w: job %s\n%w", bucket.ErrInvalidManifest, jobName, err)
+	}
+	if err := decodeJSON(patchData, &patch); err != nil {
+		return nil, fmt.Errorf("%w: job %s %s: %w", bucket.ErrInvalidManifest, jobName, patchPath, err)
+	}
+	if _, ok := patch.(map[string]any); !ok {
+		return nil, fmt.Errorf("%w: job %s %s: patch must be a JSON object", bucket.ErrInvalidManifest, jobName, patchPath)
+	}
+	patched, err := json.MarshalIndent(mergePatch(manifest, patch), "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", bucket.ErrUnexpectedError, err)
+	}
+	return append(patched, '\n'), nil
+}
+
+// decodeJSON keeps numbers as written in the manifest instead of turning them into floats.
+func decodeJSON(data []byte, value any) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(value)
+}
+
+func mergePatch(target, patch any) any {
+	patchObject, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	targetObject, ok := target.(map[string]any)
+	if !ok {
+		targetObject = map[string]any{}
+	}
+	for key, value := range patchObject {
+		if value == nil {
+			delete(targetObject, key)
+			continue
+		}
+		targetObject[key] = mergePatch(targetObject[key], value)
+	}
+	return targetObject
+}
+
+func mergeJobVars(rel string, base []byte, overlayPath string) ([]byte, error) {
+	overlay, err := os.ReadFile(overlayPath)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", bucket.ErrUnexpectedError, err)
+	}
+	vars := make(map[string]string)
+	if err := toml.Unmarshal(base, &vars); err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", bucket.ErrInvalidJobVars, JobFilePath(rel), err)
+	}
+	overrides := make(map[string]string)
+	if err := toml.Unmarshal(overlay, &overrides); err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", bucket.ErrInvalidJobVars, overlayPath, err)
+	}
+	maps.Copy(vars, overrides)
+	merged, err := toml.Marshal(vars)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", bucket.ErrUnexpectedError, err)
+	}
+	return merged, nil
+}
+
+// EffectiveJobFile is one file build reads for a job, and where its content comes from.
+type EffectiveJobFile struct {
+	Path   string
+	Source string
+}
+
+// EffectiveJobFiles lists the files of jobName as build sees them, in walk order.
+func EffectiveJobFiles(jobName string) ([]EffectiveJobFile, error) {
+	var files []EffectiveJobFile
+	err := WalkJobFiles(jobName, func(rel string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		files = append(files, EffectiveJobFile{Path: rel, Source: jobFileSource(rel)})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+func jobFileSource(rel string) string {
+	jobName, name, _ := strings.Cut(rel, "/")
+	_, baseErr := os.Stat(JobFilePath(rel))
+	_, inOverlay := overlayFile(path.Join("jobs", rel))
+	switch {
+	case name == manifestFileName:
+		if _, ok := overlayFile(path.Join("jobs", jobName, manifestPatchFileName)); ok {
+			return SourcePatched
+		}
+		return SourceBase
+	case baseErr != nil:
+		return SourceOverlay
+	case !inOverlay:
+		return SourceBase
+	case name == jobVarsFileName:
+		return SourceMerged
+	}
+	return SourceOverlay
+}
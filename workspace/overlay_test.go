@@ -0,0 +1,186 @@
+// Copyright 2025 Kiruba Sankar Swaminathan. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package workspace
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"maand/bucket"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupOverlayWorkspace writes a base job "api" and selects the "prod" overlay.
+func setupOverlayWorkspace(t *testing.T) {
+	t.Helper()
+	root := t.TempDir()
+	origLocation, origWorkspace := bucket.Location, bucket.WorkspaceLocation
+	bucket.Location = root
+	bucket.WorkspaceLocation = path.Join(root, "workspace")
+	t.Cleanup(func() {
+		bucket.Location, bucket.WorkspaceLocation = origLocation, origWorkspace
+	})
+	require.NoError(t, os.WriteFile(path.Join(root, "maand.conf"), []byte("workspace_overlay = \"prod\"\n"), 0o644))
+
+	writeOverlayTestFile(t, "jobs/api/manifest.json", `{"version": "1.0.0", "selectors": ["web"], "resources": {"memory": {"min": "128", "max": "256"}}}`)
+	writeOverlayTestFile(t, "jobs/api/vars.toml", "level = \"debug\"\nregion = \"local\"\n")
+	writeOverlayTestFile(t, "jobs/api/Makefile", "base")
+	writeOverlayTestFile(t, "jobs/api/config/app.conf", "base")
+	require.NoError(t, os.MkdirAll(path.Join(bucket.WorkspaceLocation, "overlays", "prod"), 0o755))
+}
+
+func writeOverlayTestFile(t *testing.T, rel, content string) {
+	t.Helper()
+	filePath := path.Join(bucket.WorkspaceLocation, rel)
+	require.NoError(t, os.MkdirAll(path.Dir(filePath), 0o755))
+	require.NoError(t, os.WriteFile(filePath, []byte(content), 0o644))
+}
+
+func TestReadJobFileWithoutOverlay(t *testing.T) {
+	setupOverlayWorkspace(t)
+	require.NoError(t, os.Remove(path.Join(bucket.Location, "maand.conf")))
+	writeOverlayTestFile(t, "overlays/prod/jobs/api/Makefile", "prod")
+
+	content, err := ReadJobFile("api/Makefile")
+	require.NoError(t, err)
+	assert.Equal(t, "base", string(content))
+}
+
+func TestReadJobFilePatchesManifest(t *testing.T) {
+	setupOverlayWorkspace(t)
+	writeOverlayTestFile(t, "overlays/prod/jobs/api/manifest.patch.json",
+		`{"version": "1.1.0", "selectors": ["prod"], "resources": {"memory": {"max": "1024"}, "cpu": {"min": "100"}}}`)
+
+	manifest, err := Default().GetJobManifest("api")
+	require.NoError(t, err)
+	assert.Equal(t, "1.1.0", manifest.Version)
+	assert.Equal(t, []string{"prod"}, manifest.Selectors)
+	assert.Equal(t, "128", manifest.Resources.Memory.Min)
+	assert.Equal(t, "1024", manifest.Resources.Memory.Max)
+	assert.Equal(t, "100", manifest.Resources.CPU.Min)
+}
+
+func TestReadJobFilePatchNullRemovesKey(t *testing.T) {
+	setupOverlayWorkspace(t)
+	writeOverlayTestFile(t, "overlays/prod/jobs/api/manifest.patch.json", `{"resources": null}`)
+
+	content, err := ReadJobFile("api/manifest.json")
+	require.NoError(t, err)
+	assert.NotContains(t, string(content), "resources")
+	assert.Contains(t, string(content), `"version": "1.0.0"`)
+}
+
+func TestReadJobFileRejectsNonObjectPatch(t *testing.T) {
+	setupOverlayWorkspace(t)
+	writeOverlayTestFile(t, "overlays/prod/jobs/api/manifest.patch.json", `["prod"]`)
+
+	_, err := Default().GetJobManifest("api")
+	assert.ErrorIs(t, err, bucket.ErrInvalidManifest)
+}
+
+func TestReadJobFileMergesVars(t *testing.T) {
+	setupOverlayWorkspace(t)
+	writeOverlayTestFile(t, "overlays/prod/jobs/api/vars.toml", "level = \"info\"\nreplicas = \"3\"\n")
+
+	content, err := ReadJobFile("api/vars.toml")
+	require.NoError(t, err)
+	vars := make(map[string]string)
+	require.NoError(t, toml.Unmarshal(content, &vars))
+	assert.Equal(t, map[string]string{"level": "info", "region": "local", "replicas": "3"}, vars)
+}
+
+func TestEffectiveJobFilesSources(t *testing.T) {
+	setupOverlayWorkspace(t)
+	writeOverlayTestFile(t, "overlays/prod/jobs/api/manifest.patch.json", `{"version": "1.1.0"}`)
+	writeOverlayTestFile(t, "overlays/prod/jobs/api/vars.toml", "level = \"info\"\n")
+	writeOverlayTestFile(t, "overlays/prod/jobs/api/config/app.conf", "prod")
+	writeOverlayTestFile(t, "overlays/prod/jobs/api/config/tls.conf", "prod")
+
+	files, err := EffectiveJobFiles("api")
+	require.NoError(t, err)
+	sources := make(map[string]string)
+	for _, file := range files {
+		sources[file.Path] = file.Source
+	}
+	assert.Equal(t, map[string]string{
+		"api/manifest.json":   SourcePatched,
+		"api/vars.toml":       SourceMerged,
+		"api/Makefile":        SourceBase,
+		"api/config/app.conf": SourceOverlay,
+		"api/config/tls.conf": SourceOverlay,
+	}, sources)
+
+	content, err := ReadJobFile("api/config/app.conf")
+	require.NoError(t, err)
+	assert.Equal(t, "prod", string(content))
+}
+
+func TestOverlayWorkersJSONReplacesBase(t *testing.T) {
+	setupOverlayWorkspace(t)
+	writeOverlayTestFile(t, "workers.json", `[{"host":"10.0.0.1"}]`)
+	writeOverlayTestFile(t, "overlays/prod/workers.json", `[{"host":"10.1.0.1"},{"host":"10.1.0.2"}]`)
+
+	workers, err := Default().GetWorkers()
+	require.NoError(t, err)
+	require.Len(t, workers, 2)
+	assert.Equal(t, "10.1.0.1", workers[0].Host)
+}
+
+func TestApplyWorkerFactsWritesBaseWorkersJSONWithOverlay(t *testing.T) {
+	setupOverlayWorkspace(t)
+	overlayWorkers := `[{"host":"10.0.0.1","memory":"1 mb"}]`
+	writeOverlayTestFile(t, "workers.json", `[{"host":"10.0.0.1"}]`)
+	writeOverlayTestFile(t, "overlays/prod/workers.json", overlayWorkers)
+
+	changes, err := ApplyWorkerFacts(map[string]WorkerFacts{
+		"10.0.0.1": {MemoryMB: 2048, CPUMHz: 3000, DiskMB: 10240},
+	})
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	assert.Empty(t, changes[0].OldMemory, "facts edit the base record, not the overlay one")
+
+	base, err := readWorkersJSON(path.Join(bucket.WorkspaceLocation, "workers.json"))
+	require.NoError(t, err)
+	require.Len(t, base, 1)
+	assert.Equal(t, "2048 mb", base[0].Memory)
+
+	overlay, err := os.ReadFile(path.Join(bucket.WorkspaceLocation, "overlays", "prod", "workers.json"))
+	require.NoError(t, err)
+	assert.Equal(t, overlayWorkers, string(overlay))
+}
+
+func TestValidateOverlay(t *testing.T) {
+	cases := []struct {
+		name string
+		file string
+		want string
+	}{
+		{"unknown job", "overlays/prod/jobs/web/Makefile", "job web not found"},
+		{"manifest", "overlays/prod/jobs/api/manifest.json", "can't override manifest.json"},
+		{"modules", "overlays/prod/jobs/api/_modules/run.py", "can't override _modules"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			setupOverlayWorkspace(t)
+			writeOverlayTestFile(t, tc.file, "{}")
+
+			_, err := Default().GetJobs()
+			assert.ErrorIs(t, err, bucket.ErrInvalidJob)
+			assert.ErrorContains(t, err, tc.want)
+		})
+	}
+}
+
+func TestValidateOverlayRejectsMissingDirectory(t *testing.T) {
+	setupOverlayWorkspace(t)
+	require.NoError(t, os.WriteFile(path.Join(bucket.Location, "maand.conf"), []byte("workspace_overlay = \"staging\"\n"), 0o644))
+
+	_, err := Default().GetJobs()
+	assert.ErrorIs(t, err, bucket.ErrInvalidMaandConf)
+}
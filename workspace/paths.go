@@ -30,10 +30,37 @@ func GetJobFilePath(fpath string) string {
 	return JobFilePath(fpath)
 }
 
-// WalkJobFiles walks files for jobName under workspace/jobs/.
-// Skips .venv, venv, node_modules, and __pycache__ trees.
+// WalkJobFiles walks files for jobName under workspace/jobs/, then the files the selected
+// overlay adds under overlays/<name>/jobs/. Paths are relative to jobs/ either way; read
+// them with ReadJobFile. Skips .venv, venv, node_modules, and __pycache__ trees.
 func WalkJobFiles(jobName string, callback func(path string, d fs.DirEntry, err error) error) error {
-	return fs.WalkDir(os.DirFS(path.Join(bucket.WorkspaceLocation, "jobs")), jobName, func(rel string, d fs.DirEntry, err error) error {
+	seen := make(map[string]bool)
+	err := walkJobTree(path.Join(bucket.WorkspaceLocation, "jobs"), jobName, func(rel string, d fs.DirEntry, err error) error {
+		seen[rel] = true
+		return callback(rel, d, err)
+	})
+	if err != nil {
+		return err
+	}
+
+	location, err := OverlayLocation()
+	if err != nil || location == "" {
+		return err
+	}
+	overlayJobs := path.Join(location, "jobs")
+	if _, err := os.Stat(path.Join(overlayJobs, jobName)); err != nil {
+		return nil
+	}
+	return walkJobTree(overlayJobs, jobName, func(rel string, d fs.DirEntry, err error) error {
+		if seen[rel] || rel == path.Join(jobName, manifestPatchFileName) {
+			return nil
+		}
+		return callback(rel, d, err)
+	})
+}
+
+func walkJobTree(root, jobName string, callback func(path string, d fs.DirEntry, err error) error) error {
+	return fs.WalkDir(os.DirFS(root), jobName, func(rel string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
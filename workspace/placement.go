@@ -0,0 +1,133 @@
+// Copyright 2025 Kiruba Sankar Swaminathan. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package workspace
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"maand/bucket"
+)
+
+const (
+	// PlacementRuleHard rules restrict where a job is placed; build fails when one is broken.
+	PlacementRuleHard = "hard"
+	// PlacementRuleSoft rules are preferences used when picking workers for a count job.
+	PlacementRuleSoft = "soft"
+)
+
+// PlacementRule references another job in placement.affinity or placement.anti_affinity.
+type PlacementRule struct {
+	Job  string `json:"job"`
+	Mode string `json:"mode,omitempty"`
+}
+
+// Hard reports whether the rule must hold (mode hard, the default).
+func (r PlacementRule) Hard() bool {
+	return r.Mode != PlacementRuleSoft
+}
+
+// ManifestPlacement is the manifest.json placement section. Affinity places the job only
+// on workers that run the referenced job; anti-affinity keeps it off them.
+type ManifestPlacement struct {
+	Affinity     []PlacementRule `json:"affinity,omitempty"`
+	AntiAffinity []PlacementRule `json:"anti_affinity,omitempty"`
+}
+
+// Rules returns the affinity and anti-affinity rules in manifest order, flagged by kind.
+func (p *ManifestPlacement) Rules() []PlacementConstraint {
+	if p == nil {
+		return nil
+	}
+	rules := make([]PlacementConstraint, 0, len(p.Affinity)+len(p.AntiAffinity))
+	for _, rule := range p.Affinity {
+		rules = append(rules, PlacementConstraint{PlacementRule: rule})
+	}
+	for _, rule := range p.AntiAffinity {
+		rules = append(rules, PlacementConstraint{PlacementRule: rule, Anti: true})
+	}
+	return rules
+}
+
+// PlacementConstraint is an affinity (Anti false) or anti-affinity rule.
+type PlacementConstraint struct {
+	PlacementRule
+	Anti bool
+}
+
+// Kind returns the manifest field name of the constraint.
+func (c PlacementConstraint) Kind() string {
+	if c.Anti {
+		return "anti_affinity"
+	}
+	return "affinity"
+}
+
+// NormalizePlacement validates the placement section and fills in the default hard mode.
+// It returns nil when no rules are set.
+func NormalizePlacement(raw *ManifestPlacement) (*ManifestPlacement, error) {
+	if raw == nil || len(raw.Affinity)+len(raw.AntiAffinity) == 0 {
+		return nil, nil
+	}
+
+	seen := make(map[string]string)
+	normalize := func(kind string, rules []PlacementRule) ([]PlacementRule, error) {
+		normalized := make([]PlacementRule, 0, len(rules))
+		for _, rule := range rules {
+			rule.Job = strings.TrimSpace(rule.Job)
+			if rule.Job == "" {
+				return nil, fmt.Errorf("%w: placement.%s entries need a job", bucket.ErrInvalidManifest, kind)
+			}
+			switch rule.Mode {
+			case "":
+				rule.Mode = PlacementRuleHard
+			case PlacementRuleHard, PlacementRuleSoft:
+			default:
+				return nil, fmt.Errorf(
+					"%w: placement.%s job %s mode must be one of hard, soft (got %q)",
+					bucket.ErrInvalidManifest, kind, rule.Job, rule.Mode,
+				)
+			}
+			if previous, ok := seen[rule.Job]; ok {
+				return nil, fmt.Errorf(
+					"%w: placement.%s job %s is already listed in placement.%s",
+					bucket.ErrInvalidManifest, kind, rule.Job, previous,
+				)
+			}
+			seen[rule.Job] = kind
+			normalized = append(normalized, rule)
+		}
+		return normalized, nil
+	}
+
+	affinity, err := normalize("affinity", raw.Affinity)
+	if err != nil {
+		return nil, err
+	}
+	antiAffinity, err := normalize("anti_affinity", raw.AntiAffinity)
+	if err != nil {
+		return nil, err
+	}
+	return &ManifestPlacement{Affinity: affinity, AntiAffinity: antiAffinity}, nil
+}
+
+// ValidatePlacement checks the placement section of a job manifest against the workspace
+// job names.
+func ValidatePlacement(jobName string, manifest Manifest, jobNames []string) error {
+	placement, err := NormalizePlacement(manifest.Placement)
+	if err != nil {
+		return fmt.Errorf("%w: job %s %w", bucket.ErrInvalidManifest, jobName, err)
+	}
+	for _, rule := range placement.Rules() {
+		if rule.Job == jobName {
+			return fmt.Errorf("%w: job %s placement.%s cannot reference itself", bucket.ErrInvalidManifest, jobName, rule.Kind())
+		}
+		if !slices.Contains(jobNames, rule.Job) {
+			return fmt.Errorf("%w: job %s placement.%s references unknown job %s", bucket.ErrInvalidManifest, jobName, rule.Kind(), rule.Job)
+		}
+	}
+	return nil
+}
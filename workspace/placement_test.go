@@ -0,0 +1,67 @@
+// Copyright 2025 Kiruba Sankar Swaminathan. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package workspace
+
+import (
+	"testing"
+
+	"maand/bucket"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizePlacement(t *testing.T) {
+	placement, err := NormalizePlacement(nil)
+	require.NoError(t, err)
+	assert.Nil(t, placement)
+
+	placement, err = NormalizePlacement(&ManifestPlacement{
+		Affinity:     []PlacementRule{{Job: " api "}},
+		AntiAffinity: []PlacementRule{{Job: "batch", Mode: "soft"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, &ManifestPlacement{
+		Affinity:     []PlacementRule{{Job: "api", Mode: PlacementRuleHard}},
+		AntiAffinity: []PlacementRule{{Job: "batch", Mode: PlacementRuleSoft}},
+	}, placement)
+	rules := placement.Rules()
+	require.Len(t, rules, 2)
+	assert.True(t, rules[0].Hard())
+	assert.Equal(t, "affinity", rules[0].Kind())
+	assert.False(t, rules[1].Hard())
+	assert.Equal(t, "anti_affinity", rules[1].Kind())
+
+	_, err = NormalizePlacement(&ManifestPlacement{Affinity: []PlacementRule{{Job: "api", Mode: "always"}}})
+	require.Error(t, err)
+	assert.ErrorIs(t, err, bucket.ErrInvalidManifest)
+
+	_, err = NormalizePlacement(&ManifestPlacement{Affinity: []PlacementRule{{}}})
+	require.Error(t, err)
+
+	_, err = NormalizePlacement(&ManifestPlacement{
+		Affinity:     []PlacementRule{{Job: "api"}},
+		AntiAffinity: []PlacementRule{{Job: "api"}},
+	})
+	assert.ErrorContains(t, err, "placement.anti_affinity job api is already listed in placement.affinity")
+}
+
+func TestValidatePlacement(t *testing.T) {
+	jobs := []string{"api", "agent"}
+	require.NoError(t, ValidatePlacement("agent", Manifest{}, jobs))
+	require.NoError(t, ValidatePlacement("agent", Manifest{
+		Placement: &ManifestPlacement{Affinity: []PlacementRule{{Job: "api"}}},
+	}, jobs))
+
+	err := ValidatePlacement("agent", Manifest{
+		Placement: &ManifestPlacement{Affinity: []PlacementRule{{Job: "agent"}}},
+	}, jobs)
+	assert.ErrorContains(t, err, "placement.affinity cannot reference itself")
+
+	err = ValidatePlacement("agent", Manifest{
+		Placement: &ManifestPlacement{AntiAffinity: []PlacementRule{{Job: "kafka"}}},
+	}, jobs)
+	assert.ErrorContains(t, err, "placement.anti_affinity references unknown job kafka")
+}
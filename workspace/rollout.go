@@ -0,0 +1,99 @@
+// Copyright 2025 Kiruba Sankar Swaminathan. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package workspace
+
+import (
+	"fmt"
+	"strings"
+
+	"maand/bucket"
+)
+
+const (
+	RolloutStrategyRolling = "rolling"
+	RolloutStrategyCanary  = "canary"
+)
+
+// ManifestRollout is the manifest.json rollout section.
+type ManifestRollout struct {
+	Strategy     string `json:"strategy"`
+	CanaryCount  int    `json:"canary_count,omitempty"`
+	BakeSeconds  int    `json:"bake_seconds,omitempty"`
+	HealthChecks int    `json:"health_checks,omitempty"`
+}
+
+// NormalizeRollout validates the rollout section and applies defaults. It returns nil for
+// the default rolling strategy so callers only persist canary settings.
+func NormalizeRollout(raw *ManifestRollout) (*ManifestRollout, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	switch raw.Strategy {
+	case "", RolloutStrategyRolling:
+		if raw.CanaryCount != 0 || raw.BakeSeconds != 0 || raw.HealthChecks != 0 {
+			return nil, fmt.Errorf(
+				"%w: rollout canary_count, bake_seconds and health_checks require strategy canary",
+				bucket.ErrInvalidManifest,
+			)
+		}
+		return nil, nil
+	case RolloutStrategyCanary:
+	default:
+		return nil, fmt.Errorf(
+			"%w: rollout.strategy must be one of rolling, canary (got %q)",
+			bucket.ErrInvalidManifest, raw.Strategy,
+		)
+	}
+
+	if raw.CanaryCount < 0 || raw.BakeSeconds < 0 || raw.HealthChecks < 0 {
+		return nil, fmt.Errorf(
+			"%w: rollout canary_count, bake_seconds and health_checks must be >= 0",
+			bucket.ErrInvalidManifest,
+		)
+	}
+	spec := *raw
+	if spec.CanaryCount == 0 {
+		spec.CanaryCount = 1
+	}
+	if spec.HealthChecks == 0 {
+		spec.HealthChecks = 1
+	}
+	return &spec, nil
+}
+
+// NormalizeRolloutBy returns the worker tag key named by manifest rollout_by ("" when unset).
+func NormalizeRolloutBy(raw string) string {
+	return strings.TrimSpace(raw)
+}
+
+// ValidateRollout validates the rollout section and rollout_by on a job manifest. Canary and
+// tag-grouped rollouts use the default Makefile lifecycle, so they cannot be combined with
+// job_control commands.
+func ValidateRollout(jobName string, manifest Manifest) error {
+	spec, err := NormalizeRollout(manifest.Rollout)
+	if err != nil {
+		// NormalizeRollout already wraps ErrInvalidManifest.
+		return fmt.Errorf("job %s: %w", jobName, err)
+	}
+	rolloutBy := NormalizeRolloutBy(manifest.RolloutBy)
+	if spec == nil && rolloutBy == "" {
+		return nil
+	}
+	setting := "rollout_by"
+	if spec != nil {
+		setting = "rollout.strategy canary"
+	}
+	for name, command := range manifest.Commands {
+		for _, event := range command.ExecutedOn {
+			if event == "job_control" {
+				return fmt.Errorf(
+					"%w: job %s %s cannot be combined with job_control command %s",
+					bucket.ErrInvalidManifest, jobName, setting, name,
+				)
+			}
+		}
+	}
+	return nil
+}
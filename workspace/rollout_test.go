@@ -0,0 +1,82 @@
+// Copyright 2025 Kiruba Sankar Swaminathan. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package workspace
+
+import (
+	"strings"
+	"testing"
+
+	"maand/bucket"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeRollout(t *testing.T) {
+	spec, err := NormalizeRollout(nil)
+	require.NoError(t, err)
+	assert.Nil(t, spec)
+
+	spec, err = NormalizeRollout(&ManifestRollout{Strategy: "rolling"})
+	require.NoError(t, err)
+	assert.Nil(t, spec)
+
+	spec, err = NormalizeRollout(&ManifestRollout{Strategy: "canary", BakeSeconds: 60})
+	require.NoError(t, err)
+	require.NotNil(t, spec)
+	assert.Equal(t, 1, spec.CanaryCount)
+	assert.Equal(t, 1, spec.HealthChecks)
+	assert.Equal(t, 60, spec.BakeSeconds)
+
+	_, err = NormalizeRollout(&ManifestRollout{Strategy: "blue-green"})
+	require.Error(t, err)
+	assert.ErrorIs(t, err, bucket.ErrInvalidManifest)
+
+	_, err = NormalizeRollout(&ManifestRollout{CanaryCount: 2})
+	require.Error(t, err)
+
+	_, err = NormalizeRollout(&ManifestRollout{Strategy: "canary", BakeSeconds: -1})
+	require.Error(t, err)
+}
+
+func TestValidateRolloutWrapsInvalidManifestOnce(t *testing.T) {
+	err := ValidateRollout("api", Manifest{Rollout: &ManifestRollout{Strategy: "blue-green"}})
+	require.ErrorIs(t, err, bucket.ErrInvalidManifest)
+	assert.Equal(t, 1, strings.Count(err.Error(), bucket.ErrInvalidManifest.Error()))
+	assert.Contains(t, err.Error(), "job api")
+}
+
+func TestValidateRolloutRejectsJobControl(t *testing.T) {
+	manifest := Manifest{
+		Rollout: &ManifestRollout{Strategy: "canary"},
+		Commands: map[string]JobCommand{
+			"command_control": {ExecutedOn: []string{"job_control"}},
+		},
+	}
+	err := ValidateRollout("api", manifest)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, bucket.ErrInvalidManifest)
+
+	manifest.Commands = map[string]JobCommand{
+		"command_warm": {ExecutedOn: []string{"post_deploy"}},
+	}
+	require.NoError(t, ValidateRollout("api", manifest))
+}
+
+func TestValidateRolloutByRejectsJobControl(t *testing.T) {
+	manifest := Manifest{
+		RolloutBy: " zone ",
+		Commands: map[string]JobCommand{
+			"command_control": {ExecutedOn: []string{"job_control"}},
+		},
+	}
+	err := ValidateRollout("api", manifest)
+	require.ErrorIs(t, err, bucket.ErrInvalidManifest)
+	assert.Contains(t, err.Error(), "rollout_by")
+	assert.Equal(t, "zone", NormalizeRolloutBy(manifest.RolloutBy))
+
+	manifest.RolloutBy = "  "
+	require.NoError(t, ValidateRollout("api", manifest))
+}
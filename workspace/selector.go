@@ -0,0 +1,472 @@
+// Copyright 2025 Kiruba Sankar Swaminathan. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package workspace
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"maand/bucket"
+)
+
+// Selector is a parsed placement selector, matched against a worker's labels and tags.
+//
+// The manifest selector field is an expression over terms:
+//
+//	label:<name>              the worker has the label
+//	tag:<key>                 the worker has the tag
+//	tag:<key> == <value>      the tag is set to value
+//	tag:<key> != <value>      the tag is unset or set to another value
+//	tag:<key> in (<v>, ...)   the tag is set to one of the values
+//
+// combined with ! (not), && (and), || (or) and parentheses; ! binds tightest, then &&.
+// Values that are not plain words are written in double quotes.
+type Selector interface {
+	Match(labels []string, tags map[string]string) bool
+	// String returns the normalized expression; parsing it yields the same selector.
+	String() string
+}
+
+type labelSelector struct {
+	label string
+}
+
+func (s labelSelector) Match(labels []string, _ map[string]string) bool {
+	return slices.Contains(labels, s.label)
+}
+
+func (s labelSelector) String() string {
+	return "label:" + s.label
+}
+
+const (
+	tagExists = ""
+	tagEquals = "=="
+	tagNotEq  = "!="
+	tagIn     = "in"
+)
+
+type tagSelector struct {
+	key    string
+	op     string
+	values []string
+}
+
+func (s tagSelector) Match(_ []string, tags map[string]string) bool {
+	value, ok := tags[s.key]
+	switch s.op {
+	case tagEquals:
+		return ok && value == s.values[0]
+	case tagNotEq:
+		return !ok || value != s.values[0]
+	case tagIn:
+		return ok && slices.Contains(s.values, value)
+	default:
+		return ok
+	}
+}
+
+func (s tagSelector) String() string {
+	switch s.op {
+	case tagEquals, tagNotEq:
+		return "tag:" + s.key + " " + s.op + " " + quoteSelectorValue(s.values[0])
+	case tagIn:
+		quoted := make([]string, len(s.values))
+		for i, value := range s.values {
+			quoted[i] = quoteSelectorValue(value)
+		}
+		return "tag:" + s.key + " in (" + strings.Join(quoted, ", ") + ")"
+	default:
+		return "tag:" + s.key
+	}
+}
+
+type notSelector struct {
+	operand Selector
+}
+
+func (s notSelector) Match(labels []string, tags map[string]string) bool {
+	return !s.operand.Match(labels, tags)
+}
+
+func (s notSelector) String() string {
+	switch s.operand.(type) {
+	case andSelector, orSelector:
+		return "!(" + s.operand.String() + ")"
+	}
+	return "!" + s.operand.String()
+}
+
+type andSelector []Selector
+
+func (s andSelector) Match(labels []string, tags map[string]string) bool {
+	for _, operand := range s {
+		if !operand.Match(labels, tags) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s andSelector) String() string {
+	parts := make([]string, len(s))
+	for i, operand := range s {
+		parts[i] = operand.String()
+		if _, ok := operand.(orSelector); ok {
+			parts[i] = "(" + parts[i] + ")"
+		}
+	}
+	return strings.Join(parts, " && ")
+}
+
+type orSelector []Selector
+
+func (s orSelector) Match(labels []string, tags map[string]string) bool {
+	for _, operand := range s {
+		if operand.Match(labels, tags) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s orSelector) String() string {
+	parts := make([]string, len(s))
+	for i, operand := range s {
+		parts[i] = operand.String()
+	}
+	return strings.Join(parts, " || ")
+}
+
+// LabelsSelector returns the selector of the list form: every label must be present. It
+// returns nil, which matches no worker, when labels is empty.
+func LabelsSelector(labels []string) Selector {
+	var operands andSelector
+	for _, label := range labels {
+		operand := labelSelector{label: label}
+		if label != "" && !slices.Contains(operands, Selector(operand)) {
+			operands = append(operands, operand)
+		}
+	}
+	switch len(operands) {
+	case 0:
+		return nil
+	case 1:
+		return operands[0]
+	}
+	return operands
+}
+
+// PlacementSelector returns the selector a job is placed by: the selector expression when
+// set, otherwise the selectors list (see PlacementSelectors).
+func PlacementSelector(jobName string, manifest Manifest) (Selector, error) {
+	if manifest.Selector == "" {
+		return LabelsSelector(PlacementSelectors(jobName, manifest)), nil
+	}
+	if len(manifest.Selectors) > 0 {
+		return nil, fmt.Errorf("%w: job %s sets both selector and selectors; use one", bucket.ErrInvalidManifest, jobName)
+	}
+	selector, err := ParseSelector(manifest.Selector)
+	if err != nil {
+		return nil, fmt.Errorf("%w: job %s %w", bucket.ErrInvalidManifest, jobName, err)
+	}
+	return selector, nil
+}
+
+// ParseSelector parses a selector expression.
+func ParseSelector(expr string) (Selector, error) {
+	tokens, err := lexSelector(expr)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("selector is empty")
+	}
+	p := &selectorParser{expr: expr, tokens: tokens}
+	selector, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEnd {
+		return nil, p.errorf(tok, "unexpected %s", tok)
+	}
+	return selector, nil
+}
+
+type tokenKind int
+
+const (
+	tokenEnd tokenKind = iota
+	tokenWord
+	tokenString
+	tokenAnd
+	tokenOr
+	tokenNot
+	tokenEq
+	tokenNotEq
+	tokenLParen
+	tokenRParen
+	tokenComma
+)
+
+type selectorToken struct {
+	kind  tokenKind
+	text  string
+	value string
+	pos   int
+}
+
+func (t selectorToken) String() string {
+	if t.kind == tokenEnd {
+		return "end of selector"
+	}
+	return strconv.Quote(t.text)
+}
+
+var selectorOperators = []struct {
+	text string
+	kind tokenKind
+}{
+	{"&&", tokenAnd},
+	{"||", tokenOr},
+	{"==", tokenEq},
+	{"!=", tokenNotEq},
+	{"!", tokenNot},
+	{"(", tokenLParen},
+	{")", tokenRParen},
+	{",", tokenComma},
+}
+
+func isSelectorWordByte(c byte) bool {
+	return c > ' ' && !strings.ContainsRune(`&|=!(),"`, rune(c))
+}
+
+func lexSelector(expr string) ([]selectorToken, error) {
+	var tokens []selectorToken
+	for i := 0; i < len(expr); {
+		c := expr[i]
+		if c == ' ' || c == '\t' || c == '\n' || c == '\r' {
+			i++
+			continue
+		}
+		if c == '"' {
+			end := i + 1
+			for end < len(expr) && expr[end] != '"' {
+				if expr[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(expr) {
+				return nil, fmt.Errorf("selector %q: unterminated string at offset %d", expr, i)
+			}
+			value, err := strconv.Unquote(expr[i : end+1])
+			if err != nil {
+				return nil, fmt.Errorf("selector %q: invalid string at offset %d", expr, i)
+			}
+			tokens = append(tokens, selectorToken{kind: tokenString, text: expr[i : end+1], value: value, pos: i})
+			i = end + 1
+			continue
+		}
+		matched := false
+		for _, op := range selectorOperators {
+			if strings.HasPrefix(expr[i:], op.text) {
+				tokens = append(tokens, selectorToken{kind: op.kind, text: op.text, pos: i})
+				i += len(op.text)
+				matched = true
+				break
+			}
+		}
+		if matched {
+			continue
+		}
+		if !isSelectorWordByte(c) {
+			return nil, fmt.Errorf("selector %q: unexpected %q at offset %d", expr, c, i)
+		}
+		start := i
+		for i < len(expr) && isSelectorWordByte(expr[i]) {
+			i++
+		}
+		tokens = append(tokens, selectorToken{kind: tokenWord, text: expr[start:i], value: expr[start:i], pos: start})
+	}
+	return tokens, nil
+}
+
+type selectorParser struct {
+	expr   string
+	tokens []selectorToken
+	next   int
+}
+
+func (p *selectorParser) peek() selectorToken {
+	if p.next >= len(p.tokens) {
+		return selectorToken{kind: tokenEnd, pos: len(p.expr)}
+	}
+	return p.tokens[p.next]
+}
+
+func (p *selectorParser) take() selectorToken {
+	tok := p.peek()
+	if tok.kind != tokenEnd {
+		p.next++
+	}
+	return tok
+}
+
+func (p *selectorParser) errorf(tok selectorToken, format string, args ...any) error {
+	return fmt.Errorf("selector %q: %s at offset %d", p.expr, fmt.Sprintf(format, args...), tok.pos)
+}
+
+func (p *selectorParser) parseOr() (Selector, error) {
+	var operands orSelector
+	for {
+		operand, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		if nested, ok := operand.(orSelector); ok {
+			operands = append(operands, nested...)
+		} else {
+			operands = append(operands, operand)
+		}
+		if p.peek().kind != tokenOr {
+			break
+		}
+		p.take()
+	}
+	if len(operands) == 1 {
+		return operands[0], nil
+	}
+	return operands, nil
+}
+
+func (p *selectorParser) parseAnd() (Selector, error) {
+	var operands andSelector
+	for {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if nested, ok := operand.(andSelector); ok {
+			operands = append(operands, nested...)
+		} else {
+			operands = append(operands, operand)
+		}
+		if p.peek().kind != tokenAnd {
+			break
+		}
+		p.take()
+	}
+	if len(operands) == 1 {
+		return operands[0], nil
+	}
+	return operands, nil
+}
+
+func (p *selectorParser) parseUnary() (Selector, error) {
+	if p.peek().kind == tokenNot {
+		p.take()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if inner, ok := operand.(notSelector); ok {
+			return inner.operand, nil
+		}
+		return notSelector{operand: operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *selectorParser) parsePrimary() (Selector, error) {
+	tok := p.take()
+	switch tok.kind {
+	case tokenLParen:
+		selector, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.take(); closing.kind != tokenRParen {
+			return nil, p.errorf(closing, "expected \")\", got %s", closing)
+		}
+		return selector, nil
+	case tokenWord:
+		return p.parseTerm(tok)
+	default:
+		return nil, p.errorf(tok, "expected label:<name>, tag:<key> or \"(\", got %s", tok)
+	}
+}
+
+func (p *selectorParser) parseTerm(tok selectorToken) (Selector, error) {
+	kind, name, ok := strings.Cut(tok.text, ":")
+	if !ok || name == "" || (kind != "label" && kind != "tag") {
+		return nil, p.errorf(tok, "unknown term %s; use label:<name> or tag:<key>", tok)
+	}
+	if kind == "label" {
+		return labelSelector{label: name}, nil
+	}
+
+	switch next := p.peek(); {
+	case next.kind == tokenEq || next.kind == tokenNotEq:
+		p.take()
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		op := tagEquals
+		if next.kind == tokenNotEq {
+			op = tagNotEq
+		}
+		return tagSelector{key: name, op: op, values: []string{value}}, nil
+	case next.kind == tokenWord && next.text == "in":
+		p.take()
+		if open := p.take(); open.kind != tokenLParen {
+			return nil, p.errorf(open, "expected \"(\" after in, got %s", open)
+		}
+		var values []string
+		for {
+			value, err := p.parseValue()
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+			if p.peek().kind != tokenComma {
+				break
+			}
+			p.take()
+		}
+		if closing := p.take(); closing.kind != tokenRParen {
+			return nil, p.errorf(closing, "expected \",\" or \")\", got %s", closing)
+		}
+		slices.Sort(values)
+		return tagSelector{key: name, op: tagIn, values: slices.Compact(values)}, nil
+	default:
+		return tagSelector{key: name, op: tagExists}, nil
+	}
+}
+
+func (p *selectorParser) parseValue() (string, error) {
+	tok := p.take()
+	if tok.kind != tokenWord && tok.kind != tokenString {
+		return "", p.errorf(tok, "expected a value, got %s", tok)
+	}
+	return tok.value, nil
+}
+
+// quoteSelectorValue returns value as written in a normalized expression: bare when it
+// lexes back as one word, quoted otherwise.
+func quoteSelectorValue(value string) string {
+	if value == "" {
+		return strconv.Quote(value)
+	}
+	for i := 0; i < len(value); i++ {
+		if !isSelectorWordByte(value[i]) {
+			return strconv.Quote(value)
+		}
+	}
+	return value
+}
@@ -0,0 +1,98 @@
+// Copyright 2025 Kiruba Sankar Swaminathan. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package workspace
+
+import (
+	"testing"
+
+	"maand/bucket"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSelector_normalizes(t *testing.T) {
+	for expr, normalized := range map[string]string{
+		"label:db":                            "label:db",
+		"label:db&&tag:zone in (b,a,b)":       "label:db && tag:zone in (a, b)",
+		"label:a || label:b && !label:c":      "label:a || label:b && !label:c",
+		"(label:a || label:b) && (label:c)":   "(label:a || label:b) && label:c",
+		"!(label:a && label:b)":               "!(label:a && label:b)",
+		"!!label:a":                           "label:a",
+		`tag:rack=="r 1" || tag:tier != gold`: `tag:rack == "r 1" || tag:tier != gold`,
+		"label:a && (label:b && (tag:zone))":  "label:a && label:b && tag:zone",
+		"label:a || (label:b || label:c)":     "label:a || label:b || label:c",
+	} {
+		selector, err := ParseSelector(expr)
+		require.NoError(t, err, expr)
+		assert.Equal(t, normalized, selector.String(), expr)
+
+		reparsed, err := ParseSelector(selector.String())
+		require.NoError(t, err, expr)
+		assert.Equal(t, selector, reparsed, expr)
+	}
+}
+
+func TestParseSelector_rejectsInvalidExpressions(t *testing.T) {
+	for expr, message := range map[string]string{
+		"":                     "selector is empty",
+		"db":                   `unknown term "db"; use label:<name> or tag:<key> at offset 0`,
+		"label:db &&":          "expected label:<name>, tag:<key> or \"(\", got end of selector at offset 11",
+		"(label:db":            "expected \")\", got end of selector at offset 9",
+		"label:db label:web":   `unexpected "label:web" at offset 9`,
+		"tag:zone in a":        `expected "(" after in, got "a" at offset 12`,
+		"tag:zone in (a b)":    `expected "," or ")", got "b" at offset 15`,
+		"tag:zone ==":          "expected a value, got end of selector at offset 11",
+		`tag:zone == "a`:       "unterminated string at offset 12",
+		"label:db & label:web": `unexpected '&' at offset 9`,
+		"label:":               `unknown term "label:"`,
+	} {
+		_, err := ParseSelector(expr)
+		require.Error(t, err, expr)
+		assert.ErrorContains(t, err, message, expr)
+	}
+}
+
+func TestSelectorMatch(t *testing.T) {
+	selector, err := ParseSelector("label:db && tag:zone in (a,b) && !label:decommissioning")
+	require.NoError(t, err)
+
+	assert.True(t, selector.Match([]string{"worker", "db"}, map[string]string{"zone": "a"}))
+	assert.False(t, selector.Match([]string{"worker", "db"}, map[string]string{"zone": "c"}))
+	assert.False(t, selector.Match([]string{"worker", "db"}, nil))
+	assert.False(t, selector.Match([]string{"db", "decommissioning"}, map[string]string{"zone": "b"}))
+	assert.False(t, selector.Match([]string{"worker"}, map[string]string{"zone": "a"}))
+
+	selector, err = ParseSelector("tag:tier != gold || label:gpu")
+	require.NoError(t, err)
+	assert.True(t, selector.Match(nil, nil))
+	assert.True(t, selector.Match(nil, map[string]string{"tier": "silver"}))
+	assert.False(t, selector.Match(nil, map[string]string{"tier": "gold"}))
+	assert.True(t, selector.Match([]string{"gpu"}, map[string]string{"tier": "gold"}))
+}
+
+func TestPlacementSelector(t *testing.T) {
+	selector, err := PlacementSelector("api", Manifest{})
+	require.NoError(t, err)
+	assert.Equal(t, "label:api", selector.String())
+
+	selector, err = PlacementSelector("api", Manifest{Selectors: []string{"worker", "web", "worker"}})
+	require.NoError(t, err)
+	assert.Equal(t, "label:worker && label:web", selector.String())
+
+	selector, err = PlacementSelector("api", Manifest{Selector: "tag:zone == a"})
+	require.NoError(t, err)
+	assert.Equal(t, "tag:zone == a", selector.String())
+
+	_, err = PlacementSelector("api", Manifest{Selector: "label:web", Selectors: []string{"web"}})
+	assert.ErrorIs(t, err, bucket.ErrInvalidManifest)
+	assert.ErrorContains(t, err, "job api sets both selector and selectors")
+
+	_, err = PlacementSelector("api", Manifest{Selector: "web"})
+	assert.ErrorIs(t, err, bucket.ErrInvalidManifest)
+	assert.ErrorContains(t, err, `job api selector "web": unknown term`)
+
+	assert.Nil(t, LabelsSelector(nil))
+}
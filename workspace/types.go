@@ -35,6 +35,7 @@ type CertSubject struct {
 type Manifest struct {
 	Version   string   `json:"version"`
 	Selectors []string `json:"selectors"`
+	Selector  string   `json:"selector,omitempty"`
 	Resources struct {
 		Memory struct {
 			Min string `json:"min"`
@@ -44,6 +45,10 @@ type Manifest struct {
 			Min string `json:"min"`
 			Max string `json:"max"`
 		} `json:"cpu"`
+		Disk struct {
+			Min string `json:"min"`
+			Max string `json:"max"`
+		} `json:"disk"`
 		Ports ManifestPorts `json:"ports"`
 	} `json:"resources"`
 	Commands map[string]JobCommand `json:"commands"`
@@ -56,6 +61,11 @@ type Manifest struct {
 	MaxConcurrentUpgrades int                  `json:"max_concurrent_upgrades"`
 	MaxConcurrentStarts   int                  `json:"max_concurrent_starts"`
 	MinAllocationsCount   int                  `json:"min_allocations_count"`
+	Count                 int                  `json:"count,omitempty"`
+	Placement             *ManifestPlacement   `json:"placement,omitempty"`
 	RestartPolicy         string               `json:"restart_policy,omitempty"`
 	RestartGlobs          []string             `json:"restart_globs,omitempty"`
+	RollbackOnFailure     bool                 `json:"rollback_on_failure,omitempty"`
+	Rollout               *ManifestRollout     `json:"rollout,omitempty"`
+	RolloutBy             string               `json:"rollout_by,omitempty"`
 }
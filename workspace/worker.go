@@ -13,12 +13,15 @@ type Worker struct {
 	Labels   []string          `json:"labels"`
 	Memory   string            `json:"memory"`
 	CPU      string            `json:"cpu"`
+	Disk     string            `json:"disk"`
 	Tags     map[string]string `json:"tags"`
 	Position int               `json:"position"`
+	// ReservedPorts are sorted, unique ports no job allocated to the worker may use.
+	ReservedPorts []int `json:"reserved_ports,omitempty"`
 }
 
 // NewWorker normalizes labels/tags and applies defaults for empty resources.
-func NewWorker(host string, labels []string, memory string, cpu string, tags map[string]string, position int) Worker {
+func NewWorker(host string, labels []string, memory string, cpu string, disk string, tags map[string]string, position int) Worker {
 	labels = append([]string(nil), labels...)
 	labels = append(labels, "worker")
 
@@ -37,12 +40,16 @@ func NewWorker(host string, labels []string, memory string, cpu string, tags map
 	if strings.TrimSpace(cpu) == "" {
 		cpu = "0 MHZ"
 	}
+	if strings.TrimSpace(disk) == "" {
+		disk = "0 MB"
+	}
 
 	return Worker{
 		Host:     strings.TrimSpace(host),
 		Labels:   labels,
 		Memory:   memory,
 		CPU:      cpu,
+		Disk:     disk,
 		Tags:     normalizedTags,
 		Position: position,
 	}
@@ -23,14 +23,18 @@ type WorkerRecord struct {
 	Labels   []string          `json:"labels,omitempty"`
 	Memory   string            `json:"memory,omitempty"`
 	CPU      string            `json:"cpu,omitempty"`
+	Disk     string            `json:"disk,omitempty"`
 	Tags     map[string]string `json:"tags,omitempty"`
 	Position *int              `json:"position,omitempty"`
+	// ReservedPorts are ports on the host that jobs must not use (sshd, node exporters, ...).
+	ReservedPorts []int `json:"reserved_ports,omitempty"`
 }
 
 // WorkerFacts holds resource values discovered on a worker host.
 type WorkerFacts struct {
 	MemoryMB float64
 	CPUMHz   float64
+	DiskMB   float64
 }
 
 // WorkerFactsChange describes an update to one worker entry.
@@ -40,6 +44,8 @@ type WorkerFactsChange struct {
 	NewMemory string
 	OldCPU    string
 	NewCPU    string
+	OldDisk   string
+	NewDisk   string
 }
 
 // FormatMemoryMB formats megabytes for workers.json.
@@ -52,13 +58,34 @@ func FormatCPUMHz(mhz float64) string {
 	return fmt.Sprintf("%d mhz", int(math.Round(mhz)))
 }
 
+// FormatDiskMB formats disk megabytes for workers.json.
+func FormatDiskMB(mb float64) string {
+	return fmt.Sprintf("%d mb", int(math.Round(mb)))
+}
+
+// workersJSONPath is the workers.json reads use: the selected overlay's when it has one,
+// which then replaces workspace/workers.json.
 func workersJSONPath() string {
+	if overlayPath, ok := overlayFile("workers.json"); ok {
+		return overlayPath
+	}
+	return baseWorkersJSONPath()
+}
+
+// baseWorkersJSONPath is workspace/workers.json, the only workers.json maand writes.
+// Overlays are user-owned and never rewritten.
+func baseWorkersJSONPath() string {
 	return path.Join(bucket.WorkspaceLocation, "workers.json")
 }
 
-// ReadWorkersFile loads workers.json without normalizing labels or defaults.
+// ReadWorkersFile loads workers.json, through the selected overlay, without normalizing
+// labels or defaults.
 func ReadWorkersFile() ([]WorkerRecord, error) {
-	data, err := os.ReadFile(workersJSONPath())
+	return readWorkersJSON(workersJSONPath())
+}
+
+func readWorkersJSON(workersPath string) ([]WorkerRecord, error) {
+	data, err := os.ReadFile(workersPath)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return []WorkerRecord{}, nil
@@ -73,19 +100,21 @@ func ReadWorkersFile() ([]WorkerRecord, error) {
 	return workers, nil
 }
 
-// WriteWorkersFile writes workers.json with stable field ordering.
+// WriteWorkersFile writes workspace/workers.json with stable field ordering, even when the
+// selected overlay has its own workers.json.
 func WriteWorkersFile(workers []WorkerRecord) error {
 	data, err := json.MarshalIndent(workers, "", "  ")
 	if err != nil {
 		return err
 	}
 	data = append(data, '\n')
-	return os.WriteFile(workersJSONPath(), data, 0o644)
+	return os.WriteFile(baseWorkersJSONPath(), data, 0o644)
 }
 
-// ApplyWorkerFacts updates memory and cpu on matching workers.
+// ApplyWorkerFacts updates memory, cpu and disk on matching workers in
+// workspace/workers.json.
 func ApplyWorkerFacts(updates map[string]WorkerFacts) ([]WorkerFactsChange, error) {
-	workers, err := ReadWorkersFile()
+	workers, err := readWorkersJSON(baseWorkersJSONPath())
 	if err != nil {
 		return nil, err
 	}
@@ -103,16 +132,20 @@ func ApplyWorkerFacts(updates map[string]WorkerFacts) ([]WorkerFactsChange, erro
 
 		newMemory := FormatMemoryMB(facts.MemoryMB)
 		newCPU := FormatCPUMHz(facts.CPUMHz)
+		newDisk := FormatDiskMB(facts.DiskMB)
 		change := WorkerFactsChange{
 			Host:      host,
 			OldMemory: workers[idx].Memory,
 			NewMemory: newMemory,
 			OldCPU:    workers[idx].CPU,
 			NewCPU:    newCPU,
+			OldDisk:   workers[idx].Disk,
+			NewDisk:   newDisk,
 		}
 
 		workers[idx].Memory = newMemory
 		workers[idx].CPU = newCPU
+		workers[idx].Disk = newDisk
 
 		if factsChanged(change) {
 			changes = append(changes, change)
@@ -136,6 +169,9 @@ func factsChanged(change WorkerFactsChange) bool {
 	if !cpuStringEqual(change.OldCPU, change.NewCPU) {
 		return true
 	}
+	if !diskStringEqual(change.OldDisk, change.NewDisk) {
+		return true
+	}
 	return false
 }
 
@@ -148,6 +184,15 @@ func memoryStringEqual(left, right string) bool {
 	return strings.EqualFold(strings.TrimSpace(left), strings.TrimSpace(right))
 }
 
+func diskStringEqual(left, right string) bool {
+	leftMB, leftErr := utils.ParseDiskMB(left)
+	rightMB, rightErr := utils.ParseDiskMB(right)
+	if leftErr == nil && rightErr == nil {
+		return math.Round(leftMB) == math.Round(rightMB)
+	}
+	return strings.EqualFold(strings.TrimSpace(left), strings.TrimSpace(right))
+}
+
 func cpuStringEqual(left, right string) bool {
 	leftMHz, leftErr := utils.ParseCPUMHz(left)
 	rightMHz, rightErr := utils.ParseCPUMHz(right)
@@ -129,3 +129,26 @@ func TestFilterWorkersByLabels(t *testing.T) {
 	require.Len(t, filtered, 1)
 	assert.Equal(t, "10.0.0.1", filtered[0].Host)
 }
+
+func TestGetWorkersReservedPorts(t *testing.T) {
+	root := t.TempDir()
+	bucket.Location = root
+	bucket.WorkspaceLocation = path.Join(root, "workspace")
+	require.NoError(t, os.MkdirAll(bucket.WorkspaceLocation, 0o755))
+	require.NoError(t, os.WriteFile(workersJSONPath(), []byte(`[
+  {"host":"10.0.0.1","reserved_ports":[9100,22,9100]},
+  {"host":"10.0.0.2"}
+]`), 0o644))
+
+	workers, err := Default().GetWorkers()
+	require.NoError(t, err)
+	require.Len(t, workers, 2)
+	assert.Equal(t, []int{22, 9100}, workers[0].ReservedPorts)
+	assert.Empty(t, workers[1].ReservedPorts)
+
+	require.NoError(t, os.WriteFile(workersJSONPath(), []byte(`[
+  {"host":"10.0.0.1","reserved_ports":[70000]}
+]`), 0o644))
+	_, err = Default().GetWorkers()
+	assert.ErrorIs(t, err, bucket.ErrInvalidWorkerJSON)
+}
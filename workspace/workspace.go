@@ -7,10 +7,12 @@ package workspace
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/fs"
 	"os"
 	"path"
+	"slices"
 	"strings"
 
 	"maand/bucket"
@@ -24,7 +26,8 @@ type Workspace interface {
 	LoadDisabled() (DisabledAllocations, error)
 }
 
-// DefaultWorkspace reads from bucket.WorkspaceLocation.
+// DefaultWorkspace reads from bucket.WorkspaceLocation, with the overlay selected by
+// workspace_overlay in maand.conf applied on top.
 type DefaultWorkspace struct{}
 
 // Default returns the standard workspace reader.
@@ -42,7 +45,7 @@ func (ws *DefaultWorkspace) ListWorkers() ([]Worker, error) {
 }
 
 func (ws *DefaultWorkspace) GetWorkers() ([]Worker, error) {
-	data, err := os.ReadFile(path.Join(bucket.WorkspaceLocation, "workers.json"))
+	data, err := os.ReadFile(workersJSONPath())
 	if err != nil {
 		if os.IsNotExist(err) {
 			return []Worker{}, nil
@@ -70,10 +73,14 @@ func (ws *DefaultWorkspace) GetWorkers() ([]Worker, error) {
 		if raw.Position != nil {
 			position = *raw.Position
 		}
-		w := NewWorker(host, raw.Labels, raw.Memory, raw.CPU, raw.Tags, position)
+		w := NewWorker(host, raw.Labels, raw.Memory, raw.CPU, raw.Disk, raw.Tags, position)
 		if hn := strings.TrimSpace(raw.Hostname); hn != "" {
 			w.Hostname = hn
 		}
+		w.ReservedPorts, err = normalizeReservedPorts(host, raw.ReservedPorts)
+		if err != nil {
+			return nil, err
+		}
 		workers = append(workers, w)
 	}
 	return workers, nil
@@ -84,6 +91,10 @@ func (ws *DefaultWorkspace) ListJobNames() ([]string, error) {
 }
 
 func (ws *DefaultWorkspace) GetJobs() ([]string, error) {
+	if err := ValidateOverlay(); err != nil {
+		return nil, err
+	}
+
 	paths, err := fs.Glob(os.DirFS(path.Join(bucket.WorkspaceLocation, "jobs")), "*/manifest.json")
 	if err != nil {
 		return nil, fmt.Errorf("%w: %w", bucket.ErrUnexpectedError, err)
@@ -101,8 +112,10 @@ func (ws *DefaultWorkspace) LoadManifest(jobName string) (Manifest, error) {
 }
 
 func (ws *DefaultWorkspace) GetJobManifest(jobName string) (Manifest, error) {
-	manifestFile := path.Join(bucket.WorkspaceLocation, "jobs", jobName, "manifest.json")
-	data, err := os.ReadFile(manifestFile)
+	data, err := ReadJobFile(path.Join(jobName, manifestFileName))
+	if errors.Is(err, bucket.ErrInvalidManifest) {
+		return Manifest{}, err
+	}
 	if err != nil {
 		return Manifest{}, fmt.Errorf("%w:%w", bucket.ErrUnexpectedError, err)
 	}
@@ -134,3 +147,20 @@ func (ws *DefaultWorkspace) GetDisabled() (DisabledAllocations, error) {
 	}
 	return disabled, nil
 }
+
+// normalizeReservedPorts checks the reserved_ports of a workers.json entry and returns them
+// sorted without duplicates.
+func normalizeReservedPorts(host string, ports []int) ([]int, error) {
+	if len(ports) == 0 {
+		return nil, nil
+	}
+	normalized := make([]int, 0, len(ports))
+	for _, port := range ports {
+		if port < 1 || port > 65535 {
+			return nil, fmt.Errorf("%w: worker %s reserved_ports: port %d out of range 1-65535", bucket.ErrInvalidWorkerJSON, host, port)
+		}
+		normalized = append(normalized, port)
+	}
+	slices.Sort(normalized)
+	return slices.Compact(normalized), nil
+}